@@ -10,14 +10,23 @@ package main
 // @name Authorization
 
 import (
+    "context"
+    "errors"
     "log"
+    "os"
+    "os/signal"
+    "sync"
+    "syscall"
 
     "dashboard-ac-backend/config"
     "dashboard-ac-backend/internal/api/middleware"
+    "dashboard-ac-backend/internal/domain"
+    "dashboard-ac-backend/internal/job"
     "dashboard-ac-backend/internal/repository"
     "dashboard-ac-backend/internal/routes"
     "dashboard-ac-backend/internal/service"
     "dashboard-ac-backend/pkg/logger"
+    "dashboard-ac-backend/pkg/notifier"
 
     "github.com/gofiber/fiber/v2"
     docs "dashboard-ac-backend/internal/docs"
@@ -52,16 +61,54 @@ func main() {
 	scheduleRepo := repository.NewScheduleRepository(db)
 	invoiceRepo := repository.NewInvoiceRepository(db)
 	invoiceDetailRepo := repository.NewInvoiceDetailRepository(db)
+	paymentRepo := repository.NewPaymentRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	passwordResetRepo := repository.NewPasswordResetRepository(db)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(db)
+	roleRepo := repository.NewRoleRepository(db)
+	auditLogRepo := repository.NewAuditLogRepository(db)
+	reportRepo := repository.NewReportRepository(db)
+
+	// Initialize notifier
+	userNotifier, err := notifier.New(cfg.Notifier.Driver, cfg.Notifier.FilePath)
+	if err != nil {
+		log.Fatal("Failed to initialize notifier:", err)
+	}
 
 	// Initialize services
-	authService := service.NewAuthService(userRepo, customerRepo, db, cfg.JWTSecret)
-	userService := service.NewUserService(userRepo)
-	customerService := service.NewCustomerService(customerRepo)
-	technicianService := service.NewTechnicianService(technicianRepo)
-	serviceService := service.NewServiceService(serviceRepo)
-	scheduleService := service.NewScheduleService(scheduleRepo, customerRepo, technicianRepo, serviceRepo)
-	invoiceService := service.NewInvoiceService(invoiceRepo, customerRepo, scheduleRepo)
-	invoiceDetailService := service.NewInvoiceDetailService(invoiceDetailRepo, invoiceRepo, serviceRepo)
+	auditService := service.NewAuditService(auditLogRepo)
+	authService := service.NewAuthService(userRepo, customerRepo, refreshTokenRepo, loginAttemptRepo, db, cfg.JWTSecret, service.LoginPolicy{
+		MaxFailures:     cfg.Auth.LoginMaxFailures,
+		LockoutDuration: cfg.Auth.LoginLockoutDuration,
+		BaseDelay:       cfg.Auth.LoginBaseDelay,
+		IPMaxFailures:   cfg.Auth.LoginIPMaxFailures,
+		IPWindow:        cfg.Auth.LoginIPWindow,
+	}, auditService)
+	userService := service.NewUserService(userRepo, customerRepo, technicianRepo, refreshTokenRepo, roleRepo, auditService)
+	customerService := service.NewCustomerService(customerRepo, auditService)
+	technicianService := service.NewTechnicianService(technicianRepo, auditService)
+	serviceService := service.NewServiceService(serviceRepo, auditService)
+	scheduleService := service.NewScheduleService(scheduleRepo, customerRepo, technicianRepo, serviceRepo, auditService)
+	invoiceService := service.NewInvoiceService(invoiceRepo, customerRepo, scheduleRepo, serviceRepo, auditService, cfg.Invoice.PaymentTermsDays)
+	invoiceDetailService := service.NewInvoiceDetailService(invoiceDetailRepo, invoiceRepo, scheduleRepo, serviceRepo, auditService)
+	availabilityService := service.NewAvailabilityService(technicianRepo, scheduleRepo, serviceRepo)
+	paymentService := service.NewPaymentService(paymentRepo, invoiceRepo, scheduleRepo, auditService)
+	portalService := service.NewPortalService(scheduleService, invoiceService, customerService, scheduleRepo, availabilityService)
+	passwordService := service.NewPasswordService(userRepo, passwordResetRepo, userNotifier, cfg.Auth.PasswordResetTTL, cfg.Auth.PasswordResetURL, auditService)
+	roleService := service.NewRoleService(roleRepo, auditService, cfg.Auth.PermissionCacheTTL)
+	dashboardService := service.NewDashboardService(reportRepo)
+
+	// Bill completed schedules automatically when enabled. A schedule someone already
+	// billed by hand is left alone.
+	if cfg.Invoice.AutoGenerate {
+		scheduleService.OnCompleted(func(ctx context.Context, schedule *domain.Schedule) error {
+			_, err := invoiceService.CreateFromSchedule(ctx, domain.AccessScope{}, schedule.ID.String())
+			if errors.Is(err, service.ErrScheduleAlreadyInvoiced) {
+				return nil
+			}
+			return err
+		})
+	}
 
     // Initialize Fiber app
     app := fiber.New(fiber.Config{
@@ -87,12 +134,48 @@ func main() {
 		scheduleService,
 		invoiceService,
 		invoiceDetailService,
+		availabilityService,
+		paymentService,
+		portalService,
+		passwordService,
+		roleService,
+		auditService,
+		dashboardService,
 		cfg.JWTSecret,
+		middleware.NewTokenVersionCache(authService.TokenState, cfg.Auth.TokenVersionCacheTTL),
+		cfg.RateLimit,
+		middleware.NewMemoryRateLimitStore(),
 	)
 
+	// Stop background jobs and the server on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var jobs sync.WaitGroup
+	if cfg.Invoice.OverdueSweepInterval > 0 {
+		sweeper := job.NewOverdueSweeper(invoiceService, cfg.Invoice.OverdueSweepInterval)
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			sweeper.Run(ctx)
+		}()
+	}
+
+	go func() {
+		<-ctx.Done()
+		log.Println("Shutting down server...")
+		if err := app.Shutdown(); err != nil {
+			log.Printf("Failed to shut down server: %v", err)
+		}
+	}()
+
 	// Start server
 	log.Printf("Server starting on port %s", cfg.Port)
 	if err := app.Listen(":" + cfg.Port); err != nil {
 		log.Fatal("Failed to start server:", err)
 	}
+
+	stop()
+	jobs.Wait()
+	log.Println("Server stopped")
 }
\ No newline at end of file
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"dashboard-ac-backend/config"
+	"dashboard-ac-backend/internal/job"
+	"dashboard-ac-backend/internal/repository"
+	"dashboard-ac-backend/internal/service"
+	"dashboard-ac-backend/pkg/logger"
+)
+
+// sweep-overdue marks Unpaid invoices past their due date as Overdue once and
+// exits. It is meant for cron when the in-process sweeper is disabled.
+func main() {
+	// Load configuration
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatal("Failed to load config:", err)
+	}
+
+	// Initialize logger
+	logger.InitLogger(cfg.Environment)
+
+	// Initialize database
+	db, err := config.InitDatabase(cfg)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	invoiceRepo := repository.NewInvoiceRepository(db)
+	customerRepo := repository.NewCustomerRepository(db)
+	scheduleRepo := repository.NewScheduleRepository(db)
+	serviceRepo := repository.NewServiceRepository(db)
+	auditService := service.NewAuditService(repository.NewAuditLogRepository(db))
+	invoiceService := service.NewInvoiceService(invoiceRepo, customerRepo, scheduleRepo, serviceRepo, auditService, cfg.Invoice.PaymentTermsDays)
+
+	if _, err := job.NewOverdueSweeper(invoiceService, cfg.Invoice.OverdueSweepInterval).RunOnce(context.Background()); err != nil {
+		log.Fatal("Failed to sweep overdue invoices:", err)
+	}
+}
@@ -3,9 +3,11 @@ package config
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"dashboard-ac-backend/internal/domain"
 	"dashboard-ac-backend/pkg/hash"
+	applogger "dashboard-ac-backend/pkg/logger"
 
 	"github.com/spf13/viper"
 	"gorm.io/driver/postgres"
@@ -18,6 +20,48 @@ type Config struct {
 	Port        string `mapstructure:"PORT"`
 	JWTSecret   string `mapstructure:"JWT_SECRET"`
 	Database    DatabaseConfig
+	Invoice     InvoiceConfig
+	Auth        AuthConfig
+	Notifier    NotifierConfig
+	RateLimit   RateLimitConfig
+}
+
+// RateLimitConfig sets the token bucket of each route group: up to Requests requests
+// at once, refilled at Requests per Period
+type RateLimitConfig struct {
+	Enabled bool `mapstructure:"RATE_LIMIT_ENABLED"`
+
+	AuthRequests int           `mapstructure:"RATE_LIMIT_AUTH_REQUESTS"` // public /auth routes, per IP
+	AuthPeriod   time.Duration `mapstructure:"RATE_LIMIT_AUTH_PERIOD"`
+
+	APIRequests int           `mapstructure:"RATE_LIMIT_API_REQUESTS"` // protected routes, per user
+	APIPeriod   time.Duration `mapstructure:"RATE_LIMIT_API_PERIOD"`
+}
+
+type AuthConfig struct {
+	TokenVersionCacheTTL time.Duration `mapstructure:"AUTH_TOKEN_VERSION_CACHE_TTL"` // how long a revoked access token may keep working
+	PermissionCacheTTL   time.Duration `mapstructure:"AUTH_PERMISSION_CACHE_TTL"`    // how long other instances may serve changed role permissions
+
+	PasswordResetTTL time.Duration `mapstructure:"AUTH_PASSWORD_RESET_TTL"`
+	PasswordResetURL string        `mapstructure:"AUTH_PASSWORD_RESET_URL"` // the reset token is appended to this URL
+
+	LoginMaxFailures     int           `mapstructure:"AUTH_LOGIN_MAX_FAILURES"` // failed logins before an account is locked
+	LoginLockoutDuration time.Duration `mapstructure:"AUTH_LOGIN_LOCKOUT_DURATION"`
+	LoginBaseDelay       time.Duration `mapstructure:"AUTH_LOGIN_BASE_DELAY"`      // doubled after every further failure
+	LoginIPMaxFailures   int           `mapstructure:"AUTH_LOGIN_IP_MAX_FAILURES"` // failed logins per address within the window
+	LoginIPWindow        time.Duration `mapstructure:"AUTH_LOGIN_IP_WINDOW"`
+}
+
+type NotifierConfig struct {
+	Driver   string `mapstructure:"NOTIFIER_DRIVER"` // "log" or "file"
+	FilePath string `mapstructure:"NOTIFIER_FILE_PATH"`
+}
+
+type InvoiceConfig struct {
+	PaymentTermsDays int  `mapstructure:"INVOICE_PAYMENT_TERMS_DAYS"`
+	AutoGenerate     bool `mapstructure:"INVOICE_AUTO_GENERATE"` // create an invoice when a schedule is completed
+
+	OverdueSweepInterval time.Duration `mapstructure:"INVOICE_OVERDUE_SWEEP_INTERVAL"` // 0 disables the background sweep
 }
 
 type DatabaseConfig struct {
@@ -43,6 +87,25 @@ func LoadConfig() (*Config, error) {
 	viper.SetDefault("DB_PASSWORD", "password")
 	viper.SetDefault("DB_NAME", "dashboard_ac")
 	viper.SetDefault("DB_SSLMODE", "disable")
+	viper.SetDefault("INVOICE_PAYMENT_TERMS_DAYS", 14)
+	viper.SetDefault("INVOICE_AUTO_GENERATE", false)
+	viper.SetDefault("INVOICE_OVERDUE_SWEEP_INTERVAL", "1h")
+	viper.SetDefault("AUTH_TOKEN_VERSION_CACHE_TTL", "5s")
+	viper.SetDefault("AUTH_PERMISSION_CACHE_TTL", "30s")
+	viper.SetDefault("AUTH_PASSWORD_RESET_TTL", "30m")
+	viper.SetDefault("AUTH_PASSWORD_RESET_URL", "http://localhost:3000/reset-password?token=")
+	viper.SetDefault("AUTH_LOGIN_MAX_FAILURES", 5)
+	viper.SetDefault("AUTH_LOGIN_LOCKOUT_DURATION", "15m")
+	viper.SetDefault("AUTH_LOGIN_BASE_DELAY", "1s")
+	viper.SetDefault("AUTH_LOGIN_IP_MAX_FAILURES", 20)
+	viper.SetDefault("AUTH_LOGIN_IP_WINDOW", "15m")
+	viper.SetDefault("RATE_LIMIT_ENABLED", true)
+	viper.SetDefault("RATE_LIMIT_AUTH_REQUESTS", 10)
+	viper.SetDefault("RATE_LIMIT_AUTH_PERIOD", "1m")
+	viper.SetDefault("RATE_LIMIT_API_REQUESTS", 300)
+	viper.SetDefault("RATE_LIMIT_API_PERIOD", "1m")
+	viper.SetDefault("NOTIFIER_DRIVER", "log")
+	viper.SetDefault("NOTIFIER_FILE_PATH", "notifications.log")
 
 	// Try to read from environment-specific config file
 	env := viper.GetString("ENVIRONMENT")
@@ -64,6 +127,26 @@ func LoadConfig() (*Config, error) {
 		return nil, fmt.Errorf("failed to unmarshal database config: %w", err)
 	}
 
+	// Unmarshal invoice config separately
+	if err := viper.Unmarshal(&config.Invoice); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal invoice config: %w", err)
+	}
+
+	// Unmarshal auth config separately
+	if err := viper.Unmarshal(&config.Auth); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal auth config: %w", err)
+	}
+
+	// Unmarshal notifier config separately
+	if err := viper.Unmarshal(&config.Notifier); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal notifier config: %w", err)
+	}
+
+	// Unmarshal rate limit config separately
+	if err := viper.Unmarshal(&config.RateLimit); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rate limit config: %w", err)
+	}
+
 	return &config, nil
 }
 
@@ -77,11 +160,12 @@ func InitDatabase(cfg *Config) (*gorm.DB, error) {
 		cfg.Database.SSLMode,
 	)
 
+	// Queries are logged through the request-scoped logger of their context
 	var gormLogger logger.Interface
 	if cfg.Environment == "production" {
-		gormLogger = logger.Default.LogMode(logger.Silent)
+		gormLogger = applogger.NewGormLogger(logger.Silent)
 	} else {
-		gormLogger = logger.Default.LogMode(logger.Info)
+		gormLogger = applogger.NewGormLogger(logger.Info)
 	}
 
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
@@ -98,33 +182,174 @@ func InitDatabase(cfg *Config) (*gorm.DB, error) {
 func AutoMigrate(db *gorm.DB) error {
 	log.Println("Starting database migration...")
 	
-	// Run auto migration for all models
+	if err := MigrateSchema(db); err != nil {
+		log.Printf("Failed to run migrations: %v", err)
+		return err
+	}
+	
+	log.Println("Database migration completed successfully")
+
+	// Link existing accounts to their customer records
+	if err := BackfillUserLinks(db); err != nil {
+		log.Printf("Failed to backfill user links: %v", err)
+		return fmt.Errorf("failed to backfill user links: %w", err)
+	}
+	
+	// Create the built-in roles and their permissions
+	if err := SeedRoles(db); err != nil {
+		log.Printf("Failed to seed roles: %v", err)
+		return fmt.Errorf("failed to seed roles: %w", err)
+	}
+
+	// Run seeding for initial data
+	if err := seedInitialData(db); err != nil {
+		log.Printf("Failed to seed initial data: %v", err)
+		return fmt.Errorf("failed to seed initial data: %w", err)
+	}
+	
+	return nil
+}
+
+// MigrateSchema brings the tables of every model up to date. It is shared by the
+// server and the migrate command so both migrate the same models the same way.
+func MigrateSchema(db *gorm.DB) error {
+	// Links to records that no longer exist would stop the foreign keys being added
+	if err := ClearDanglingUserLinks(db); err != nil {
+		return fmt.Errorf("failed to clear dangling user links: %w", err)
+	}
+
 	err := db.AutoMigrate(
 		&domain.User{},
 		&domain.Customer{},
 		&domain.Technician{},
 		&domain.Service{},
 		&domain.Schedule{},
+		&domain.ScheduleStatusHistory{},
 		&domain.Invoice{},
 		&domain.InvoiceDetail{},
+		&domain.Payment{},
+		&domain.RefreshToken{},
+		&domain.PasswordResetToken{},
+		&domain.LoginAttempt{},
+		&domain.RoleDefinition{},
+		&domain.RolePermission{},
+		&domain.AuditLog{},
 	)
-	
 	if err != nil {
-		log.Printf("Failed to run migrations: %v", err)
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
-	
-	log.Println("Database migration completed successfully")
-	
-	// Run seeding for initial data
-	if err := seedInitialData(db); err != nil {
-		log.Printf("Failed to seed initial data: %v", err)
-		return fmt.Errorf("failed to seed initial data: %w", err)
+
+	return nil
+}
+
+// BackfillUserLinks sets users.customer_id for customer accounts created before the
+// column existed, matching customer records by email. Technician records have no
+// email, so technician accounts are linked by an admin through the users API.
+// Accounts that are already linked are left untouched, so this is safe to re-run.
+func BackfillUserLinks(db *gorm.DB) error {
+	result := db.Exec(`
+		UPDATE users SET customer_id = (
+			SELECT customers.id FROM customers
+			WHERE LOWER(customers.email) = LOWER(users.email) AND customers.deleted_at IS NULL
+			LIMIT 1
+		)
+		WHERE users.customer_id IS NULL
+			AND users.role = ?
+			AND EXISTS (
+				SELECT 1 FROM customers
+				WHERE LOWER(customers.email) = LOWER(users.email) AND customers.deleted_at IS NULL
+			)`, domain.RoleCustomer)
+	if result.Error != nil {
+		return result.Error
 	}
-	
+
+	if result.RowsAffected > 0 {
+		log.Printf("Linked %d user(s) to their customer records", result.RowsAffected)
+	}
+
+	return nil
+}
+
+// ClearDanglingUserLinks unlinks accounts from customer and technician records that
+// were hard-deleted before users.customer_id and users.technician_id had foreign keys.
+// It does nothing on a fresh database, so it is safe to run before every migration.
+func ClearDanglingUserLinks(db *gorm.DB) error {
+	links := []struct {
+		column, table string
+	}{
+		{"customer_id", "customers"},
+		{"technician_id", "technicians"},
+	}
+
+	for _, link := range links {
+		if !db.Migrator().HasColumn(&domain.User{}, link.column) || !db.Migrator().HasTable(link.table) {
+			continue
+		}
+
+		result := db.Exec(fmt.Sprintf(`
+			UPDATE users SET %[1]s = NULL
+			WHERE %[1]s IS NOT NULL
+				AND NOT EXISTS (SELECT 1 FROM %[2]s WHERE %[2]s.id = users.%[1]s)`, link.column, link.table))
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected > 0 {
+			log.Printf("Unlinked %d user(s) from missing %s", result.RowsAffected, link.table)
+		}
+	}
+
 	return nil
 }
 
+// SeedRoles creates the built-in roles with their default permissions. Roles that
+// already exist are left untouched, so permissions changed by an admin survive restarts.
+func SeedRoles(db *gorm.DB) error {
+	for _, role := range domain.DefaultRoles() {
+		var count int64
+		if err := db.Model(&domain.RoleDefinition{}).Where("name = ?", role.Name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&role).Error; err != nil {
+				return err
+			}
+			for _, permission := range role.Permissions {
+				if err := tx.Create(&domain.RolePermission{Role: role.Name, Permission: permission}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		log.Printf("Created built-in role %s", role.Name)
+	}
+
+	return nil
+}
+
+// InitialAdminPassword returns the password for a newly seeded admin: the configured
+// ADMIN_INITIAL_PASSWORD, or a generated one so no well-known default exists
+func InitialAdminPassword() (password string, generated bool, err error) {
+	password = viper.GetString("ADMIN_INITIAL_PASSWORD")
+	if password != "" {
+		return password, false, nil
+	}
+
+	token, err := hash.GenerateToken()
+	if err != nil {
+		return "", false, fmt.Errorf("failed to generate admin password: %w", err)
+	}
+
+	return token[:16], true, nil
+}
+
 // seedInitialData creates initial admin user if not exists
 func seedInitialData(db *gorm.DB) error {
 	log.Println("Checking for initial data...")
@@ -138,8 +363,13 @@ func seedInitialData(db *gorm.DB) error {
 		return nil
 	}
 	
+	password, generated, err := InitialAdminPassword()
+	if err != nil {
+		return err
+	}
+
 	// Create admin user
-	hashedPassword, err := hash.HashPassword("admin123")
+	hashedPassword, err := hash.HashPassword(password)
 	if err != nil {
 		return fmt.Errorf("failed to hash admin password: %w", err)
 	}
@@ -156,7 +386,11 @@ func seedInitialData(db *gorm.DB) error {
 	}
 	
 	log.Println("Initial admin user created successfully")
-	log.Println("Admin credentials - Email: admin@dashboardac.com, Password: admin123")
+	if generated {
+		log.Printf("Admin credentials - Email: admin@dashboardac.com, Password: %s (generated, change it after the first login)", password)
+	} else {
+		log.Println("Admin credentials - Email: admin@dashboardac.com, Password: from ADMIN_INITIAL_PASSWORD")
+	}
 	
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/api/response"
+	"dashboard-ac-backend/internal/apperror"
+	"dashboard-ac-backend/internal/service"
+	"dashboard-ac-backend/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AuditHandler struct {
+	auditService service.AuditService
+}
+
+func NewAuditHandler(auditService service.AuditService) *AuditHandler {
+	return &AuditHandler{
+		auditService: auditService,
+	}
+}
+
+// ListAuditLogs lists recorded mutations, newest first
+// @Summary List audit logs
+// @Description List recorded creates, updates and deletes, newest first
+// @Tags audit
+// @Produce json
+// @Param entity_type query string false "Entity type, e.g. invoice"
+// @Param entity_id query string false "Entity ID"
+// @Param actor_id query int false "ID of the user who made the change"
+// @Param date_from query string false "Date from (YYYY-MM-DD)"
+// @Param date_to query string false "Date to, inclusive (YYYY-MM-DD)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Success 200 {object} response.BaseResponse{data=[]domain.AuditLog}
+// @Failure 400 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /audit-logs [get]
+func (h *AuditHandler) ListAuditLogs(c *fiber.Ctx) error {
+	req := request.AuditLogListRequest{
+		PaginationRequest: request.GetPaginationFromQuery(c),
+		EntityType:        c.Query("entity_type"),
+		EntityID:          c.Query("entity_id"),
+		ActorID:           uint(c.QueryInt("actor_id")),
+		DateFrom:          c.Query("date_from"),
+		DateTo:            c.Query("date_to"),
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(&req); len(errors) > 0 {
+		return apperror.ValidationFailed(errors)
+	}
+
+	entries, total, err := h.auditService.List(c.UserContext(), &req)
+	if err != nil {
+		return err
+	}
+
+	paginationMeta := response.CalculatePagination(req.Page, req.GetLimit(), total)
+	return response.Paginated(c, "Audit logs retrieved successfully", entries, paginationMeta)
+}
+
@@ -1,8 +1,10 @@
 package handler
 
 import (
+    "dashboard-ac-backend/internal/api/middleware"
     "dashboard-ac-backend/internal/api/request"
     "dashboard-ac-backend/internal/api/response"
+    "dashboard-ac-backend/internal/apperror"
     "dashboard-ac-backend/internal/domain"
     "dashboard-ac-backend/internal/service"
     "dashboard-ac-backend/pkg/utils"
@@ -33,26 +35,27 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
     // @Router /auth/register [post]
     var req request.RegisterRequest
     if err := c.BodyParser(&req); err != nil {
-        return response.BadRequest(c, "Invalid request body", nil)
+        return apperror.InvalidBody(err)
     }
 
 	// Validate request
 	if errors := utils.ValidateStruct(&req); len(errors) > 0 {
-		return response.BadRequest(c, "Validation failed", errors)
+		return apperror.ValidationFailed(errors)
 	}
 
 	// Register user
-	user, err := h.authService.Register(&req)
+	user, err := h.authService.Register(c.UserContext(), &req)
 	if err != nil {
-		return response.BadRequest(c, err.Error(), nil)
+		return err
 	}
 
 	// Prepare response data
 	responseData := map[string]interface{}{
-		"id":    user.ID,
-		"name":  user.Name,
-		"email": user.Email,
-		"role":  user.Role,
+		"id":          user.ID,
+		"name":        user.Name,
+		"email":       user.Email,
+		"role":        user.Role,
+		"customer_id": user.CustomerID,
 	}
 
 	// Add success message based on role
@@ -77,29 +80,33 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
     // @Success 200 {object} response.BaseResponse
     // @Failure 401 {object} response.BaseResponse
     // @Failure 400 {object} response.BaseResponse
+    // @Failure 423 {object} response.BaseResponse
+    // @Failure 429 {object} response.BaseResponse
     // @Router /auth/login [post]
     var req request.LoginRequest
     if err := c.BodyParser(&req); err != nil {
-        return response.BadRequest(c, "Invalid request body", nil)
+        return apperror.InvalidBody(err)
     }
 
 	// Validate request
 	if errors := utils.ValidateStruct(&req); len(errors) > 0 {
-		return response.BadRequest(c, "Validation failed", errors)
+		return apperror.ValidationFailed(errors)
 	}
 
 	// Login user
-	tokenPair, user, err := h.authService.Login(&req)
+	tokenPair, user, err := h.authService.Login(c.UserContext(), &req, clientInfo(c))
 	if err != nil {
-		return response.Unauthorized(c, err.Error())
+		return err
 	}
 
 	return response.Success(c, "Login successful", map[string]interface{}{
 		"user": map[string]interface{}{
-			"id":    user.ID,
-			"name":  user.Name,
-			"email": user.Email,
-			"role":  user.Role,
+			"id":            user.ID,
+			"name":          user.Name,
+			"email":         user.Email,
+			"role":          user.Role,
+			"customer_id":   user.CustomerID,
+			"technician_id": user.TechnicianID,
 		},
 		"tokens": tokenPair,
 	})
@@ -119,18 +126,18 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
     // @Router /auth/refresh [post]
     var req request.RefreshTokenRequest
     if err := c.BodyParser(&req); err != nil {
-        return response.BadRequest(c, "Invalid request body", nil)
+        return apperror.InvalidBody(err)
     }
 
 	// Validate request
 	if errors := utils.ValidateStruct(&req); len(errors) > 0 {
-		return response.BadRequest(c, "Validation failed", errors)
+		return apperror.ValidationFailed(errors)
 	}
 
 	// Refresh token
-	tokenPair, err := h.authService.RefreshToken(req.RefreshToken)
+	tokenPair, err := h.authService.RefreshToken(c.UserContext(), req.RefreshToken, clientInfo(c))
 	if err != nil {
-		return response.Unauthorized(c, err.Error())
+		return err
 	}
 
 	return response.Success(c, "Token refreshed successfully", map[string]interface{}{
@@ -138,6 +145,60 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 	})
 }
 
+func (h *AuthHandler) Logout(c *fiber.Ctx) error {
+    // Logout godoc
+    // @Summary Logout
+    // @Description Mencabut refresh token beserta seluruh turunannya (sesi perangkat ini)
+    // @Tags Auth
+    // @Accept json
+    // @Produce json
+    // @Param request body request.RefreshTokenRequest true "Refresh Token Request"
+    // @Success 200 {object} response.BaseResponse
+    // @Failure 401 {object} response.BaseResponse
+    // @Failure 400 {object} response.BaseResponse
+    // @Router /auth/logout [post]
+    var req request.RefreshTokenRequest
+    if err := c.BodyParser(&req); err != nil {
+        return apperror.InvalidBody(err)
+    }
+
+	// Validate request
+	if errors := utils.ValidateStruct(&req); len(errors) > 0 {
+		return apperror.ValidationFailed(errors)
+	}
+
+	if err := h.authService.Logout(c.UserContext(), req.RefreshToken); err != nil {
+		return err
+	}
+
+	return response.Success(c, "Logged out successfully", nil)
+}
+
+func (h *AuthHandler) LogoutAll(c *fiber.Ctx) error {
+    // LogoutAll godoc
+    // @Summary Logout dari semua perangkat
+    // @Description Mencabut semua refresh token milik pengguna saat ini
+    // @Tags Auth
+    // @Security BearerAuth
+    // @Produce json
+    // @Success 200 {object} response.BaseResponse
+    // @Failure 401 {object} response.BaseResponse
+    // @Router /auth/logout-all [post]
+	userID, _, _, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	revoked, err := h.authService.LogoutAll(c.UserContext(), userID)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Logged out from all devices successfully", map[string]interface{}{
+		"revoked_sessions": revoked,
+	})
+}
+
 func (h *AuthHandler) Me(c *fiber.Ctx) error {
     // Me godoc
     // @Summary Profil pengguna saat ini
@@ -152,9 +213,26 @@ func (h *AuthHandler) Me(c *fiber.Ctx) error {
     userEmail := c.Locals("user_email").(string)
     userRole := c.Locals("user_role").(domain.Role)
 
-    return response.Success(c, "User profile retrieved successfully", map[string]interface{}{
-        "id":    userID,
-        "email": userEmail,
-        "role":  string(userRole),
-    })
-}
\ No newline at end of file
+    profile := map[string]interface{}{
+        "id":          userID,
+        "email":       userEmail,
+        "role":        string(userRole),
+        "permissions": middleware.GetPermissionsFromContext(c).List(),
+    }
+    if customerID, ok := middleware.GetCustomerIDFromContext(c); ok {
+        profile["customer_id"] = customerID
+    }
+    if technicianID, ok := middleware.GetTechnicianIDFromContext(c); ok {
+        profile["technician_id"] = technicianID
+    }
+
+    return response.Success(c, "User profile retrieved successfully", profile)
+}
+
+// clientInfo describes the device making the request, for tracking refresh tokens
+func clientInfo(c *fiber.Ctx) service.ClientInfo {
+	return service.ClientInfo{
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+		IPAddress: c.IP(),
+	}
+}
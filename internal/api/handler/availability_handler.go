@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"strconv"
+
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/api/response"
+	"dashboard-ac-backend/internal/apperror"
+	"dashboard-ac-backend/internal/service"
+	"dashboard-ac-backend/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AvailabilityHandler struct {
+	availabilityService service.AvailabilityService
+}
+
+func NewAvailabilityHandler(availabilityService service.AvailabilityService) *AvailabilityHandler {
+	return &AvailabilityHandler{
+		availabilityService: availabilityService,
+	}
+}
+
+// GetTechnicianAvailability returns the free start times of one technician
+// @Summary Get technician availability
+// @Description Get the open start times of a technician on a day for a given service
+// @Tags technicians
+// @Accept json
+// @Produce json
+// @Param id path string true "Technician ID"
+// @Param date query string true "Date (YYYY-MM-DD)"
+// @Param service_id query string true "Service ID"
+// @Param interval query int false "Minutes between candidate slots" default(30)
+// @Success 200 {object} response.BaseResponse{data=service.TechnicianAvailability}
+// @Failure 400 {object} response.BaseResponse
+// @Failure 404 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /technicians/{id}/availability [get]
+func (h *AvailabilityHandler) GetTechnicianAvailability(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return apperror.Validation("missing_parameter", "Technician ID is required")
+	}
+
+	req, err := availabilityRequestFromQuery(c)
+	if err != nil {
+		return err
+	}
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return apperror.ValidationFailed(errors)
+	}
+
+	availability, err := h.availabilityService.GetTechnicianAvailability(c.UserContext(), id, req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Technician availability retrieved successfully", availability)
+}
+
+// GetAvailability returns the free start times of every technician
+// @Summary Get availability of all technicians
+// @Description Get the open start times of every technician on a day for a given service
+// @Tags technicians
+// @Accept json
+// @Produce json
+// @Param date query string true "Date (YYYY-MM-DD)"
+// @Param service_id query string true "Service ID"
+// @Param interval query int false "Minutes between candidate slots" default(30)
+// @Success 200 {object} response.BaseResponse{data=[]service.TechnicianAvailability}
+// @Failure 400 {object} response.BaseResponse
+// @Failure 404 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /technicians/availability [get]
+func (h *AvailabilityHandler) GetAvailability(c *fiber.Ctx) error {
+	req, err := availabilityRequestFromQuery(c)
+	if err != nil {
+		return err
+	}
+	if errors := utils.ValidateStruct(req); len(errors) > 0 {
+		return apperror.ValidationFailed(errors)
+	}
+
+	availability, err := h.availabilityService.GetAvailability(c.UserContext(), req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Technician availability retrieved successfully", availability)
+}
+
+// availabilityRequestFromQuery reads the availability query. A missing interval takes
+// the default; one that is not a number is rejected rather than ignored.
+func availabilityRequestFromQuery(c *fiber.Ctx) (*request.AvailabilityRequest, error) {
+	interval, err := strconv.Atoi(c.Query("interval", strconv.Itoa(service.DefaultSlotInterval)))
+	if err != nil {
+		return nil, apperror.Validation("invalid_interval", "interval must be a number of minutes",
+			apperror.FieldError{Field: "interval", Message: "interval must be a whole number of minutes"})
+	}
+
+	return &request.AvailabilityRequest{
+		Date:      c.Query("date"),
+		ServiceID: c.Query("service_id"),
+		Interval:  interval,
+	}, nil
+}
@@ -1,10 +1,10 @@
 package handler
 
 import (
-	"strconv"
-
 	"dashboard-ac-backend/internal/api/request"
 	"dashboard-ac-backend/internal/api/response"
+	"dashboard-ac-backend/internal/apperror"
+	"dashboard-ac-backend/internal/domain"
 	"dashboard-ac-backend/internal/service"
 
 	"github.com/gofiber/fiber/v2"
@@ -34,12 +34,12 @@ func NewCustomerHandler(customerService service.CustomerService) *CustomerHandle
 func (h *CustomerHandler) CreateCustomer(c *fiber.Ctx) error {
 	var req request.CustomerCreateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return response.BadRequest(c, "Invalid request body", err.Error())
+		return apperror.InvalidBody(err)
 	}
 
-	customer, err := h.customerService.Create(&req)
+	customer, err := h.customerService.Create(c.UserContext(), &req)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to create customer")
+		return err
 	}
 
 	return response.Created(c, "Customer created successfully", customer)
@@ -60,15 +60,12 @@ func (h *CustomerHandler) CreateCustomer(c *fiber.Ctx) error {
 func (h *CustomerHandler) GetCustomer(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "Customer ID is required", nil)
+		return apperror.Validation("missing_parameter", "Customer ID is required")
 	}
 
-	customer, err := h.customerService.GetByID(id)
+	customer, err := h.customerService.GetByID(c.UserContext(), id)
 	if err != nil {
-		if err.Error() == "customer not found" {
-			return response.NotFound(c, "Customer not found")
-		}
-		return response.InternalServerError(c, "Failed to get customer")
+		return err
 	}
 
 	return response.Success(c, "Customer retrieved successfully", customer)
@@ -90,20 +87,17 @@ func (h *CustomerHandler) GetCustomer(c *fiber.Ctx) error {
 func (h *CustomerHandler) UpdateCustomer(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "Customer ID is required", nil)
+		return apperror.Validation("missing_parameter", "Customer ID is required")
 	}
 
 	var req request.CustomerUpdateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return response.BadRequest(c, "Invalid request body", err.Error())
+		return apperror.InvalidBody(err)
 	}
 
-	customer, err := h.customerService.Update(id, &req)
+	customer, err := h.customerService.Update(c.UserContext(), id, &req)
 	if err != nil {
-		if err.Error() == "customer not found" {
-			return response.NotFound(c, "Customer not found")
-		}
-		return response.InternalServerError(c, "Failed to update customer")
+		return err
 	}
 
 	return response.Success(c, "Customer updated successfully", customer)
@@ -124,15 +118,12 @@ func (h *CustomerHandler) UpdateCustomer(c *fiber.Ctx) error {
 func (h *CustomerHandler) DeleteCustomer(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "Customer ID is required", nil)
+		return apperror.Validation("missing_parameter", "Customer ID is required")
 	}
 
-	err := h.customerService.Delete(id)
+	err := h.customerService.Delete(c.UserContext(), id)
 	if err != nil {
-		if err.Error() == "customer not found" {
-			return response.NotFound(c, "Customer not found")
-		}
-		return response.InternalServerError(c, "Failed to delete customer")
+		return err
 	}
 
 	return response.Success(c, "Customer deleted successfully", nil)
@@ -146,26 +137,23 @@ func (h *CustomerHandler) DeleteCustomer(c *fiber.Ctx) error {
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param cursor query string false "Page by cursor instead of page number; pass it empty for the first page, then next_cursor or prev_cursor from the previous response"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. name,-created_at"
+// @Param filter query string false "Comma separated field:operator:value conditions, e.g. email:like:gmail.com"
+// @Param search query string false "Text matched against the name, phone and email"
 // @Success 200 {object} response.BaseResponse{data=[]domain.Customer}
 // @Failure 400 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
 // @Router /customers [get]
 func (h *CustomerHandler) ListCustomers(c *fiber.Ctx) error {
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	pagination := request.GetPaginationFromQuery(c)
 
-	pagination := &request.PaginationRequest{
-		Page:  page,
-		Limit: limit,
-	}
-
-	customers, total, err := h.customerService.List(pagination)
+	customers, page, err := h.customerService.List(c.UserContext(), pagination)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to get customers")
+		return err
 	}
 
-	paginationMeta := response.CalculatePagination(page, limit, total)
-	return response.Paginated(c, "Customers retrieved successfully", customers, paginationMeta)
+	return paginated(c, "Customers retrieved successfully", customers, pagination, page)
 }
 
 // SearchCustomers searches for customers based on criteria
@@ -179,29 +167,66 @@ func (h *CustomerHandler) ListCustomers(c *fiber.Ctx) error {
 // @Param email query string false "Customer email"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param cursor query string false "Page by cursor instead of page number; pass it empty for the first page, then next_cursor or prev_cursor from the previous response"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. name,-created_at"
+// @Param filter query string false "Comma separated field:operator:value conditions, e.g. email:like:gmail.com"
+// @Param search query string false "Text matched against the name, phone and email"
 // @Success 200 {object} response.BaseResponse{data=[]domain.Customer}
 // @Failure 400 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
 // @Router /customers/search [get]
 func (h *CustomerHandler) SearchCustomers(c *fiber.Ctx) error {
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	pagination := request.GetPaginationFromQuery(c)
 
 	searchReq := &request.CustomerSearchRequest{
-		PaginationRequest: &request.PaginationRequest{
-			Page:  page,
-			Limit: limit,
-		},
-		Name:  c.Query("name"),
-		Phone: c.Query("phone"),
-		Email: c.Query("email"),
+		PaginationRequest: pagination,
+		Name:              c.Query("name"),
+		Phone:             c.Query("phone"),
+		Email:             c.Query("email"),
 	}
 
-	customers, total, err := h.customerService.Search(searchReq)
+	customers, page, err := h.customerService.Search(c.UserContext(), searchReq)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to search customers")
+		return err
+	}
+
+	return paginated(c, "Customer search completed successfully", customers, pagination, page)
+}
+
+// ExportCustomers downloads the customers matching the search criteria as a spreadsheet
+// @Summary Export customers
+// @Description Download the customers matching the search criteria as a CSV or Excel file
+// @Tags customers
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "File format" Enums(csv, xlsx) default(csv)
+// @Param name query string false "Customer name"
+// @Param phone query string false "Customer phone"
+// @Param email query string false "Customer email"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. name,-created_at"
+// @Param filter query string false "Comma separated field:operator:value conditions, e.g. email:like:gmail.com"
+// @Param search query string false "Text matched against the name, phone and email"
+// @Success 200 {file} file
+// @Failure 400 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /customers/export [get]
+func (h *CustomerHandler) ExportCustomers(c *fiber.Ctx) error {
+	searchReq := &request.CustomerSearchRequest{
+		PaginationRequest: request.GetPaginationFromQuery(c),
+		Name:              c.Query("name"),
+		Phone:             c.Query("phone"),
+		Email:             c.Query("email"),
 	}
 
-	paginationMeta := response.CalculatePagination(page, limit, total)
-	return response.Paginated(c, "Customer search completed successfully", customers, paginationMeta)
+	header := []interface{}{"ID", "Name", "Phone", "Email", "Address", "Created At"}
+	return exportFile(c, "customers", header, h.customerService.Export(c.UserContext(), searchReq), func(customer *domain.Customer) []interface{} {
+		return []interface{}{
+			customer.ID.String(),
+			customer.Name,
+			customer.Phone,
+			customer.Email,
+			customer.Address,
+			customer.CreatedAt.Format("2006-01-02 15:04:05"),
+		}
+	})
 }
\ No newline at end of file
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/api/response"
+	"dashboard-ac-backend/internal/apperror"
+	"dashboard-ac-backend/internal/service"
+	"dashboard-ac-backend/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type DashboardHandler struct {
+	dashboardService service.DashboardService
+}
+
+func NewDashboardHandler(dashboardService service.DashboardService) *DashboardHandler {
+	return &DashboardHandler{
+		dashboardService: dashboardService,
+	}
+}
+
+// GetSummary returns the key figures of a date range
+// @Summary Dashboard summary
+// @Description Revenue (invoiced, paid, outstanding, overdue), job counts by status, top services by revenue and technician utilization for a date range
+// @Tags dashboard
+// @Produce json
+// @Param date_from query string false "Date from (YYYY-MM-DD), defaults to the start of the month of date_to"
+// @Param date_to query string false "Date to, inclusive (YYYY-MM-DD), defaults to today"
+// @Param top query int false "Number of top services" default(5)
+// @Success 200 {object} response.BaseResponse{data=service.DashboardSummary}
+// @Failure 400 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /dashboard/summary [get]
+func (h *DashboardHandler) GetSummary(c *fiber.Ctx) error {
+	req := request.DashboardSummaryRequest{
+		DateFrom: c.Query("date_from"),
+		DateTo:   c.Query("date_to"),
+		Top:      c.QueryInt("top"),
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(&req); len(errors) > 0 {
+		return apperror.ValidationFailed(errors)
+	}
+
+	summary, err := h.dashboardService.Summary(c.UserContext(), &req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Dashboard summary retrieved successfully", summary)
+}
+
+// GetRevenueTimeseries returns revenue per period
+// @Summary Revenue time series
+// @Description Invoiced and paid amounts per day, week (starting Monday) or month; periods without revenue are listed with zeros
+// @Tags dashboard
+// @Produce json
+// @Param interval query string false "Period length" Enums(day, week, month) default(day)
+// @Param date_from query string false "Date from (YYYY-MM-DD), defaults to 30 days, 12 weeks or 12 months before date_to"
+// @Param date_to query string false "Date to, inclusive (YYYY-MM-DD), defaults to today"
+// @Success 200 {object} response.BaseResponse{data=service.RevenueTimeseries}
+// @Failure 400 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /dashboard/revenue-timeseries [get]
+func (h *DashboardHandler) GetRevenueTimeseries(c *fiber.Ctx) error {
+	req := request.RevenueTimeseriesRequest{
+		DateFrom: c.Query("date_from"),
+		DateTo:   c.Query("date_to"),
+		Interval: c.Query("interval"),
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(&req); len(errors) > 0 {
+		return apperror.ValidationFailed(errors)
+	}
+
+	timeseries, err := h.dashboardService.RevenueTimeseries(c.UserContext(), &req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Revenue time series retrieved successfully", timeseries)
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"bufio"
+	"fmt"
+	"time"
+
+	"dashboard-ac-backend/internal/api/middleware"
+	"dashboard-ac-backend/internal/apperror"
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/pkg/export"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var ErrInvalidExportFormat = apperror.Validation("invalid_format", "invalid export format",
+	apperror.FieldError{Field: "format", Message: "format must be one of: csv xlsx"})
+
+// exportFile responds with the records next loads, batch by batch, as a CSV or XLSX
+// file named after name, one row per record. The first batch is loaded before the
+// response starts so that a bad filter or sort is still reported as an error; once
+// the file is streaming a failure can only cut it short, and is logged.
+func exportFile[T any](c *fiber.Ctx, name string, header []interface{}, next func() ([]T, error), row func(T) []interface{}) error {
+	format, err := export.ParseFormat(c.Query("format"))
+	if err != nil {
+		return ErrInvalidExportFormat
+	}
+
+	batch, err := next()
+	if err != nil {
+		return err
+	}
+
+	log := middleware.RequestLogger(c)
+	c.Set(fiber.HeaderContentType, format.ContentType())
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s-%s.%s"`, name, time.Now().Format("20060102"), format))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		if err := writeExport(w, format, name, header, batch, next, row); err != nil {
+			log.Error().Err(err).Str("export", name).Msg("Export failed")
+		}
+	})
+	return nil
+}
+
+// writeExport writes the header and a row for every record, starting with the batch
+// already loaded, and flushes each batch to the client as it goes
+func writeExport[T any](w *bufio.Writer, format export.Format, name string, header []interface{}, batch []T, next func() ([]T, error), row func(T) []interface{}) error {
+	writer, err := export.NewWriter(format, w, name)
+	if err != nil {
+		return err
+	}
+	if err := writer.WriteRow(header...); err != nil {
+		return err
+	}
+
+	for len(batch) > 0 {
+		for _, record := range batch {
+			if err := writer.WriteRow(row(record)...); err != nil {
+				return err
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			return err
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if batch, err = next(); err != nil {
+			return err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// Helper functions to name the related records of an exported row, blank when the
+// record was not loaded
+
+func customerName(customer *domain.Customer) string {
+	if customer == nil {
+		return ""
+	}
+	return customer.Name
+}
+
+func technicianName(technician *domain.Technician) string {
+	if technician == nil {
+		return ""
+	}
+	return technician.Name
+}
+
+func serviceName(service *domain.Service) string {
+	if service == nil {
+		return ""
+	}
+	return service.Name
+}
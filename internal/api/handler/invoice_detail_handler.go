@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"dashboard-ac-backend/internal/api/middleware"
 	"dashboard-ac-backend/internal/api/request"
 	"dashboard-ac-backend/internal/api/response"
+	"dashboard-ac-backend/internal/apperror"
 	"dashboard-ac-backend/internal/service"
 
 	"github.com/gofiber/fiber/v2"
@@ -27,17 +29,18 @@ func NewInvoiceDetailHandler(invoiceDetailService service.InvoiceDetailService)
 // @Param invoice_detail body request.InvoiceDetailCreateRequest true "Invoice detail creation data"
 // @Success 201 {object} response.BaseResponse{data=domain.InvoiceDetail}
 // @Failure 400 {object} response.BaseResponse
+// @Failure 404 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
 // @Router /invoice-details [post]
 func (h *InvoiceDetailHandler) CreateInvoiceDetail(c *fiber.Ctx) error {
 	var req request.InvoiceDetailCreateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return response.BadRequest(c, "Invalid request body", err.Error())
+		return apperror.InvalidBody(err)
 	}
 
-	invoiceDetail, err := h.invoiceDetailService.Create(&req)
+	invoiceDetail, err := h.invoiceDetailService.Create(c.UserContext(), middleware.GetAccessScope(c), &req)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to create invoice detail")
+		return err
 	}
 
 	return response.Created(c, "Invoice detail created successfully", invoiceDetail)
@@ -58,15 +61,12 @@ func (h *InvoiceDetailHandler) CreateInvoiceDetail(c *fiber.Ctx) error {
 func (h *InvoiceDetailHandler) GetInvoiceDetail(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "Invoice detail ID is required", nil)
+		return apperror.Validation("missing_parameter", "Invoice detail ID is required")
 	}
 
-	invoiceDetail, err := h.invoiceDetailService.GetByID(id)
+	invoiceDetail, err := h.invoiceDetailService.GetByID(c.UserContext(), middleware.GetAccessScope(c), id)
 	if err != nil {
-		if err.Error() == "invoice detail not found" {
-			return response.NotFound(c, "Invoice detail not found")
-		}
-		return response.InternalServerError(c, "Failed to get invoice detail")
+		return err
 	}
 
 	return response.Success(c, "Invoice detail retrieved successfully", invoiceDetail)
@@ -88,20 +88,17 @@ func (h *InvoiceDetailHandler) GetInvoiceDetail(c *fiber.Ctx) error {
 func (h *InvoiceDetailHandler) UpdateInvoiceDetail(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "Invoice detail ID is required", nil)
+		return apperror.Validation("missing_parameter", "Invoice detail ID is required")
 	}
 
 	var req request.InvoiceDetailUpdateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return response.BadRequest(c, "Invalid request body", err.Error())
+		return apperror.InvalidBody(err)
 	}
 
-	invoiceDetail, err := h.invoiceDetailService.Update(id, &req)
+	invoiceDetail, err := h.invoiceDetailService.Update(c.UserContext(), middleware.GetAccessScope(c), id, &req)
 	if err != nil {
-		if err.Error() == "invoice detail not found" {
-			return response.NotFound(c, "Invoice detail not found")
-		}
-		return response.InternalServerError(c, "Failed to update invoice detail")
+		return err
 	}
 
 	return response.Success(c, "Invoice detail updated successfully", invoiceDetail)
@@ -122,15 +119,12 @@ func (h *InvoiceDetailHandler) UpdateInvoiceDetail(c *fiber.Ctx) error {
 func (h *InvoiceDetailHandler) DeleteInvoiceDetail(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "Invoice detail ID is required", nil)
+		return apperror.Validation("missing_parameter", "Invoice detail ID is required")
 	}
 
-	err := h.invoiceDetailService.Delete(id)
+	err := h.invoiceDetailService.Delete(c.UserContext(), middleware.GetAccessScope(c), id)
 	if err != nil {
-		if err.Error() == "invoice detail not found" {
-			return response.NotFound(c, "Invoice detail not found")
-		}
-		return response.InternalServerError(c, "Failed to delete invoice detail")
+		return err
 	}
 
 	return response.Success(c, "Invoice detail deleted successfully", nil)
@@ -145,17 +139,18 @@ func (h *InvoiceDetailHandler) DeleteInvoiceDetail(c *fiber.Ctx) error {
 // @Param invoice_id path string true "Invoice ID"
 // @Success 200 {object} response.BaseResponse{data=[]domain.InvoiceDetail}
 // @Failure 400 {object} response.BaseResponse
+// @Failure 404 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
 // @Router /invoice-details/invoice/{invoice_id} [get]
 func (h *InvoiceDetailHandler) GetInvoiceDetailsByInvoice(c *fiber.Ctx) error {
 	invoiceID := c.Params("invoice_id")
 	if invoiceID == "" {
-		return response.BadRequest(c, "Invoice ID is required", nil)
+		return apperror.Validation("missing_parameter", "Invoice ID is required")
 	}
 
-	invoiceDetails, err := h.invoiceDetailService.GetByInvoiceID(invoiceID)
+	invoiceDetails, err := h.invoiceDetailService.GetByInvoiceID(c.UserContext(), middleware.GetAccessScope(c), invoiceID)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to get invoice details")
+		return err
 	}
 
 	return response.Success(c, "Invoice details retrieved successfully", invoiceDetails)
@@ -170,17 +165,18 @@ func (h *InvoiceDetailHandler) GetInvoiceDetailsByInvoice(c *fiber.Ctx) error {
 // @Param invoice_id path string true "Invoice ID"
 // @Success 200 {object} response.BaseResponse
 // @Failure 400 {object} response.BaseResponse
+// @Failure 404 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
 // @Router /invoice-details/invoice/{invoice_id} [delete]
 func (h *InvoiceDetailHandler) DeleteInvoiceDetailsByInvoice(c *fiber.Ctx) error {
 	invoiceID := c.Params("invoice_id")
 	if invoiceID == "" {
-		return response.BadRequest(c, "Invoice ID is required", nil)
+		return apperror.Validation("missing_parameter", "Invoice ID is required")
 	}
 
-	err := h.invoiceDetailService.DeleteByInvoiceID(invoiceID)
+	err := h.invoiceDetailService.DeleteByInvoiceID(c.UserContext(), middleware.GetAccessScope(c), invoiceID)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to delete invoice details")
+		return err
 	}
 
 	return response.Success(c, "Invoice details deleted successfully", nil)
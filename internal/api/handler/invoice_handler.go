@@ -1,12 +1,13 @@
 package handler
 
 import (
-	"strconv"
-
+	"dashboard-ac-backend/internal/api/middleware"
 	"dashboard-ac-backend/internal/api/request"
 	"dashboard-ac-backend/internal/api/response"
+	"dashboard-ac-backend/internal/apperror"
 	"dashboard-ac-backend/internal/domain"
 	"dashboard-ac-backend/internal/service"
+	"dashboard-ac-backend/pkg/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -23,7 +24,7 @@ func NewInvoiceHandler(invoiceService service.InvoiceService) *InvoiceHandler {
 
 // CreateInvoice creates a new invoice
 // @Summary Create a new invoice
-// @Description Create a new invoice with the provided information
+// @Description Create a new invoice, optionally with line items, in a single transaction
 // @Tags invoices
 // @Accept json
 // @Produce json
@@ -35,12 +36,43 @@ func NewInvoiceHandler(invoiceService service.InvoiceService) *InvoiceHandler {
 func (h *InvoiceHandler) CreateInvoice(c *fiber.Ctx) error {
 	var req request.InvoiceCreateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return response.BadRequest(c, "Invalid request body", err.Error())
+		return apperror.InvalidBody(err)
+	}
+
+	// Validate request, including each line item
+	if errors := utils.ValidateStruct(&req); len(errors) > 0 {
+		return apperror.ValidationFailed(errors)
+	}
+
+	invoice, err := h.invoiceService.Create(c.UserContext(), middleware.GetAccessScope(c), &req)
+	if err != nil {
+		return err
+	}
+
+	return response.Created(c, "Invoice created successfully", invoice)
+}
+
+// CreateInvoiceFromSchedule bills a completed schedule
+// @Summary Create invoice from schedule
+// @Description Create an invoice with one line for the schedule's service at its current price
+// @Tags invoices
+// @Accept json
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Success 201 {object} response.BaseResponse{data=domain.Invoice}
+// @Failure 404 {object} response.BaseResponse
+// @Failure 409 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /schedules/{id}/invoice [post]
+func (h *InvoiceHandler) CreateInvoiceFromSchedule(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return apperror.Validation("missing_parameter", "Schedule ID is required")
 	}
 
-	invoice, err := h.invoiceService.Create(&req)
+	invoice, err := h.invoiceService.CreateFromSchedule(c.UserContext(), middleware.GetAccessScope(c), id)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to create invoice")
+		return err
 	}
 
 	return response.Created(c, "Invoice created successfully", invoice)
@@ -53,6 +85,7 @@ func (h *InvoiceHandler) CreateInvoice(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path string true "Invoice ID"
+// @Param include query string false "Comma separated related records to embed: customer, schedule, technician, service, details"
 // @Success 200 {object} response.BaseResponse{data=domain.Invoice}
 // @Failure 400 {object} response.BaseResponse
 // @Failure 404 {object} response.BaseResponse
@@ -61,15 +94,16 @@ func (h *InvoiceHandler) CreateInvoice(c *fiber.Ctx) error {
 func (h *InvoiceHandler) GetInvoice(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "Invoice ID is required", nil)
+		return apperror.Validation("missing_parameter", "Invoice ID is required")
 	}
 
-	invoice, err := h.invoiceService.GetByID(id)
+	invoice, err := h.invoiceService.GetByID(c.UserContext(), middleware.GetAccessScope(c), id)
 	if err != nil {
-		if err.Error() == "invoice not found" {
-			return response.NotFound(c, "Invoice not found")
-		}
-		return response.InternalServerError(c, "Failed to get invoice")
+		return err
+	}
+
+	if err := h.invoiceService.LoadRelations(c.UserContext(), invoice, c.Query("include")); err != nil {
+		return err
 	}
 
 	return response.Success(c, "Invoice retrieved successfully", invoice)
@@ -86,25 +120,28 @@ func (h *InvoiceHandler) GetInvoice(c *fiber.Ctx) error {
 // @Success 200 {object} response.BaseResponse{data=domain.Invoice}
 // @Failure 400 {object} response.BaseResponse
 // @Failure 404 {object} response.BaseResponse
+// @Failure 409 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
 // @Router /invoices/{id} [put]
 func (h *InvoiceHandler) UpdateInvoice(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "Invoice ID is required", nil)
+		return apperror.Validation("missing_parameter", "Invoice ID is required")
 	}
 
 	var req request.InvoiceUpdateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return response.BadRequest(c, "Invalid request body", err.Error())
+		return apperror.InvalidBody(err)
+	}
+
+	// Technicians may only change invoices of their own jobs
+	if _, err := h.invoiceService.GetByID(c.UserContext(), middleware.GetAccessScope(c), id); err != nil {
+		return err
 	}
 
-	invoice, err := h.invoiceService.Update(id, &req)
+	invoice, err := h.invoiceService.Update(c.UserContext(), id, &req)
 	if err != nil {
-		if err.Error() == "invoice not found" {
-			return response.NotFound(c, "Invoice not found")
-		}
-		return response.InternalServerError(c, "Failed to update invoice")
+		return err
 	}
 
 	return response.Success(c, "Invoice updated successfully", invoice)
@@ -125,15 +162,16 @@ func (h *InvoiceHandler) UpdateInvoice(c *fiber.Ctx) error {
 func (h *InvoiceHandler) DeleteInvoice(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "Invoice ID is required", nil)
+		return apperror.Validation("missing_parameter", "Invoice ID is required")
+	}
+
+	if _, err := h.invoiceService.GetByID(c.UserContext(), middleware.GetAccessScope(c), id); err != nil {
+		return err
 	}
 
-	err := h.invoiceService.Delete(id)
+	err := h.invoiceService.Delete(c.UserContext(), id)
 	if err != nil {
-		if err.Error() == "invoice not found" {
-			return response.NotFound(c, "Invoice not found")
-		}
-		return response.InternalServerError(c, "Failed to delete invoice")
+		return err
 	}
 
 	return response.Success(c, "Invoice deleted successfully", nil)
@@ -147,26 +185,23 @@ func (h *InvoiceHandler) DeleteInvoice(c *fiber.Ctx) error {
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param cursor query string false "Page by cursor instead of page number; pass it empty for the first page, then next_cursor or prev_cursor from the previous response"
+// @Param include query string false "Comma separated related records to embed: customer, schedule, technician, service, details"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. -invoice_date,total_amount"
+// @Param filter query string false "Comma separated field:operator:value conditions, e.g. status:eq:Paid,total_amount:gte:500000"
 // @Success 200 {object} response.BaseResponse{data=[]domain.Invoice}
 // @Failure 400 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
 // @Router /invoices [get]
 func (h *InvoiceHandler) ListInvoices(c *fiber.Ctx) error {
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
-
-	pagination := &request.PaginationRequest{
-		Page:  page,
-		Limit: limit,
-	}
+	pagination := request.GetPaginationFromQuery(c)
 
-	invoices, total, err := h.invoiceService.List(pagination)
+	invoices, page, err := h.invoiceService.List(c.UserContext(), middleware.GetAccessScope(c), pagination)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to get invoices")
+		return err
 	}
 
-	paginationMeta := response.CalculatePagination(page, limit, total)
-	return response.Paginated(c, "Invoices retrieved successfully", invoices, paginationMeta)
+	return paginated(c, "Invoices retrieved successfully", invoices, pagination, page)
 }
 
 // SearchInvoices searches for invoices based on criteria
@@ -182,33 +217,83 @@ func (h *InvoiceHandler) ListInvoices(c *fiber.Ctx) error {
 // @Param date_to query string false "Date to (YYYY-MM-DD)"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param cursor query string false "Page by cursor instead of page number; pass it empty for the first page, then next_cursor or prev_cursor from the previous response"
+// @Param include query string false "Comma separated related records to embed: customer, schedule, technician, service, details"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. -invoice_date,total_amount"
+// @Param filter query string false "Comma separated field:operator:value conditions, e.g. status:eq:Paid,total_amount:gte:500000"
 // @Success 200 {object} response.BaseResponse{data=[]domain.Invoice}
 // @Failure 400 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
 // @Router /invoices/search [get]
 func (h *InvoiceHandler) SearchInvoices(c *fiber.Ctx) error {
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	pagination := request.GetPaginationFromQuery(c)
 
 	searchReq := &request.InvoiceSearchRequest{
-		PaginationRequest: &request.PaginationRequest{
-			Page:  page,
-			Limit: limit,
-		},
-		CustomerID: c.Query("customer_id"),
-		ScheduleID: c.Query("schedule_id"),
-		Status:     c.Query("status"),
-		DateFrom:   c.Query("date_from"),
-		DateTo:     c.Query("date_to"),
+		PaginationRequest: pagination,
+		CustomerID:        c.Query("customer_id"),
+		ScheduleID:        c.Query("schedule_id"),
+		Status:            c.Query("status"),
+		DateFrom:          c.Query("date_from"),
+		DateTo:            c.Query("date_to"),
 	}
 
-	invoices, total, err := h.invoiceService.Search(searchReq)
+	invoices, page, err := h.invoiceService.Search(c.UserContext(), middleware.GetAccessScope(c), searchReq)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to search invoices")
+		return err
+	}
+
+	return paginated(c, "Invoice search completed successfully", invoices, pagination, page)
+}
+
+// ExportInvoices downloads the invoices matching the search criteria as a spreadsheet
+// @Summary Export invoices
+// @Description Download the invoices matching the search criteria, with customer, technician and service names and totals, as a CSV or Excel file
+// @Tags invoices
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "File format" Enums(csv, xlsx) default(csv)
+// @Param customer_id query string false "Customer ID"
+// @Param schedule_id query string false "Schedule ID"
+// @Param status query string false "Invoice status"
+// @Param date_from query string false "Date from (YYYY-MM-DD)"
+// @Param date_to query string false "Date to (YYYY-MM-DD)"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. -invoice_date,total_amount"
+// @Param filter query string false "Comma separated field:operator:value conditions, e.g. status:eq:Paid,total_amount:gte:500000"
+// @Success 200 {file} file
+// @Failure 400 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /invoices/export [get]
+func (h *InvoiceHandler) ExportInvoices(c *fiber.Ctx) error {
+	searchReq := &request.InvoiceSearchRequest{
+		PaginationRequest: request.GetPaginationFromQuery(c),
+		CustomerID:        c.Query("customer_id"),
+		ScheduleID:        c.Query("schedule_id"),
+		Status:            c.Query("status"),
+		DateFrom:          c.Query("date_from"),
+		DateTo:            c.Query("date_to"),
 	}
 
-	paginationMeta := response.CalculatePagination(page, limit, total)
-	return response.Paginated(c, "Invoice search completed successfully", invoices, paginationMeta)
+	header := []interface{}{"ID", "Invoice Date", "Due Date", "Status", "Customer", "Technician", "Service", "Total Amount", "Amount Paid", "Balance Due"}
+	invoices := h.invoiceService.Export(c.UserContext(), middleware.GetAccessScope(c), searchReq)
+	return exportFile(c, "invoices", header, invoices, func(invoice *domain.Invoice) []interface{} {
+		var technician, service string
+		if invoice.Schedule != nil {
+			technician = technicianName(invoice.Schedule.Technician)
+			service = serviceName(invoice.Schedule.Service)
+		}
+		return []interface{}{
+			invoice.ID.String(),
+			invoice.InvoiceDate.Format("2006-01-02"),
+			invoice.DueDate.Format("2006-01-02"),
+			string(invoice.Status),
+			customerName(invoice.Customer),
+			technician,
+			service,
+			invoice.TotalAmount,
+			invoice.AmountPaid,
+			invoice.BalanceDue,
+		}
+	})
 }
 
 // GetInvoicesByCustomer retrieves invoices by customer ID
@@ -220,6 +305,10 @@ func (h *InvoiceHandler) SearchInvoices(c *fiber.Ctx) error {
 // @Param customer_id path string true "Customer ID"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param cursor query string false "Page by cursor instead of page number; pass it empty for the first page, then next_cursor or prev_cursor from the previous response"
+// @Param include query string false "Comma separated related records to embed: customer, schedule, technician, service, details"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. -invoice_date,total_amount"
+// @Param filter query string false "Comma separated field:operator:value conditions, e.g. status:eq:Paid,total_amount:gte:500000"
 // @Success 200 {object} response.BaseResponse{data=[]domain.Invoice}
 // @Failure 400 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
@@ -227,24 +316,17 @@ func (h *InvoiceHandler) SearchInvoices(c *fiber.Ctx) error {
 func (h *InvoiceHandler) GetInvoicesByCustomer(c *fiber.Ctx) error {
 	customerID := c.Params("customer_id")
 	if customerID == "" {
-		return response.BadRequest(c, "Customer ID is required", nil)
+		return apperror.Validation("missing_parameter", "Customer ID is required")
 	}
 
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	pagination := request.GetPaginationFromQuery(c)
 
-	pagination := &request.PaginationRequest{
-		Page:  page,
-		Limit: limit,
-	}
-
-	invoices, total, err := h.invoiceService.GetByCustomerID(customerID, pagination)
+	invoices, page, err := h.invoiceService.GetByCustomerID(c.UserContext(), middleware.GetAccessScope(c), customerID, pagination)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to get invoices")
+		return err
 	}
 
-	paginationMeta := response.CalculatePagination(page, limit, total)
-	return response.Paginated(c, "Customer invoices retrieved successfully", invoices, paginationMeta)
+	return paginated(c, "Customer invoices retrieved successfully", invoices, pagination, page)
 }
 
 // GetInvoicesBySchedule retrieves invoices by schedule ID
@@ -254,6 +336,7 @@ func (h *InvoiceHandler) GetInvoicesByCustomer(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param schedule_id path string true "Schedule ID"
+// @Param include query string false "Comma separated related records to embed: customer, schedule, technician, service, details"
 // @Success 200 {object} response.BaseResponse{data=domain.Invoice}
 // @Failure 400 {object} response.BaseResponse
 // @Failure 404 {object} response.BaseResponse
@@ -262,15 +345,16 @@ func (h *InvoiceHandler) GetInvoicesByCustomer(c *fiber.Ctx) error {
 func (h *InvoiceHandler) GetInvoicesBySchedule(c *fiber.Ctx) error {
 	scheduleID := c.Params("schedule_id")
 	if scheduleID == "" {
-		return response.BadRequest(c, "Schedule ID is required", nil)
+		return apperror.Validation("missing_parameter", "Schedule ID is required")
 	}
 
-	invoice, err := h.invoiceService.GetByScheduleID(scheduleID)
+	invoice, err := h.invoiceService.GetByScheduleID(c.UserContext(), middleware.GetAccessScope(c), scheduleID)
 	if err != nil {
-		if err.Error() == "invoice not found" {
-			return response.NotFound(c, "Invoice not found")
-		}
-		return response.InternalServerError(c, "Failed to get invoice")
+		return err
+	}
+
+	if err := h.invoiceService.LoadRelations(c.UserContext(), invoice, c.Query("include")); err != nil {
+		return err
 	}
 
 	return response.Success(c, "Schedule invoice retrieved successfully", invoice)
@@ -285,6 +369,10 @@ func (h *InvoiceHandler) GetInvoicesBySchedule(c *fiber.Ctx) error {
 // @Param status path string true "Invoice status"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param cursor query string false "Page by cursor instead of page number; pass it empty for the first page, then next_cursor or prev_cursor from the previous response"
+// @Param include query string false "Comma separated related records to embed: customer, schedule, technician, service, details"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. -invoice_date,total_amount"
+// @Param filter query string false "Comma separated field:operator:value conditions, e.g. status:eq:Paid,total_amount:gte:500000"
 // @Success 200 {object} response.BaseResponse{data=[]domain.Invoice}
 // @Failure 400 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
@@ -292,24 +380,17 @@ func (h *InvoiceHandler) GetInvoicesBySchedule(c *fiber.Ctx) error {
 func (h *InvoiceHandler) GetInvoicesByStatus(c *fiber.Ctx) error {
 	statusStr := c.Params("status")
 	if statusStr == "" {
-		return response.BadRequest(c, "Status is required", nil)
+		return apperror.Validation("missing_parameter", "Status is required")
 	}
 
 	status := domain.InvoiceStatus(statusStr)
 
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
-
-	pagination := &request.PaginationRequest{
-		Page:  page,
-		Limit: limit,
-	}
+	pagination := request.GetPaginationFromQuery(c)
 
-	invoices, total, err := h.invoiceService.GetByStatus(status, pagination)
+	invoices, page, err := h.invoiceService.GetByStatus(c.UserContext(), middleware.GetAccessScope(c), status, pagination)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to get invoices")
+		return err
 	}
 
-	paginationMeta := response.CalculatePagination(page, limit, total)
-	return response.Paginated(c, "Invoices by status retrieved successfully", invoices, paginationMeta)
+	return paginated(c, "Invoices by status retrieved successfully", invoices, pagination, page)
 }
\ No newline at end of file
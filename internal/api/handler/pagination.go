@@ -0,0 +1,24 @@
+package handler
+
+import (
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/api/response"
+	"dashboard-ac-backend/internal/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// paginated responds with one page of a list, described by cursors when the client
+// paged by cursor and by page number and total otherwise
+func paginated(c *fiber.Ctx, message string, data interface{}, pagination *request.PaginationRequest, page repository.ListPage) error {
+	if pagination.UseCursor {
+		return response.CursorPaginated(c, message, data, response.CursorPaginationMeta{
+			Limit:      pagination.GetLimit(),
+			NextCursor: page.NextCursor,
+			PrevCursor: page.PrevCursor,
+		})
+	}
+
+	paginationMeta := response.CalculatePagination(pagination.Page, pagination.GetLimit(), page.Total)
+	return response.Paginated(c, message, data, paginationMeta)
+}
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"dashboard-ac-backend/internal/api/middleware"
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/api/response"
+	"dashboard-ac-backend/internal/apperror"
+	"dashboard-ac-backend/internal/service"
+	"dashboard-ac-backend/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PasswordHandler struct {
+	passwordService service.PasswordService
+}
+
+func NewPasswordHandler(passwordService service.PasswordService) *PasswordHandler {
+	return &PasswordHandler{
+		passwordService: passwordService,
+	}
+}
+
+// ChangePassword changes the password of the logged-in user
+// @Summary Change password
+// @Description Change the password of the logged-in user. All sessions are ended, so the user has to log in again.
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body request.ChangePasswordRequest true "Change Password Request"
+// @Success 200 {object} response.BaseResponse
+// @Failure 400 {object} response.BaseResponse
+// @Failure 401 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /auth/change-password [post]
+func (h *PasswordHandler) ChangePassword(c *fiber.Ctx) error {
+	var req request.ChangePasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.InvalidBody(err)
+	}
+
+	if errors := utils.ValidateStruct(&req); len(errors) > 0 {
+		return apperror.ValidationFailed(errors)
+	}
+
+	userID, _, _, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.passwordService.ChangePassword(c.UserContext(), userID, &req); err != nil {
+		return err
+	}
+
+	return response.Success(c, "Password changed successfully, please log in again", nil)
+}
+
+// ForgotPassword sends a password reset link
+// @Summary Forgot password
+// @Description Send a single-use password reset link to the account's email. The response is the same whether or not the account exists.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body request.ForgotPasswordRequest true "Forgot Password Request"
+// @Success 200 {object} response.BaseResponse
+// @Failure 400 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /auth/forgot-password [post]
+func (h *PasswordHandler) ForgotPassword(c *fiber.Ctx) error {
+	var req request.ForgotPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.InvalidBody(err)
+	}
+
+	if errors := utils.ValidateStruct(&req); len(errors) > 0 {
+		return apperror.ValidationFailed(errors)
+	}
+
+	if err := h.passwordService.ForgotPassword(c.UserContext(), &req); err != nil {
+		return err
+	}
+
+	return response.Success(c, "If the email belongs to an account, a password reset link has been sent", nil)
+}
+
+// ResetPassword sets a new password with a reset token
+// @Summary Reset password
+// @Description Set a new password with the token from the reset link. All sessions of the user are ended.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body request.ResetPasswordRequest true "Reset Password Request"
+// @Success 200 {object} response.BaseResponse
+// @Failure 400 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /auth/reset-password [post]
+func (h *PasswordHandler) ResetPassword(c *fiber.Ctx) error {
+	var req request.ResetPasswordRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.InvalidBody(err)
+	}
+
+	if errors := utils.ValidateStruct(&req); len(errors) > 0 {
+		return apperror.ValidationFailed(errors)
+	}
+
+	if err := h.passwordService.ResetPassword(c.UserContext(), &req); err != nil {
+		return err
+	}
+
+	return response.Success(c, "Password reset successfully, please log in with the new password", nil)
+}
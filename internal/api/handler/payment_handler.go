@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"dashboard-ac-backend/internal/api/middleware"
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/api/response"
+	"dashboard-ac-backend/internal/apperror"
+	"dashboard-ac-backend/internal/service"
+	"dashboard-ac-backend/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PaymentHandler struct {
+	paymentService service.PaymentService
+}
+
+func NewPaymentHandler(paymentService service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: paymentService,
+	}
+}
+
+// RecordPayment records a payment against an invoice
+// @Summary Record invoice payment
+// @Description Record a full or partial payment; the invoice becomes PartiallyPaid or Paid depending on the remaining balance
+// @Tags invoices
+// @Accept json
+// @Produce json
+// @Param id path string true "Invoice ID"
+// @Param payment body request.PaymentCreateRequest true "Payment data"
+// @Success 201 {object} response.BaseResponse{data=service.PaymentReceipt}
+// @Failure 400 {object} response.BaseResponse
+// @Failure 404 {object} response.BaseResponse
+// @Failure 409 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /invoices/{id}/payments [post]
+func (h *PaymentHandler) RecordPayment(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return apperror.Validation("missing_parameter", "Invoice ID is required")
+	}
+
+	var req request.PaymentCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.InvalidBody(err)
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(&req); len(errors) > 0 {
+		return apperror.ValidationFailed(errors)
+	}
+
+	userID, _, _, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	receipt, err := h.paymentService.Record(c.UserContext(), middleware.GetAccessScope(c), id, &req, userID)
+	if err != nil {
+		return err
+	}
+
+	return response.Created(c, "Payment recorded successfully", receipt)
+}
+
+// GetInvoicePayments lists the payments recorded against an invoice
+// @Summary Get invoice payments
+// @Description Get the payments of an invoice, oldest first
+// @Tags invoices
+// @Accept json
+// @Produce json
+// @Param id path string true "Invoice ID"
+// @Success 200 {object} response.BaseResponse{data=[]domain.Payment}
+// @Failure 400 {object} response.BaseResponse
+// @Failure 404 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /invoices/{id}/payments [get]
+func (h *PaymentHandler) GetInvoicePayments(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == "" {
+		return apperror.Validation("missing_parameter", "Invoice ID is required")
+	}
+
+	payments, err := h.paymentService.GetByInvoiceID(c.UserContext(), middleware.GetAccessScope(c), id)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Payments retrieved successfully", payments)
+}
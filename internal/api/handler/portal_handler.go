@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"dashboard-ac-backend/internal/api/middleware"
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/api/response"
+	"dashboard-ac-backend/internal/apperror"
+	"dashboard-ac-backend/internal/service"
+	"dashboard-ac-backend/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PortalHandler serves the customer self-service portal. The customer is always
+// taken from the JWT, never from the URL or body; routes must be guarded by
+// middleware.RequireCustomerAccount.
+type PortalHandler struct {
+	portalService service.PortalService
+}
+
+func NewPortalHandler(portalService service.PortalService) *PortalHandler {
+	return &PortalHandler{
+		portalService: portalService,
+	}
+}
+
+// ListMySchedules lists the caller's schedules
+// @Summary List my schedules
+// @Description Get a paginated list of the authenticated customer's schedules
+// @Tags portal
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param cursor query string false "Page by cursor instead of page number; pass it empty for the first page, then next_cursor or prev_cursor from the previous response"
+// @Param include query string false "Comma separated related records to embed: customer, technician, service"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. date,time"
+// @Param filter query string false "Comma separated field:operator:value conditions, e.g. status:eq:Completed"
+// @Success 200 {object} response.BaseResponse{data=[]domain.Schedule}
+// @Failure 400 {object} response.BaseResponse
+// @Failure 403 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /portal/schedules [get]
+func (h *PortalHandler) ListMySchedules(c *fiber.Ctx) error {
+	customerID, _ := middleware.GetCustomerIDFromContext(c)
+
+	pagination := request.GetPaginationFromQuery(c)
+
+	schedules, page, err := h.portalService.ListSchedules(c.UserContext(), customerID, pagination)
+	if err != nil {
+		return err
+	}
+
+	return paginated(c, "Schedules retrieved successfully", schedules, pagination, page)
+}
+
+// ListMyInvoices lists the caller's invoices
+// @Summary List my invoices
+// @Description Get a paginated list of the authenticated customer's invoices
+// @Tags portal
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(10)
+// @Param cursor query string false "Page by cursor instead of page number; pass it empty for the first page, then next_cursor or prev_cursor from the previous response"
+// @Param include query string false "Comma separated related records to embed: customer, schedule, technician, service, details"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. -invoice_date"
+// @Param filter query string false "Comma separated field:operator:value conditions, e.g. status:eq:Unpaid"
+// @Success 200 {object} response.BaseResponse{data=[]domain.Invoice}
+// @Failure 400 {object} response.BaseResponse
+// @Failure 403 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /portal/invoices [get]
+func (h *PortalHandler) ListMyInvoices(c *fiber.Ctx) error {
+	customerID, _ := middleware.GetCustomerIDFromContext(c)
+
+	pagination := request.GetPaginationFromQuery(c)
+
+	invoices, page, err := h.portalService.ListInvoices(c.UserContext(), customerID, pagination)
+	if err != nil {
+		return err
+	}
+
+	return paginated(c, "Invoices retrieved successfully", invoices, pagination, page)
+}
+
+// RequestBooking books a service for the caller
+// @Summary Request a service booking
+// @Description Create a Pending schedule for the authenticated customer; the time must fall within the technician's working hours, and any available technician is assigned when none is given
+// @Tags portal
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param booking body request.PortalBookingRequest true "Booking data"
+// @Success 201 {object} response.BaseResponse{data=domain.Schedule}
+// @Failure 400 {object} response.BaseResponse
+// @Failure 403 {object} response.BaseResponse
+// @Failure 404 {object} response.BaseResponse
+// @Failure 409 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /portal/schedules [post]
+func (h *PortalHandler) RequestBooking(c *fiber.Ctx) error {
+	customerID, _ := middleware.GetCustomerIDFromContext(c)
+
+	var req request.PortalBookingRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.InvalidBody(err)
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(&req); len(errors) > 0 {
+		return apperror.ValidationFailed(errors)
+	}
+
+	schedule, err := h.portalService.RequestBooking(c.UserContext(), customerID, &req)
+	if err != nil {
+		return err
+	}
+
+	return response.Created(c, "Booking requested successfully", schedule)
+}
+
+// CancelBooking cancels one of the caller's pending bookings
+// @Summary Cancel my booking
+// @Description Cancel a Pending schedule that belongs to the authenticated customer
+// @Tags portal
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Param cancel body request.PortalCancelRequest false "Cancellation reason"
+// @Success 200 {object} response.BaseResponse{data=domain.Schedule}
+// @Failure 400 {object} response.BaseResponse
+// @Failure 403 {object} response.BaseResponse
+// @Failure 404 {object} response.BaseResponse
+// @Failure 409 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /portal/schedules/{id}/cancel [post]
+func (h *PortalHandler) CancelBooking(c *fiber.Ctx) error {
+	customerID, _ := middleware.GetCustomerIDFromContext(c)
+
+	var req request.PortalCancelRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return apperror.InvalidBody(err)
+		}
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(&req); len(errors) > 0 {
+		return apperror.ValidationFailed(errors)
+	}
+
+	userID, _, _, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	schedule, err := h.portalService.CancelBooking(c.UserContext(), customerID, c.Params("id"), userID, req.Reason)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Booking canceled successfully", schedule)
+}
+
+// GetMyProfile returns the caller's customer record
+// @Summary Get my customer profile
+// @Description Get the customer record linked to the authenticated account
+// @Tags portal
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} response.BaseResponse{data=domain.Customer}
+// @Failure 403 {object} response.BaseResponse
+// @Failure 404 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /portal/profile [get]
+func (h *PortalHandler) GetMyProfile(c *fiber.Ctx) error {
+	customerID, _ := middleware.GetCustomerIDFromContext(c)
+
+	customer, err := h.portalService.GetProfile(c.UserContext(), customerID)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Profile retrieved successfully", customer)
+}
+
+// UpdateMyProfile updates the caller's customer record
+// @Summary Update my customer profile
+// @Description Update the name, phone or address of the customer record linked to the authenticated account
+// @Tags portal
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param profile body request.PortalProfileUpdateRequest true "Profile update data"
+// @Success 200 {object} response.BaseResponse{data=domain.Customer}
+// @Failure 400 {object} response.BaseResponse
+// @Failure 403 {object} response.BaseResponse
+// @Failure 404 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /portal/profile [put]
+func (h *PortalHandler) UpdateMyProfile(c *fiber.Ctx) error {
+	customerID, _ := middleware.GetCustomerIDFromContext(c)
+
+	var req request.PortalProfileUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.InvalidBody(err)
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(&req); len(errors) > 0 {
+		return apperror.ValidationFailed(errors)
+	}
+
+	customer, err := h.portalService.UpdateProfile(c.UserContext(), customerID, &req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Profile updated successfully", customer)
+}
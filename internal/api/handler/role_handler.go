@@ -0,0 +1,151 @@
+package handler
+
+import (
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/api/response"
+	"dashboard-ac-backend/internal/apperror"
+	"dashboard-ac-backend/internal/service"
+	"dashboard-ac-backend/pkg/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RoleHandler struct {
+	roleService service.RoleService
+}
+
+func NewRoleHandler(roleService service.RoleService) *RoleHandler {
+	return &RoleHandler{
+		roleService: roleService,
+	}
+}
+
+// ListPermissions lists every permission that can be granted to a role
+// @Summary List permissions
+// @Description List every permission ("resource:action") that can be granted to a role
+// @Tags roles
+// @Produce json
+// @Success 200 {object} response.BaseResponse{data=[]domain.Permission}
+// @Router /permissions [get]
+func (h *RoleHandler) ListPermissions(c *fiber.Ctx) error {
+	return response.Success(c, "Permissions retrieved successfully", h.roleService.ListPermissions())
+}
+
+// ListRoles lists the roles with their permissions
+// @Summary List roles
+// @Description List every role with the permissions it grants
+// @Tags roles
+// @Produce json
+// @Success 200 {object} response.BaseResponse{data=[]domain.RoleDefinition}
+// @Failure 500 {object} response.BaseResponse
+// @Router /roles [get]
+func (h *RoleHandler) ListRoles(c *fiber.Ctx) error {
+	roles, err := h.roleService.List(c.UserContext())
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Roles retrieved successfully", roles)
+}
+
+// GetRole retrieves a role by name
+// @Summary Get role by name
+// @Description Get a role with the permissions it grants
+// @Tags roles
+// @Produce json
+// @Param name path string true "Role name"
+// @Success 200 {object} response.BaseResponse{data=domain.RoleDefinition}
+// @Failure 404 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /roles/{name} [get]
+func (h *RoleHandler) GetRole(c *fiber.Ctx) error {
+	role, err := h.roleService.GetByName(c.UserContext(), c.Params("name"))
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Role retrieved successfully", role)
+}
+
+// CreateRole creates a custom role
+// @Summary Create a role
+// @Description Create a custom role with a set of permissions
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param role body request.RoleCreateRequest true "Role data"
+// @Success 201 {object} response.BaseResponse{data=domain.RoleDefinition}
+// @Failure 400 {object} response.BaseResponse
+// @Failure 409 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /roles [post]
+func (h *RoleHandler) CreateRole(c *fiber.Ctx) error {
+	var req request.RoleCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.InvalidBody(err)
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(&req); len(errors) > 0 {
+		return apperror.ValidationFailed(errors)
+	}
+
+	role, err := h.roleService.Create(c.UserContext(), &req)
+	if err != nil {
+		return err
+	}
+
+	return response.Created(c, "Role created successfully", role)
+}
+
+// UpdateRole updates the description or permissions of a role
+// @Summary Update a role
+// @Description Update the description of a role or replace its permissions; the admin role cannot be changed
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Param name path string true "Role name"
+// @Param role body request.RoleUpdateRequest true "Role update data"
+// @Success 200 {object} response.BaseResponse{data=domain.RoleDefinition}
+// @Failure 400 {object} response.BaseResponse
+// @Failure 404 {object} response.BaseResponse
+// @Failure 409 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /roles/{name} [put]
+func (h *RoleHandler) UpdateRole(c *fiber.Ctx) error {
+	var req request.RoleUpdateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.InvalidBody(err)
+	}
+
+	// Validate request
+	if errors := utils.ValidateStruct(&req); len(errors) > 0 {
+		return apperror.ValidationFailed(errors)
+	}
+
+	role, err := h.roleService.Update(c.UserContext(), c.Params("name"), &req)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Role updated successfully", role)
+}
+
+// DeleteRole deletes a custom role
+// @Summary Delete a role
+// @Description Delete a custom role that is not assigned to any user
+// @Tags roles
+// @Produce json
+// @Param name path string true "Role name"
+// @Success 200 {object} response.BaseResponse
+// @Failure 404 {object} response.BaseResponse
+// @Failure 409 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /roles/{name} [delete]
+func (h *RoleHandler) DeleteRole(c *fiber.Ctx) error {
+	if err := h.roleService.Delete(c.UserContext(), c.Params("name")); err != nil {
+		return err
+	}
+
+	return response.Success(c, "Role deleted successfully", nil)
+}
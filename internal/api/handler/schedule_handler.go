@@ -1,12 +1,15 @@
 package handler
 
 import (
-	"strconv"
+	"time"
 
+	"dashboard-ac-backend/internal/api/middleware"
 	"dashboard-ac-backend/internal/api/request"
 	"dashboard-ac-backend/internal/api/response"
+	"dashboard-ac-backend/internal/apperror"
 	"dashboard-ac-backend/internal/domain"
 	"dashboard-ac-backend/internal/service"
+	"dashboard-ac-backend/pkg/utils"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -30,17 +33,18 @@ func NewScheduleHandler(scheduleService service.ScheduleService) *ScheduleHandle
 // @Param schedule body request.ScheduleCreateRequest true "Schedule creation data"
 // @Success 201 {object} response.BaseResponse{data=domain.Schedule}
 // @Failure 400 {object} response.BaseResponse
+// @Failure 409 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
 // @Router /schedules [post]
 func (h *ScheduleHandler) CreateSchedule(c *fiber.Ctx) error {
 	var req request.ScheduleCreateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return response.BadRequest(c, "Invalid request body", err.Error())
+		return apperror.InvalidBody(err)
 	}
 
-	schedule, err := h.scheduleService.Create(&req)
+	schedule, err := h.scheduleService.Create(c.UserContext(), &req)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to create schedule")
+		return err
 	}
 
 	return response.Created(c, "Schedule created successfully", schedule)
@@ -53,6 +57,7 @@ func (h *ScheduleHandler) CreateSchedule(c *fiber.Ctx) error {
 // @Accept json
 // @Produce json
 // @Param id path string true "Schedule ID"
+// @Param include query string false "Comma separated related records to embed: customer, technician, service"
 // @Success 200 {object} response.BaseResponse{data=domain.Schedule}
 // @Failure 400 {object} response.BaseResponse
 // @Failure 404 {object} response.BaseResponse
@@ -61,15 +66,16 @@ func (h *ScheduleHandler) CreateSchedule(c *fiber.Ctx) error {
 func (h *ScheduleHandler) GetSchedule(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "Schedule ID is required", nil)
+		return apperror.Validation("missing_parameter", "Schedule ID is required")
 	}
 
-	schedule, err := h.scheduleService.GetByID(id)
+	schedule, err := h.scheduleService.GetByID(c.UserContext(), middleware.GetAccessScope(c), id)
 	if err != nil {
-		if err.Error() == "schedule not found" {
-			return response.NotFound(c, "Schedule not found")
-		}
-		return response.InternalServerError(c, "Failed to get schedule")
+		return err
+	}
+
+	if err := h.scheduleService.LoadRelations(c.UserContext(), schedule, c.Query("include")); err != nil {
+		return err
 	}
 
 	return response.Success(c, "Schedule retrieved successfully", schedule)
@@ -86,25 +92,33 @@ func (h *ScheduleHandler) GetSchedule(c *fiber.Ctx) error {
 // @Success 200 {object} response.BaseResponse{data=domain.Schedule}
 // @Failure 400 {object} response.BaseResponse
 // @Failure 404 {object} response.BaseResponse
+// @Failure 409 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
 // @Router /schedules/{id} [put]
 func (h *ScheduleHandler) UpdateSchedule(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "Schedule ID is required", nil)
+		return apperror.Validation("missing_parameter", "Schedule ID is required")
 	}
 
 	var req request.ScheduleUpdateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return response.BadRequest(c, "Invalid request body", err.Error())
+		return apperror.InvalidBody(err)
 	}
 
-	schedule, err := h.scheduleService.Update(id, &req)
+	userID, _, _, err := middleware.GetUserFromContext(c)
 	if err != nil {
-		if err.Error() == "schedule not found" {
-			return response.NotFound(c, "Schedule not found")
-		}
-		return response.InternalServerError(c, "Failed to update schedule")
+		return err
+	}
+
+	// Technicians may only change their own jobs
+	if _, err := h.scheduleService.GetByID(c.UserContext(), middleware.GetAccessScope(c), id); err != nil {
+		return err
+	}
+
+	schedule, err := h.scheduleService.Update(c.UserContext(), id, &req, userID)
+	if err != nil {
+		return err
 	}
 
 	return response.Success(c, "Schedule updated successfully", schedule)
@@ -125,15 +139,16 @@ func (h *ScheduleHandler) UpdateSchedule(c *fiber.Ctx) error {
 func (h *ScheduleHandler) DeleteSchedule(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "Schedule ID is required", nil)
+		return apperror.Validation("missing_parameter", "Schedule ID is required")
+	}
+
+	if _, err := h.scheduleService.GetByID(c.UserContext(), middleware.GetAccessScope(c), id); err != nil {
+		return err
 	}
 
-	err := h.scheduleService.Delete(id)
+	err := h.scheduleService.Delete(c.UserContext(), id)
 	if err != nil {
-		if err.Error() == "schedule not found" {
-			return response.NotFound(c, "Schedule not found")
-		}
-		return response.InternalServerError(c, "Failed to delete schedule")
+		return err
 	}
 
 	return response.Success(c, "Schedule deleted successfully", nil)
@@ -147,26 +162,23 @@ func (h *ScheduleHandler) DeleteSchedule(c *fiber.Ctx) error {
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param cursor query string false "Page by cursor instead of page number; pass it empty for the first page, then next_cursor or prev_cursor from the previous response"
+// @Param include query string false "Comma separated related records to embed: customer, technician, service"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. date,time"
+// @Param filter query string false "Comma separated field:operator:value conditions, e.g. status:in:Pending|On-Progress"
 // @Success 200 {object} response.BaseResponse{data=[]domain.Schedule}
 // @Failure 400 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
 // @Router /schedules [get]
 func (h *ScheduleHandler) ListSchedules(c *fiber.Ctx) error {
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	pagination := request.GetPaginationFromQuery(c)
 
-	pagination := &request.PaginationRequest{
-		Page:  page,
-		Limit: limit,
-	}
-
-	schedules, total, err := h.scheduleService.List(pagination)
+	schedules, page, err := h.scheduleService.List(c.UserContext(), middleware.GetAccessScope(c), pagination)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to get schedules")
+		return err
 	}
 
-	paginationMeta := response.CalculatePagination(page, limit, total)
-	return response.Paginated(c, "Schedules retrieved successfully", schedules, paginationMeta)
+	return paginated(c, "Schedules retrieved successfully", schedules, pagination, page)
 }
 
 // SearchSchedules searches for schedules based on criteria
@@ -183,34 +195,78 @@ func (h *ScheduleHandler) ListSchedules(c *fiber.Ctx) error {
 // @Param date_to query string false "Date to (YYYY-MM-DD)"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param cursor query string false "Page by cursor instead of page number; pass it empty for the first page, then next_cursor or prev_cursor from the previous response"
+// @Param include query string false "Comma separated related records to embed: customer, technician, service"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. date,time"
+// @Param filter query string false "Comma separated field:operator:value conditions, e.g. status:in:Pending|On-Progress"
 // @Success 200 {object} response.BaseResponse{data=[]domain.Schedule}
 // @Failure 400 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
 // @Router /schedules/search [get]
 func (h *ScheduleHandler) SearchSchedules(c *fiber.Ctx) error {
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	pagination := request.GetPaginationFromQuery(c)
 
 	searchReq := &request.ScheduleSearchRequest{
-		PaginationRequest: &request.PaginationRequest{
-			Page:  page,
-			Limit: limit,
-		},
-		CustomerID:   c.Query("customer_id"),
-		TechnicianID: c.Query("technician_id"),
-		ServiceID:    c.Query("service_id"),
-		Status:       c.Query("status"),
-		DateFrom:     c.Query("date_from"),
-		DateTo:       c.Query("date_to"),
-	}
-
-	schedules, total, err := h.scheduleService.Search(searchReq)
+		PaginationRequest: pagination,
+		CustomerID:        c.Query("customer_id"),
+		TechnicianID:      c.Query("technician_id"),
+		ServiceID:         c.Query("service_id"),
+		Status:            c.Query("status"),
+		DateFrom:          c.Query("date_from"),
+		DateTo:            c.Query("date_to"),
+	}
+
+	schedules, page, err := h.scheduleService.Search(c.UserContext(), middleware.GetAccessScope(c), searchReq)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to search schedules")
+		return err
+	}
+
+	return paginated(c, "Schedule search completed successfully", schedules, pagination, page)
+}
+
+// ExportSchedules downloads the schedules matching the search criteria as a spreadsheet
+// @Summary Export schedules
+// @Description Download the schedules matching the search criteria, with customer, technician and service names, as a CSV or Excel file
+// @Tags schedules
+// @Produce text/csv
+// @Produce application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
+// @Param format query string false "File format" Enums(csv, xlsx) default(csv)
+// @Param customer_id query string false "Customer ID"
+// @Param technician_id query string false "Technician ID"
+// @Param service_id query string false "Service ID"
+// @Param status query string false "Schedule status"
+// @Param date_from query string false "Date from (YYYY-MM-DD)"
+// @Param date_to query string false "Date to (YYYY-MM-DD)"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. -date,time"
+// @Param filter query string false "Comma separated field:operator:value conditions, e.g. status:in:Pending|On-Progress"
+// @Success 200 {file} file
+// @Failure 400 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /schedules/export [get]
+func (h *ScheduleHandler) ExportSchedules(c *fiber.Ctx) error {
+	searchReq := &request.ScheduleSearchRequest{
+		PaginationRequest: request.GetPaginationFromQuery(c),
+		CustomerID:        c.Query("customer_id"),
+		TechnicianID:      c.Query("technician_id"),
+		ServiceID:         c.Query("service_id"),
+		Status:            c.Query("status"),
+		DateFrom:          c.Query("date_from"),
+		DateTo:            c.Query("date_to"),
 	}
 
-	paginationMeta := response.CalculatePagination(page, limit, total)
-	return response.Paginated(c, "Schedule search completed successfully", schedules, paginationMeta)
+	header := []interface{}{"ID", "Date", "Time", "Status", "Customer", "Technician", "Service"}
+	schedules := h.scheduleService.Export(c.UserContext(), middleware.GetAccessScope(c), searchReq)
+	return exportFile(c, "schedules", header, schedules, func(schedule *domain.Schedule) []interface{} {
+		return []interface{}{
+			schedule.ID.String(),
+			schedule.Date.Format("2006-01-02"),
+			schedule.Time.Format("15:04"),
+			string(schedule.Status),
+			customerName(schedule.Customer),
+			technicianName(schedule.Technician),
+			serviceName(schedule.Service),
+		}
+	})
 }
 
 // GetSchedulesByCustomer retrieves schedules by customer ID
@@ -222,6 +278,10 @@ func (h *ScheduleHandler) SearchSchedules(c *fiber.Ctx) error {
 // @Param customer_id path string true "Customer ID"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param cursor query string false "Page by cursor instead of page number; pass it empty for the first page, then next_cursor or prev_cursor from the previous response"
+// @Param include query string false "Comma separated related records to embed: customer, technician, service"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. date,time"
+// @Param filter query string false "Comma separated field:operator:value conditions, e.g. status:in:Pending|On-Progress"
 // @Success 200 {object} response.BaseResponse{data=[]domain.Schedule}
 // @Failure 400 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
@@ -229,24 +289,17 @@ func (h *ScheduleHandler) SearchSchedules(c *fiber.Ctx) error {
 func (h *ScheduleHandler) GetSchedulesByCustomer(c *fiber.Ctx) error {
 	customerID := c.Params("customer_id")
 	if customerID == "" {
-		return response.BadRequest(c, "Customer ID is required", nil)
+		return apperror.Validation("missing_parameter", "Customer ID is required")
 	}
 
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
-
-	pagination := &request.PaginationRequest{
-		Page:  page,
-		Limit: limit,
-	}
+	pagination := request.GetPaginationFromQuery(c)
 
-	schedules, total, err := h.scheduleService.GetByCustomerID(customerID, pagination)
+	schedules, page, err := h.scheduleService.GetByCustomerID(c.UserContext(), middleware.GetAccessScope(c), customerID, pagination)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to get schedules")
+		return err
 	}
 
-	paginationMeta := response.CalculatePagination(page, limit, total)
-	return response.Paginated(c, "Customer schedules retrieved successfully", schedules, paginationMeta)
+	return paginated(c, "Customer schedules retrieved successfully", schedules, pagination, page)
 }
 
 // GetSchedulesByTechnician retrieves schedules by technician ID
@@ -258,6 +311,10 @@ func (h *ScheduleHandler) GetSchedulesByCustomer(c *fiber.Ctx) error {
 // @Param technician_id path string true "Technician ID"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param cursor query string false "Page by cursor instead of page number; pass it empty for the first page, then next_cursor or prev_cursor from the previous response"
+// @Param include query string false "Comma separated related records to embed: customer, technician, service"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. date,time"
+// @Param filter query string false "Comma separated field:operator:value conditions, e.g. status:in:Pending|On-Progress"
 // @Success 200 {object} response.BaseResponse{data=[]domain.Schedule}
 // @Failure 400 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
@@ -265,24 +322,17 @@ func (h *ScheduleHandler) GetSchedulesByCustomer(c *fiber.Ctx) error {
 func (h *ScheduleHandler) GetSchedulesByTechnician(c *fiber.Ctx) error {
 	technicianID := c.Params("technician_id")
 	if technicianID == "" {
-		return response.BadRequest(c, "Technician ID is required", nil)
+		return apperror.Validation("missing_parameter", "Technician ID is required")
 	}
 
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
-
-	pagination := &request.PaginationRequest{
-		Page:  page,
-		Limit: limit,
-	}
+	pagination := request.GetPaginationFromQuery(c)
 
-	schedules, total, err := h.scheduleService.GetByTechnicianID(technicianID, pagination)
+	schedules, page, err := h.scheduleService.GetByTechnicianID(c.UserContext(), middleware.GetAccessScope(c), technicianID, pagination)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to get schedules")
+		return err
 	}
 
-	paginationMeta := response.CalculatePagination(page, limit, total)
-	return response.Paginated(c, "Technician schedules retrieved successfully", schedules, paginationMeta)
+	return paginated(c, "Technician schedules retrieved successfully", schedules, pagination, page)
 }
 
 // GetSchedulesByStatus retrieves schedules by status
@@ -294,6 +344,10 @@ func (h *ScheduleHandler) GetSchedulesByTechnician(c *fiber.Ctx) error {
 // @Param status path string true "Schedule status"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param cursor query string false "Page by cursor instead of page number; pass it empty for the first page, then next_cursor or prev_cursor from the previous response"
+// @Param include query string false "Comma separated related records to embed: customer, technician, service"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. date,time"
+// @Param filter query string false "Comma separated field:operator:value conditions, e.g. status:in:Pending|On-Progress"
 // @Success 200 {object} response.BaseResponse{data=[]domain.Schedule}
 // @Failure 400 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
@@ -301,24 +355,177 @@ func (h *ScheduleHandler) GetSchedulesByTechnician(c *fiber.Ctx) error {
 func (h *ScheduleHandler) GetSchedulesByStatus(c *fiber.Ctx) error {
 	statusStr := c.Params("status")
 	if statusStr == "" {
-		return response.BadRequest(c, "Status is required", nil)
+		return apperror.Validation("missing_parameter", "Status is required")
 	}
 
 	status := domain.ScheduleStatus(statusStr)
 
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	pagination := request.GetPaginationFromQuery(c)
+
+	schedules, page, err := h.scheduleService.GetByStatus(c.UserContext(), middleware.GetAccessScope(c), status, pagination)
+	if err != nil {
+		return err
+	}
+
+	return paginated(c, "Schedules by status retrieved successfully", schedules, pagination, page)
+}
+
+// StartSchedule moves a pending schedule to On-Progress
+// @Summary Start schedule
+// @Description Move a pending schedule to On-Progress
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Success 200 {object} response.BaseResponse{data=domain.Schedule}
+// @Failure 404 {object} response.BaseResponse
+// @Failure 409 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /schedules/{id}/start [post]
+func (h *ScheduleHandler) StartSchedule(c *fiber.Ctx) error {
+	userID, _, _, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.scheduleService.GetByID(c.UserContext(), middleware.GetAccessScope(c), c.Params("id")); err != nil {
+		return err
+	}
+
+	schedule, err := h.scheduleService.Start(c.UserContext(), c.Params("id"), userID)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Schedule started successfully", schedule)
+}
+
+// CompleteSchedule moves an in-progress schedule to Completed
+// @Summary Complete schedule
+// @Description Move an in-progress schedule to Completed
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Success 200 {object} response.BaseResponse{data=domain.Schedule}
+// @Failure 404 {object} response.BaseResponse
+// @Failure 409 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /schedules/{id}/complete [post]
+func (h *ScheduleHandler) CompleteSchedule(c *fiber.Ctx) error {
+	userID, _, _, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.scheduleService.GetByID(c.UserContext(), middleware.GetAccessScope(c), c.Params("id")); err != nil {
+		return err
+	}
+
+	schedule, err := h.scheduleService.Complete(c.UserContext(), c.Params("id"), userID)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Schedule completed successfully", schedule)
+}
+
+// CancelSchedule cancels a pending or in-progress schedule
+// @Summary Cancel schedule
+// @Description Cancel a pending or in-progress schedule with a reason
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Param cancel body request.ScheduleCancelRequest true "Cancellation reason"
+// @Success 200 {object} response.BaseResponse{data=domain.Schedule}
+// @Failure 400 {object} response.BaseResponse
+// @Failure 404 {object} response.BaseResponse
+// @Failure 409 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /schedules/{id}/cancel [post]
+func (h *ScheduleHandler) CancelSchedule(c *fiber.Ctx) error {
+	var req request.ScheduleCancelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return apperror.InvalidBody(err)
+	}
+
+	if errors := utils.ValidateStruct(&req); len(errors) > 0 {
+		return apperror.ValidationFailed(errors)
+	}
+
+	userID, _, _, err := middleware.GetUserFromContext(c)
+	if err != nil {
+		return err
+	}
+
+	if _, err := h.scheduleService.GetByID(c.UserContext(), middleware.GetAccessScope(c), c.Params("id")); err != nil {
+		return err
+	}
+
+	schedule, err := h.scheduleService.Cancel(c.UserContext(), c.Params("id"), userID, req.Reason)
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Schedule canceled successfully", schedule)
+}
+
+// GetScheduleHistory retrieves the status history of a schedule
+// @Summary Get schedule status history
+// @Description Get every status transition of a schedule, oldest first
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Success 200 {object} response.BaseResponse{data=[]domain.ScheduleStatusHistory}
+// @Failure 404 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /schedules/{id}/history [get]
+func (h *ScheduleHandler) GetScheduleHistory(c *fiber.Ctx) error {
+	if _, err := h.scheduleService.GetByID(c.UserContext(), middleware.GetAccessScope(c), c.Params("id")); err != nil {
+		return err
+	}
+
+	history, err := h.scheduleService.GetStatusHistory(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return err
+	}
+
+	return response.Success(c, "Schedule history retrieved successfully", history)
+}
 
-	pagination := &request.PaginationRequest{
-		Page:  page,
-		Limit: limit,
+// GetMyJobs retrieves the logged-in technician's agenda for a day
+// @Summary Get my jobs
+// @Description Get the non-canceled jobs of the logged-in technician on a day, in start order
+// @Tags schedules
+// @Accept json
+// @Produce json
+// @Param date query string false "Date (YYYY-MM-DD), defaults to today"
+// @Success 200 {object} response.BaseResponse{data=[]domain.Schedule}
+// @Failure 400 {object} response.BaseResponse
+// @Failure 403 {object} response.BaseResponse
+// @Failure 500 {object} response.BaseResponse
+// @Router /me/jobs [get]
+func (h *ScheduleHandler) GetMyJobs(c *fiber.Ctx) error {
+	technicianID, ok := middleware.GetTechnicianIDFromContext(c)
+	if !ok {
+		return apperror.Forbidden("technician_not_linked", "Account is not linked to a technician record")
 	}
 
-	schedules, total, err := h.scheduleService.GetByStatus(status, pagination)
+	date := time.Now()
+	if raw := c.Query("date"); raw != "" {
+		parsed, err := time.Parse("2006-01-02", raw)
+		if err != nil {
+			return service.ErrInvalidDate
+		}
+		date = parsed
+	}
+
+	schedules, err := h.scheduleService.GetTechnicianAgenda(c.UserContext(), technicianID, date)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to get schedules")
+		return err
 	}
 
-	paginationMeta := response.CalculatePagination(page, limit, total)
-	return response.Paginated(c, "Schedules by status retrieved successfully", schedules, paginationMeta)
-}
\ No newline at end of file
+	return response.Success(c, "Jobs retrieved successfully", schedules)
+}
@@ -1,11 +1,11 @@
 package handler
 
 import (
-	"strconv"
-
 	"dashboard-ac-backend/internal/api/request"
 	"dashboard-ac-backend/internal/api/response"
+	"dashboard-ac-backend/internal/apperror"
 	"dashboard-ac-backend/internal/service"
+	"dashboard-ac-backend/pkg/money"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -34,12 +34,12 @@ func NewServiceHandler(serviceService service.ServiceService) *ServiceHandler {
 func (h *ServiceHandler) CreateService(c *fiber.Ctx) error {
 	var req request.ServiceCreateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return response.BadRequest(c, "Invalid request body", err.Error())
+		return apperror.InvalidBody(err)
 	}
 
-	service, err := h.serviceService.Create(&req)
+	service, err := h.serviceService.Create(c.UserContext(), &req)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to create service")
+		return err
 	}
 
 	return response.Created(c, "Service created successfully", service)
@@ -60,15 +60,12 @@ func (h *ServiceHandler) CreateService(c *fiber.Ctx) error {
 func (h *ServiceHandler) GetService(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "Service ID is required", nil)
+		return apperror.Validation("missing_parameter", "Service ID is required")
 	}
 
-	service, err := h.serviceService.GetByID(id)
+	service, err := h.serviceService.GetByID(c.UserContext(), id)
 	if err != nil {
-		if err.Error() == "service not found" {
-			return response.NotFound(c, "Service not found")
-		}
-		return response.InternalServerError(c, "Failed to get service")
+		return err
 	}
 
 	return response.Success(c, "Service retrieved successfully", service)
@@ -90,20 +87,17 @@ func (h *ServiceHandler) GetService(c *fiber.Ctx) error {
 func (h *ServiceHandler) UpdateService(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "Service ID is required", nil)
+		return apperror.Validation("missing_parameter", "Service ID is required")
 	}
 
 	var req request.ServiceUpdateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return response.BadRequest(c, "Invalid request body", err.Error())
+		return apperror.InvalidBody(err)
 	}
 
-	service, err := h.serviceService.Update(id, &req)
+	service, err := h.serviceService.Update(c.UserContext(), id, &req)
 	if err != nil {
-		if err.Error() == "service not found" {
-			return response.NotFound(c, "Service not found")
-		}
-		return response.InternalServerError(c, "Failed to update service")
+		return err
 	}
 
 	return response.Success(c, "Service updated successfully", service)
@@ -124,15 +118,12 @@ func (h *ServiceHandler) UpdateService(c *fiber.Ctx) error {
 func (h *ServiceHandler) DeleteService(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "Service ID is required", nil)
+		return apperror.Validation("missing_parameter", "Service ID is required")
 	}
 
-	err := h.serviceService.Delete(id)
+	err := h.serviceService.Delete(c.UserContext(), id)
 	if err != nil {
-		if err.Error() == "service not found" {
-			return response.NotFound(c, "Service not found")
-		}
-		return response.InternalServerError(c, "Failed to delete service")
+		return err
 	}
 
 	return response.Success(c, "Service deleted successfully", nil)
@@ -146,26 +137,23 @@ func (h *ServiceHandler) DeleteService(c *fiber.Ctx) error {
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param cursor query string false "Page by cursor instead of page number; pass it empty for the first page, then next_cursor or prev_cursor from the previous response"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. price,name"
+// @Param filter query string false "Comma separated field:operator:value conditions, e.g. price:lte:300000,duration:gte:60"
+// @Param search query string false "Text matched against the service name"
 // @Success 200 {object} response.BaseResponse{data=[]domain.Service}
 // @Failure 400 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
 // @Router /services [get]
 func (h *ServiceHandler) ListServices(c *fiber.Ctx) error {
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
-
-	pagination := &request.PaginationRequest{
-		Page:  page,
-		Limit: limit,
-	}
+	pagination := request.GetPaginationFromQuery(c)
 
-	services, total, err := h.serviceService.List(pagination)
+	services, page, err := h.serviceService.List(c.UserContext(), pagination)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to get services")
+		return err
 	}
 
-	paginationMeta := response.CalculatePagination(page, limit, total)
-	return response.Paginated(c, "Services retrieved successfully", services, paginationMeta)
+	return paginated(c, "Services retrieved successfully", services, pagination, page)
 }
 
 // SearchServices searches for services based on criteria
@@ -179,32 +167,30 @@ func (h *ServiceHandler) ListServices(c *fiber.Ctx) error {
 // @Param max_price query number false "Maximum price"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param cursor query string false "Page by cursor instead of page number; pass it empty for the first page, then next_cursor or prev_cursor from the previous response"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. price,name"
+// @Param filter query string false "Comma separated field:operator:value conditions, e.g. price:lte:300000,duration:gte:60"
+// @Param search query string false "Text matched against the service name"
 // @Success 200 {object} response.BaseResponse{data=[]domain.Service}
 // @Failure 400 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
 // @Router /services/search [get]
 func (h *ServiceHandler) SearchServices(c *fiber.Ctx) error {
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
-	
-	minPrice, _ := strconv.ParseFloat(c.Query("min_price", "0"), 64)
-	maxPrice, _ := strconv.ParseFloat(c.Query("max_price", "0"), 64)
+	pagination := request.GetPaginationFromQuery(c)
+	minPrice, _ := money.Parse(c.Query("min_price", "0"))
+	maxPrice, _ := money.Parse(c.Query("max_price", "0"))
 
 	searchReq := &request.ServiceSearchRequest{
-		PaginationRequest: &request.PaginationRequest{
-			Page:  page,
-			Limit: limit,
-		},
-		Name:     c.Query("name"),
-		MinPrice: minPrice,
-		MaxPrice: maxPrice,
+		PaginationRequest: pagination,
+		Name:              c.Query("name"),
+		MinPrice:          minPrice,
+		MaxPrice:          maxPrice,
 	}
 
-	services, total, err := h.serviceService.Search(searchReq)
+	services, page, err := h.serviceService.Search(c.UserContext(), searchReq)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to search services")
+		return err
 	}
 
-	paginationMeta := response.CalculatePagination(page, limit, total)
-	return response.Paginated(c, "Service search completed successfully", services, paginationMeta)
+	return paginated(c, "Service search completed successfully", services, pagination, page)
 }
\ No newline at end of file
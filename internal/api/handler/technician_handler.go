@@ -1,10 +1,9 @@
 package handler
 
 import (
-	"strconv"
-
 	"dashboard-ac-backend/internal/api/request"
 	"dashboard-ac-backend/internal/api/response"
+	"dashboard-ac-backend/internal/apperror"
 	"dashboard-ac-backend/internal/service"
 
 	"github.com/gofiber/fiber/v2"
@@ -34,12 +33,12 @@ func NewTechnicianHandler(technicianService service.TechnicianService) *Technici
 func (h *TechnicianHandler) CreateTechnician(c *fiber.Ctx) error {
 	var req request.TechnicianCreateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return response.BadRequest(c, "Invalid request body", err.Error())
+		return apperror.InvalidBody(err)
 	}
 
-	technician, err := h.technicianService.Create(&req)
+	technician, err := h.technicianService.Create(c.UserContext(), &req)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to create technician")
+		return err
 	}
 
 	return response.Created(c, "Technician created successfully", technician)
@@ -60,15 +59,12 @@ func (h *TechnicianHandler) CreateTechnician(c *fiber.Ctx) error {
 func (h *TechnicianHandler) GetTechnician(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "Technician ID is required", nil)
+		return apperror.Validation("missing_parameter", "Technician ID is required")
 	}
 
-	technician, err := h.technicianService.GetByID(id)
+	technician, err := h.technicianService.GetByID(c.UserContext(), id)
 	if err != nil {
-		if err.Error() == "technician not found" {
-			return response.NotFound(c, "Technician not found")
-		}
-		return response.InternalServerError(c, "Failed to get technician")
+		return err
 	}
 
 	return response.Success(c, "Technician retrieved successfully", technician)
@@ -90,20 +86,17 @@ func (h *TechnicianHandler) GetTechnician(c *fiber.Ctx) error {
 func (h *TechnicianHandler) UpdateTechnician(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "Technician ID is required", nil)
+		return apperror.Validation("missing_parameter", "Technician ID is required")
 	}
 
 	var req request.TechnicianUpdateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return response.BadRequest(c, "Invalid request body", err.Error())
+		return apperror.InvalidBody(err)
 	}
 
-	technician, err := h.technicianService.Update(id, &req)
+	technician, err := h.technicianService.Update(c.UserContext(), id, &req)
 	if err != nil {
-		if err.Error() == "technician not found" {
-			return response.NotFound(c, "Technician not found")
-		}
-		return response.InternalServerError(c, "Failed to update technician")
+		return err
 	}
 
 	return response.Success(c, "Technician updated successfully", technician)
@@ -124,15 +117,12 @@ func (h *TechnicianHandler) UpdateTechnician(c *fiber.Ctx) error {
 func (h *TechnicianHandler) DeleteTechnician(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if id == "" {
-		return response.BadRequest(c, "Technician ID is required", nil)
+		return apperror.Validation("missing_parameter", "Technician ID is required")
 	}
 
-	err := h.technicianService.Delete(id)
+	err := h.technicianService.Delete(c.UserContext(), id)
 	if err != nil {
-		if err.Error() == "technician not found" {
-			return response.NotFound(c, "Technician not found")
-		}
-		return response.InternalServerError(c, "Failed to delete technician")
+		return err
 	}
 
 	return response.Success(c, "Technician deleted successfully", nil)
@@ -146,26 +136,23 @@ func (h *TechnicianHandler) DeleteTechnician(c *fiber.Ctx) error {
 // @Produce json
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param cursor query string false "Page by cursor instead of page number; pass it empty for the first page, then next_cursor or prev_cursor from the previous response"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. name"
+// @Param filter query string false "Comma separated field:operator:value conditions, e.g. specialization:like:inverter"
+// @Param search query string false "Text matched against the name, phone and specialization"
 // @Success 200 {object} response.BaseResponse{data=[]domain.Technician}
 // @Failure 400 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
 // @Router /technicians [get]
 func (h *TechnicianHandler) ListTechnicians(c *fiber.Ctx) error {
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
-
-	pagination := &request.PaginationRequest{
-		Page:  page,
-		Limit: limit,
-	}
+	pagination := request.GetPaginationFromQuery(c)
 
-	technicians, total, err := h.technicianService.List(pagination)
+	technicians, page, err := h.technicianService.List(c.UserContext(), pagination)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to get technicians")
+		return err
 	}
 
-	paginationMeta := response.CalculatePagination(page, limit, total)
-	return response.Paginated(c, "Technicians retrieved successfully", technicians, paginationMeta)
+	return paginated(c, "Technicians retrieved successfully", technicians, pagination, page)
 }
 
 // SearchTechnicians searches for technicians based on criteria
@@ -178,28 +165,27 @@ func (h *TechnicianHandler) ListTechnicians(c *fiber.Ctx) error {
 // @Param specialization query string false "Technician specialization"
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Items per page" default(10)
+// @Param cursor query string false "Page by cursor instead of page number; pass it empty for the first page, then next_cursor or prev_cursor from the previous response"
+// @Param sort query string false "Comma separated sort fields, prefix with - for descending, e.g. name"
+// @Param filter query string false "Comma separated field:operator:value conditions, e.g. specialization:like:inverter"
+// @Param search query string false "Text matched against the name, phone and specialization"
 // @Success 200 {object} response.BaseResponse{data=[]domain.Technician}
 // @Failure 400 {object} response.BaseResponse
 // @Failure 500 {object} response.BaseResponse
 // @Router /technicians/search [get]
 func (h *TechnicianHandler) SearchTechnicians(c *fiber.Ctx) error {
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	pagination := request.GetPaginationFromQuery(c)
 
 	searchReq := &request.TechnicianSearchRequest{
-		PaginationRequest: &request.PaginationRequest{
-			Page:  page,
-			Limit: limit,
-		},
-		Name:           c.Query("name"),
-		Specialization: c.Query("specialization"),
+		PaginationRequest: pagination,
+		Name:              c.Query("name"),
+		Specialization:    c.Query("specialization"),
 	}
 
-	technicians, total, err := h.technicianService.Search(searchReq)
+	technicians, page, err := h.technicianService.Search(c.UserContext(), searchReq)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to search technicians")
+		return err
 	}
 
-	paginationMeta := response.CalculatePagination(page, limit, total)
-	return response.Paginated(c, "Technician search completed successfully", technicians, paginationMeta)
+	return paginated(c, "Technician search completed successfully", technicians, pagination, page)
 }
\ No newline at end of file
@@ -5,6 +5,7 @@ import (
 
 	"dashboard-ac-backend/internal/api/request"
 	"dashboard-ac-backend/internal/api/response"
+	"dashboard-ac-backend/internal/apperror"
 	"dashboard-ac-backend/internal/domain"
 	"dashboard-ac-backend/internal/service"
 	"dashboard-ac-backend/pkg/utils"
@@ -12,6 +13,10 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
+// errInvalidUserID is returned for a user ID path parameter that is not a number
+var errInvalidUserID = apperror.Validation("invalid_user_id", "Invalid user ID",
+	apperror.FieldError{Field: "id", Message: "must be a positive integer"})
+
 type UserHandler struct {
 	userService service.UserService
 }
@@ -25,25 +30,27 @@ func NewUserHandler(userService service.UserService) *UserHandler {
 func (h *UserHandler) Create(c *fiber.Ctx) error {
 	var req request.UserCreateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return response.BadRequest(c, "Invalid request body", nil)
+		return apperror.InvalidBody(err)
 	}
 
 	// Validate request
 	if errors := utils.ValidateStruct(&req); len(errors) > 0 {
-		return response.BadRequest(c, "Validation failed", errors)
+		return apperror.ValidationFailed(errors)
 	}
 
 	// Create user
-	user, err := h.userService.Create(&req)
+	user, err := h.userService.Create(c.UserContext(), &req)
 	if err != nil {
-		return response.BadRequest(c, err.Error(), nil)
+		return err
 	}
 
 	return response.Created(c, "User created successfully", map[string]interface{}{
-		"id":    user.ID,
-		"name":  user.Name,
-		"email": user.Email,
-		"role":  user.Role,
+		"id":            user.ID,
+		"name":          user.Name,
+		"email":         user.Email,
+		"role":          user.Role,
+		"customer_id":   user.CustomerID,
+		"technician_id": user.TechnicianID,
 	})
 }
 
@@ -51,22 +58,24 @@ func (h *UserHandler) GetByID(c *fiber.Ctx) error {
 	idParam := c.Params("id")
 	id, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		return response.BadRequest(c, "Invalid user ID", nil)
+		return errInvalidUserID
 	}
 
-	user, err := h.userService.GetByID(uint(id))
+	user, err := h.userService.GetByID(c.UserContext(), uint(id))
 	if err != nil {
-		return response.NotFound(c, err.Error())
+		return err
 	}
 
 	return response.Success(c, "User retrieved successfully", map[string]interface{}{
-		"id":         user.ID,
-		"name":       user.Name,
-		"email":      user.Email,
-		"role":       user.Role,
-		"is_active":  user.IsActive,
-		"created_at": user.CreatedAt,
-		"updated_at": user.UpdatedAt,
+		"id":            user.ID,
+		"name":          user.Name,
+		"email":         user.Email,
+		"role":          user.Role,
+		"customer_id":   user.CustomerID,
+		"technician_id": user.TechnicianID,
+		"is_active":     user.IsActive,
+		"created_at":    user.CreatedAt,
+		"updated_at":    user.UpdatedAt,
 	})
 }
 
@@ -74,32 +83,34 @@ func (h *UserHandler) Update(c *fiber.Ctx) error {
 	idParam := c.Params("id")
 	id, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		return response.BadRequest(c, "Invalid user ID", nil)
+		return errInvalidUserID
 	}
 
 	var req request.UserUpdateRequest
 	if err = c.BodyParser(&req); err != nil {
-		return response.BadRequest(c, "Invalid request body", nil)
+		return apperror.InvalidBody(err)
 	}
 
 	// Validate request
 	if errors := utils.ValidateStruct(&req); len(errors) > 0 {
-		return response.BadRequest(c, "Validation failed", errors)
+		return apperror.ValidationFailed(errors)
 	}
 
 	// Update user
-	user, err := h.userService.Update(uint(id), &req)
+	user, err := h.userService.Update(c.UserContext(), uint(id), &req)
 	if err != nil {
-		return response.BadRequest(c, err.Error(), nil)
+		return err
 	}
 
 	return response.Success(c, "User updated successfully", map[string]interface{}{
-		"id":         user.ID,
-		"name":       user.Name,
-		"email":      user.Email,
-		"role":       user.Role,
-		"is_active":  user.IsActive,
-		"updated_at": user.UpdatedAt,
+		"id":            user.ID,
+		"name":          user.Name,
+		"email":         user.Email,
+		"role":          user.Role,
+		"customer_id":   user.CustomerID,
+		"technician_id": user.TechnicianID,
+		"is_active":     user.IsActive,
+		"updated_at":    user.UpdatedAt,
 	})
 }
 
@@ -107,50 +118,74 @@ func (h *UserHandler) Delete(c *fiber.Ctx) error {
 	idParam := c.Params("id")
 	id, err := strconv.ParseUint(idParam, 10, 32)
 	if err != nil {
-		return response.BadRequest(c, "Invalid user ID", nil)
+		return errInvalidUserID
 	}
 
-	if err := h.userService.Delete(uint(id)); err != nil {
-		return response.BadRequest(c, err.Error(), nil)
+	if err := h.userService.Delete(c.UserContext(), uint(id)); err != nil {
+		return err
 	}
 
 	return response.Success(c, "User deleted successfully", nil)
 }
 
-func (h *UserHandler) List(c *fiber.Ctx) error {
-	// Parse pagination parameters
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+func (h *UserHandler) RevokeSessions(c *fiber.Ctx) error {
+	idParam := c.Params("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return errInvalidUserID
+	}
 
-	pagination := &request.PaginationRequest{
-		Page:  page,
-		Limit: limit,
+	revoked, err := h.userService.RevokeSessions(c.UserContext(), uint(id))
+	if err != nil {
+		return err
 	}
 
+	return response.Success(c, "User sessions revoked successfully", map[string]interface{}{
+		"revoked_sessions": revoked,
+	})
+}
+
+func (h *UserHandler) Unlock(c *fiber.Ctx) error {
+	idParam := c.Params("id")
+	id, err := strconv.ParseUint(idParam, 10, 32)
+	if err != nil {
+		return errInvalidUserID
+	}
+
+	if err := h.userService.Unlock(c.UserContext(), uint(id)); err != nil {
+		return err
+	}
+
+	return response.Success(c, "User unlocked successfully", nil)
+}
+
+func (h *UserHandler) List(c *fiber.Ctx) error {
+	// Parse pagination parameters
+	pagination := request.GetPaginationFromQuery(c)
+
 	// Get users
-	users, total, err := h.userService.List(pagination)
+	users, page, err := h.userService.List(c.UserContext(), pagination)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to retrieve users")
+		return err
 	}
 
 	// Format response data
 	var userData []map[string]interface{}
 	for _, user := range users {
 		userData = append(userData, map[string]interface{}{
-			"id":         user.ID,
-			"name":       user.Name,
-			"email":      user.Email,
-			"role":       user.Role,
-			"is_active":  user.IsActive,
-			"created_at": user.CreatedAt,
-			"updated_at": user.UpdatedAt,
+			"id":            user.ID,
+			"name":          user.Name,
+			"email":         user.Email,
+			"role":          user.Role,
+			"customer_id":   user.CustomerID,
+			"technician_id": user.TechnicianID,
+			"is_active":     user.IsActive,
+			"created_at":    user.CreatedAt,
+			"updated_at":    user.UpdatedAt,
 		})
 	}
 
-	// Calculate pagination metadata
-	paginationMeta := response.CalculatePagination(pagination.Page, pagination.GetLimit(), total)
-
-	return response.Paginated(c, "Users retrieved successfully", userData, paginationMeta)
+	return paginated(c, "Users retrieved successfully", userData, pagination, page)
 }
 
 func (h *UserHandler) GetByRole(c *fiber.Ctx) error {
@@ -158,41 +193,34 @@ func (h *UserHandler) GetByRole(c *fiber.Ctx) error {
 	role := domain.Role(roleParam)
 
 	// Validate role
-	if role != domain.RoleAdmin && role != domain.RoleTechnician && role != domain.RoleCustomer {
+	if !domain.IsValidRoleName(roleParam) {
 		return response.BadRequest(c, "Invalid role", nil)
 	}
 
 	// Parse pagination parameters
-	page, _ := strconv.Atoi(c.Query("page", "1"))
-	limit, _ := strconv.Atoi(c.Query("limit", "10"))
-
-	pagination := &request.PaginationRequest{
-		Page:  page,
-		Limit: limit,
-	}
+	pagination := request.GetPaginationFromQuery(c)
 
 	// Get users by role
-	users, total, err := h.userService.GetByRole(role, pagination)
+	users, page, err := h.userService.GetByRole(c.UserContext(), role, pagination)
 	if err != nil {
-		return response.InternalServerError(c, "Failed to retrieve users")
+		return err
 	}
 
 	// Format response data
 	var userData []map[string]interface{}
 	for _, user := range users {
 		userData = append(userData, map[string]interface{}{
-			"id":         user.ID,
-			"name":       user.Name,
-			"email":      user.Email,
-			"role":       user.Role,
-			"is_active":  user.IsActive,
-			"created_at": user.CreatedAt,
-			"updated_at": user.UpdatedAt,
+			"id":            user.ID,
+			"name":          user.Name,
+			"email":         user.Email,
+			"role":          user.Role,
+			"customer_id":   user.CustomerID,
+			"technician_id": user.TechnicianID,
+			"is_active":     user.IsActive,
+			"created_at":    user.CreatedAt,
+			"updated_at":    user.UpdatedAt,
 		})
 	}
 
-	// Calculate pagination metadata
-	paginationMeta := response.CalculatePagination(pagination.Page, pagination.GetLimit(), total)
-
-	return response.Paginated(c, "Users retrieved successfully", userData, paginationMeta)
+	return paginated(c, "Users retrieved successfully", userData, pagination, page)
 }
\ No newline at end of file
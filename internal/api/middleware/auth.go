@@ -6,9 +6,14 @@ import (
 	"dashboard-ac-backend/pkg/jwt"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
 )
 
-func JWTAuth(jwtSecret string) fiber.Handler {
+// JWTAuth authenticates requests with an access token. When tokenVersions is set, tokens
+// of deactivated users or tokens issued before the user's token version was bumped are
+// rejected as well.
+func JWTAuth(jwtSecret string, tokenVersions *TokenVersionCache) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Get authorization header
 		authHeader := c.Get("Authorization")
@@ -33,10 +38,36 @@ func JWTAuth(jwtSecret string) fiber.Handler {
 			return response.Unauthorized(c, "Invalid token type")
 		}
 
+		// Check that the token has not been revoked since it was issued
+		if tokenVersions != nil {
+			current, err := tokenVersions.IsCurrent(c.UserContext(), claims.UserID, claims.TokenVersion)
+			if err != nil {
+				RequestLogger(c).Error().Err(err).Uint("user_id", claims.UserID).Msg("Failed to check token version")
+				return response.InternalServerError(c, "Failed to verify token")
+			}
+			if !current {
+				return response.Unauthorized(c, "Token has been revoked")
+			}
+		}
+
 		// Store user info in context
 		c.Locals("user_id", claims.UserID)
 		c.Locals("user_email", claims.Email)
 		c.Locals("user_role", claims.Role)
+		if claims.CustomerID != nil {
+			c.Locals("customer_id", *claims.CustomerID)
+		}
+		if claims.TechnicianID != nil {
+			c.Locals("technician_id", *claims.TechnicianID)
+		}
+		addLogContext(c, func(l zerolog.Context) zerolog.Context {
+			return l.Uint("user_id", claims.UserID).Str("role", string(claims.Role))
+		})
+
+		// Changes made by the request are recorded in the user's name
+		actor := domain.AuditActorFromContext(c.UserContext())
+		actor.UserID, actor.Email = &claims.UserID, claims.Email
+		c.SetUserContext(domain.ContextWithAuditActor(c.UserContext(), actor))
 
 		return c.Next()
 	}
@@ -60,12 +91,42 @@ func RequireRole(roles ...domain.Role) fiber.Handler {
 	}
 }
 
-func RequireAdmin() fiber.Handler {
-	return RequireRole(domain.RoleAdmin)
+// RequireTechnicianAccount allows technician accounts that are linked to a technician record
+func RequireTechnicianAccount() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userRole, ok := c.Locals("user_role").(domain.Role)
+		if !ok {
+			return response.Unauthorized(c, "User role not found in context")
+		}
+		if userRole != domain.RoleTechnician {
+			return response.Forbidden(c, "Insufficient permissions")
+		}
+
+		if _, ok := GetTechnicianIDFromContext(c); !ok {
+			return response.Forbidden(c, "Account is not linked to a technician record")
+		}
+
+		return c.Next()
+	}
 }
 
-func RequireAdminOrTechnician() fiber.Handler {
-	return RequireRole(domain.RoleAdmin, domain.RoleTechnician)
+// RequireCustomerAccount allows customer accounts that are linked to a customer record
+func RequireCustomerAccount() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userRole, ok := c.Locals("user_role").(domain.Role)
+		if !ok {
+			return response.Unauthorized(c, "User role not found in context")
+		}
+		if userRole != domain.RoleCustomer {
+			return response.Forbidden(c, "Insufficient permissions")
+		}
+
+		if _, ok := GetCustomerIDFromContext(c); !ok {
+			return response.Forbidden(c, "Account is not linked to a customer record")
+		}
+
+		return c.Next()
+	}
 }
 
 func GetUserFromContext(c *fiber.Ctx) (uint, string, domain.Role, error) {
@@ -85,4 +146,28 @@ func GetUserFromContext(c *fiber.Ctx) (uint, string, domain.Role, error) {
 	}
 
 	return userID, userEmail, userRole, nil
-}
\ No newline at end of file
+}
+
+// GetCustomerIDFromContext returns the customer record linked to the authenticated user
+func GetCustomerIDFromContext(c *fiber.Ctx) (uuid.UUID, bool) {
+	customerID, ok := c.Locals("customer_id").(uuid.UUID)
+	return customerID, ok
+}
+
+// GetTechnicianIDFromContext returns the technician record linked to the authenticated user
+func GetTechnicianIDFromContext(c *fiber.Ctx) (uuid.UUID, bool) {
+	technicianID, ok := c.Locals("technician_id").(uuid.UUID)
+	return technicianID, ok
+}
+
+// GetAccessScope returns the records the authenticated user may see. Technicians are
+// limited to their own jobs; a technician account without a linked record sees nothing.
+func GetAccessScope(c *fiber.Ctx) domain.AccessScope {
+	userRole, _ := c.Locals("user_role").(domain.Role)
+	if userRole != domain.RoleTechnician {
+		return domain.AccessScope{}
+	}
+
+	technicianID, _ := GetTechnicianIDFromContext(c)
+	return domain.TechnicianScope(technicianID)
+}
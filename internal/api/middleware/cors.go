@@ -10,7 +10,7 @@ func CORS() fiber.Handler {
 		AllowOrigins:  "*",
 		AllowMethods:  "GET,POST,HEAD,PUT,DELETE,PATCH,OPTIONS",
 		AllowHeaders:  "Origin,Content-Type,Accept,Authorization,X-Requested-With",
-		ExposeHeaders: "Content-Length,Access-Control-Allow-Origin,Access-Control-Allow-Headers,Content-Type",
+		ExposeHeaders: "Content-Length,Access-Control-Allow-Origin,Access-Control-Allow-Headers,Content-Type,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,Retry-After",
 		MaxAge:        86400, // 24 hours
 	})
 }
@@ -21,7 +21,7 @@ func CORSWithConfig(allowOrigins string) fiber.Handler {
 		AllowMethods:     "GET,POST,HEAD,PUT,DELETE,PATCH,OPTIONS",
 		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,X-Requested-With",
 		AllowCredentials: true,
-		ExposeHeaders:    "Content-Length,Access-Control-Allow-Origin,Access-Control-Allow-Headers,Content-Type",
+		ExposeHeaders:    "Content-Length,Access-Control-Allow-Origin,Access-Control-Allow-Headers,Content-Type,RateLimit-Limit,RateLimit-Remaining,RateLimit-Reset,Retry-After",
 		MaxAge:           86400, // 24 hours
 	})
 }
\ No newline at end of file
@@ -1,41 +1,56 @@
 package middleware
 
 import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
 	"dashboard-ac-backend/internal/api/response"
+	"dashboard-ac-backend/internal/apperror"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/rs/zerolog/log"
 )
 
+// ErrorHandler turns errors returned by handlers into error responses. Errors from the
+// apperror package are answered with their status, code and details, client errors
+// raised by Fiber itself (unknown route, oversized body) with their status, and
+// anything else with a 500 that does not reveal the cause.
 func ErrorHandler(c *fiber.Ctx, err error) error {
-	// Default error code
-	code := fiber.StatusInternalServerError
+	status := fiber.StatusInternalServerError
+	code := "internal_error"
+	message := "Internal server error"
+	var details interface{}
 
-	// Check if it's a Fiber error
-	if e, ok := err.(*fiber.Error); ok {
-		code = e.Code
+	var fiberErr *fiber.Error
+	if appErr := apperror.From(err); appErr != nil {
+		status, code, message, details = appErr.Kind.HTTPStatus(), appErr.Code, appErr.Message, appErr.Details
+		if appErr.RetryAfter > 0 {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(appErr.RetryAfter.Seconds()))))
+		}
+	} else if errors.As(err, &fiberErr) && fiberErr.Code < fiber.StatusInternalServerError {
+		status, code, message = fiberErr.Code, statusCode(fiberErr.Code), fiberErr.Message
 	}
 
-	// Log the error
-	log.Error().
+	// Client errors are expected; only failures of the API itself are logged as errors
+	event := RequestLogger(c).Info()
+	if status >= fiber.StatusInternalServerError {
+		event = RequestLogger(c).Error()
+	}
+	event.
 		Err(err).
 		Str("method", c.Method()).
 		Str("path", c.Path()).
 		Str("ip", c.IP()).
-		Int("status", code).
+		Int("status", status).
+		Str("code", code).
 		Msg("HTTP Error")
 
-	// Handle different error codes
-	switch code {
-	case fiber.StatusBadRequest:
-		return response.BadRequest(c, err.Error(), nil)
-	case fiber.StatusUnauthorized:
-		return response.Unauthorized(c, err.Error())
-	case fiber.StatusForbidden:
-		return response.Forbidden(c, err.Error())
-	case fiber.StatusNotFound:
-		return response.NotFound(c, err.Error())
-	default:
-		return response.InternalServerError(c, "Internal server error")
-	}
-}
\ No newline at end of file
+	return response.Error(c, status, code, message, details)
+}
+
+// statusCode derives an error code from an HTTP status, e.g. "method_not_allowed"
+func statusCode(status int) string {
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_")
+}
@@ -4,7 +4,6 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/rs/zerolog/log"
 )
 
 func Logger() fiber.Handler {
@@ -14,8 +13,8 @@ func Logger() fiber.Handler {
 		// Process request
 		err := c.Next()
 
-		// Log request details
-		log.Info().
+		// Log request details with the request ID and, once authenticated, the user
+		RequestLogger(c).Info().
 			Str("method", c.Method()).
 			Str("path", c.Path()).
 			Str("ip", c.IP()).
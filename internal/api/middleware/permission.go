@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+
+	"dashboard-ac-backend/internal/api/response"
+	"dashboard-ac-backend/internal/domain"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// PermissionResolver returns the effective permissions of a role
+type PermissionResolver func(ctx context.Context, role domain.Role) (domain.PermissionSet, error)
+
+// LoadPermissions resolves the permissions of the authenticated user's role and stores
+// them for RequirePermission; it must run after JWTAuth
+func LoadPermissions(resolve PermissionResolver) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userRole, ok := c.Locals("user_role").(domain.Role)
+		if !ok {
+			return response.Unauthorized(c, "User role not found in context")
+		}
+
+		permissions, err := resolve(c.UserContext(), userRole)
+		if err != nil {
+			RequestLogger(c).Error().Err(err).Str("role", string(userRole)).Msg("Failed to load role permissions")
+			return response.InternalServerError(c, "Failed to load permissions")
+		}
+
+		c.Locals("permissions", permissions)
+		return c.Next()
+	}
+}
+
+// RequirePermission allows users whose role holds every given permission
+func RequirePermission(permissions ...domain.Permission) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		granted := GetPermissionsFromContext(c)
+		for _, permission := range permissions {
+			if !granted.Has(permission) {
+				return response.Forbidden(c, "Insufficient permissions")
+			}
+		}
+
+		return c.Next()
+	}
+}
+
+// GetPermissionsFromContext returns the permissions loaded by LoadPermissions, or an
+// empty set when they were not loaded
+func GetPermissionsFromContext(c *fiber.Ctx) domain.PermissionSet {
+	permissions, _ := c.Locals("permissions").(domain.PermissionSet)
+	return permissions
+}
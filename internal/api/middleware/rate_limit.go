@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"dashboard-ac-backend/internal/api/response"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RateLimitRule describes a token bucket: it holds up to Limit tokens and is refilled
+// at Limit tokens per Period. Every request takes one token.
+type RateLimitRule struct {
+	Limit  int
+	Period time.Duration
+}
+
+// interval returns how long it takes to refill a single token
+func (r RateLimitRule) interval() time.Duration {
+	return r.Period / time.Duration(r.Limit)
+}
+
+// RateLimitResult is the state of a bucket after a request tried to take a token
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // until the next token is available, when not allowed
+	Reset      time.Duration // until the bucket is full again
+}
+
+// RateLimitStore keeps the token buckets. MemoryRateLimitStore works for a single
+// instance; a shared backend (e.g. Redis) can implement this to limit across instances.
+type RateLimitStore interface {
+	Take(key string, rule RateLimitRule, now time.Time) (RateLimitResult, error)
+}
+
+// RateLimitConfig configures RateLimit for one route group
+type RateLimitConfig struct {
+	Name    string // keeps the buckets of different groups apart
+	Rule    RateLimitRule
+	KeyFunc func(c *fiber.Ctx) string
+	Store   RateLimitStore
+}
+
+// RateLimit throttles requests with a token bucket per key. It sets the RateLimit-Limit,
+// RateLimit-Remaining and RateLimit-Reset headers on every response and answers 429
+// with Retry-After once the bucket is empty. When the store fails, requests are let
+// through rather than failing the API. A rule without a positive limit and period
+// disables limiting.
+func RateLimit(config RateLimitConfig) fiber.Handler {
+	if config.Rule.Limit <= 0 || config.Rule.Period <= 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		key := config.Name + ":" + config.KeyFunc(c)
+
+		result, err := config.Store.Take(key, config.Rule, time.Now())
+		if err != nil {
+			RequestLogger(c).Error().Err(err).Str("key", key).Msg("Rate limit store failed, allowing request")
+			return c.Next()
+		}
+
+		c.Set("RateLimit-Limit", strconv.Itoa(config.Rule.Limit))
+		c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			return response.TooManyRequests(c, "Too many requests, please try again later", result.RetryAfter)
+		}
+
+		return c.Next()
+	}
+}
+
+// KeyByIP keys buckets by client address, for public routes
+func KeyByIP(c *fiber.Ctx) string {
+	return "ip:" + c.IP()
+}
+
+// KeyByUser keys buckets by the authenticated user and falls back to the client
+// address; it must run after JWTAuth
+func KeyByUser(c *fiber.Ctx) string {
+	if userID, ok := c.Locals("user_id").(uint); ok {
+		return fmt.Sprintf("user:%d", userID)
+	}
+	return KeyByIP(c)
+}
+
+// MemoryRateLimitStore keeps token buckets in process memory
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	takes   int
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	period  time.Duration
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: make(map[string]*tokenBucket)}
+}
+
+func (s *MemoryRateLimitStore) Take(key string, rule RateLimitRule, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	capacity := float64(rule.Limit)
+	interval := rule.interval()
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: capacity, updated: now, period: rule.Period}
+		s.buckets[key] = bucket
+	} else {
+		// Refill for the time passed since the last request
+		elapsed := now.Sub(bucket.updated)
+		bucket.tokens = math.Min(capacity, bucket.tokens+float64(elapsed)/float64(interval))
+		bucket.updated = now
+	}
+
+	result := RateLimitResult{}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - bucket.tokens) * float64(interval))
+	}
+	result.Remaining = int(bucket.tokens)
+	result.Reset = time.Duration((capacity - bucket.tokens) * float64(interval))
+
+	s.takes++
+	if s.takes%1000 == 0 {
+		s.pruneLocked(now)
+	}
+
+	return result, nil
+}
+
+// pruneLocked drops buckets that have been idle long enough to be full again; a
+// missing bucket behaves exactly like a full one
+func (s *MemoryRateLimitStore) pruneLocked(now time.Time) {
+	for key, bucket := range s.buckets {
+		if now.Sub(bucket.updated) >= bucket.period {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
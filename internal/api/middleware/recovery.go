@@ -5,7 +5,6 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/recover"
-	"github.com/rs/zerolog/log"
 )
 
 func Recovery() fiber.Handler {
@@ -13,7 +12,7 @@ func Recovery() fiber.Handler {
 		EnableStackTrace: true,
 		StackTraceHandler: func(c *fiber.Ctx, e interface{}) {
 			// Log the panic with stack trace
-			log.Error().
+			RequestLogger(c).Error().
 				Interface("panic", e).
 				Str("method", c.Method()).
 				Str("path", c.Path()).
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"regexp"
+
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/pkg/logger"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// requestIDPattern accepts the IDs proxies and clients commonly send (UUIDs, trace IDs)
+// while keeping arbitrary input out of logs and response headers
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID tags every request with an ID, taken from the X-Request-ID header when it is
+// well-formed and generated otherwise, and echoes it in the response. It attaches a
+// logger carrying the ID to the request context, so handlers, services and database
+// queries log with it, and the audit actor that changes are recorded with. It must run
+// before any other middleware.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		requestID := c.Get(fiber.HeaderXRequestID)
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+
+		c.Locals("request_id", requestID)
+		c.Set(fiber.HeaderXRequestID, requestID)
+
+		requestLogger := log.With().Str("request_id", requestID).Logger()
+		ctx := requestLogger.WithContext(c.UserContext())
+		c.SetUserContext(domain.ContextWithAuditActor(ctx, domain.AuditActor{IPAddress: c.IP(), RequestID: requestID}))
+
+		return c.Next()
+	}
+}
+
+// GetRequestIDFromContext returns the ID assigned by RequestID
+func GetRequestIDFromContext(c *fiber.Ctx) string {
+	requestID, _ := c.Locals("request_id").(string)
+	return requestID
+}
+
+// RequestLogger returns the logger of the current request, or the global logger when
+// RequestID did not run
+func RequestLogger(c *fiber.Ctx) *zerolog.Logger {
+	return logger.FromContext(c.UserContext())
+}
+
+// addLogContext adds fields to the logger of the current request
+func addLogContext(c *fiber.Ctx, fields func(zerolog.Context) zerolog.Context) {
+	requestLogger := fields(RequestLogger(c).With()).Logger()
+	c.SetUserContext(requestLogger.WithContext(c.UserContext()))
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenStateLookup returns the current token version of a user and whether the user
+// may still authenticate
+type TokenStateLookup func(ctx context.Context, userID uint) (tokenVersion int, active bool, err error)
+
+// TokenVersionCache remembers the token state of recently seen users for a short TTL,
+// so JWTAuth does not hit the database on every request while deactivations and role
+// changes still take effect within seconds
+type TokenVersionCache struct {
+	lookup TokenStateLookup
+	ttl    time.Duration
+
+	mu      sync.Mutex
+	entries map[uint]tokenStateEntry
+}
+
+type tokenStateEntry struct {
+	version   int
+	active    bool
+	expiresAt time.Time
+}
+
+func NewTokenVersionCache(lookup TokenStateLookup, ttl time.Duration) *TokenVersionCache {
+	return &TokenVersionCache{
+		lookup:  lookup,
+		ttl:     ttl,
+		entries: make(map[uint]tokenStateEntry),
+	}
+}
+
+// IsCurrent reports whether a token with the given version is still valid for the user
+func (c *TokenVersionCache) IsCurrent(ctx context.Context, userID uint, tokenVersion int) (bool, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[userID]
+	c.mu.Unlock()
+
+	if !ok || !now.Before(entry.expiresAt) {
+		version, active, err := c.lookup(ctx, userID)
+		if err != nil {
+			return false, err
+		}
+
+		entry = tokenStateEntry{version: version, active: active, expiresAt: now.Add(c.ttl)}
+		c.mu.Lock()
+		c.entries[userID] = entry
+		c.pruneLocked(now)
+		c.mu.Unlock()
+	}
+
+	return entry.active && entry.version == tokenVersion, nil
+}
+
+// pruneLocked drops expired entries once the cache has grown, keeping memory bounded
+// by the number of users active within one TTL
+func (c *TokenVersionCache) pruneLocked(now time.Time) {
+	if len(c.entries) < 1024 {
+		return
+	}
+	for userID, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, userID)
+		}
+	}
+}
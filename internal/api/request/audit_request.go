@@ -0,0 +1,10 @@
+package request
+
+type AuditLogListRequest struct {
+	*PaginationRequest
+	EntityType string `json:"entity_type" query:"entity_type" validate:"omitempty,max=50"`
+	EntityID   string `json:"entity_id" query:"entity_id" validate:"omitempty,max=64"`
+	ActorID    uint   `json:"actor_id" query:"actor_id"`
+	DateFrom   string `json:"date_from" query:"date_from" validate:"omitempty,datetime=2006-01-02"`
+	DateTo     string `json:"date_to" query:"date_to" validate:"omitempty,datetime=2006-01-02"` // inclusive
+}
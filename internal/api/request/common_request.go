@@ -12,6 +12,12 @@ type PaginationRequest struct {
 	Sort   string `json:"sort" query:"sort"`
 	Filter string `json:"filter" query:"filter"`
 	Search string `json:"search" query:"search"`
+	// Cursor pages the list by keyset instead of page number; UseCursor is set when the
+	// cursor parameter is present, so an empty cursor asks for the first page
+	Cursor    string `json:"cursor" query:"cursor"`
+	UseCursor bool   `json:"-"`
+	// Include names related records to embed, e.g. "customer,technician"
+	Include string `json:"include" query:"include"`
 }
 
 // GetPaginationFromQuery extracts pagination parameters from query string
@@ -27,11 +33,14 @@ func GetPaginationFromQuery(c *fiber.Ctx) *PaginationRequest {
 	}
 
 	return &PaginationRequest{
-		Page:   page,
-		Limit:  limit,
-		Sort:   c.Query("sort", "created_at desc"),
-		Filter: c.Query("filter", ""),
-		Search: c.Query("search", ""),
+		Page:      page,
+		Limit:     limit,
+		Sort:      c.Query("sort"),
+		Filter:    c.Query("filter"),
+		Search:    c.Query("search"),
+		Cursor:    c.Query("cursor"),
+		UseCursor: c.Context().QueryArgs().Has("cursor"),
+		Include:   c.Query("include"),
 	}
 }
 
@@ -56,12 +65,18 @@ type UserCreateRequest struct {
 	Name     string `json:"name" validate:"required,min=2,max=100"`
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=6"`
-	Role     string `json:"role" validate:"required,oneof=admin technician customer"`
+	Role     string `json:"role" validate:"required,min=2,max=20"`
+	// Optional links to the customer or technician record this account belongs to
+	CustomerID   string `json:"customer_id,omitempty" validate:"omitempty,uuid"`
+	TechnicianID string `json:"technician_id,omitempty" validate:"omitempty,uuid"`
 }
 
 type UserUpdateRequest struct {
 	Name     *string `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
 	Email    *string `json:"email,omitempty" validate:"omitempty,email"`
-	Role     *string `json:"role,omitempty" validate:"omitempty,oneof=admin technician customer"`
+	Role     *string `json:"role,omitempty" validate:"omitempty,min=2,max=20"`
 	IsActive *bool   `json:"is_active,omitempty"`
+	// An empty string removes the link
+	CustomerID   *string `json:"customer_id,omitempty" validate:"omitempty,len=0|uuid"`
+	TechnicianID *string `json:"technician_id,omitempty" validate:"omitempty,len=0|uuid"`
 }
\ No newline at end of file
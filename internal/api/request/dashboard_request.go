@@ -0,0 +1,13 @@
+package request
+
+type DashboardSummaryRequest struct {
+	DateFrom string `json:"date_from" query:"date_from" validate:"omitempty,datetime=2006-01-02"`
+	DateTo   string `json:"date_to" query:"date_to" validate:"omitempty,datetime=2006-01-02"` // inclusive
+	Top      int    `json:"top" query:"top" validate:"omitempty,min=1,max=50"`                // number of top services
+}
+
+type RevenueTimeseriesRequest struct {
+	DateFrom string `json:"date_from" query:"date_from" validate:"omitempty,datetime=2006-01-02"`
+	DateTo   string `json:"date_to" query:"date_to" validate:"omitempty,datetime=2006-01-02"` // inclusive
+	Interval string `json:"interval" query:"interval" validate:"omitempty,oneof=day week month"`
+}
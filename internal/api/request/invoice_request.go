@@ -1,18 +1,30 @@
 package request
 
-import "time"
+import (
+	"time"
+
+	"dashboard-ac-backend/pkg/money"
+)
 
 type InvoiceCreateRequest struct {
-	ScheduleID  string    `json:"schedule_id" validate:"required,uuid"`
-	CustomerID  string    `json:"customer_id" validate:"required,uuid"`
-	InvoiceDate time.Time `json:"invoice_date" validate:"required"`
-	DueDate     time.Time `json:"due_date" validate:"required"`
+	ScheduleID  string               `json:"schedule_id" validate:"required,uuid"`
+	CustomerID  string               `json:"customer_id" validate:"required,uuid"`
+	InvoiceDate time.Time            `json:"invoice_date" validate:"required"`
+	DueDate     time.Time            `json:"due_date" validate:"required"`
+	Items       []InvoiceItemRequest `json:"items,omitempty" validate:"omitempty,dive"`
+}
+
+// InvoiceItemRequest is a line item created together with its invoice
+type InvoiceItemRequest struct {
+	ServiceID string       `json:"service_id" validate:"required,uuid"`
+	Quantity  int          `json:"quantity" validate:"required,min=1"`
+	UnitPrice *money.Money `json:"unit_price,omitempty" validate:"omitempty,min=0"` // defaults to the service price
 }
 
 type InvoiceUpdateRequest struct {
 	InvoiceDate *time.Time `json:"invoice_date,omitempty"`
 	DueDate     *time.Time `json:"due_date,omitempty"`
-	Status      *string    `json:"status,omitempty" validate:"omitempty,oneof=Unpaid Paid Overdue"`
+	Status      *string    `json:"status,omitempty" validate:"omitempty,oneof=Unpaid Overdue"` // Paid and PartiallyPaid follow from payments
 }
 
 type InvoiceSearchRequest struct {
@@ -25,13 +37,20 @@ type InvoiceSearchRequest struct {
 }
 
 type InvoiceDetailCreateRequest struct {
-	InvoiceID string  `json:"invoice_id" validate:"required,uuid"`
-	ServiceID string  `json:"service_id" validate:"required,uuid"`
-	Quantity  int     `json:"quantity" validate:"required,min=1"`
-	UnitPrice float64 `json:"unit_price" validate:"required,min=0"`
+	InvoiceID string      `json:"invoice_id" validate:"required,uuid"`
+	ServiceID string      `json:"service_id" validate:"required,uuid"`
+	Quantity  int         `json:"quantity" validate:"required,min=1"`
+	UnitPrice money.Money `json:"unit_price" validate:"required,min=0"`
 }
 
 type InvoiceDetailUpdateRequest struct {
-	Quantity  *int     `json:"quantity,omitempty" validate:"omitempty,min=1"`
-	UnitPrice *float64 `json:"unit_price,omitempty" validate:"omitempty,min=0"`
-}
\ No newline at end of file
+	Quantity  *int         `json:"quantity,omitempty" validate:"omitempty,min=1"`
+	UnitPrice *money.Money `json:"unit_price,omitempty" validate:"omitempty,min=0"`
+}
+
+type PaymentCreateRequest struct {
+	Amount          money.Money `json:"amount" validate:"required,gt=0"`
+	Method          string      `json:"method" validate:"required,oneof=Cash Transfer QRIS"`
+	ReferenceNumber string      `json:"reference_number,omitempty" validate:"omitempty,max=100"`
+	PaidAt          *time.Time  `json:"paid_at,omitempty"` // defaults to now
+}
@@ -0,0 +1,23 @@
+package request
+
+import "time"
+
+// PortalBookingRequest is a service booking made by a customer for themselves
+type PortalBookingRequest struct {
+	ServiceID    string    `json:"service_id" validate:"required,uuid"`
+	TechnicianID string    `json:"technician_id,omitempty" validate:"omitempty,uuid"` // any free technician when empty
+	Date         time.Time `json:"date" validate:"required"`
+	Time         time.Time `json:"time" validate:"required"`
+}
+
+type PortalCancelRequest struct {
+	Reason string `json:"reason,omitempty" validate:"omitempty,max=500"`
+}
+
+// PortalProfileUpdateRequest changes a customer's own record; the email stays
+// tied to the login account and can only be changed by an admin
+type PortalProfileUpdateRequest struct {
+	Name    *string `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+	Phone   *string `json:"phone,omitempty" validate:"omitempty,min=10,max=15"`
+	Address *string `json:"address,omitempty" validate:"omitempty,min=10,max=500"`
+}
@@ -0,0 +1,13 @@
+package request
+
+type RoleCreateRequest struct {
+	Name        string   `json:"name" validate:"required,min=2,max=20"`
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions" validate:"dive,required"`
+}
+
+// RoleUpdateRequest replaces the permissions of a role when Permissions is set
+type RoleUpdateRequest struct {
+	Description *string   `json:"description,omitempty" validate:"omitempty,max=255"`
+	Permissions *[]string `json:"permissions,omitempty" validate:"omitempty,dive,required"`
+}
@@ -18,6 +18,10 @@ type ScheduleUpdateRequest struct {
 	Status       *string    `json:"status,omitempty" validate:"omitempty,oneof=Pending On-Progress Completed Canceled"`
 }
 
+type ScheduleCancelRequest struct {
+	Reason string `json:"reason" validate:"required,min=3,max=500"`
+}
+
 type ScheduleSearchRequest struct {
 	*PaginationRequest
 	CustomerID   string `json:"customer_id" query:"customer_id"`
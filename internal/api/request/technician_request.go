@@ -4,12 +4,26 @@ type TechnicianCreateRequest struct {
 	Name           string `json:"name" validate:"required,min=2,max=100"`
 	Phone          string `json:"phone" validate:"required,min=10,max=15"`
 	Specialization string `json:"specialization" validate:"required,min=2,max=100"`
+	WorkStart      string `json:"work_start,omitempty" validate:"omitempty,datetime=15:04"` // HH:MM, defaults to 08:00
+	WorkEnd        string `json:"work_end,omitempty" validate:"omitempty,datetime=15:04"`   // HH:MM, defaults to 17:00
+	BreakStart     string `json:"break_start,omitempty" validate:"omitempty,datetime=15:04"`
+	BreakEnd       string `json:"break_end,omitempty" validate:"omitempty,datetime=15:04"`
 }
 
 type TechnicianUpdateRequest struct {
 	Name           *string `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
 	Phone          *string `json:"phone,omitempty" validate:"omitempty,min=10,max=15"`
 	Specialization *string `json:"specialization,omitempty" validate:"omitempty,min=2,max=100"`
+	WorkStart      *string `json:"work_start,omitempty" validate:"omitempty,datetime=15:04"`
+	WorkEnd        *string `json:"work_end,omitempty" validate:"omitempty,datetime=15:04"`
+	BreakStart     *string `json:"break_start,omitempty" validate:"omitempty,datetime=15:04"` // empty string clears the break
+	BreakEnd       *string `json:"break_end,omitempty" validate:"omitempty,datetime=15:04"`
+}
+
+type AvailabilityRequest struct {
+	Date      string `json:"date" query:"date" validate:"required,datetime=2006-01-02"`
+	ServiceID string `json:"service_id" query:"service_id" validate:"required,uuid"`
+	Interval  int    `json:"interval" query:"interval" validate:"min=5,max=240"` // slot step in minutes
 }
 
 type TechnicianSearchRequest struct {
@@ -1,14 +1,21 @@
 package response
 
 import (
+	"math"
+	"strconv"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 )
 
 type BaseResponse struct {
 	Status  string      `json:"status"`
+	Code    string      `json:"code,omitempty"` // stable machine-readable error code
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
 	Error   interface{} `json:"error,omitempty"`
+	// RequestID is set on error responses so a reported failure can be found in the logs
+	RequestID string `json:"request_id,omitempty"`
 }
 
 type PaginationMeta struct {
@@ -25,6 +32,21 @@ type PaginatedResponse struct {
 	Pagination PaginationMeta `json:"pagination"`
 }
 
+// CursorPaginationMeta replaces PaginationMeta when a list is paged by cursor. The
+// cursors are empty when there is no page in that direction; the total is not counted.
+type CursorPaginationMeta struct {
+	Limit      int    `json:"limit"`
+	NextCursor string `json:"next_cursor"`
+	PrevCursor string `json:"prev_cursor"`
+}
+
+type CursorPaginatedResponse struct {
+	Status     string               `json:"status"`
+	Message    string               `json:"message"`
+	Data       interface{}          `json:"data"`
+	Pagination CursorPaginationMeta `json:"pagination"`
+}
+
 type ErrorDetail struct {
 	Field   string `json:"field,omitempty"`
 	Message string `json:"message"`
@@ -47,39 +69,39 @@ func Created(c *fiber.Ctx, message string, data interface{}) error {
 }
 
 func BadRequest(c *fiber.Ctx, message string, errors interface{}) error {
-	return c.Status(fiber.StatusBadRequest).JSON(BaseResponse{
-		Status:  "error",
-		Message: message,
-		Error:   errors,
-	})
+	return Error(c, fiber.StatusBadRequest, "bad_request", message, errors)
 }
 
 func Unauthorized(c *fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusUnauthorized).JSON(BaseResponse{
-		Status:  "error",
-		Message: message,
-	})
+	return Error(c, fiber.StatusUnauthorized, "unauthorized", message, nil)
 }
 
 func Forbidden(c *fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusForbidden).JSON(BaseResponse{
-		Status:  "error",
-		Message: message,
-	})
+	return Error(c, fiber.StatusForbidden, "forbidden", message, nil)
 }
 
 func NotFound(c *fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusNotFound).JSON(BaseResponse{
-		Status:  "error",
-		Message: message,
-	})
+	return Error(c, fiber.StatusNotFound, "not_found", message, nil)
+}
+
+func Conflict(c *fiber.Ctx, message string, errors interface{}) error {
+	return Error(c, fiber.StatusConflict, "conflict", message, errors)
+}
+
+// Locked responds 423, e.g. for an account that is temporarily locked
+func Locked(c *fiber.Ctx, message string, errors interface{}) error {
+	return Error(c, fiber.StatusLocked, "locked", message, errors)
+}
+
+// TooManyRequests responds 429 and sets Retry-After to the whole number of seconds the
+// client should wait
+func TooManyRequests(c *fiber.Ctx, message string, retryAfter time.Duration) error {
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	return Error(c, fiber.StatusTooManyRequests, "too_many_requests", message, nil)
 }
 
 func InternalServerError(c *fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusInternalServerError).JSON(BaseResponse{
-		Status:  "error",
-		Message: message,
-	})
+	return Error(c, fiber.StatusInternalServerError, "internal_error", message, nil)
 }
 
 func Paginated(c *fiber.Ctx, message string, data interface{}, pagination PaginationMeta) error {
@@ -91,6 +113,33 @@ func Paginated(c *fiber.Ctx, message string, data interface{}, pagination Pagina
 	})
 }
 
+func CursorPaginated(c *fiber.Ctx, message string, data interface{}, pagination CursorPaginationMeta) error {
+	return c.Status(fiber.StatusOK).JSON(CursorPaginatedResponse{
+		Status:     "success",
+		Message:    message,
+		Data:       data,
+		Pagination: pagination,
+	})
+}
+
+// Error writes an error response with a machine-readable code and the request ID.
+// Details, when set, is returned in the "error" field.
+func Error(c *fiber.Ctx, status int, code, message string, details interface{}) error {
+	return c.Status(status).JSON(BaseResponse{
+		Status:    "error",
+		Code:      code,
+		Message:   message,
+		Error:     details,
+		RequestID: requestID(c),
+	})
+}
+
+// requestID returns the ID the RequestID middleware assigned to the request
+func requestID(c *fiber.Ctx) string {
+	requestID, _ := c.Locals("request_id").(string)
+	return requestID
+}
+
 func CalculatePagination(page, limit int, total int64) PaginationMeta {
 	totalPages := int((total + int64(limit) - 1) / int64(limit))
 	return PaginationMeta{
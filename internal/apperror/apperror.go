@@ -0,0 +1,160 @@
+// Package apperror defines the errors services return for failures the client can act
+// on. Each error carries a kind, which decides the HTTP status, and a stable
+// machine-readable code; middleware.ErrorHandler turns them into error responses.
+// Any other error is reported to clients as an internal error.
+package apperror
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"dashboard-ac-backend/pkg/utils"
+)
+
+// Kind classifies an error by how the API answers it
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindValidation
+	KindUnauthorized
+	KindForbidden
+	KindNotFound
+	KindConflict
+	KindLocked
+	KindTooManyRequests
+)
+
+// HTTPStatus returns the status code the API answers errors of this kind with
+func (k Kind) HTTPStatus() int {
+	switch k {
+	case KindValidation:
+		return http.StatusBadRequest
+	case KindUnauthorized:
+		return http.StatusUnauthorized
+	case KindForbidden:
+		return http.StatusForbidden
+	case KindNotFound:
+		return http.StatusNotFound
+	case KindConflict:
+		return http.StatusConflict
+	case KindLocked:
+		return http.StatusLocked
+	case KindTooManyRequests:
+		return http.StatusTooManyRequests
+	}
+	return http.StatusInternalServerError
+}
+
+// FieldError describes why one field of a request was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is a failure reported to the client. Details, when set, is returned in the
+// "error" field of the response: the field errors of a validation failure or extra
+// data such as the conflicting records.
+type Error struct {
+	Kind       Kind
+	Code       string
+	Message    string
+	Details    interface{}
+	RetryAfter time.Duration // sent as Retry-After when set
+	Err        error         // underlying cause, logged but never sent to the client
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is an Error with the same code, so errors.Is matches a
+// sentinel even when the returned error carries its own details
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// WithDetails returns a copy of the error carrying details
+func (e *Error) WithDetails(details interface{}) *Error {
+	copied := *e
+	copied.Details = details
+	return &copied
+}
+
+// Wrap returns a copy of the error with err as its cause
+func (e *Error) Wrap(err error) *Error {
+	copied := *e
+	copied.Err = err
+	return &copied
+}
+
+// Coder is implemented by error types that carry data of their own, such as the
+// conflicting schedules of a double booking, and know how they are reported
+type Coder interface {
+	AppError() *Error
+}
+
+// From returns the Error describing err, or nil when err is not a client error
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	var coder Coder
+	if errors.As(err, &coder) {
+		return coder.AppError()
+	}
+	return nil
+}
+
+func Validation(code, message string, fields ...FieldError) *Error {
+	e := &Error{Kind: KindValidation, Code: code, Message: message}
+	if len(fields) > 0 {
+		e.Details = fields
+	}
+	return e
+}
+
+func Unauthorized(code, message string) *Error {
+	return &Error{Kind: KindUnauthorized, Code: code, Message: message}
+}
+
+func Forbidden(code, message string) *Error {
+	return &Error{Kind: KindForbidden, Code: code, Message: message}
+}
+
+func NotFound(code, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+func Conflict(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+func Locked(code, message string) *Error {
+	return &Error{Kind: KindLocked, Code: code, Message: message}
+}
+
+func TooManyRequests(code, message string, retryAfter time.Duration) *Error {
+	return &Error{Kind: KindTooManyRequests, Code: code, Message: message, RetryAfter: retryAfter}
+}
+
+// InvalidBody reports a request body that could not be parsed
+func InvalidBody(err error) *Error {
+	return &Error{Kind: KindValidation, Code: "invalid_body", Message: "Invalid request body", Details: err.Error(), Err: err}
+}
+
+// ValidationFailed reports the fields of a request that failed struct validation
+func ValidationFailed(errs []utils.ValidationError) *Error {
+	fields := make([]FieldError, 0, len(errs))
+	for _, e := range errs {
+		fields = append(fields, FieldError{Field: e.Field, Message: e.Message})
+	}
+	return Validation("validation_failed", "Validation failed", fields...)
+}
@@ -0,0 +1,25 @@
+package domain
+
+import "github.com/google/uuid"
+
+// AccessScope limits which schedules and invoices a caller may see. The zero value
+// is unrestricted (admins); a technician scope only covers that technician's jobs.
+type AccessScope struct {
+	TechnicianID *uuid.UUID
+}
+
+// TechnicianScope restricts access to the jobs of one technician. Technician
+// accounts without a linked record get uuid.Nil, which matches nothing.
+func TechnicianScope(technicianID uuid.UUID) AccessScope {
+	return AccessScope{TechnicianID: &technicianID}
+}
+
+// IsRestricted reports whether the scope filters anything
+func (s AccessScope) IsRestricted() bool {
+	return s.TechnicianID != nil
+}
+
+// AllowsSchedule reports whether the schedule is visible in this scope
+func (s AccessScope) AllowsSchedule(schedule *Schedule) bool {
+	return s.TechnicianID == nil || schedule.TechnicianID == *s.TechnicianID
+}
@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AuditAction string
+
+const (
+	AuditActionCreate AuditAction = "create"
+	AuditActionUpdate AuditAction = "update"
+	AuditActionDelete AuditAction = "delete"
+)
+
+// Entity types recorded in the audit log
+const (
+	AuditEntityUser          = "user"
+	AuditEntityRole          = "role"
+	AuditEntityCustomer      = "customer"
+	AuditEntityTechnician    = "technician"
+	AuditEntityService       = "service"
+	AuditEntitySchedule      = "schedule"
+	AuditEntityInvoice       = "invoice"
+	AuditEntityInvoiceDetail = "invoice_detail"
+	AuditEntityPayment       = "payment"
+)
+
+// AuditChanges holds the fields of an entity as they were before or after a change,
+// stored as a JSON object
+type AuditChanges map[string]interface{}
+
+func (c AuditChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+func (c *AuditChanges) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*c = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported audit changes value")
+	}
+	return json.Unmarshal(data, c)
+}
+
+// AuditLog records one create, update or delete. Before and After only hold the fields
+// that changed; a create has no Before and a delete no After.
+type AuditLog struct {
+	ID         uuid.UUID    `json:"id" gorm:"type:uuid;primary_key"`
+	ActorID    *uint        `json:"actor_id,omitempty" gorm:"index"` // empty for self-registration and background jobs
+	ActorEmail string       `json:"actor_email,omitempty" gorm:"type:varchar(255)"`
+	Action     AuditAction  `json:"action" gorm:"type:varchar(20);not null"`
+	EntityType string       `json:"entity_type" gorm:"type:varchar(50);not null;index:idx_audit_logs_entity"`
+	EntityID   string       `json:"entity_id" gorm:"type:varchar(64);not null;index:idx_audit_logs_entity"`
+	Before     AuditChanges `json:"before,omitempty" gorm:"type:jsonb"`
+	After      AuditChanges `json:"after,omitempty" gorm:"type:jsonb"`
+	IPAddress  string       `json:"ip_address" gorm:"type:varchar(45)"`
+	RequestID  string       `json:"request_id,omitempty" gorm:"type:varchar(64);index"`
+	CreatedAt  time.Time    `json:"created_at" gorm:"index"`
+}
+
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
+
+// AuditActor is who makes the changes of a request and where the request came from.
+// Changes made by background jobs have no actor.
+type AuditActor struct {
+	UserID    *uint
+	Email     string
+	IPAddress string
+	RequestID string
+}
+
+type auditActorKey struct{}
+
+// ContextWithAuditActor returns a copy of ctx carrying actor, so that changes made with
+// the context are recorded in their name
+func ContextWithAuditActor(ctx context.Context, actor AuditActor) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// AuditActorFromContext returns the actor carried by ctx, empty when there is none
+func AuditActorFromContext(ctx context.Context) AuditActor {
+	actor, _ := ctx.Value(auditActorKey{}).(AuditActor)
+	return actor
+}
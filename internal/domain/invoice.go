@@ -3,6 +3,8 @@ package domain
 import (
 	"time"
 
+	"dashboard-ac-backend/pkg/money"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -13,21 +15,29 @@ const (
 	InvoiceStatusUnpaid  InvoiceStatus = "Unpaid"
 	InvoiceStatusPaid    InvoiceStatus = "Paid"
 	InvoiceStatusOverdue InvoiceStatus = "Overdue"
+
+	// InvoiceStatusPartiallyPaid marks an invoice with payments that do not yet clear the balance
+	InvoiceStatusPartiallyPaid InvoiceStatus = "PartiallyPaid"
 )
 
 type Invoice struct {
 	ID           uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
-	ScheduleID   uuid.UUID      `json:"schedule_id" gorm:"type:uuid;not null" validate:"required"`
+	ScheduleID   uuid.UUID      `json:"schedule_id" gorm:"type:uuid;not null;uniqueIndex:idx_invoices_schedule_id,where:deleted_at IS NULL" validate:"required"`
 	CustomerID   uuid.UUID      `json:"customer_id" gorm:"type:uuid;not null" validate:"required"`
 	InvoiceDate  time.Time      `json:"invoice_date" gorm:"type:date;not null" validate:"required"`
 	DueDate      time.Time      `json:"due_date" gorm:"type:date;not null" validate:"required"`
-	TotalAmount  float64        `json:"total_amount" gorm:"type:decimal(10,2);not null" validate:"required,min=0"`
-	Status       InvoiceStatus  `json:"status" gorm:"type:varchar(20);default:'Unpaid'" validate:"required,oneof=Unpaid Paid Overdue"`
+	TotalAmount  money.Money    `json:"total_amount" gorm:"type:decimal(10,2);not null" validate:"required,min=0"`
+	AmountPaid   money.Money    `json:"amount_paid" gorm:"type:decimal(10,2);not null;default:0"`
+	BalanceDue   money.Money    `json:"balance_due" gorm:"-"`
+	Status       InvoiceStatus  `json:"status" gorm:"type:varchar(20);default:'Unpaid'" validate:"required,oneof=Unpaid PartiallyPaid Paid Overdue"`
 	CreatedAt    time.Time      `json:"created_at"`
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
 
-	// Relations will be handled at repository level to avoid circular imports
+	// Related records, only set when a request asks to include them
+	Customer *Customer        `json:"customer,omitempty" gorm:"-"`
+	Schedule *Schedule        `json:"schedule,omitempty" gorm:"-"`
+	Details  []*InvoiceDetail `json:"details,omitempty" gorm:"-"`
 }
 
 func (i *Invoice) BeforeCreate(tx *gorm.DB) error {
@@ -37,11 +47,37 @@ func (i *Invoice) BeforeCreate(tx *gorm.DB) error {
 	if i.Status == "" {
 		i.Status = InvoiceStatusUnpaid
 	}
+	i.BalanceDue = i.TotalAmount.Sub(i.AmountPaid)
+	return nil
+}
+
+func (i *Invoice) AfterFind(tx *gorm.DB) error {
+	i.BalanceDue = i.TotalAmount.Sub(i.AmountPaid)
 	return nil
 }
 
 func (i *Invoice) IsValidStatus() bool {
 	return i.Status == InvoiceStatusUnpaid || 
+		   i.Status == InvoiceStatusPartiallyPaid ||
 		   i.Status == InvoiceStatusPaid || 
 		   i.Status == InvoiceStatusOverdue
+}
+
+// ApplyPayments records the amount paid so far and derives the payment status from it:
+// Paid once the balance is cleared, PartiallyPaid while some of it is outstanding. An
+// invoice without payments keeps Unpaid or Overdue.
+func (i *Invoice) ApplyPayments(amountPaid money.Money) {
+	i.AmountPaid = amountPaid
+	i.BalanceDue = i.TotalAmount.Sub(amountPaid)
+
+	switch {
+	case amountPaid.IsZero():
+		if i.Status == InvoiceStatusPaid || i.Status == InvoiceStatusPartiallyPaid {
+			i.Status = InvoiceStatusUnpaid
+		}
+	case !i.BalanceDue.IsNegative() && !i.BalanceDue.IsZero():
+		i.Status = InvoiceStatusPartiallyPaid
+	default:
+		i.Status = InvoiceStatusPaid
+	}
 }
\ No newline at end of file
@@ -3,6 +3,8 @@ package domain
 import (
 	"time"
 
+	"dashboard-ac-backend/pkg/money"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -12,8 +14,8 @@ type InvoiceDetail struct {
 	InvoiceID uuid.UUID      `json:"invoice_id" gorm:"type:uuid;not null" validate:"required"`
 	ServiceID uuid.UUID      `json:"service_id" gorm:"type:uuid;not null" validate:"required"`
 	Quantity  int            `json:"quantity" gorm:"not null" validate:"required,min=1"`
-	UnitPrice float64        `json:"unit_price" gorm:"type:decimal(10,2);not null" validate:"required,min=0"`
-	Subtotal  float64        `json:"subtotal" gorm:"type:decimal(10,2);not null" validate:"required,min=0"`
+	UnitPrice money.Money    `json:"unit_price" gorm:"type:decimal(10,2);not null" validate:"required,min=0"`
+	Subtotal  money.Money    `json:"subtotal" gorm:"type:decimal(10,2);not null" validate:"required,min=0"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
@@ -26,12 +28,12 @@ func (id *InvoiceDetail) BeforeCreate(tx *gorm.DB) error {
 		id.ID = uuid.New()
 	}
 	// Calculate subtotal automatically
-	id.Subtotal = float64(id.Quantity) * id.UnitPrice
+	id.Subtotal = id.UnitPrice.Mul(int64(id.Quantity))
 	return nil
 }
 
 func (id *InvoiceDetail) BeforeUpdate(tx *gorm.DB) error {
 	// Recalculate subtotal on update
-	id.Subtotal = float64(id.Quantity) * id.UnitPrice
+	id.Subtotal = id.UnitPrice.Mul(int64(id.Quantity))
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,38 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LoginFailureReason explains why a login attempt was rejected
+type LoginFailureReason string
+
+const (
+	LoginFailureInvalidCredentials LoginFailureReason = "invalid_credentials"
+	LoginFailureAccountInactive    LoginFailureReason = "account_inactive"
+	LoginFailureAccountLocked      LoginFailureReason = "account_locked"
+	LoginFailureThrottled          LoginFailureReason = "throttled"
+)
+
+// LoginAttempt records one login request, successful or not. UserID is empty when the
+// email does not belong to any account.
+type LoginAttempt struct {
+	ID            uuid.UUID          `json:"id" gorm:"type:uuid;primary_key"`
+	UserID        *uint              `json:"user_id,omitempty" gorm:"index"`
+	Email         string             `json:"email" gorm:"type:varchar(255);not null;index"`
+	IPAddress     string             `json:"ip_address" gorm:"type:varchar(45);not null;index:idx_login_attempts_ip_created"`
+	UserAgent     string             `json:"user_agent" gorm:"type:varchar(255)"`
+	Success       bool               `json:"success" gorm:"not null"`
+	FailureReason LoginFailureReason `json:"failure_reason,omitempty" gorm:"type:varchar(30)"`
+	CreatedAt     time.Time          `json:"created_at" gorm:"index:idx_login_attempts_ip_created"`
+}
+
+func (a *LoginAttempt) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package domain
+
+import (
+	"time"
+
+	"dashboard-ac-backend/pkg/money"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PaymentMethod string
+
+const (
+	PaymentMethodCash     PaymentMethod = "Cash"
+	PaymentMethodTransfer PaymentMethod = "Transfer"
+	PaymentMethodQRIS     PaymentMethod = "QRIS"
+)
+
+type Payment struct {
+	ID              uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	InvoiceID       uuid.UUID      `json:"invoice_id" gorm:"type:uuid;not null;index" validate:"required"`
+	Amount          money.Money    `json:"amount" gorm:"type:decimal(10,2);not null" validate:"required,gt=0"`
+	Method          PaymentMethod  `json:"method" gorm:"type:varchar(20);not null" validate:"required,oneof=Cash Transfer QRIS"`
+	ReferenceNumber string         `json:"reference_number" gorm:"type:varchar(100)" validate:"max=100"`
+	PaidAt          time.Time      `json:"paid_at" gorm:"not null"`
+	RecordedBy      uint           `json:"recorded_by" gorm:"not null"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `json:"-" gorm:"index"`
+
+	// Relations will be handled at repository level to avoid circular imports
+}
+
+func (p *Payment) BeforeCreate(tx *gorm.DB) error {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	if p.PaidAt.IsZero() {
+		p.PaidAt = time.Now()
+	}
+	return nil
+}
+
+func (p *Payment) IsValidMethod() bool {
+	return p.Method == PaymentMethodCash ||
+		p.Method == PaymentMethodTransfer ||
+		p.Method == PaymentMethodQRIS
+}
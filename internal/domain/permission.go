@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"regexp"
+	"sort"
+	"time"
+)
+
+// Permission grants one action on one resource, written as "resource:action"
+type Permission string
+
+const (
+	PermUsersRead        Permission = "users:read"
+	PermUsersWrite       Permission = "users:write"
+	PermRolesRead        Permission = "roles:read"
+	PermRolesWrite       Permission = "roles:write"
+	PermCustomersRead    Permission = "customers:read"
+	PermCustomersWrite   Permission = "customers:write"
+	PermTechniciansRead  Permission = "technicians:read"
+	PermTechniciansWrite Permission = "technicians:write"
+	PermServicesRead     Permission = "services:read"
+	PermServicesWrite    Permission = "services:write"
+	PermSchedulesRead    Permission = "schedules:read"
+	PermSchedulesWrite   Permission = "schedules:write"
+	PermInvoicesRead     Permission = "invoices:read"
+	PermInvoicesWrite    Permission = "invoices:write"
+	PermPaymentsRead     Permission = "payments:read"
+	PermPaymentsWrite    Permission = "payments:write"
+	PermAuditLogsRead    Permission = "audit_logs:read"
+	PermReportsRead      Permission = "reports:read"
+)
+
+var allPermissions = []Permission{
+	PermUsersRead, PermUsersWrite,
+	PermRolesRead, PermRolesWrite,
+	PermCustomersRead, PermCustomersWrite,
+	PermTechniciansRead, PermTechniciansWrite,
+	PermServicesRead, PermServicesWrite,
+	PermSchedulesRead, PermSchedulesWrite,
+	PermInvoicesRead, PermInvoicesWrite,
+	PermPaymentsRead, PermPaymentsWrite,
+	PermAuditLogsRead,
+	PermReportsRead,
+}
+
+// AllPermissions returns every permission the API checks
+func AllPermissions() []Permission {
+	return append([]Permission(nil), allPermissions...)
+}
+
+// IsValid reports whether the API knows the permission
+func (p Permission) IsValid() bool {
+	for _, known := range allPermissions {
+		if p == known {
+			return true
+		}
+	}
+	return false
+}
+
+// PermissionSet is the effective set of permissions of a role
+type PermissionSet map[Permission]struct{}
+
+func NewPermissionSet(permissions ...Permission) PermissionSet {
+	set := make(PermissionSet, len(permissions))
+	for _, permission := range permissions {
+		set[permission] = struct{}{}
+	}
+	return set
+}
+
+func (s PermissionSet) Has(permission Permission) bool {
+	_, ok := s[permission]
+	return ok
+}
+
+// List returns the permissions in a stable order
+func (s PermissionSet) List() []Permission {
+	permissions := make([]Permission, 0, len(s))
+	for permission := range s {
+		permissions = append(permissions, permission)
+	}
+	sort.Slice(permissions, func(i, j int) bool { return permissions[i] < permissions[j] })
+	return permissions
+}
+
+// RoleDefinition is a role users can be assigned to. The admin role always holds every
+// permission and cannot be changed; the other built-in roles can be edited but not deleted.
+type RoleDefinition struct {
+	Name        Role         `json:"name" gorm:"type:varchar(20);primaryKey"`
+	Description string       `json:"description" gorm:"type:varchar(255)"`
+	BuiltIn     bool         `json:"built_in" gorm:"not null;default:false"`
+	Permissions []Permission `json:"permissions" gorm:"-"` // loaded from role_permissions
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+func (RoleDefinition) TableName() string {
+	return "roles"
+}
+
+// RolePermission grants a permission to a role
+type RolePermission struct {
+	Role       Role       `json:"role" gorm:"type:varchar(20);primaryKey"`
+	Permission Permission `json:"permission" gorm:"type:varchar(50);primaryKey"`
+}
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,19}$`)
+
+// IsValidRoleName reports whether name can be used for a new role: 2 to 20 lowercase
+// letters, digits, dashes or underscores, starting with a letter
+func IsValidRoleName(name string) bool {
+	return roleNamePattern.MatchString(name)
+}
+
+// DefaultRoles returns the built-in roles with the permissions that reproduce the
+// original fixed access rules: technicians manage customers, schedules, invoices and
+// payments, customers only use the portal
+func DefaultRoles() []RoleDefinition {
+	return []RoleDefinition{
+		{
+			Name:        RoleAdmin,
+			Description: "Full access to every resource",
+			BuiltIn:     true,
+			Permissions: AllPermissions(),
+		},
+		{
+			Name:        RoleTechnician,
+			Description: "Manages customers, their schedules, invoices and payments",
+			BuiltIn:     true,
+			Permissions: []Permission{
+				PermCustomersRead, PermCustomersWrite,
+				PermSchedulesRead, PermSchedulesWrite,
+				PermInvoicesRead, PermInvoicesWrite,
+				PermPaymentsRead, PermPaymentsWrite,
+			},
+		},
+		{
+			Name:        RoleCustomer,
+			Description: "Self-service portal only",
+			BuiltIn:     true,
+			Permissions: []Permission{},
+		},
+	}
+}
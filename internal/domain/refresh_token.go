@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is the server-side record of an issued refresh token. The ID is the
+// token's jti and only a hash of the token itself is stored. Every token obtained by
+// rotating another one shares its FamilyID, so a whole login can be revoked at once.
+type RefreshToken struct {
+	ID           uuid.UUID  `json:"id" gorm:"type:uuid;primary_key"`
+	UserID       uint       `json:"user_id" gorm:"not null;index"`
+	FamilyID     uuid.UUID  `json:"family_id" gorm:"type:uuid;not null;index"`
+	TokenHash    string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"`
+	UserAgent    string     `json:"user_agent" gorm:"type:varchar(255)"`
+	IPAddress    string     `json:"ip_address" gorm:"type:varchar(45)"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	ReplacedByID *uuid.UUID `json:"replaced_by_id,omitempty" gorm:"type:uuid"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+func (t *RefreshToken) IsRevoked() bool {
+	return t.RevokedAt != nil
+}
+
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"time"
+
+	"dashboard-ac-backend/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+// ReportInterval is the length of the periods a time series is grouped into
+type ReportInterval string
+
+const (
+	ReportIntervalDay   ReportInterval = "day"
+	ReportIntervalWeek  ReportInterval = "week"
+	ReportIntervalMonth ReportInterval = "month"
+)
+
+// PeriodStart returns the first day of the period containing t. Weeks start on Monday.
+func (i ReportInterval) PeriodStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	switch i {
+	case ReportIntervalWeek:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case ReportIntervalMonth:
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	return day
+}
+
+// Next returns the start of the period after the one starting at start
+func (i ReportInterval) Next(start time.Time) time.Time {
+	switch i {
+	case ReportIntervalWeek:
+		return start.AddDate(0, 0, 7)
+	case ReportIntervalMonth:
+		return start.AddDate(0, 1, 0)
+	}
+	return start.AddDate(0, 0, 1)
+}
+
+// RevenueSummary totals what was billed and collected in a period
+type RevenueSummary struct {
+	Invoiced    money.Money `json:"invoiced"`    // total of the invoices dated in the period
+	Paid        money.Money `json:"paid"`        // payments received in the period
+	Outstanding money.Money `json:"outstanding"` // unpaid balance of the invoices dated in the period, overdue included
+	Overdue     money.Money `json:"overdue"`     // part of the outstanding balance that is past its due date
+}
+
+// ServiceRevenue is what one service was billed for in a period
+type ServiceRevenue struct {
+	ServiceID   uuid.UUID   `json:"service_id"`
+	ServiceName string      `json:"service_name"`
+	Quantity    int64       `json:"quantity"`
+	Revenue     money.Money `json:"revenue"`
+}
+
+// TechnicianUtilization compares the time a technician is booked for with the working
+// time they have in a period. Canceled jobs are not counted.
+type TechnicianUtilization struct {
+	TechnicianID     uuid.UUID `json:"technician_id"`
+	TechnicianName   string    `json:"technician_name"`
+	Jobs             int64     `json:"jobs"`
+	BookedMinutes    int64     `json:"booked_minutes"`
+	AvailableMinutes int64     `json:"available_minutes"`
+	Utilization      float64   `json:"utilization"` // booked share of the available minutes, in percent
+}
+
+// RevenuePoint is the revenue of one period of a time series
+type RevenuePoint struct {
+	Period   string      `json:"period"` // first day of the period, YYYY-MM-DD
+	Invoiced money.Money `json:"invoiced"`
+	Paid     money.Money `json:"paid"`
+}
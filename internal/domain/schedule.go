@@ -16,6 +16,28 @@ const (
 	ScheduleStatusCanceled    ScheduleStatus = "Canceled"
 )
 
+// scheduleTransitions lists the statuses each status may move to; statuses
+// missing from the map are terminal
+var scheduleTransitions = map[ScheduleStatus][]ScheduleStatus{
+	ScheduleStatusPending:    {ScheduleStatusOnProgress, ScheduleStatusCanceled},
+	ScheduleStatusOnProgress: {ScheduleStatusCompleted, ScheduleStatusCanceled},
+}
+
+// CanTransitionTo reports whether a schedule in this status may move to next
+func (s ScheduleStatus) CanTransitionTo(next ScheduleStatus) bool {
+	for _, allowed := range scheduleTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// IsTerminal reports whether no further status changes are allowed
+func (s ScheduleStatus) IsTerminal() bool {
+	return len(scheduleTransitions[s]) == 0
+}
+
 type Schedule struct {
 	ID           uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	CustomerID   uuid.UUID      `json:"customer_id" gorm:"type:uuid;not null" validate:"required"`
@@ -28,7 +50,10 @@ type Schedule struct {
 	UpdatedAt    time.Time      `json:"updated_at"`
 	DeletedAt    gorm.DeletedAt `json:"-" gorm:"index"`
 
-	// Relations will be handled at repository level to avoid circular imports
+	// Related records, only set when a request asks to include them
+	Customer   *Customer   `json:"customer,omitempty" gorm:"-"`
+	Technician *Technician `json:"technician,omitempty" gorm:"-"`
+	Service    *Service    `json:"service,omitempty" gorm:"-"`
 }
 
 func (s *Schedule) BeforeCreate(tx *gorm.DB) error {
@@ -41,9 +66,41 @@ func (s *Schedule) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// StartAt combines the schedule's Date and Time columns into a single instant
+func (s *Schedule) StartAt() time.Time {
+	return CombineDateTime(s.Date, s.Time)
+}
+
+// Window returns the span the schedule occupies for a service lasting duration minutes
+func (s *Schedule) Window(duration int) ScheduleWindow {
+	start := s.StartAt()
+	return ScheduleWindow{
+		ScheduleID: s.ID,
+		Start:      start,
+		End:        start.Add(time.Duration(duration) * time.Minute),
+	}
+}
+
 func (s *Schedule) IsValidStatus() bool {
 	return s.Status == ScheduleStatusPending || 
 		   s.Status == ScheduleStatusOnProgress || 
 		   s.Status == ScheduleStatusCompleted || 
 		   s.Status == ScheduleStatusCanceled
-}
\ No newline at end of file
+}
+
+// ScheduleWindow is the time span a schedule blocks on its technician's agenda
+type ScheduleWindow struct {
+	ScheduleID uuid.UUID `json:"schedule_id"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+}
+
+// Overlaps reports whether the window intersects the half-open range [start, end)
+func (w ScheduleWindow) Overlaps(start, end time.Time) bool {
+	return w.Start.Before(end) && start.Before(w.End)
+}
+
+// CombineDateTime takes the calendar day from date and the clock time from clock
+func CombineDateTime(date, clock time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day(), clock.Hour(), clock.Minute(), clock.Second(), 0, date.Location())
+}
@@ -0,0 +1,33 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ScheduleStatusHistory records a single status transition of a schedule
+type ScheduleStatusHistory struct {
+	ID         uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ScheduleID uuid.UUID      `json:"schedule_id" gorm:"type:uuid;not null;index"`
+	FromStatus ScheduleStatus `json:"from_status" gorm:"type:varchar(20);not null"`
+	ToStatus   ScheduleStatus `json:"to_status" gorm:"type:varchar(20);not null"`
+	Reason     string         `json:"reason,omitempty" gorm:"type:text"`
+	ChangedBy  uint           `json:"changed_by" gorm:"not null;index"`
+	ChangedAt  time.Time      `json:"changed_at" gorm:"not null"`
+}
+
+func (ScheduleStatusHistory) TableName() string {
+	return "schedule_status_history"
+}
+
+func (h *ScheduleStatusHistory) BeforeCreate(tx *gorm.DB) error {
+	if h.ID == uuid.Nil {
+		h.ID = uuid.New()
+	}
+	if h.ChangedAt.IsZero() {
+		h.ChangedAt = time.Now()
+	}
+	return nil
+}
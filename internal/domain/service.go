@@ -3,6 +3,8 @@ package domain
 import (
 	"time"
 
+	"dashboard-ac-backend/pkg/money"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
@@ -10,7 +12,7 @@ import (
 type Service struct {
 	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	Name      string         `json:"name" gorm:"not null" validate:"required,min=2,max=100"`
-	Price     float64        `json:"price" gorm:"type:decimal(10,2);not null" validate:"required,min=0"`
+	Price     money.Money    `json:"price" gorm:"type:decimal(10,2);not null" validate:"required,min=0"`
 	Duration  int            `json:"duration" gorm:"not null" validate:"required,min=1"` // in minutes
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
@@ -1,17 +1,32 @@
 package domain
 
 import (
+	"errors"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// ClockLayout is the HH:MM format used for working hours and breaks
+const ClockLayout = "15:04"
+
+const (
+	DefaultWorkStart = "08:00"
+	DefaultWorkEnd   = "17:00"
+)
+
+var ErrInvalidWorkingHours = errors.New("invalid working hours")
+
 type Technician struct {
 	ID             uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	Name           string         `json:"name" gorm:"not null" validate:"required,min=2,max=100"`
 	Phone          string         `json:"phone" gorm:"uniqueIndex;not null" validate:"required,min=10,max=15"`
 	Specialization string         `json:"specialization" gorm:"not null" validate:"required,min=2,max=100"`
+	WorkStart      string         `json:"work_start" gorm:"type:varchar(5);not null;default:'08:00'" validate:"omitempty,datetime=15:04"`
+	WorkEnd        string         `json:"work_end" gorm:"type:varchar(5);not null;default:'17:00'" validate:"omitempty,datetime=15:04"`
+	BreakStart     string         `json:"break_start" gorm:"type:varchar(5)" validate:"omitempty,datetime=15:04"` // empty means no break
+	BreakEnd       string         `json:"break_end" gorm:"type:varchar(5)" validate:"omitempty,datetime=15:04"`
 	CreatedAt      time.Time      `json:"created_at"`
 	UpdatedAt      time.Time      `json:"updated_at"`
 	DeletedAt      gorm.DeletedAt `json:"-" gorm:"index"`
@@ -23,5 +38,85 @@ func (t *Technician) BeforeCreate(tx *gorm.DB) error {
 	if t.ID == uuid.Nil {
 		t.ID = uuid.New()
 	}
+	if t.WorkStart == "" {
+		t.WorkStart = DefaultWorkStart
+	}
+	if t.WorkEnd == "" {
+		t.WorkEnd = DefaultWorkEnd
+	}
+	return nil
+}
+
+// ValidateWorkingHours checks that the working day is well formed and the break,
+// if any, falls inside it
+func (t *Technician) ValidateWorkingHours() error {
+	day := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	start, end, err := t.WorkingHours(day)
+	if err != nil || !start.Before(end) {
+		return ErrInvalidWorkingHours
+	}
+
+	breakStart, breakEnd, hasBreak, err := t.BreakWindow(day)
+	if err != nil {
+		return ErrInvalidWorkingHours
+	}
+	if hasBreak && (!breakStart.Before(breakEnd) || breakStart.Before(start) || breakEnd.After(end)) {
+		return ErrInvalidWorkingHours
+	}
+
 	return nil
-}
\ No newline at end of file
+}
+
+// WorkingHours returns the technician's working day on the given date
+func (t *Technician) WorkingHours(day time.Time) (time.Time, time.Time, error) {
+	workStart, workEnd := t.WorkStart, t.WorkEnd
+	if workStart == "" {
+		workStart = DefaultWorkStart
+	}
+	if workEnd == "" {
+		workEnd = DefaultWorkEnd
+	}
+
+	start, err := clockOn(day, workStart)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	end, err := clockOn(day, workEnd)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+
+	return start, end, nil
+}
+
+// BreakWindow returns the technician's break on the given date; ok is false when
+// no break is configured
+func (t *Technician) BreakWindow(day time.Time) (start, end time.Time, ok bool, err error) {
+	if t.BreakStart == "" && t.BreakEnd == "" {
+		return time.Time{}, time.Time{}, false, nil
+	}
+	if t.BreakStart == "" || t.BreakEnd == "" {
+		return time.Time{}, time.Time{}, false, ErrInvalidWorkingHours
+	}
+
+	start, err = clockOn(day, t.BreakStart)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, err
+	}
+	end, err = clockOn(day, t.BreakEnd)
+	if err != nil {
+		return time.Time{}, time.Time{}, false, err
+	}
+
+	return start, end, true, nil
+}
+
+// clockOn places an HH:MM clock value on the calendar day of day
+func clockOn(day time.Time, value string) (time.Time, error) {
+	clock, err := time.Parse(ClockLayout, value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return CombineDateTime(day, clock), nil
+}
@@ -3,6 +3,7 @@ package domain
 import (
 	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -15,12 +16,26 @@ const (
 )
 
 type User struct {
-	ID        uint           `json:"id" gorm:"primarykey"`
-	Name      string         `json:"name" gorm:"not null"`
-	Email     string         `json:"email" gorm:"uniqueIndex;not null"`
-	Password  string         `json:"-" gorm:"not null"`
-	Role      Role           `json:"role" gorm:"type:varchar(20);default:'customer'"`
-	IsActive  bool           `json:"is_active" gorm:"default:true"`
+	ID           uint       `json:"id" gorm:"primarykey"`
+	Name         string     `json:"name" gorm:"not null"`
+	Email        string     `json:"email" gorm:"uniqueIndex;not null"`
+	Password     string     `json:"-" gorm:"not null"`
+	Role         Role       `json:"role" gorm:"type:varchar(20);default:'customer'"`
+	IsActive     bool       `json:"is_active" gorm:"default:true"`
+	CustomerID   *uuid.UUID `json:"customer_id,omitempty" gorm:"type:uuid;index"`   // the customer record of a customer account
+	TechnicianID *uuid.UUID `json:"technician_id,omitempty" gorm:"type:uuid;index"` // the technician record of a technician account
+	TokenVersion int        `json:"-" gorm:"not null;default:0"`                    // access tokens carrying an older version are rejected
+
+	// The linked records, declared so that migrations add the foreign keys; an account
+	// outlives its record and is unlinked when the record is removed
+	Customer   *Customer   `json:"-" gorm:"foreignKey:CustomerID;constraint:OnDelete:SET NULL"`
+	Technician *Technician `json:"-" gorm:"foreignKey:TechnicianID;constraint:OnDelete:SET NULL"`
+
+	// Failed password attempts since the last successful login; too many lock the account
+	FailedLoginCount  int        `json:"failed_login_count" gorm:"not null;default:0"`
+	LastFailedLoginAt *time.Time `json:"last_failed_login_at,omitempty"`
+	LockedUntil       *time.Time `json:"locked_until,omitempty"`
+
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
@@ -44,4 +59,30 @@ func (u *User) IsTechnician() bool {
 
 func (u *User) IsCustomer() bool {
 	return u.Role == RoleCustomer
-}
\ No newline at end of file
+}
+
+// IsLocked reports whether the account is temporarily locked at the given time
+func (u *User) IsLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// RevokeAccessTokens invalidates every access token issued so far
+func (u *User) RevokeAccessTokens() {
+	u.TokenVersion++
+}
+
+// HasSameClaims reports whether tokens issued for other still describe u correctly
+func (u *User) HasSameClaims(other *User) bool {
+	return u.Email == other.Email &&
+		u.Role == other.Role &&
+		u.IsActive == other.IsActive &&
+		sameUUID(u.CustomerID, other.CustomerID) &&
+		sameUUID(u.TechnicianID, other.TechnicianID)
+}
+
+func sameUUID(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,68 @@
+package job
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"dashboard-ac-backend/internal/repository"
+	"dashboard-ac-backend/internal/service"
+	"dashboard-ac-backend/pkg/logger"
+)
+
+// OverdueSweeper periodically marks invoices past their due date that still have a
+// balance, whether Unpaid or PartiallyPaid, as Overdue
+type OverdueSweeper struct {
+	invoiceService service.InvoiceService
+	interval       time.Duration
+}
+
+func NewOverdueSweeper(invoiceService service.InvoiceService, interval time.Duration) *OverdueSweeper {
+	return &OverdueSweeper{
+		invoiceService: invoiceService,
+		interval:       interval,
+	}
+}
+
+// Run sweeps once immediately and then on every interval until ctx is canceled
+func (s *OverdueSweeper) Run(ctx context.Context) {
+	logger.FromContext(ctx).Info().Dur("interval", s.interval).Msg("Overdue invoice sweeper started")
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		// Errors are logged by RunOnce; the next tick simply tries again
+		_, _ = s.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			logger.FromContext(ctx).Info().Msg("Overdue invoice sweeper stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce performs a single sweep and logs its outcome. A sweep skipped because
+// another instance holds the lock is not treated as an error.
+func (s *OverdueSweeper) RunOnce(ctx context.Context) (int64, error) {
+	started := time.Now()
+
+	marked, err := s.invoiceService.MarkOverdue(ctx, started)
+	if err != nil {
+		if errors.Is(err, repository.ErrSweepInProgress) {
+			logger.FromContext(ctx).Info().Msg("Overdue invoice sweep skipped, another instance is running it")
+			return 0, nil
+		}
+		logger.FromContext(ctx).Error().Err(err).Msg("Overdue invoice sweep failed")
+		return 0, err
+	}
+
+	logger.FromContext(ctx).Info().
+		Int64("marked_overdue", marked).
+		Dur("duration", time.Since(started)).
+		Msg("Overdue invoice sweep completed")
+
+	return marked, nil
+}
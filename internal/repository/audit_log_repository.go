@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"dashboard-ac-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// AuditLogFilter narrows down audit log queries; empty fields match everything
+type AuditLogFilter struct {
+	EntityType string
+	EntityID   string
+	ActorID    *uint
+	From       *time.Time // inclusive
+	To         *time.Time // exclusive
+}
+
+type AuditLogRepository interface {
+	Create(ctx context.Context, entry *domain.AuditLog) error
+	List(ctx context.Context, filter AuditLogFilter, offset, limit int) ([]*domain.AuditLog, int64, error)
+}
+
+type auditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
+	return &auditLogRepository{db: db}
+}
+
+func (r *auditLogRepository) Create(ctx context.Context, entry *domain.AuditLog) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *auditLogRepository) List(ctx context.Context, filter AuditLogFilter, offset, limit int) ([]*domain.AuditLog, int64, error) {
+	var entries []*domain.AuditLog
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&domain.AuditLog{})
+
+	// Apply filters
+	if filter.EntityType != "" {
+		query = query.Where("entity_type = ?", filter.EntityType)
+	}
+	if filter.EntityID != "" {
+		query = query.Where("entity_id = ?", filter.EntityID)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	// Count total records with filters
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&entries).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return entries, total, nil
+}
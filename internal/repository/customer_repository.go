@@ -1,18 +1,35 @@
 package repository
 
 import (
+	"context"
+
 	"dashboard-ac-backend/internal/domain"
 
 	"gorm.io/gorm"
 )
 
 type CustomerRepository interface {
-	Create(customer *domain.Customer) error
-	GetByID(id string) (*domain.Customer, error)
-	Update(customer *domain.Customer) error
-	Delete(id string) error
-	List(offset, limit int) ([]*domain.Customer, int64, error)
-	Search(name, phone, email string, offset, limit int) ([]*domain.Customer, int64, error)
+	Create(ctx context.Context, customer *domain.Customer) error
+	GetByID(ctx context.Context, id string) (*domain.Customer, error)
+	Update(ctx context.Context, customer *domain.Customer) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, opts ListOptions) ([]*domain.Customer, ListPage, error)
+	Search(ctx context.Context, name, phone, email string, opts ListOptions) ([]*domain.Customer, ListPage, error)
+}
+
+// customerList lists the fields customers can be sorted, filtered and searched by
+var customerList = listSpec{
+	fields: map[string]listField{
+		"name":       {column: "name", typ: fieldText},
+		"phone":      {column: "phone", typ: fieldText},
+		"email":      {column: "email", typ: fieldText},
+		"address":    {column: "address", typ: fieldText},
+		"created_at": {column: "created_at", typ: fieldTimestamp},
+		"updated_at": {column: "updated_at", typ: fieldTimestamp},
+	},
+	key:         listField{column: "id", typ: fieldUUID},
+	search:      []string{"name", "phone", "email"},
+	defaultSort: "-created_at",
 }
 
 type customerRepository struct {
@@ -23,50 +40,42 @@ func NewCustomerRepository(db *gorm.DB) CustomerRepository {
 	return &customerRepository{db: db}
 }
 
-func (r *customerRepository) Create(customer *domain.Customer) error {
-	return r.db.Create(customer).Error
+func (r *customerRepository) Create(ctx context.Context, customer *domain.Customer) error {
+	return r.db.WithContext(ctx).Create(customer).Error
 }
 
-func (r *customerRepository) GetByID(id string) (*domain.Customer, error) {
+func (r *customerRepository) GetByID(ctx context.Context, id string) (*domain.Customer, error) {
 	var customer domain.Customer
-	err := r.db.Where("id = ?", id).First(&customer).Error
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&customer).Error
 	if err != nil {
 		return nil, err
 	}
 	return &customer, nil
 }
 
-func (r *customerRepository) Update(customer *domain.Customer) error {
-	return r.db.Save(customer).Error
+func (r *customerRepository) Update(ctx context.Context, customer *domain.Customer) error {
+	return r.db.WithContext(ctx).Save(customer).Error
 }
 
-func (r *customerRepository) Delete(id string) error {
-	return r.db.Delete(&domain.Customer{}, "id = ?", id).Error
+func (r *customerRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&domain.Customer{}, "id = ?", id).Error
 }
 
-func (r *customerRepository) List(offset, limit int) ([]*domain.Customer, int64, error) {
+func (r *customerRepository) List(ctx context.Context, opts ListOptions) ([]*domain.Customer, ListPage, error) {
 	var customers []*domain.Customer
-	var total int64
 
-	// Count total records
-	if err := r.db.Model(&domain.Customer{}).Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// Get paginated records
-	err := r.db.Offset(offset).Limit(limit).Order("created_at DESC").Find(&customers).Error
+	page, err := findPage(r.db.WithContext(ctx).Model(&domain.Customer{}), customerList, opts, &customers)
 	if err != nil {
-		return nil, 0, err
+		return nil, ListPage{}, err
 	}
 
-	return customers, total, nil
+	return customers, page, nil
 }
 
-func (r *customerRepository) Search(name, phone, email string, offset, limit int) ([]*domain.Customer, int64, error) {
+func (r *customerRepository) Search(ctx context.Context, name, phone, email string, opts ListOptions) ([]*domain.Customer, ListPage, error) {
 	var customers []*domain.Customer
-	var total int64
 
-	query := r.db.Model(&domain.Customer{})
+	query := r.db.WithContext(ctx).Model(&domain.Customer{})
 
 	// Apply filters
 	if name != "" {
@@ -79,16 +88,10 @@ func (r *customerRepository) Search(name, phone, email string, offset, limit int
 		query = query.Where("email ILIKE ?", "%"+email+"%")
 	}
 
-	// Count total records with filters
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// Get paginated records with filters
-	err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&customers).Error
+	page, err := findPage(query, customerList, opts, &customers)
 	if err != nil {
-		return nil, 0, err
+		return nil, ListPage{}, err
 	}
 
-	return customers, total, nil
+	return customers, page, nil
 }
\ No newline at end of file
@@ -0,0 +1,170 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"dashboard-ac-backend/internal/domain"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// scheduleIncludes lists the related records that can be loaded with schedules
+var scheduleIncludes = []string{"customer", "technician", "service"}
+
+// invoiceIncludes lists the related records that can be loaded with invoices. The
+// technician and service are attached to the invoice's schedule, which is loaded for
+// them when it is not asked for itself.
+var invoiceIncludes = []string{"customer", "schedule", "technician", "service", "details"}
+
+// parseInclude reads an include parameter, a comma separated list of related records
+// such as "customer,technician", and rejects names that are not allowed
+func parseInclude(include string, allowed []string) (map[string]bool, error) {
+	includes := make(map[string]bool)
+	include = strings.TrimSpace(include)
+	if include == "" {
+		return includes, nil
+	}
+
+	for _, name := range strings.Split(include, ",") {
+		name = strings.TrimSpace(name)
+		if !slices.Contains(allowed, name) {
+			return nil, &ListQueryError{Param: "include", Reason: fmt.Sprintf("cannot include %q, use %s", name, strings.Join(allowed, ", "))}
+		}
+		includes[name] = true
+	}
+	return includes, nil
+}
+
+// loadScheduleRelations attaches the related records named in includes to schedules,
+// with one query per kind of record
+func loadScheduleRelations(db *gorm.DB, schedules []*domain.Schedule, includes map[string]bool) error {
+	if len(schedules) == 0 {
+		return nil
+	}
+
+	if includes["customer"] {
+		customers, err := findByIDs(db, distinctIDs(schedules, func(s *domain.Schedule) uuid.UUID { return s.CustomerID }),
+			func(c *domain.Customer) uuid.UUID { return c.ID })
+		if err != nil {
+			return err
+		}
+		for _, schedule := range schedules {
+			schedule.Customer = customers[schedule.CustomerID]
+		}
+	}
+
+	if includes["technician"] {
+		technicians, err := findByIDs(db, distinctIDs(schedules, func(s *domain.Schedule) uuid.UUID { return s.TechnicianID }),
+			func(t *domain.Technician) uuid.UUID { return t.ID })
+		if err != nil {
+			return err
+		}
+		for _, schedule := range schedules {
+			schedule.Technician = technicians[schedule.TechnicianID]
+		}
+	}
+
+	if includes["service"] {
+		services, err := findByIDs(db, distinctIDs(schedules, func(s *domain.Schedule) uuid.UUID { return s.ServiceID }),
+			func(s *domain.Service) uuid.UUID { return s.ID })
+		if err != nil {
+			return err
+		}
+		for _, schedule := range schedules {
+			schedule.Service = services[schedule.ServiceID]
+		}
+	}
+
+	return nil
+}
+
+// loadInvoiceRelations attaches the related records named in includes to invoices,
+// with one query per kind of record
+func loadInvoiceRelations(db *gorm.DB, invoices []*domain.Invoice, includes map[string]bool) error {
+	if len(invoices) == 0 {
+		return nil
+	}
+
+	if includes["customer"] {
+		customers, err := findByIDs(db, distinctIDs(invoices, func(i *domain.Invoice) uuid.UUID { return i.CustomerID }),
+			func(c *domain.Customer) uuid.UUID { return c.ID })
+		if err != nil {
+			return err
+		}
+		for _, invoice := range invoices {
+			invoice.Customer = customers[invoice.CustomerID]
+		}
+	}
+
+	if includes["schedule"] || includes["technician"] || includes["service"] {
+		schedules, err := findByIDs(db, distinctIDs(invoices, func(i *domain.Invoice) uuid.UUID { return i.ScheduleID }),
+			func(s *domain.Schedule) uuid.UUID { return s.ID })
+		if err != nil {
+			return err
+		}
+
+		loaded := make([]*domain.Schedule, 0, len(schedules))
+		for _, schedule := range schedules {
+			loaded = append(loaded, schedule)
+		}
+		nested := map[string]bool{"technician": includes["technician"], "service": includes["service"]}
+		if err := loadScheduleRelations(db, loaded, nested); err != nil {
+			return err
+		}
+
+		for _, invoice := range invoices {
+			invoice.Schedule = schedules[invoice.ScheduleID]
+		}
+	}
+
+	if includes["details"] {
+		var details []*domain.InvoiceDetail
+		ids := distinctIDs(invoices, func(i *domain.Invoice) uuid.UUID { return i.ID })
+		if err := db.Where("invoice_id IN ?", ids).Order("created_at ASC").Find(&details).Error; err != nil {
+			return err
+		}
+
+		byInvoice := make(map[uuid.UUID][]*domain.InvoiceDetail, len(invoices))
+		for _, detail := range details {
+			byInvoice[detail.InvoiceID] = append(byInvoice[detail.InvoiceID], detail)
+		}
+		for _, invoice := range invoices {
+			invoice.Details = byInvoice[invoice.ID]
+		}
+	}
+
+	return nil
+}
+
+// findByIDs loads the records with the given IDs in one query, keyed by ID. Deleted
+// records are included so that past schedules and invoices still show what they
+// referred to.
+func findByIDs[T any](db *gorm.DB, ids []uuid.UUID, idOf func(*T) uuid.UUID) (map[uuid.UUID]*T, error) {
+	var records []*T
+	if err := db.Unscoped().Where("id IN ?", ids).Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uuid.UUID]*T, len(records))
+	for _, record := range records {
+		byID[idOf(record)] = record
+	}
+	return byID, nil
+}
+
+// distinctIDs collects the IDs idOf returns for records, without repeats
+func distinctIDs[T any](records []*T, idOf func(*T) uuid.UUID) []uuid.UUID {
+	seen := make(map[uuid.UUID]bool, len(records))
+	ids := make([]uuid.UUID, 0, len(records))
+	for _, record := range records {
+		id := idOf(record)
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
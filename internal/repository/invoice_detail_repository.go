@@ -1,18 +1,31 @@
 package repository
 
 import (
+	"context"
+	"errors"
+
 	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/pkg/money"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrTotalBelowAmountPaid is returned when a line item change would bring the invoice
+// total below what has already been paid
+var ErrTotalBelowAmountPaid = errors.New("invoice total would fall below the amount paid")
+
 type InvoiceDetailRepository interface {
-	Create(invoiceDetail *domain.InvoiceDetail) error
-	GetByID(id string) (*domain.InvoiceDetail, error)
-	Update(invoiceDetail *domain.InvoiceDetail) error
-	Delete(id string) error
-	GetByInvoiceID(invoiceID string) ([]*domain.InvoiceDetail, error)
-	DeleteByInvoiceID(invoiceID string) error
+	Create(ctx context.Context, invoiceDetail *domain.InvoiceDetail) error
+	GetByID(ctx context.Context, id string) (*domain.InvoiceDetail, error)
+	Update(ctx context.Context, invoiceDetail *domain.InvoiceDetail) error
+	Delete(ctx context.Context, id string) error
+	GetByInvoiceID(ctx context.Context, invoiceID string) ([]*domain.InvoiceDetail, error)
+	DeleteByInvoiceID(ctx context.Context, invoiceID string) error
+	CreateAndRecalculate(ctx context.Context, invoiceDetail *domain.InvoiceDetail) (*InvoiceChange, error)
+	UpdateAndRecalculate(ctx context.Context, invoiceDetail *domain.InvoiceDetail) (*InvoiceChange, error)
+	DeleteAndRecalculate(ctx context.Context, invoiceDetail *domain.InvoiceDetail) (*InvoiceChange, error)
+	DeleteByInvoiceIDAndRecalculate(ctx context.Context, invoiceID string) (*InvoiceChange, error)
 }
 
 type invoiceDetailRepository struct {
@@ -23,36 +36,116 @@ func NewInvoiceDetailRepository(db *gorm.DB) InvoiceDetailRepository {
 	return &invoiceDetailRepository{db: db}
 }
 
-func (r *invoiceDetailRepository) Create(invoiceDetail *domain.InvoiceDetail) error {
-	return r.db.Create(invoiceDetail).Error
+func (r *invoiceDetailRepository) Create(ctx context.Context, invoiceDetail *domain.InvoiceDetail) error {
+	return r.db.WithContext(ctx).Create(invoiceDetail).Error
 }
 
-func (r *invoiceDetailRepository) GetByID(id string) (*domain.InvoiceDetail, error) {
+func (r *invoiceDetailRepository) GetByID(ctx context.Context, id string) (*domain.InvoiceDetail, error) {
 	var invoiceDetail domain.InvoiceDetail
-	err := r.db.Where("id = ?", id).First(&invoiceDetail).Error
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&invoiceDetail).Error
 	if err != nil {
 		return nil, err
 	}
 	return &invoiceDetail, nil
 }
 
-func (r *invoiceDetailRepository) Update(invoiceDetail *domain.InvoiceDetail) error {
-	return r.db.Save(invoiceDetail).Error
+func (r *invoiceDetailRepository) Update(ctx context.Context, invoiceDetail *domain.InvoiceDetail) error {
+	return r.db.WithContext(ctx).Save(invoiceDetail).Error
 }
 
-func (r *invoiceDetailRepository) Delete(id string) error {
-	return r.db.Delete(&domain.InvoiceDetail{}, "id = ?", id).Error
+func (r *invoiceDetailRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&domain.InvoiceDetail{}, "id = ?", id).Error
 }
 
-func (r *invoiceDetailRepository) GetByInvoiceID(invoiceID string) ([]*domain.InvoiceDetail, error) {
+func (r *invoiceDetailRepository) GetByInvoiceID(ctx context.Context, invoiceID string) ([]*domain.InvoiceDetail, error) {
 	var invoiceDetails []*domain.InvoiceDetail
-	err := r.db.Where("invoice_id = ?", invoiceID).Order("created_at ASC").Find(&invoiceDetails).Error
+	err := r.db.WithContext(ctx).Where("invoice_id = ?", invoiceID).Order("created_at ASC").Find(&invoiceDetails).Error
 	if err != nil {
 		return nil, err
 	}
 	return invoiceDetails, nil
 }
 
-func (r *invoiceDetailRepository) DeleteByInvoiceID(invoiceID string) error {
-	return r.db.Delete(&domain.InvoiceDetail{}, "invoice_id = ?", invoiceID).Error
-}
\ No newline at end of file
+func (r *invoiceDetailRepository) DeleteByInvoiceID(ctx context.Context, invoiceID string) error {
+	return r.db.WithContext(ctx).Delete(&domain.InvoiceDetail{}, "invoice_id = ?", invoiceID).Error
+}
+
+// CreateAndRecalculate adds a line item and refreshes the invoice total atomically
+func (r *invoiceDetailRepository) CreateAndRecalculate(ctx context.Context, invoiceDetail *domain.InvoiceDetail) (*InvoiceChange, error) {
+	return r.withInvoiceLock(ctx, invoiceDetail.InvoiceID.String(), func(tx *gorm.DB) error {
+		return tx.Create(invoiceDetail).Error
+	})
+}
+
+// UpdateAndRecalculate saves a line item and refreshes the invoice total atomically
+func (r *invoiceDetailRepository) UpdateAndRecalculate(ctx context.Context, invoiceDetail *domain.InvoiceDetail) (*InvoiceChange, error) {
+	return r.withInvoiceLock(ctx, invoiceDetail.InvoiceID.String(), func(tx *gorm.DB) error {
+		return tx.Save(invoiceDetail).Error
+	})
+}
+
+// DeleteAndRecalculate removes a line item and refreshes the invoice total atomically
+func (r *invoiceDetailRepository) DeleteAndRecalculate(ctx context.Context, invoiceDetail *domain.InvoiceDetail) (*InvoiceChange, error) {
+	return r.withInvoiceLock(ctx, invoiceDetail.InvoiceID.String(), func(tx *gorm.DB) error {
+		return tx.Delete(&domain.InvoiceDetail{}, "id = ?", invoiceDetail.ID).Error
+	})
+}
+
+// DeleteByInvoiceIDAndRecalculate removes all line items of an invoice and resets its total
+func (r *invoiceDetailRepository) DeleteByInvoiceIDAndRecalculate(ctx context.Context, invoiceID string) (*InvoiceChange, error) {
+	return r.withInvoiceLock(ctx, invoiceID, func(tx *gorm.DB) error {
+		return tx.Delete(&domain.InvoiceDetail{}, "invoice_id = ?", invoiceID).Error
+	})
+}
+
+// withInvoiceLock runs fn in a transaction that holds a row lock on the parent
+// invoice, then recomputes the invoice total from its remaining line items so
+// concurrent edits cannot leave a stale total or payment status behind. A change
+// that would leave the total below the amount paid is rolled back with
+// ErrTotalBelowAmountPaid.
+func (r *invoiceDetailRepository) withInvoiceLock(ctx context.Context, invoiceID string, fn func(tx *gorm.DB) error) (*InvoiceChange, error) {
+	var before, invoice domain.Invoice
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", invoiceID).
+			First(&invoice).Error
+		if err != nil {
+			return err
+		}
+		before = invoice
+
+		if err := fn(tx); err != nil {
+			return err
+		}
+
+		var totalAmount money.Money
+		err = tx.Model(&domain.InvoiceDetail{}).
+			Select("COALESCE(SUM(subtotal), 0)").
+			Where("invoice_id = ?", invoiceID).
+			Scan(&totalAmount).Error
+		if err != nil {
+			return err
+		}
+
+		if totalAmount < invoice.AmountPaid {
+			return ErrTotalBelowAmountPaid
+		}
+
+		// A changed total can settle or reopen the balance, so re-derive the status
+		invoice.TotalAmount = totalAmount
+		invoice.ApplyPayments(invoice.AmountPaid)
+
+		return tx.Model(&domain.Invoice{}).
+			Where("id = ?", invoiceID).
+			Updates(map[string]interface{}{
+				"total_amount": invoice.TotalAmount,
+				"status":       invoice.Status,
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &InvoiceChange{Before: &before, After: &invoice}, nil
+}
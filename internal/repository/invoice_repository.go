@@ -1,23 +1,65 @@
 package repository
 
 import (
+	"context"
+	"errors"
 	"time"
 
 	"dashboard-ac-backend/internal/domain"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// overdueSweepLockKey identifies the Postgres advisory lock held while marking
+// overdue invoices, so only one replica sweeps at a time
+const overdueSweepLockKey int64 = 0x0AC0_0001
+
+// ErrSweepInProgress is returned when another instance holds the overdue sweep lock
+var ErrSweepInProgress = errors.New("overdue sweep is already running elsewhere")
+
+// ErrScheduleInvoiced is returned when an invoice is created for a schedule that
+// already has one. The unique index on schedule_id enforces it, so concurrent
+// requests cannot both bill the same schedule.
+var ErrScheduleInvoiced = errors.New("schedule already has an invoice")
+
+// InvoiceChange is an invoice as it was read under its row lock and as it was written
+type InvoiceChange struct {
+	Before *domain.Invoice
+	After  *domain.Invoice
+}
+
 type InvoiceRepository interface {
-	Create(invoice *domain.Invoice) error
-	GetByID(id string) (*domain.Invoice, error)
-	Update(invoice *domain.Invoice) error
-	Delete(id string) error
-	List(offset, limit int) ([]*domain.Invoice, int64, error)
-	Search(customerID, scheduleID string, status domain.InvoiceStatus, dateFrom, dateTo *time.Time, offset, limit int) ([]*domain.Invoice, int64, error)
-	GetByCustomerID(customerID string, offset, limit int) ([]*domain.Invoice, int64, error)
-	GetByScheduleID(scheduleID string) (*domain.Invoice, error)
-	GetByStatus(status domain.InvoiceStatus, offset, limit int) ([]*domain.Invoice, int64, error)
+	Create(ctx context.Context, invoice *domain.Invoice) error
+	GetByID(ctx context.Context, id string) (*domain.Invoice, error)
+	Update(ctx context.Context, id string, apply func(invoice *domain.Invoice) error) (*domain.Invoice, error)
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, scope domain.AccessScope, opts ListOptions) ([]*domain.Invoice, ListPage, error)
+	Search(ctx context.Context, scope domain.AccessScope, customerID, scheduleID string, status domain.InvoiceStatus, dateFrom, dateTo *time.Time, opts ListOptions) ([]*domain.Invoice, ListPage, error)
+	GetByCustomerID(ctx context.Context, scope domain.AccessScope, customerID string, opts ListOptions) ([]*domain.Invoice, ListPage, error)
+	GetByScheduleID(ctx context.Context, scheduleID string) (*domain.Invoice, error)
+	GetByStatus(ctx context.Context, scope domain.AccessScope, status domain.InvoiceStatus, opts ListOptions) ([]*domain.Invoice, ListPage, error)
+	LoadRelations(ctx context.Context, invoices []*domain.Invoice, include string) error
+	CreateWithDetails(ctx context.Context, invoice *domain.Invoice, details []*domain.InvoiceDetail) error
+	MarkOverdue(ctx context.Context, dueBefore time.Time) ([]*domain.Invoice, error)
+}
+
+// invoiceList lists the fields invoices can be sorted and filtered by
+var invoiceList = listSpec{
+	fields: map[string]listField{
+		"customer_id":  {column: "invoices.customer_id", typ: fieldUUID},
+		"schedule_id":  {column: "invoices.schedule_id", typ: fieldUUID},
+		"invoice_date": {column: "invoices.invoice_date", typ: fieldDate},
+		"due_date":     {column: "invoices.due_date", typ: fieldDate},
+		"total_amount": {column: "invoices.total_amount", typ: fieldMoney},
+		"amount_paid":  {column: "invoices.amount_paid", typ: fieldMoney},
+		"status":       {column: "invoices.status", typ: fieldText},
+		"created_at":   {column: "invoices.created_at", typ: fieldTimestamp},
+		"updated_at":   {column: "invoices.updated_at", typ: fieldTimestamp},
+	},
+	key:         listField{column: "invoices.id", typ: fieldUUID},
+	defaultSort: "-invoice_date",
 }
 
 type invoiceRepository struct {
@@ -28,50 +70,144 @@ func NewInvoiceRepository(db *gorm.DB) InvoiceRepository {
 	return &invoiceRepository{db: db}
 }
 
-func (r *invoiceRepository) Create(invoice *domain.Invoice) error {
-	return r.db.Create(invoice).Error
+func (r *invoiceRepository) Create(ctx context.Context, invoice *domain.Invoice) error {
+	if err := r.db.WithContext(ctx).Create(invoice).Error; err != nil {
+		if isDuplicateKey(r.db, err) {
+			return ErrScheduleInvoiced
+		}
+		return err
+	}
+	return nil
+}
+
+// CreateWithDetails persists an invoice together with its line items in one transaction
+func (r *invoiceRepository) CreateWithDetails(ctx context.Context, invoice *domain.Invoice, details []*domain.InvoiceDetail) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(invoice).Error; err != nil {
+			if isDuplicateKey(tx, err) {
+				return ErrScheduleInvoiced
+			}
+			return err
+		}
+
+		for _, detail := range details {
+			detail.InvoiceID = invoice.ID
+			if err := tx.Create(detail).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 }
 
-func (r *invoiceRepository) GetByID(id string) (*domain.Invoice, error) {
+// MarkOverdue flags every Unpaid or PartiallyPaid invoice with a balance left that
+// was due before dueBefore as Overdue and returns the flagged invoices as they were
+// before. Payments turn an Overdue invoice PartiallyPaid, so the sweep flags it again
+// while it is owed. On Postgres the update runs under a transaction-scoped advisory
+// lock; if another instance holds it, ErrSweepInProgress is returned.
+func (r *invoiceRepository) MarkOverdue(ctx context.Context, dueBefore time.Time) ([]*domain.Invoice, error) {
+	var flagged []*domain.Invoice
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "postgres" {
+			var acquired bool
+			if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", overdueSweepLockKey).Scan(&acquired).Error; err != nil {
+				return err
+			}
+			if !acquired {
+				return ErrSweepInProgress
+			}
+		}
+
+		// Lock the invoices first so payments cannot change them between the read
+		// and the update
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("(status = ? OR (status = ? AND total_amount > amount_paid)) AND due_date < ?",
+				domain.InvoiceStatusUnpaid, domain.InvoiceStatusPartiallyPaid, dueBefore).
+			Find(&flagged).Error
+		if err != nil || len(flagged) == 0 {
+			return err
+		}
+
+		ids := make([]uuid.UUID, 0, len(flagged))
+		for _, invoice := range flagged {
+			ids = append(ids, invoice.ID)
+		}
+		return tx.Model(&domain.Invoice{}).
+			Where("id IN ?", ids).
+			Update("status", domain.InvoiceStatusOverdue).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return flagged, nil
+}
+
+func (r *invoiceRepository) GetByID(ctx context.Context, id string) (*domain.Invoice, error) {
 	var invoice domain.Invoice
-	err := r.db.Where("id = ?", id).First(&invoice).Error
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&invoice).Error
 	if err != nil {
 		return nil, err
 	}
 	return &invoice, nil
 }
 
-func (r *invoiceRepository) Update(invoice *domain.Invoice) error {
-	return r.db.Save(invoice).Error
+// Update loads the invoice under a row lock, lets apply change it and writes back only
+// the invoice date, due date and status. The amounts are left to payments and line
+// item edits, so an update cannot undo a payment recorded meanwhile.
+func (r *invoiceRepository) Update(ctx context.Context, id string, apply func(invoice *domain.Invoice) error) (*domain.Invoice, error) {
+	var invoice domain.Invoice
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", id).
+			First(&invoice).Error
+		if err != nil {
+			return err
+		}
+
+		if err := apply(&invoice); err != nil {
+			return err
+		}
+
+		return tx.Model(&domain.Invoice{}).
+			Where("id = ?", invoice.ID).
+			Updates(map[string]interface{}{
+				"invoice_date": invoice.InvoiceDate,
+				"due_date":     invoice.DueDate,
+				"status":       invoice.Status,
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &invoice, nil
 }
 
-func (r *invoiceRepository) Delete(id string) error {
-	return r.db.Delete(&domain.Invoice{}, "id = ?", id).Error
+func (r *invoiceRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&domain.Invoice{}, "id = ?", id).Error
 }
 
-func (r *invoiceRepository) List(offset, limit int) ([]*domain.Invoice, int64, error) {
+func (r *invoiceRepository) List(ctx context.Context, scope domain.AccessScope, opts ListOptions) ([]*domain.Invoice, ListPage, error) {
 	var invoices []*domain.Invoice
-	var total int64
 
-	// Count total records
-	if err := r.db.Model(&domain.Invoice{}).Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
+	query := scopeInvoices(r.db, r.db.WithContext(ctx).Model(&domain.Invoice{}), scope)
 
-	// Get paginated records
-	err := r.db.Offset(offset).Limit(limit).Order("invoice_date DESC").Find(&invoices).Error
+	page, err := r.findPage(ctx, query, opts, &invoices)
 	if err != nil {
-		return nil, 0, err
+		return nil, ListPage{}, err
 	}
 
-	return invoices, total, nil
+	return invoices, page, nil
 }
 
-func (r *invoiceRepository) Search(customerID, scheduleID string, status domain.InvoiceStatus, dateFrom, dateTo *time.Time, offset, limit int) ([]*domain.Invoice, int64, error) {
+func (r *invoiceRepository) Search(ctx context.Context, scope domain.AccessScope, customerID, scheduleID string, status domain.InvoiceStatus, dateFrom, dateTo *time.Time, opts ListOptions) ([]*domain.Invoice, ListPage, error) {
 	var invoices []*domain.Invoice
-	var total int64
 
-	query := r.db.Model(&domain.Invoice{})
+	query := scopeInvoices(r.db, r.db.WithContext(ctx).Model(&domain.Invoice{}), scope)
 
 	// Apply filters
 	if customerID != "" {
@@ -90,61 +226,81 @@ func (r *invoiceRepository) Search(customerID, scheduleID string, status domain.
 		query = query.Where("invoice_date <= ?", dateTo)
 	}
 
-	// Count total records with filters
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// Get paginated records with filters
-	err := query.Offset(offset).Limit(limit).Order("invoice_date DESC").Find(&invoices).Error
+	page, err := r.findPage(ctx, query, opts, &invoices)
 	if err != nil {
-		return nil, 0, err
+		return nil, ListPage{}, err
 	}
 
-	return invoices, total, nil
+	return invoices, page, nil
 }
 
-func (r *invoiceRepository) GetByCustomerID(customerID string, offset, limit int) ([]*domain.Invoice, int64, error) {
+func (r *invoiceRepository) GetByCustomerID(ctx context.Context, scope domain.AccessScope, customerID string, opts ListOptions) ([]*domain.Invoice, ListPage, error) {
 	var invoices []*domain.Invoice
-	var total int64
 
-	// Count total records
-	if err := r.db.Model(&domain.Invoice{}).Where("customer_id = ?", customerID).Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
+	query := scopeInvoices(r.db, r.db.WithContext(ctx).Model(&domain.Invoice{}), scope).Where("customer_id = ?", customerID)
 
-	// Get paginated records
-	err := r.db.Where("customer_id = ?", customerID).Offset(offset).Limit(limit).Order("invoice_date DESC").Find(&invoices).Error
+	page, err := r.findPage(ctx, query, opts, &invoices)
 	if err != nil {
-		return nil, 0, err
+		return nil, ListPage{}, err
 	}
 
-	return invoices, total, nil
+	return invoices, page, nil
 }
 
-func (r *invoiceRepository) GetByScheduleID(scheduleID string) (*domain.Invoice, error) {
+func (r *invoiceRepository) GetByScheduleID(ctx context.Context, scheduleID string) (*domain.Invoice, error) {
 	var invoice domain.Invoice
-	err := r.db.Where("schedule_id = ?", scheduleID).First(&invoice).Error
+	err := r.db.WithContext(ctx).Where("schedule_id = ?", scheduleID).First(&invoice).Error
 	if err != nil {
 		return nil, err
 	}
 	return &invoice, nil
 }
 
-func (r *invoiceRepository) GetByStatus(status domain.InvoiceStatus, offset, limit int) ([]*domain.Invoice, int64, error) {
+func (r *invoiceRepository) GetByStatus(ctx context.Context, scope domain.AccessScope, status domain.InvoiceStatus, opts ListOptions) ([]*domain.Invoice, ListPage, error) {
 	var invoices []*domain.Invoice
-	var total int64
 
-	// Count total records
-	if err := r.db.Model(&domain.Invoice{}).Where("status = ?", status).Count(&total).Error; err != nil {
-		return nil, 0, err
+	query := scopeInvoices(r.db, r.db.WithContext(ctx).Model(&domain.Invoice{}), scope).Where("status = ?", status)
+
+	page, err := r.findPage(ctx, query, opts, &invoices)
+	if err != nil {
+		return nil, ListPage{}, err
+	}
+
+	return invoices, page, nil
+}
+
+// LoadRelations attaches the related records named in include, e.g. "customer,details",
+// to invoices
+func (r *invoiceRepository) LoadRelations(ctx context.Context, invoices []*domain.Invoice, include string) error {
+	includes, err := parseInclude(include, invoiceIncludes)
+	if err != nil {
+		return err
+	}
+	return loadInvoiceRelations(r.db.WithContext(ctx), invoices, includes)
+}
+
+// findPage loads a page of invoices with the related records opts.Include names
+func (r *invoiceRepository) findPage(ctx context.Context, query *gorm.DB, opts ListOptions, invoices *[]*domain.Invoice) (ListPage, error) {
+	includes, err := parseInclude(opts.Include, invoiceIncludes)
+	if err != nil {
+		return ListPage{}, err
 	}
 
-	// Get paginated records
-	err := r.db.Where("status = ?", status).Offset(offset).Limit(limit).Order("invoice_date DESC").Find(&invoices).Error
+	page, err := findPage(query, invoiceList, opts, invoices)
 	if err != nil {
-		return nil, 0, err
+		return ListPage{}, err
 	}
+	return page, loadInvoiceRelations(r.db.WithContext(ctx), *invoices, includes)
+}
 
-	return invoices, total, nil
-}
\ No newline at end of file
+// isDuplicateKey reports whether err is a unique constraint violation, whether or not
+// the connection was opened with TranslateError
+func isDuplicateKey(db *gorm.DB, err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	if translator, ok := db.Dialector.(gorm.ErrorTranslator); ok {
+		return errors.Is(translator.Translate(err), gorm.ErrDuplicatedKey)
+	}
+	return false
+}
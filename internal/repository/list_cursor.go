@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// cursor is the decoded form of the opaque cursors handed to clients. It holds the
+// sort key of the record a page starts next to and the sort it was issued for, so a
+// cursor cannot be replayed against a different order.
+type cursor struct {
+	Sort   []string `json:"s"`           // sort terms, e.g. "-date"
+	Values []string `json:"v"`           // the record's value for each term
+	Before bool     `json:"b,omitempty"` // the page ends before the record instead of starting after it
+}
+
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor reads a cursor issued for terms and parses its values for binding
+func decodeCursor(raw string, terms []sortTerm) (cursor, []interface{}, error) {
+	invalid := &ListQueryError{Param: "cursor", Reason: "malformed cursor"}
+
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil || json.Unmarshal(data, &c) != nil || len(c.Values) != len(c.Sort) {
+		return cursor{}, nil, invalid
+	}
+	if strings.Join(c.Sort, ",") != strings.Join(sortKeys(terms), ",") {
+		return cursor{}, nil, &ListQueryError{Param: "cursor", Reason: "cursor was issued for a different sort"}
+	}
+
+	values := make([]interface{}, len(terms))
+	for i, term := range terms {
+		value, err := term.field.parseCursor(c.Values[i])
+		if err != nil {
+			return cursor{}, nil, invalid
+		}
+		values[i] = value
+	}
+	return c, values, nil
+}
+
+// sortKeys describes terms the way they are recorded in a cursor
+func sortKeys(terms []sortTerm) []string {
+	keys := make([]string, len(terms))
+	for i, term := range terms {
+		keys[i] = term.name
+		if term.desc {
+			keys[i] = "-" + term.name
+		}
+	}
+	return keys
+}
+
+// parseCursor converts a value recorded in a cursor to the type the column is
+// compared with. Times are recorded in full rather than in the filter formats.
+func (f listField) parseCursor(value string) (interface{}, error) {
+	switch f.typ {
+	case fieldDate, fieldTimestamp:
+		return time.Parse(time.RFC3339Nano, value)
+	case fieldTime:
+		parsed, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return nil, err
+		}
+		return parsed.Format("15:04:05"), nil
+	}
+	return f.parse(value)
+}
+
+// keysetCondition selects the records after (or before) the given sort key values in
+// the order of terms: (a > ?) OR (a = ? AND b > ?) OR ..., with < for descending terms
+func keysetCondition(query *gorm.DB, terms []sortTerm, values []interface{}, before bool) *gorm.DB {
+	var branches []string
+	var args []interface{}
+	for i, term := range terms {
+		conditions := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			conditions = append(conditions, terms[j].field.column+" = ?")
+			args = append(args, values[j])
+		}
+
+		op := ">"
+		if term.desc != before {
+			op = "<"
+		}
+		conditions = append(conditions, term.field.column+" "+op+" ?")
+		args = append(args, values[i])
+
+		branches = append(branches, "("+strings.Join(conditions, " AND ")+")")
+	}
+	return query.Where("("+strings.Join(branches, " OR ")+")", args...)
+}
+
+// findCursorPage loads the page next to the record opts.Cursor points to, or the
+// first page when there is no cursor, and returns cursors for the pages around it
+func findCursorPage(query *gorm.DB, terms []sortTerm, opts ListOptions, dest interface{}) (ListPage, error) {
+	hasCursor := opts.Cursor != ""
+	backward := false
+	if hasCursor {
+		c, values, err := decodeCursor(opts.Cursor, terms)
+		if err != nil {
+			return ListPage{}, err
+		}
+		backward = c.Before
+		query = keysetCondition(query, terms, values, backward)
+	}
+
+	// One extra record tells whether another page follows in the direction of travel.
+	// Pages before a cursor are read in reverse and flipped back afterwards.
+	result := query.Order(orderClause(terms, backward)).Limit(opts.Limit + 1).Find(dest)
+	if result.Error != nil {
+		return ListPage{}, result.Error
+	}
+
+	records := reflect.ValueOf(dest).Elem()
+	more := records.Len() > opts.Limit
+	if more {
+		records.Set(records.Slice(0, opts.Limit))
+	}
+	if backward {
+		swap := reflect.Swapper(records.Interface())
+		for i, j := 0, records.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+
+	var page ListPage
+	if records.Len() == 0 {
+		return page, nil
+	}
+
+	var err error
+	if backward || more {
+		page.NextCursor, err = cursorFor(result.Statement, terms, records.Index(records.Len()-1), false)
+		if err != nil {
+			return ListPage{}, err
+		}
+	}
+	if (backward && more) || (hasCursor && !backward) {
+		page.PrevCursor, err = cursorFor(result.Statement, terms, records.Index(0), true)
+		if err != nil {
+			return ListPage{}, err
+		}
+	}
+	return page, nil
+}
+
+// cursorFor returns the cursor pointing to record
+func cursorFor(stmt *gorm.Statement, terms []sortTerm, record reflect.Value, before bool) (string, error) {
+	c := cursor{Sort: sortKeys(terms), Values: make([]string, len(terms)), Before: before}
+	for i, term := range terms {
+		var field *schema.Field
+		if stmt.Schema != nil {
+			field = stmt.Schema.LookUpField(term.name)
+		}
+		if field == nil {
+			return "", fmt.Errorf("cursor: model has no field %q", term.name)
+		}
+
+		value, _ := field.ValueOf(stmt.Context, record)
+		if t, ok := value.(time.Time); ok {
+			c.Values[i] = t.Format(time.RFC3339Nano)
+		} else {
+			c.Values[i] = fmt.Sprint(value)
+		}
+	}
+	return c.encode(), nil
+}
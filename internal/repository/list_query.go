@@ -0,0 +1,320 @@
+package repository
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"dashboard-ac-backend/internal/apperror"
+	"dashboard-ac-backend/pkg/money"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ListOptions selects one page of a list and how it is sorted and filtered. Sort,
+// Filter and Search come from the client unchanged and are checked against the fields
+// the list allows, so they never reach the SQL as written.
+//
+// Sort is a comma separated list of fields, each optionally followed by a direction:
+// "name", "-created_at", "date desc" or "total_amount:asc". Filter is a comma
+// separated list of field:operator:value conditions that must all hold, for example
+// "status:eq:Paid,total_amount:gte:500000"; the in operator takes values separated
+// by "|". Search matches the list's text fields case-insensitively.
+//
+// With UseCursor the list is paged by keyset instead of Offset: the page starts next
+// to the record Cursor points to, or at the start of the list when Cursor is empty,
+// and the matching records are not counted.
+//
+// Include names the related records to load with schedules and invoices, for example
+// "customer,technician"; other lists ignore it.
+type ListOptions struct {
+	Offset    int
+	Limit     int
+	Sort      string
+	Filter    string
+	Search    string
+	UseCursor bool
+	Cursor    string
+	Include   string
+}
+
+// ListPage describes the page a list query returned. Total is only counted for
+// offset pagination; the cursors are only set for cursor pagination and are empty
+// when there is no page in that direction.
+type ListPage struct {
+	Total      int64
+	NextCursor string
+	PrevCursor string
+}
+
+// ListQueryError is returned for a sort, filter, cursor or include the list does not
+// support
+type ListQueryError struct {
+	Param  string // "sort", "filter", "cursor" or "include"
+	Reason string
+}
+
+func (e *ListQueryError) Error() string {
+	return fmt.Sprintf("invalid %s: %s", e.Param, e.Reason)
+}
+
+func (e *ListQueryError) AppError() *apperror.Error {
+	return apperror.Validation("invalid_"+e.Param, e.Error(), apperror.FieldError{Field: e.Param, Message: e.Reason})
+}
+
+// fieldType decides which operators a field supports and how filter values are parsed
+type fieldType int
+
+const (
+	fieldText fieldType = iota
+	fieldUUID
+	fieldBool
+	fieldNumber
+	fieldMoney
+	fieldDate      // YYYY-MM-DD
+	fieldTime      // HH:MM
+	fieldTimestamp // YYYY-MM-DD or RFC 3339
+)
+
+type filterOp string
+
+const (
+	opEq   filterOp = "eq"
+	opNe   filterOp = "ne"
+	opGt   filterOp = "gt"
+	opGte  filterOp = "gte"
+	opLt   filterOp = "lt"
+	opLte  filterOp = "lte"
+	opLike filterOp = "like"
+	opIn   filterOp = "in"
+)
+
+var opSQL = map[filterOp]string{
+	opEq:  "=",
+	opNe:  "<>",
+	opGt:  ">",
+	opGte: ">=",
+	opLt:  "<",
+	opLte: "<=",
+}
+
+// listField maps a field name clients use to its column
+type listField struct {
+	column string
+	typ    fieldType
+}
+
+func (f listField) supports(op filterOp) bool {
+	switch op {
+	case opEq, opNe:
+		return true
+	case opIn:
+		return f.typ != fieldBool
+	case opLike:
+		return f.typ == fieldText
+	case opGt, opGte, opLt, opLte:
+		return f.typ != fieldText && f.typ != fieldUUID && f.typ != fieldBool
+	}
+	return false
+}
+
+// parse converts a filter value to the type the column is compared with
+func (f listField) parse(value string) (interface{}, error) {
+	switch f.typ {
+	case fieldUUID:
+		return uuid.Parse(value)
+	case fieldBool:
+		return strconv.ParseBool(value)
+	case fieldNumber:
+		return strconv.ParseInt(value, 10, 64)
+	case fieldMoney:
+		return money.Parse(value)
+	case fieldDate:
+		return time.Parse("2006-01-02", value)
+	case fieldTime:
+		parsed, err := time.Parse("15:04", value)
+		if err != nil {
+			return nil, err
+		}
+		return parsed.Format("15:04:05"), nil
+	case fieldTimestamp:
+		if parsed, err := time.Parse("2006-01-02", value); err == nil {
+			return parsed, nil
+		}
+		return time.Parse(time.RFC3339, value)
+	}
+	return value, nil
+}
+
+// listSpec describes what a list can be sorted, filtered and searched by
+type listSpec struct {
+	fields      map[string]listField
+	key         listField // unique column that breaks ties between equal sort keys
+	search      []string  // text columns the search term is matched against
+	defaultSort string    // sort used when the client does not ask for one, in the client syntax
+}
+
+// sortTerm is one column of an ORDER BY
+type sortTerm struct {
+	name  string // field name, which is also the name of the model field
+	field listField
+	desc  bool
+}
+
+// order parses a sort parameter into ORDER BY terms over whitelisted columns. The
+// key is appended when it is not sorted on already so that the order is total, which
+// keeps pages stable and lets a cursor point to exactly one record.
+func (s listSpec) order(sort string) ([]sortTerm, error) {
+	sort = strings.TrimSpace(sort)
+	if sort == "" {
+		sort = s.defaultSort
+	}
+
+	var terms []sortTerm
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(sort, ",") {
+		item = strings.TrimSpace(item)
+		name, desc := item, false
+		if strings.HasPrefix(item, "-") {
+			name, desc = item[1:], true
+		} else if i := strings.IndexAny(item, " :"); i >= 0 {
+			name = item[:i]
+			switch strings.ToLower(strings.TrimSpace(item[i+1:])) {
+			case "asc":
+			case "desc":
+				desc = true
+			default:
+				return nil, &ListQueryError{Param: "sort", Reason: fmt.Sprintf("unknown direction in %q, use asc or desc", item)}
+			}
+		}
+
+		field, ok := s.fields[name]
+		if !ok {
+			return nil, &ListQueryError{Param: "sort", Reason: fmt.Sprintf("cannot sort by %q", name)}
+		}
+		if seen[name] {
+			return nil, &ListQueryError{Param: "sort", Reason: fmt.Sprintf("%q is listed more than once", name)}
+		}
+		seen[name] = true
+		terms = append(terms, sortTerm{name: name, field: field, desc: desc})
+	}
+
+	if !seen["id"] {
+		terms = append(terms, sortTerm{name: "id", field: s.key, desc: terms[len(terms)-1].desc})
+	}
+	return terms, nil
+}
+
+// orderClause writes terms as an ORDER BY clause, optionally with every direction
+// reversed
+func orderClause(terms []sortTerm, reverse bool) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		direction := "ASC"
+		if term.desc != reverse {
+			direction = "DESC"
+		}
+		parts[i] = term.field.column + " " + direction
+	}
+	return strings.Join(parts, ", ")
+}
+
+// filter adds the conditions of a filter parameter to query. Only whitelisted columns
+// are written into the SQL; values are always bound as parameters.
+func (s listSpec) filter(query *gorm.DB, filter string) (*gorm.DB, error) {
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return query, nil
+	}
+
+	for _, condition := range strings.Split(filter, ",") {
+		parts := strings.SplitN(strings.TrimSpace(condition), ":", 3)
+		if len(parts) != 3 {
+			return nil, &ListQueryError{Param: "filter", Reason: fmt.Sprintf("%q is not in the form field:operator:value", condition)}
+		}
+		name, op, raw := parts[0], filterOp(strings.ToLower(parts[1])), parts[2]
+
+		field, ok := s.fields[name]
+		if !ok {
+			return nil, &ListQueryError{Param: "filter", Reason: fmt.Sprintf("cannot filter by %q", name)}
+		}
+		if !field.supports(op) {
+			return nil, &ListQueryError{Param: "filter", Reason: fmt.Sprintf("operator %q is not supported for %q", op, name)}
+		}
+
+		switch op {
+		case opLike:
+			query = query.Where(field.column+" ILIKE ?", "%"+escapeLike(raw)+"%")
+		case opIn:
+			var values []interface{}
+			for _, item := range strings.Split(raw, "|") {
+				value, err := field.parse(item)
+				if err != nil {
+					return nil, &ListQueryError{Param: "filter", Reason: fmt.Sprintf("invalid value %q for %q", item, name)}
+				}
+				values = append(values, value)
+			}
+			query = query.Where(field.column+" IN ?", values)
+		default:
+			value, err := field.parse(raw)
+			if err != nil {
+				return nil, &ListQueryError{Param: "filter", Reason: fmt.Sprintf("invalid value %q for %q", raw, name)}
+			}
+			query = query.Where(field.column+" "+opSQL[op]+" ?", value)
+		}
+	}
+	return query, nil
+}
+
+// searchFor restricts query to records whose search columns contain term
+func (s listSpec) searchFor(query *gorm.DB, term string) *gorm.DB {
+	term = strings.TrimSpace(term)
+	if term == "" || len(s.search) == 0 {
+		return query
+	}
+
+	conditions := make([]string, len(s.search))
+	args := make([]interface{}, len(s.search))
+	for i, column := range s.search {
+		conditions[i] = column + " ILIKE ?"
+		args[i] = "%" + escapeLike(term) + "%"
+	}
+	return query.Where("("+strings.Join(conditions, " OR ")+")", args...)
+}
+
+// findPage applies the filter, search and sort of opts to query and loads the
+// requested page of the matching records into dest, which must point to a slice of
+// model pointers
+func findPage(query *gorm.DB, spec listSpec, opts ListOptions, dest interface{}) (ListPage, error) {
+	terms, err := spec.order(opts.Sort)
+	if err != nil {
+		return ListPage{}, err
+	}
+	query, err = spec.filter(query, opts.Filter)
+	if err != nil {
+		return ListPage{}, err
+	}
+	query = spec.searchFor(query, opts.Search)
+
+	if opts.UseCursor {
+		return findCursorPage(query, terms, opts, dest)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return ListPage{}, err
+	}
+
+	err = query.Order(orderClause(terms, false)).Offset(opts.Offset).Limit(opts.Limit).Find(dest).Error
+	if err != nil {
+		return ListPage{}, err
+	}
+	return ListPage{Total: total}, nil
+}
+
+// escapeLike makes the LIKE wildcards in s match literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"dashboard-ac-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type LoginAttemptRepository interface {
+	Create(ctx context.Context, attempt *domain.LoginAttempt) error
+	CountFailuresByIP(ctx context.Context, ipAddress string, since time.Time) (int64, time.Time, error)
+}
+
+type loginAttemptRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginAttemptRepository(db *gorm.DB) LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+func (r *loginAttemptRepository) Create(ctx context.Context, attempt *domain.LoginAttempt) error {
+	return r.db.WithContext(ctx).Create(attempt).Error
+}
+
+// CountFailuresByIP counts the logins from an address refused for wrong credentials
+// since the given time and returns when the oldest of them was made. Attempts refused
+// because the address was throttled or the account locked are not counted, so a
+// throttled address cannot keep extending its own throttle.
+func (r *loginAttemptRepository) CountFailuresByIP(ctx context.Context, ipAddress string, since time.Time) (int64, time.Time, error) {
+	query := r.db.WithContext(ctx).Model(&domain.LoginAttempt{}).
+		Where("ip_address = ? AND success = ? AND failure_reason = ? AND created_at >= ?",
+			ipAddress, false, domain.LoginFailureInvalidCredentials, since).
+		Session(&gorm.Session{})
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil || count == 0 {
+		return count, time.Time{}, err
+	}
+
+	var oldest domain.LoginAttempt
+	if err := query.Select("created_at").Order("created_at ASC").First(&oldest).Error; err != nil {
+		return 0, time.Time{}, err
+	}
+	return count, oldest.CreatedAt, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"dashboard-ac-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// ErrResetTokenInvalid is returned when a reset token is unknown, used or expired
+var ErrResetTokenInvalid = errors.New("reset token is invalid or expired")
+
+type PasswordResetRepository interface {
+	Create(ctx context.Context, token *domain.PasswordResetToken) error
+	Redeem(ctx context.Context, tokenHash, passwordHash string) (userID uint, err error)
+}
+
+type passwordResetRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetRepository(db *gorm.DB) PasswordResetRepository {
+	return &passwordResetRepository{db: db}
+}
+
+// Create stores a new reset token. Older unused tokens of the user are retired so only
+// the most recently mailed link works.
+func (r *passwordResetRepository) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&domain.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", time.Now()).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(token).Error
+	})
+}
+
+// Redeem marks the token as used and replaces the password of its user in one
+// transaction. The token is claimed with a conditional update, so it can only be
+// redeemed once even under concurrent requests. It returns the ID of the user.
+func (r *passwordResetRepository) Redeem(ctx context.Context, tokenHash, passwordHash string) (uint, error) {
+	var token domain.PasswordResetToken
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		result := tx.Model(&domain.PasswordResetToken{}).
+			Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrResetTokenInvalid
+		}
+
+		if err := tx.Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+			return err
+		}
+
+		return replacePassword(tx, token.UserID, passwordHash)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return token.UserID, nil
+}
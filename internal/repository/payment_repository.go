@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/pkg/money"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrPaymentExceedsBalance is returned when a payment is larger than what is still owed
+var ErrPaymentExceedsBalance = errors.New("payment exceeds the invoice balance due")
+
+type PaymentRepository interface {
+	CreateAndApply(ctx context.Context, payment *domain.Payment) (*InvoiceChange, error)
+	GetByID(ctx context.Context, id string) (*domain.Payment, error)
+	GetByInvoiceID(ctx context.Context, invoiceID string) ([]*domain.Payment, error)
+}
+
+type paymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+// CreateAndApply records a payment and refreshes the invoice's amount paid and status
+// in one transaction. The invoice row stays locked while the balance is checked so two
+// concurrent payments cannot both pass the check.
+func (r *paymentRepository) CreateAndApply(ctx context.Context, payment *domain.Payment) (*InvoiceChange, error) {
+	var before, invoice domain.Invoice
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", payment.InvoiceID).
+			First(&invoice).Error
+		if err != nil {
+			return err
+		}
+		before = invoice
+
+		if payment.Amount > invoice.BalanceDue {
+			return ErrPaymentExceedsBalance
+		}
+
+		if err := tx.Create(payment).Error; err != nil {
+			return err
+		}
+
+		var amountPaid money.Money
+		err = tx.Model(&domain.Payment{}).
+			Select("COALESCE(SUM(amount), 0)").
+			Where("invoice_id = ?", payment.InvoiceID).
+			Scan(&amountPaid).Error
+		if err != nil {
+			return err
+		}
+
+		invoice.ApplyPayments(amountPaid)
+
+		return tx.Model(&domain.Invoice{}).
+			Where("id = ?", invoice.ID).
+			Updates(map[string]interface{}{
+				"amount_paid": invoice.AmountPaid,
+				"status":      invoice.Status,
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &InvoiceChange{Before: &before, After: &invoice}, nil
+}
+
+func (r *paymentRepository) GetByID(ctx context.Context, id string) (*domain.Payment, error) {
+	var payment domain.Payment
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&payment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &payment, nil
+}
+
+func (r *paymentRepository) GetByInvoiceID(ctx context.Context, invoiceID string) ([]*domain.Payment, error) {
+	var payments []*domain.Payment
+	err := r.db.WithContext(ctx).Where("invoice_id = ?", invoiceID).Order("paid_at ASC").Find(&payments).Error
+	return payments, err
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"dashboard-ac-backend/internal/domain"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrRefreshTokenReused is returned when a refresh token that was already rotated or
+// revoked is presented again
+var ErrRefreshTokenReused = errors.New("refresh token has already been used")
+
+type RefreshTokenRepository interface {
+	Create(ctx context.Context, token *domain.RefreshToken) error
+	GetByID(ctx context.Context, id string) (*domain.RefreshToken, error)
+	Rotate(ctx context.Context, currentID uuid.UUID, next *domain.RefreshToken) error
+	RevokeFamily(ctx context.Context, familyID uuid.UUID) error
+	RevokeAllForUser(ctx context.Context, userID uint) (int64, error)
+}
+
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *domain.RefreshToken) error {
+	return r.db.WithContext(ctx).Create(token).Error
+}
+
+func (r *refreshTokenRepository) GetByID(ctx context.Context, id string) (*domain.RefreshToken, error) {
+	var token domain.RefreshToken
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Rotate revokes the current token and stores its replacement in one transaction. The
+// revoke only matches a token that is still active, so when two requests race with the
+// same token exactly one of them wins and the other gets ErrRefreshTokenReused.
+func (r *refreshTokenRepository) Rotate(ctx context.Context, currentID uuid.UUID, next *domain.RefreshToken) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", currentID).
+			Updates(map[string]interface{}{
+				"revoked_at":     time.Now(),
+				"replaced_by_id": next.ID,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+
+		return tx.Create(next).Error
+	})
+}
+
+// RevokeFamily revokes every still-active token that descends from the same login
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&domain.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeAllForUser revokes every active token of a user and returns how many were revoked
+func (r *refreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uint) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&domain.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,254 @@
+package repository
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/pkg/money"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReportRepository computes the dashboard figures with SQL aggregates. Every method
+// covers the days from from (inclusive) to to (exclusive); both are midnights.
+type ReportRepository interface {
+	Revenue(ctx context.Context, from, to, today time.Time) (domain.RevenueSummary, error)
+	JobCountsByStatus(ctx context.Context, from, to time.Time) (map[domain.ScheduleStatus]int64, error)
+	TopServices(ctx context.Context, from, to time.Time, limit int) ([]domain.ServiceRevenue, error)
+	TechnicianUtilization(ctx context.Context, from, to time.Time) ([]domain.TechnicianUtilization, error)
+	RevenueTimeseries(ctx context.Context, from, to time.Time, interval domain.ReportInterval) ([]domain.RevenuePoint, error)
+}
+
+type reportRepository struct {
+	db *gorm.DB
+}
+
+func NewReportRepository(db *gorm.DB) ReportRepository {
+	return &reportRepository{db: db}
+}
+
+// Revenue totals the invoices dated in the period and the payments received in it.
+// Invoices due before today that still have a balance count as overdue, whether or
+// not the overdue sweep has flagged them yet.
+func (r *reportRepository) Revenue(ctx context.Context, from, to, today time.Time) (domain.RevenueSummary, error) {
+	var summary domain.RevenueSummary
+
+	unpaid := []domain.InvoiceStatus{domain.InvoiceStatusUnpaid, domain.InvoiceStatusPartiallyPaid, domain.InvoiceStatusOverdue}
+	var invoices struct {
+		Invoiced    money.Money
+		Outstanding money.Money
+		Overdue     money.Money
+	}
+	err := r.db.WithContext(ctx).Model(&domain.Invoice{}).
+		Select(`COALESCE(SUM(total_amount), 0) AS invoiced,
+			COALESCE(SUM(CASE WHEN status IN ? THEN total_amount - amount_paid ELSE 0 END), 0) AS outstanding,
+			COALESCE(SUM(CASE WHEN status IN ? AND (status = ? OR due_date < ?) THEN total_amount - amount_paid ELSE 0 END), 0) AS overdue`,
+			unpaid, unpaid, domain.InvoiceStatusOverdue, today).
+		Where("invoice_date >= ? AND invoice_date < ?", from, to).
+		Scan(&invoices).Error
+	if err != nil {
+		return summary, err
+	}
+
+	var payments struct {
+		Paid money.Money
+	}
+	err = r.db.WithContext(ctx).Model(&domain.Payment{}).
+		Select("COALESCE(SUM(amount), 0) AS paid").
+		Where("paid_at >= ? AND paid_at < ?", from, to).
+		Scan(&payments).Error
+	if err != nil {
+		return summary, err
+	}
+
+	summary.Invoiced = invoices.Invoiced
+	summary.Paid = payments.Paid
+	summary.Outstanding = invoices.Outstanding
+	summary.Overdue = invoices.Overdue
+	return summary, nil
+}
+
+// JobCountsByStatus counts the schedules dated in the period by status. Every status
+// is listed, with zero when there are no such schedules.
+func (r *reportRepository) JobCountsByStatus(ctx context.Context, from, to time.Time) (map[domain.ScheduleStatus]int64, error) {
+	var rows []struct {
+		Status domain.ScheduleStatus
+		Count  int64
+	}
+	err := r.db.WithContext(ctx).Model(&domain.Schedule{}).
+		Select("status, COUNT(*) AS count").
+		Where("date >= ? AND date < ?", from, to).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := map[domain.ScheduleStatus]int64{
+		domain.ScheduleStatusPending:    0,
+		domain.ScheduleStatusOnProgress: 0,
+		domain.ScheduleStatusCompleted:  0,
+		domain.ScheduleStatusCanceled:   0,
+	}
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// TopServices ranks services by what they were billed for on the invoices dated in
+// the period
+func (r *reportRepository) TopServices(ctx context.Context, from, to time.Time, limit int) ([]domain.ServiceRevenue, error) {
+	services := []domain.ServiceRevenue{}
+	err := r.db.WithContext(ctx).Table("invoice_details").
+		Select(`invoice_details.service_id, services.name AS service_name,
+			SUM(invoice_details.quantity) AS quantity, SUM(invoice_details.subtotal) AS revenue`).
+		Joins("JOIN invoices ON invoices.id = invoice_details.invoice_id AND invoices.deleted_at IS NULL").
+		Joins("JOIN services ON services.id = invoice_details.service_id").
+		Where("invoice_details.deleted_at IS NULL").
+		Where("invoices.invoice_date >= ? AND invoices.invoice_date < ?", from, to).
+		Group("invoice_details.service_id, services.name").
+		Order("revenue DESC, services.name ASC").
+		Limit(limit).
+		Scan(&services).Error
+	if err != nil {
+		return nil, err
+	}
+	return services, nil
+}
+
+// TechnicianUtilization sums the duration of each technician's jobs dated in the
+// period and compares it with their working hours, less breaks, on every day of it
+func (r *reportRepository) TechnicianUtilization(ctx context.Context, from, to time.Time) ([]domain.TechnicianUtilization, error) {
+	var rows []struct {
+		ID            uuid.UUID
+		Name          string
+		WorkStart     string
+		WorkEnd       string
+		BreakStart    string
+		BreakEnd      string
+		Jobs          int64
+		BookedMinutes int64
+	}
+	err := r.db.WithContext(ctx).Model(&domain.Technician{}).
+		Select(`technicians.id, technicians.name, technicians.work_start, technicians.work_end,
+			technicians.break_start, technicians.break_end,
+			COUNT(schedules.id) AS jobs, COALESCE(SUM(services.duration), 0) AS booked_minutes`).
+		Joins(`LEFT JOIN schedules ON schedules.technician_id = technicians.id AND schedules.deleted_at IS NULL
+			AND schedules.status <> ? AND schedules.date >= ? AND schedules.date < ?`,
+			domain.ScheduleStatusCanceled, from, to).
+		Joins("LEFT JOIN services ON services.id = schedules.service_id").
+		Group("technicians.id, technicians.name, technicians.work_start, technicians.work_end, technicians.break_start, technicians.break_end").
+		Order("technicians.name ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	days := int64(math.Round(to.Sub(from).Hours() / 24))
+	utilization := make([]domain.TechnicianUtilization, 0, len(rows))
+	for _, row := range rows {
+		technician := domain.Technician{WorkStart: row.WorkStart, WorkEnd: row.WorkEnd, BreakStart: row.BreakStart, BreakEnd: row.BreakEnd}
+		available := days * workingMinutes(&technician)
+
+		entry := domain.TechnicianUtilization{
+			TechnicianID:     row.ID,
+			TechnicianName:   row.Name,
+			Jobs:             row.Jobs,
+			BookedMinutes:    row.BookedMinutes,
+			AvailableMinutes: available,
+		}
+		if available > 0 {
+			entry.Utilization = math.Round(float64(row.BookedMinutes)/float64(available)*1000) / 10
+		}
+		utilization = append(utilization, entry)
+	}
+	return utilization, nil
+}
+
+// workingMinutes is the length of a technician's working day less their break; it is
+// zero when the working hours are malformed
+func workingMinutes(technician *domain.Technician) int64 {
+	day := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	start, end, err := technician.WorkingHours(day)
+	if err != nil || !start.Before(end) {
+		return 0
+	}
+	minutes := int64(end.Sub(start) / time.Minute)
+
+	breakStart, breakEnd, hasBreak, err := technician.BreakWindow(day)
+	if err == nil && hasBreak && breakStart.Before(breakEnd) {
+		minutes -= int64(breakEnd.Sub(breakStart) / time.Minute)
+	}
+	return minutes
+}
+
+// RevenueTimeseries groups the invoiced totals and payments of the period by interval.
+// Every period is listed, with zeros when nothing was invoiced or paid in it.
+func (r *reportRepository) RevenueTimeseries(ctx context.Context, from, to time.Time, interval domain.ReportInterval) ([]domain.RevenuePoint, error) {
+	var invoiced []struct {
+		Period string
+		Total  money.Money
+	}
+	period := r.periodExpr("invoice_date", interval)
+	err := r.db.WithContext(ctx).Model(&domain.Invoice{}).
+		Select(period+" AS period, SUM(total_amount) AS total").
+		Where("invoice_date >= ? AND invoice_date < ?", from, to).
+		Group(period).
+		Scan(&invoiced).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var paid []struct {
+		Period string
+		Total  money.Money
+	}
+	period = r.periodExpr("paid_at", interval)
+	err = r.db.WithContext(ctx).Model(&domain.Payment{}).
+		Select(period+" AS period, SUM(amount) AS total").
+		Where("paid_at >= ? AND paid_at < ?", from, to).
+		Group(period).
+		Scan(&paid).Error
+	if err != nil {
+		return nil, err
+	}
+
+	points := []domain.RevenuePoint{}
+	index := make(map[string]int)
+	for start := interval.PeriodStart(from); start.Before(to); start = interval.Next(start) {
+		key := start.Format("2006-01-02")
+		index[key] = len(points)
+		points = append(points, domain.RevenuePoint{Period: key})
+	}
+	for _, row := range invoiced {
+		if i, ok := index[row.Period]; ok {
+			points[i].Invoiced = row.Total
+		}
+	}
+	for _, row := range paid {
+		if i, ok := index[row.Period]; ok {
+			points[i].Paid = row.Total
+		}
+	}
+	return points, nil
+}
+
+// periodExpr returns SQL for the first day of the period containing column, formatted
+// as YYYY-MM-DD, in the dialect of the database. Weeks start on Monday.
+func (r *reportRepository) periodExpr(column string, interval domain.ReportInterval) string {
+	if r.db.Dialector.Name() == "postgres" {
+		return "to_char(date_trunc('" + string(interval) + "', " + column + "), 'YYYY-MM-DD')"
+	}
+
+	switch interval {
+	case domain.ReportIntervalWeek:
+		return "strftime('%Y-%m-%d', " + column + ", 'weekday 0', '-6 days')"
+	case domain.ReportIntervalMonth:
+		return "strftime('%Y-%m-01', " + column + ")"
+	}
+	return "strftime('%Y-%m-%d', " + column + ")"
+}
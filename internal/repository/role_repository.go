@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+
+	"dashboard-ac-backend/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RoleRepository interface {
+	Create(ctx context.Context, role *domain.RoleDefinition) error
+	GetByName(ctx context.Context, name domain.Role) (*domain.RoleDefinition, error)
+	List(ctx context.Context) ([]*domain.RoleDefinition, error)
+	Update(ctx context.Context, role *domain.RoleDefinition) error
+	Delete(ctx context.Context, name domain.Role) error
+	CountUsers(ctx context.Context, name domain.Role) (int64, error)
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+// Create stores the role together with its permissions
+func (r *roleRepository) Create(ctx context.Context, role *domain.RoleDefinition) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(role).Error; err != nil {
+			return err
+		}
+		return insertRolePermissions(tx, role.Name, role.Permissions)
+	})
+}
+
+func (r *roleRepository) GetByName(ctx context.Context, name domain.Role) (*domain.RoleDefinition, error) {
+	var role domain.RoleDefinition
+	if err := r.db.WithContext(ctx).Where("name = ?", name).First(&role).Error; err != nil {
+		return nil, err
+	}
+
+	permissions, err := r.permissionsByRole(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	role.Permissions = append([]domain.Permission{}, permissions[name]...)
+
+	return &role, nil
+}
+
+func (r *roleRepository) List(ctx context.Context) ([]*domain.RoleDefinition, error) {
+	var roles []*domain.RoleDefinition
+	if err := r.db.WithContext(ctx).Order("name ASC").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	permissions, err := r.permissionsByRole(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		role.Permissions = append([]domain.Permission{}, permissions[role.Name]...)
+	}
+
+	return roles, nil
+}
+
+// Update saves the description and replaces the permissions of the role. The role row
+// is locked so concurrent updates cannot interleave their permission sets.
+func (r *roleRepository) Update(ctx context.Context, role *domain.RoleDefinition) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current domain.RoleDefinition
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("name = ?", role.Name).First(&current).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&current).Update("description", role.Description).Error; err != nil {
+			return err
+		}
+		role.UpdatedAt = current.UpdatedAt
+
+		if err := tx.Where("role = ?", role.Name).Delete(&domain.RolePermission{}).Error; err != nil {
+			return err
+		}
+		return insertRolePermissions(tx, role.Name, role.Permissions)
+	})
+}
+
+func (r *roleRepository) Delete(ctx context.Context, name domain.Role) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role = ?", name).Delete(&domain.RolePermission{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("name = ?", name).Delete(&domain.RoleDefinition{}).Error
+	})
+}
+
+// CountUsers returns how many accounts are assigned to the role
+func (r *roleRepository) CountUsers(ctx context.Context, name domain.Role) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&domain.User{}).Where("role = ?", name).Count(&count).Error
+	return count, err
+}
+
+// permissionsByRole loads the permissions of one role, or of every role when name is empty
+func (r *roleRepository) permissionsByRole(ctx context.Context, name domain.Role) (map[domain.Role][]domain.Permission, error) {
+	query := r.db.WithContext(ctx).Order("permission ASC")
+	if name != "" {
+		query = query.Where("role = ?", name)
+	}
+
+	var rows []domain.RolePermission
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	permissions := make(map[domain.Role][]domain.Permission)
+	for _, row := range rows {
+		permissions[row.Role] = append(permissions[row.Role], row.Permission)
+	}
+	return permissions, nil
+}
+
+func insertRolePermissions(tx *gorm.DB, role domain.Role, permissions []domain.Permission) error {
+	if len(permissions) == 0 {
+		return nil
+	}
+
+	rows := make([]domain.RolePermission, 0, len(permissions))
+	for _, permission := range permissions {
+		rows = append(rows, domain.RolePermission{Role: role, Permission: permission})
+	}
+	return tx.Create(&rows).Error
+}
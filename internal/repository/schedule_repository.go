@@ -1,23 +1,53 @@
 package repository
 
 import (
+	"context"
+	"errors"
 	"time"
 
 	"dashboard-ac-backend/internal/domain"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// ErrScheduleStatusChanged is returned when a schedule's status was changed by
+// someone else between reading and writing it
+var ErrScheduleStatusChanged = errors.New("schedule status was changed concurrently")
+
 type ScheduleRepository interface {
-	Create(schedule *domain.Schedule) error
-	GetByID(id string) (*domain.Schedule, error)
-	Update(schedule *domain.Schedule) error
-	Delete(id string) error
-	List(offset, limit int) ([]*domain.Schedule, int64, error)
-	Search(customerID, technicianID, serviceID string, status domain.ScheduleStatus, dateFrom, dateTo *time.Time, offset, limit int) ([]*domain.Schedule, int64, error)
-	GetByCustomerID(customerID string, offset, limit int) ([]*domain.Schedule, int64, error)
-	GetByTechnicianID(technicianID string, offset, limit int) ([]*domain.Schedule, int64, error)
-	GetByStatus(status domain.ScheduleStatus, offset, limit int) ([]*domain.Schedule, int64, error)
+	Create(ctx context.Context, schedule *domain.Schedule) error
+	GetByID(ctx context.Context, id string) (*domain.Schedule, error)
+	Update(ctx context.Context, schedule *domain.Schedule) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, scope domain.AccessScope, opts ListOptions) ([]*domain.Schedule, ListPage, error)
+	Search(ctx context.Context, scope domain.AccessScope, customerID, technicianID, serviceID string, status domain.ScheduleStatus, dateFrom, dateTo *time.Time, opts ListOptions) ([]*domain.Schedule, ListPage, error)
+	GetByCustomerID(ctx context.Context, scope domain.AccessScope, customerID string, opts ListOptions) ([]*domain.Schedule, ListPage, error)
+	GetByTechnicianID(ctx context.Context, scope domain.AccessScope, technicianID string, opts ListOptions) ([]*domain.Schedule, ListPage, error)
+	GetByStatus(ctx context.Context, scope domain.AccessScope, status domain.ScheduleStatus, opts ListOptions) ([]*domain.Schedule, ListPage, error)
+	LoadRelations(ctx context.Context, schedules []*domain.Schedule, include string) error
+	GetTechnicianAgenda(ctx context.Context, technicianID string, date time.Time) ([]*domain.Schedule, error)
+	GetTechnicianWindows(ctx context.Context, technicianID string, from, to time.Time, excludeID string) ([]domain.ScheduleWindow, error)
+	UpdateWithHistory(ctx context.Context, schedule *domain.Schedule, history *domain.ScheduleStatusHistory) error
+	GetStatusHistory(ctx context.Context, scheduleID string) ([]*domain.ScheduleStatusHistory, error)
+	WithTechnicianLock(ctx context.Context, technicianID uuid.UUID, fn func(repo ScheduleRepository) error) error
+}
+
+// scheduleList lists the fields schedules can be sorted and filtered by
+var scheduleList = listSpec{
+	fields: map[string]listField{
+		"customer_id":   {column: "schedules.customer_id", typ: fieldUUID},
+		"technician_id": {column: "schedules.technician_id", typ: fieldUUID},
+		"service_id":    {column: "schedules.service_id", typ: fieldUUID},
+		"date":          {column: "schedules.date", typ: fieldDate},
+		"time":          {column: "schedules.time", typ: fieldTime},
+		"status":        {column: "schedules.status", typ: fieldText},
+		"created_at":    {column: "schedules.created_at", typ: fieldTimestamp},
+		"updated_at":    {column: "schedules.updated_at", typ: fieldTimestamp},
+	},
+	key:         listField{column: "schedules.id", typ: fieldUUID},
+	defaultSort: "-date,-time",
 }
 
 type scheduleRepository struct {
@@ -28,50 +58,97 @@ func NewScheduleRepository(db *gorm.DB) ScheduleRepository {
 	return &scheduleRepository{db: db}
 }
 
-func (r *scheduleRepository) Create(schedule *domain.Schedule) error {
-	return r.db.Create(schedule).Error
+func (r *scheduleRepository) Create(ctx context.Context, schedule *domain.Schedule) error {
+	return r.db.WithContext(ctx).Create(schedule).Error
 }
 
-func (r *scheduleRepository) GetByID(id string) (*domain.Schedule, error) {
+func (r *scheduleRepository) GetByID(ctx context.Context, id string) (*domain.Schedule, error) {
 	var schedule domain.Schedule
-	err := r.db.Where("id = ?", id).First(&schedule).Error
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&schedule).Error
 	if err != nil {
 		return nil, err
 	}
 	return &schedule, nil
 }
 
-func (r *scheduleRepository) Update(schedule *domain.Schedule) error {
-	return r.db.Save(schedule).Error
+func (r *scheduleRepository) Update(ctx context.Context, schedule *domain.Schedule) error {
+	return r.db.WithContext(ctx).Save(schedule).Error
 }
 
-func (r *scheduleRepository) Delete(id string) error {
-	return r.db.Delete(&domain.Schedule{}, "id = ?", id).Error
+// UpdateWithHistory saves the schedule and records its status transition in one
+// transaction. The row is locked first so a transition can only be applied from the
+// status it was computed against.
+func (r *scheduleRepository) UpdateWithHistory(ctx context.Context, schedule *domain.Schedule, history *domain.ScheduleStatusHistory) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current domain.Schedule
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "status").
+			Where("id = ?", schedule.ID).
+			First(&current).Error
+		if err != nil {
+			return err
+		}
+
+		if current.Status != history.FromStatus {
+			return ErrScheduleStatusChanged
+		}
+
+		if err := tx.Save(schedule).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(history).Error
+	})
 }
 
-func (r *scheduleRepository) List(offset, limit int) ([]*domain.Schedule, int64, error) {
-	var schedules []*domain.Schedule
-	var total int64
+// WithTechnicianLock runs fn in a transaction that first locks the technician's row,
+// so the bookings of one technician are checked and written one request at a time.
+// fn is given a repository bound to the transaction and must use it for both.
+func (r *scheduleRepository) WithTechnicianLock(ctx context.Context, technicianID uuid.UUID, fn func(repo ScheduleRepository) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var technician domain.Technician
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", technicianID).
+			First(&technician).Error
+		if err != nil {
+			return err
+		}
+
+		return fn(&scheduleRepository{db: tx})
+	})
+}
 
-	// Count total records
-	if err := r.db.Model(&domain.Schedule{}).Count(&total).Error; err != nil {
-		return nil, 0, err
+func (r *scheduleRepository) GetStatusHistory(ctx context.Context, scheduleID string) ([]*domain.ScheduleStatusHistory, error) {
+	var history []*domain.ScheduleStatusHistory
+	err := r.db.WithContext(ctx).Where("schedule_id = ?", scheduleID).Order("changed_at ASC").Find(&history).Error
+	if err != nil {
+		return nil, err
 	}
+	return history, nil
+}
+
+func (r *scheduleRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&domain.Schedule{}, "id = ?", id).Error
+}
+
+func (r *scheduleRepository) List(ctx context.Context, scope domain.AccessScope, opts ListOptions) ([]*domain.Schedule, ListPage, error) {
+	var schedules []*domain.Schedule
+
+	query := scopeSchedules(r.db.WithContext(ctx).Model(&domain.Schedule{}), scope)
 
-	// Get paginated records
-	err := r.db.Offset(offset).Limit(limit).Order("date DESC, time DESC").Find(&schedules).Error
+	page, err := r.findPage(ctx, query, opts, &schedules)
 	if err != nil {
-		return nil, 0, err
+		return nil, ListPage{}, err
 	}
 
-	return schedules, total, nil
+	return schedules, page, nil
 }
 
-func (r *scheduleRepository) Search(customerID, technicianID, serviceID string, status domain.ScheduleStatus, dateFrom, dateTo *time.Time, offset, limit int) ([]*domain.Schedule, int64, error) {
+func (r *scheduleRepository) Search(ctx context.Context, scope domain.AccessScope, customerID, technicianID, serviceID string, status domain.ScheduleStatus, dateFrom, dateTo *time.Time, opts ListOptions) ([]*domain.Schedule, ListPage, error) {
 	var schedules []*domain.Schedule
-	var total int64
 
-	query := r.db.Model(&domain.Schedule{})
+	query := scopeSchedules(r.db.WithContext(ctx).Model(&domain.Schedule{}), scope)
 
 	// Apply filters
 	if customerID != "" {
@@ -93,70 +170,136 @@ func (r *scheduleRepository) Search(customerID, technicianID, serviceID string,
 		query = query.Where("date <= ?", dateTo)
 	}
 
-	// Count total records with filters
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+	page, err := r.findPage(ctx, query, opts, &schedules)
+	if err != nil {
+		return nil, ListPage{}, err
 	}
 
-	// Get paginated records with filters
-	err := query.Offset(offset).Limit(limit).Order("date DESC, time DESC").Find(&schedules).Error
+	return schedules, page, nil
+}
+
+func (r *scheduleRepository) GetByCustomerID(ctx context.Context, scope domain.AccessScope, customerID string, opts ListOptions) ([]*domain.Schedule, ListPage, error) {
+	var schedules []*domain.Schedule
+
+	query := scopeSchedules(r.db.WithContext(ctx).Model(&domain.Schedule{}), scope).Where("customer_id = ?", customerID)
+
+	page, err := r.findPage(ctx, query, opts, &schedules)
 	if err != nil {
-		return nil, 0, err
+		return nil, ListPage{}, err
 	}
 
-	return schedules, total, nil
+	return schedules, page, nil
 }
 
-func (r *scheduleRepository) GetByCustomerID(customerID string, offset, limit int) ([]*domain.Schedule, int64, error) {
+func (r *scheduleRepository) GetByTechnicianID(ctx context.Context, scope domain.AccessScope, technicianID string, opts ListOptions) ([]*domain.Schedule, ListPage, error) {
 	var schedules []*domain.Schedule
-	var total int64
 
-	// Count total records
-	if err := r.db.Model(&domain.Schedule{}).Where("customer_id = ?", customerID).Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
+	query := scopeSchedules(r.db.WithContext(ctx).Model(&domain.Schedule{}), scope).Where("technician_id = ?", technicianID)
 
-	// Get paginated records
-	err := r.db.Where("customer_id = ?", customerID).Offset(offset).Limit(limit).Order("date DESC, time DESC").Find(&schedules).Error
+	page, err := r.findPage(ctx, query, opts, &schedules)
 	if err != nil {
-		return nil, 0, err
+		return nil, ListPage{}, err
 	}
 
-	return schedules, total, nil
+	return schedules, page, nil
 }
 
-func (r *scheduleRepository) GetByTechnicianID(technicianID string, offset, limit int) ([]*domain.Schedule, int64, error) {
+func (r *scheduleRepository) GetByStatus(ctx context.Context, scope domain.AccessScope, status domain.ScheduleStatus, opts ListOptions) ([]*domain.Schedule, ListPage, error) {
 	var schedules []*domain.Schedule
-	var total int64
 
-	// Count total records
-	if err := r.db.Model(&domain.Schedule{}).Where("technician_id = ?", technicianID).Count(&total).Error; err != nil {
-		return nil, 0, err
+	query := scopeSchedules(r.db.WithContext(ctx).Model(&domain.Schedule{}), scope).Where("status = ?", status)
+
+	page, err := r.findPage(ctx, query, opts, &schedules)
+	if err != nil {
+		return nil, ListPage{}, err
 	}
 
-	// Get paginated records
-	err := r.db.Where("technician_id = ?", technicianID).Offset(offset).Limit(limit).Order("date DESC, time DESC").Find(&schedules).Error
+	return schedules, page, nil
+}
+
+// LoadRelations attaches the related records named in include, e.g. "customer,service",
+// to schedules
+func (r *scheduleRepository) LoadRelations(ctx context.Context, schedules []*domain.Schedule, include string) error {
+	includes, err := parseInclude(include, scheduleIncludes)
+	if err != nil {
+		return err
+	}
+	return loadScheduleRelations(r.db.WithContext(ctx), schedules, includes)
+}
+
+// findPage loads a page of schedules with the related records opts.Include names
+func (r *scheduleRepository) findPage(ctx context.Context, query *gorm.DB, opts ListOptions, schedules *[]*domain.Schedule) (ListPage, error) {
+	includes, err := parseInclude(opts.Include, scheduleIncludes)
 	if err != nil {
-		return nil, 0, err
+		return ListPage{}, err
 	}
 
-	return schedules, total, nil
+	page, err := findPage(query, scheduleList, opts, schedules)
+	if err != nil {
+		return ListPage{}, err
+	}
+	return page, loadScheduleRelations(r.db.WithContext(ctx), *schedules, includes)
 }
 
-func (r *scheduleRepository) GetByStatus(status domain.ScheduleStatus, offset, limit int) ([]*domain.Schedule, int64, error) {
+// GetTechnicianAgenda returns a technician's non-canceled schedules on one day in start order
+func (r *scheduleRepository) GetTechnicianAgenda(ctx context.Context, technicianID string, date time.Time) ([]*domain.Schedule, error) {
 	var schedules []*domain.Schedule
-	var total int64
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
 
-	// Count total records
-	if err := r.db.Model(&domain.Schedule{}).Where("status = ?", status).Count(&total).Error; err != nil {
-		return nil, 0, err
+	err := r.db.WithContext(ctx).
+		Where("technician_id = ? AND date = ?", technicianID, day).
+		Where("status <> ?", domain.ScheduleStatusCanceled).
+		Order("time ASC").
+		Find(&schedules).Error
+	if err != nil {
+		return nil, err
 	}
 
-	// Get paginated records
-	err := r.db.Where("status = ?", status).Offset(offset).Limit(limit).Order("date DESC, time DESC").Find(&schedules).Error
-	if err != nil {
-		return nil, 0, err
+	return schedules, nil
+}
+
+// scheduleWindowRow is the projection used to compute booked windows
+type scheduleWindowRow struct {
+	ID       uuid.UUID
+	Date     time.Time
+	Time     time.Time
+	Duration int
+}
+
+// GetTechnicianWindows returns the booked windows of a technician's non-canceled
+// schedules that overlap [from, to). Candidates are narrowed by date in SQL and the
+// exact overlap is computed in Go so the query stays portable between Postgres and SQLite.
+func (r *scheduleRepository) GetTechnicianWindows(ctx context.Context, technicianID string, from, to time.Time, excludeID string) ([]domain.ScheduleWindow, error) {
+	var rows []scheduleWindowRow
+
+	fromDay := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location())
+	toDay := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, to.Location())
+
+	query := r.db.WithContext(ctx).Table("schedules").
+		Select("schedules.id, schedules.date, schedules.time, services.duration").
+		Joins("JOIN services ON services.id = schedules.service_id").
+		Where("schedules.deleted_at IS NULL").
+		Where("schedules.technician_id = ?", technicianID).
+		Where("schedules.status <> ?", domain.ScheduleStatusCanceled).
+		// Include the previous day so jobs running past midnight are considered
+		Where("schedules.date BETWEEN ? AND ?", fromDay.AddDate(0, 0, -1), toDay)
+
+	if excludeID != "" {
+		query = query.Where("schedules.id <> ?", excludeID)
+	}
+
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
 	}
 
-	return schedules, total, nil
-}
\ No newline at end of file
+	windows := make([]domain.ScheduleWindow, 0, len(rows))
+	for _, row := range rows {
+		schedule := domain.Schedule{ID: row.ID, Date: row.Date, Time: row.Time}
+		window := schedule.Window(row.Duration)
+		if window.Overlaps(from, to) {
+			windows = append(windows, window)
+		}
+	}
+
+	return windows, nil
+}
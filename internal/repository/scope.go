@@ -0,0 +1,24 @@
+package repository
+
+import (
+	"dashboard-ac-backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+// scopeSchedules restricts a schedules query to the records visible in scope
+func scopeSchedules(query *gorm.DB, scope domain.AccessScope) *gorm.DB {
+	if !scope.IsRestricted() {
+		return query
+	}
+	return query.Where("schedules.technician_id = ?", *scope.TechnicianID)
+}
+
+// scopeInvoices restricts an invoices query to invoices whose schedule is visible in scope
+func scopeInvoices(db, query *gorm.DB, scope domain.AccessScope) *gorm.DB {
+	if !scope.IsRestricted() {
+		return query
+	}
+	visible := db.Model(&domain.Schedule{}).Select("id").Where("technician_id = ?", *scope.TechnicianID)
+	return query.Where("invoices.schedule_id IN (?)", visible)
+}
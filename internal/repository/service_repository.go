@@ -1,18 +1,35 @@
 package repository
 
 import (
+	"context"
+
 	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/pkg/money"
 
 	"gorm.io/gorm"
 )
 
 type ServiceRepository interface {
-	Create(service *domain.Service) error
-	GetByID(id string) (*domain.Service, error)
-	Update(service *domain.Service) error
-	Delete(id string) error
-	List(offset, limit int) ([]*domain.Service, int64, error)
-	Search(name string, minPrice, maxPrice float64, offset, limit int) ([]*domain.Service, int64, error)
+	Create(ctx context.Context, service *domain.Service) error
+	GetByID(ctx context.Context, id string) (*domain.Service, error)
+	Update(ctx context.Context, service *domain.Service) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, opts ListOptions) ([]*domain.Service, ListPage, error)
+	Search(ctx context.Context, name string, minPrice, maxPrice money.Money, opts ListOptions) ([]*domain.Service, ListPage, error)
+}
+
+// serviceList lists the fields services can be sorted, filtered and searched by
+var serviceList = listSpec{
+	fields: map[string]listField{
+		"name":       {column: "name", typ: fieldText},
+		"price":      {column: "price", typ: fieldMoney},
+		"duration":   {column: "duration", typ: fieldNumber},
+		"created_at": {column: "created_at", typ: fieldTimestamp},
+		"updated_at": {column: "updated_at", typ: fieldTimestamp},
+	},
+	key:         listField{column: "id", typ: fieldUUID},
+	search:      []string{"name"},
+	defaultSort: "-created_at",
 }
 
 type serviceRepository struct {
@@ -23,50 +40,42 @@ func NewServiceRepository(db *gorm.DB) ServiceRepository {
 	return &serviceRepository{db: db}
 }
 
-func (r *serviceRepository) Create(service *domain.Service) error {
-	return r.db.Create(service).Error
+func (r *serviceRepository) Create(ctx context.Context, service *domain.Service) error {
+	return r.db.WithContext(ctx).Create(service).Error
 }
 
-func (r *serviceRepository) GetByID(id string) (*domain.Service, error) {
+func (r *serviceRepository) GetByID(ctx context.Context, id string) (*domain.Service, error) {
 	var service domain.Service
-	err := r.db.Where("id = ?", id).First(&service).Error
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&service).Error
 	if err != nil {
 		return nil, err
 	}
 	return &service, nil
 }
 
-func (r *serviceRepository) Update(service *domain.Service) error {
-	return r.db.Save(service).Error
+func (r *serviceRepository) Update(ctx context.Context, service *domain.Service) error {
+	return r.db.WithContext(ctx).Save(service).Error
 }
 
-func (r *serviceRepository) Delete(id string) error {
-	return r.db.Delete(&domain.Service{}, "id = ?", id).Error
+func (r *serviceRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&domain.Service{}, "id = ?", id).Error
 }
 
-func (r *serviceRepository) List(offset, limit int) ([]*domain.Service, int64, error) {
+func (r *serviceRepository) List(ctx context.Context, opts ListOptions) ([]*domain.Service, ListPage, error) {
 	var services []*domain.Service
-	var total int64
 
-	// Count total records
-	if err := r.db.Model(&domain.Service{}).Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// Get paginated records
-	err := r.db.Offset(offset).Limit(limit).Order("created_at DESC").Find(&services).Error
+	page, err := findPage(r.db.WithContext(ctx).Model(&domain.Service{}), serviceList, opts, &services)
 	if err != nil {
-		return nil, 0, err
+		return nil, ListPage{}, err
 	}
 
-	return services, total, nil
+	return services, page, nil
 }
 
-func (r *serviceRepository) Search(name string, minPrice, maxPrice float64, offset, limit int) ([]*domain.Service, int64, error) {
+func (r *serviceRepository) Search(ctx context.Context, name string, minPrice, maxPrice money.Money, opts ListOptions) ([]*domain.Service, ListPage, error) {
 	var services []*domain.Service
-	var total int64
 
-	query := r.db.Model(&domain.Service{})
+	query := r.db.WithContext(ctx).Model(&domain.Service{})
 
 	// Apply filters
 	if name != "" {
@@ -79,16 +88,10 @@ func (r *serviceRepository) Search(name string, minPrice, maxPrice float64, offs
 		query = query.Where("price <= ?", maxPrice)
 	}
 
-	// Count total records with filters
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// Get paginated records with filters
-	err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&services).Error
+	page, err := findPage(query, serviceList, opts, &services)
 	if err != nil {
-		return nil, 0, err
+		return nil, ListPage{}, err
 	}
 
-	return services, total, nil
+	return services, page, nil
 }
\ No newline at end of file
@@ -1,18 +1,35 @@
 package repository
 
 import (
+	"context"
+
 	"dashboard-ac-backend/internal/domain"
 
 	"gorm.io/gorm"
 )
 
 type TechnicianRepository interface {
-	Create(technician *domain.Technician) error
-	GetByID(id string) (*domain.Technician, error)
-	Update(technician *domain.Technician) error
-	Delete(id string) error
-	List(offset, limit int) ([]*domain.Technician, int64, error)
-	Search(name, specialization string, offset, limit int) ([]*domain.Technician, int64, error)
+	Create(ctx context.Context, technician *domain.Technician) error
+	GetByID(ctx context.Context, id string) (*domain.Technician, error)
+	Update(ctx context.Context, technician *domain.Technician) error
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, opts ListOptions) ([]*domain.Technician, ListPage, error)
+	Search(ctx context.Context, name, specialization string, opts ListOptions) ([]*domain.Technician, ListPage, error)
+	GetAll(ctx context.Context) ([]*domain.Technician, error)
+}
+
+// technicianList lists the fields technicians can be sorted, filtered and searched by
+var technicianList = listSpec{
+	fields: map[string]listField{
+		"name":           {column: "name", typ: fieldText},
+		"phone":          {column: "phone", typ: fieldText},
+		"specialization": {column: "specialization", typ: fieldText},
+		"created_at":     {column: "created_at", typ: fieldTimestamp},
+		"updated_at":     {column: "updated_at", typ: fieldTimestamp},
+	},
+	key:         listField{column: "id", typ: fieldUUID},
+	search:      []string{"name", "phone", "specialization"},
+	defaultSort: "-created_at",
 }
 
 type technicianRepository struct {
@@ -23,50 +40,42 @@ func NewTechnicianRepository(db *gorm.DB) TechnicianRepository {
 	return &technicianRepository{db: db}
 }
 
-func (r *technicianRepository) Create(technician *domain.Technician) error {
-	return r.db.Create(technician).Error
+func (r *technicianRepository) Create(ctx context.Context, technician *domain.Technician) error {
+	return r.db.WithContext(ctx).Create(technician).Error
 }
 
-func (r *technicianRepository) GetByID(id string) (*domain.Technician, error) {
+func (r *technicianRepository) GetByID(ctx context.Context, id string) (*domain.Technician, error) {
 	var technician domain.Technician
-	err := r.db.Where("id = ?", id).First(&technician).Error
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&technician).Error
 	if err != nil {
 		return nil, err
 	}
 	return &technician, nil
 }
 
-func (r *technicianRepository) Update(technician *domain.Technician) error {
-	return r.db.Save(technician).Error
+func (r *technicianRepository) Update(ctx context.Context, technician *domain.Technician) error {
+	return r.db.WithContext(ctx).Save(technician).Error
 }
 
-func (r *technicianRepository) Delete(id string) error {
-	return r.db.Delete(&domain.Technician{}, "id = ?", id).Error
+func (r *technicianRepository) Delete(ctx context.Context, id string) error {
+	return r.db.WithContext(ctx).Delete(&domain.Technician{}, "id = ?", id).Error
 }
 
-func (r *technicianRepository) List(offset, limit int) ([]*domain.Technician, int64, error) {
+func (r *technicianRepository) List(ctx context.Context, opts ListOptions) ([]*domain.Technician, ListPage, error) {
 	var technicians []*domain.Technician
-	var total int64
 
-	// Count total records
-	if err := r.db.Model(&domain.Technician{}).Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// Get paginated records
-	err := r.db.Offset(offset).Limit(limit).Order("created_at DESC").Find(&technicians).Error
+	page, err := findPage(r.db.WithContext(ctx).Model(&domain.Technician{}), technicianList, opts, &technicians)
 	if err != nil {
-		return nil, 0, err
+		return nil, ListPage{}, err
 	}
 
-	return technicians, total, nil
+	return technicians, page, nil
 }
 
-func (r *technicianRepository) Search(name, specialization string, offset, limit int) ([]*domain.Technician, int64, error) {
+func (r *technicianRepository) Search(ctx context.Context, name, specialization string, opts ListOptions) ([]*domain.Technician, ListPage, error) {
 	var technicians []*domain.Technician
-	var total int64
 
-	query := r.db.Model(&domain.Technician{})
+	query := r.db.WithContext(ctx).Model(&domain.Technician{})
 
 	// Apply filters
 	if name != "" {
@@ -76,16 +85,19 @@ func (r *technicianRepository) Search(name, specialization string, offset, limit
 		query = query.Where("specialization ILIKE ?", "%"+specialization+"%")
 	}
 
-	// Count total records with filters
-	if err := query.Count(&total).Error; err != nil {
-		return nil, 0, err
+	page, err := findPage(query, technicianList, opts, &technicians)
+	if err != nil {
+		return nil, ListPage{}, err
 	}
 
-	// Get paginated records with filters
-	err := query.Offset(offset).Limit(limit).Order("created_at DESC").Find(&technicians).Error
+	return technicians, page, nil
+}
+
+func (r *technicianRepository) GetAll(ctx context.Context) ([]*domain.Technician, error) {
+	var technicians []*domain.Technician
+	err := r.db.WithContext(ctx).Order("name ASC").Find(&technicians).Error
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
-
-	return technicians, total, nil
-}
\ No newline at end of file
+	return technicians, nil
+}
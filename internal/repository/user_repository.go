@@ -1,19 +1,43 @@
 package repository
 
 import (
+	"context"
+	"time"
+
 	"dashboard-ac-backend/internal/domain"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository interface {
-	Create(user *domain.User) error
-	GetByID(id uint) (*domain.User, error)
-	GetByEmail(email string) (*domain.User, error)
-	Update(user *domain.User) error
-	Delete(id uint) error
-	List(offset, limit int) ([]*domain.User, int64, error)
-	GetByRole(role domain.Role, offset, limit int) ([]*domain.User, int64, error)
+	Create(ctx context.Context, user *domain.User) error
+	GetByID(ctx context.Context, id uint) (*domain.User, error)
+	GetByEmail(ctx context.Context, email string) (*domain.User, error)
+	Update(ctx context.Context, user *domain.User) error
+	Delete(ctx context.Context, id uint) error
+	List(ctx context.Context, opts ListOptions) ([]*domain.User, ListPage, error)
+	GetByRole(ctx context.Context, role domain.Role, opts ListOptions) ([]*domain.User, ListPage, error)
+	IncrementTokenVersion(ctx context.Context, id uint) error
+	UpdatePassword(ctx context.Context, id uint, passwordHash string) error
+	RegisterLoginFailure(ctx context.Context, id uint, maxFailures int, lockout time.Duration) (*domain.User, error)
+	ResetLoginFailures(ctx context.Context, id uint) error
+}
+
+// userList lists the fields users can be sorted, filtered and searched by
+var userList = listSpec{
+	fields: map[string]listField{
+		"id":         {column: "id", typ: fieldNumber},
+		"name":       {column: "name", typ: fieldText},
+		"email":      {column: "email", typ: fieldText},
+		"role":       {column: "role", typ: fieldText},
+		"is_active":  {column: "is_active", typ: fieldBool},
+		"created_at": {column: "created_at", typ: fieldTimestamp},
+		"updated_at": {column: "updated_at", typ: fieldTimestamp},
+	},
+	key:         listField{column: "id", typ: fieldNumber},
+	search:      []string{"name", "email"},
+	defaultSort: "id",
 }
 
 type userRepository struct {
@@ -24,68 +48,140 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) Create(user *domain.User) error {
-	return r.db.Create(user).Error
+func (r *userRepository) Create(ctx context.Context, user *domain.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
 }
 
-func (r *userRepository) GetByID(id uint) (*domain.User, error) {
+func (r *userRepository) GetByID(ctx context.Context, id uint) (*domain.User, error) {
 	var user domain.User
-	err := r.db.First(&user, id).Error
+	err := r.db.WithContext(ctx).First(&user, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) GetByEmail(email string) (*domain.User, error) {
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
 	var user domain.User
-	err := r.db.Where("email = ?", email).First(&user).Error
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
 	if err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) Update(user *domain.User) error {
-	return r.db.Save(user).Error
+func (r *userRepository) Update(ctx context.Context, user *domain.User) error {
+	return r.db.WithContext(ctx).Save(user).Error
 }
 
-func (r *userRepository) Delete(id uint) error {
-	return r.db.Delete(&domain.User{}, id).Error
+func (r *userRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&domain.User{}, id).Error
 }
 
-func (r *userRepository) List(offset, limit int) ([]*domain.User, int64, error) {
+func (r *userRepository) List(ctx context.Context, opts ListOptions) ([]*domain.User, ListPage, error) {
 	var users []*domain.User
-	var total int64
 
-	// Count total records
-	if err := r.db.Model(&domain.User{}).Count(&total).Error; err != nil {
-		return nil, 0, err
-	}
-
-	// Get paginated records
-	err := r.db.Offset(offset).Limit(limit).Find(&users).Error
+	page, err := findPage(r.db.WithContext(ctx).Model(&domain.User{}), userList, opts, &users)
 	if err != nil {
-		return nil, 0, err
+		return nil, ListPage{}, err
 	}
 
-	return users, total, nil
+	return users, page, nil
 }
 
-func (r *userRepository) GetByRole(role domain.Role, offset, limit int) ([]*domain.User, int64, error) {
+func (r *userRepository) GetByRole(ctx context.Context, role domain.Role, opts ListOptions) ([]*domain.User, ListPage, error) {
 	var users []*domain.User
-	var total int64
 
-	// Count total records with role filter
-	if err := r.db.Model(&domain.User{}).Where("role = ?", role).Count(&total).Error; err != nil {
-		return nil, 0, err
+	query := r.db.WithContext(ctx).Model(&domain.User{}).Where("role = ?", role)
+
+	page, err := findPage(query, userList, opts, &users)
+	if err != nil {
+		return nil, ListPage{}, err
 	}
 
-	// Get paginated records with role filter
-	err := r.db.Where("role = ?", role).Offset(offset).Limit(limit).Find(&users).Error
+	return users, page, nil
+}
+
+// IncrementTokenVersion invalidates every access token issued to the user so far
+func (r *userRepository) IncrementTokenVersion(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&domain.User{}).
+		Where("id = ?", id).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error
+}
+
+// UpdatePassword replaces the password and ends every session of the user
+func (r *userRepository) UpdatePassword(ctx context.Context, id uint, passwordHash string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return replacePassword(tx, id, passwordHash)
+	})
+}
+
+// RegisterLoginFailure counts a failed password attempt and locks the account for the
+// lockout duration once maxFailures is reached. The row is locked while counting so
+// concurrent attempts are all counted.
+func (r *userRepository) RegisterLoginFailure(ctx context.Context, id uint, maxFailures int, lockout time.Duration) (*domain.User, error) {
+	var user domain.User
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ?", id).
+			First(&user).Error
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		user.FailedLoginCount++
+		user.LastFailedLoginAt = &now
+		if maxFailures > 0 && user.FailedLoginCount >= maxFailures {
+			lockedUntil := now.Add(lockout)
+			user.LockedUntil = &lockedUntil
+		}
+
+		return tx.Model(&domain.User{}).
+			Where("id = ?", id).
+			UpdateColumns(map[string]interface{}{
+				"failed_login_count":   user.FailedLoginCount,
+				"last_failed_login_at": user.LastFailedLoginAt,
+				"locked_until":         user.LockedUntil,
+			}).Error
+	})
 	if err != nil {
-		return nil, 0, err
+		return nil, err
+	}
+
+	return &user, nil
+}
+
+// ResetLoginFailures clears the failed attempt counter and any lock
+func (r *userRepository) ResetLoginFailures(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Model(&domain.User{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"failed_login_count":   0,
+			"last_failed_login_at": nil,
+			"locked_until":         nil,
+		}).Error
+}
+
+// replacePassword stores a new password hash, invalidates the user's access tokens and
+// revokes their refresh tokens. It must run inside a transaction.
+func replacePassword(tx *gorm.DB, userID uint, passwordHash string) error {
+	result := tx.Model(&domain.User{}).
+		Where("id = ?", userID).
+		UpdateColumns(map[string]interface{}{
+			"password":      passwordHash,
+			"token_version": gorm.Expr("token_version + 1"),
+			"updated_at":    time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
 	}
 
-	return users, total, nil
-}
\ No newline at end of file
+	return tx.Model(&domain.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
@@ -1,8 +1,10 @@
 package routes
 
 import (
+    "dashboard-ac-backend/config"
     "dashboard-ac-backend/internal/api/handler"
     "dashboard-ac-backend/internal/api/middleware"
+    "dashboard-ac-backend/internal/domain"
     "dashboard-ac-backend/internal/service"
 
     "github.com/gofiber/fiber/v2"
@@ -19,9 +21,20 @@ func SetupRoutes(
 	scheduleService service.ScheduleService,
 	invoiceService service.InvoiceService,
 	invoiceDetailService service.InvoiceDetailService,
+	availabilityService service.AvailabilityService,
+	paymentService service.PaymentService,
+	portalService service.PortalService,
+	passwordService service.PasswordService,
+	roleService service.RoleService,
+	auditService service.AuditService,
+	dashboardService service.DashboardService,
 	jwtSecret string,
+	tokenVersions *middleware.TokenVersionCache,
+	rateLimit config.RateLimitConfig,
+	rateLimitStore middleware.RateLimitStore,
 ) {
     // Setup global middleware
+    app.Use(middleware.RequestID())
     app.Use(middleware.Logger())
     app.Use(middleware.Recovery())
     app.Use(middleware.CORS())
@@ -44,6 +57,13 @@ func SetupRoutes(
 	scheduleHandler := handler.NewScheduleHandler(scheduleService)
 	invoiceHandler := handler.NewInvoiceHandler(invoiceService)
 	invoiceDetailHandler := handler.NewInvoiceDetailHandler(invoiceDetailService)
+	availabilityHandler := handler.NewAvailabilityHandler(availabilityService)
+	paymentHandler := handler.NewPaymentHandler(paymentService)
+	portalHandler := handler.NewPortalHandler(portalService)
+	passwordHandler := handler.NewPasswordHandler(passwordService)
+	roleHandler := handler.NewRoleHandler(roleService)
+	auditHandler := handler.NewAuditHandler(auditService)
+	dashboardHandler := handler.NewDashboardHandler(dashboardService)
 
 	// Health check endpoint
 	app.Get("/health", healthHandler.Check)
@@ -51,84 +71,147 @@ func SetupRoutes(
 	// API v1 routes
 	api := app.Group("/api/v1")
 
-	// Auth routes (public)
+	// Auth routes (public), limited per client address
 	auth := api.Group("/auth")
+	if rateLimit.Enabled {
+		auth.Use(middleware.RateLimit(middleware.RateLimitConfig{
+			Name:    "auth",
+			Rule:    middleware.RateLimitRule{Limit: rateLimit.AuthRequests, Period: rateLimit.AuthPeriod},
+			KeyFunc: middleware.KeyByIP,
+			Store:   rateLimitStore,
+		}))
+	}
 	auth.Post("/register", authHandler.Register)
 	auth.Post("/login", authHandler.Login)
 	auth.Post("/refresh", authHandler.RefreshToken)
-
-	// Protected routes
-	protected := api.Group("", middleware.JWTAuth(jwtSecret))
+	auth.Post("/logout", authHandler.Logout)
+	auth.Post("/logout-all", middleware.JWTAuth(jwtSecret, tokenVersions), authHandler.LogoutAll)
+	auth.Post("/change-password", middleware.JWTAuth(jwtSecret, tokenVersions), passwordHandler.ChangePassword)
+	auth.Post("/forgot-password", passwordHandler.ForgotPassword)
+	auth.Post("/reset-password", passwordHandler.ResetPassword)
+
+	// Protected routes, limited per user; access is granted by the permissions of the user's role
+	protected := api.Group("", middleware.JWTAuth(jwtSecret, tokenVersions))
+	if rateLimit.Enabled {
+		protected.Use(middleware.RateLimit(middleware.RateLimitConfig{
+			Name:    "api",
+			Rule:    middleware.RateLimitRule{Limit: rateLimit.APIRequests, Period: rateLimit.APIPeriod},
+			KeyFunc: middleware.KeyByUser,
+			Store:   rateLimitStore,
+		}))
+	}
+	protected.Use(middleware.LoadPermissions(roleService.PermissionsFor))
 	
 	// User profile
 	protected.Get("/me", authHandler.Me)
-
-	// User management routes (admin only)
-	users := protected.Group("/users", middleware.RequireAdmin())
-	users.Post("/", userHandler.Create)
+	protected.Get("/me/jobs", middleware.RequireTechnicianAccount(), scheduleHandler.GetMyJobs)
+
+	// Permission and role management routes
+	protected.Get("/permissions", middleware.RequirePermission(domain.PermRolesRead), roleHandler.ListPermissions)
+	roles := protected.Group("/roles", middleware.RequirePermission(domain.PermRolesRead))
+	roles.Get("/", roleHandler.ListRoles)
+	roles.Post("/", middleware.RequirePermission(domain.PermRolesWrite), roleHandler.CreateRole)
+	roles.Get("/:name", roleHandler.GetRole)
+	roles.Put("/:name", middleware.RequirePermission(domain.PermRolesWrite), roleHandler.UpdateRole)
+	roles.Delete("/:name", middleware.RequirePermission(domain.PermRolesWrite), roleHandler.DeleteRole)
+
+	// Audit log of every create, update and delete
+	protected.Get("/audit-logs", middleware.RequirePermission(domain.PermAuditLogsRead), auditHandler.ListAuditLogs)
+
+	// Dashboard figures
+	dashboard := protected.Group("/dashboard", middleware.RequirePermission(domain.PermReportsRead))
+	dashboard.Get("/summary", dashboardHandler.GetSummary)
+	dashboard.Get("/revenue-timeseries", dashboardHandler.GetRevenueTimeseries)
+
+	// User management routes
+	users := protected.Group("/users", middleware.RequirePermission(domain.PermUsersRead))
+	users.Post("/", middleware.RequirePermission(domain.PermUsersWrite), userHandler.Create)
 	users.Get("/", userHandler.List)
 	users.Get("/:id", userHandler.GetByID)
-	users.Put("/:id", userHandler.Update)
-	users.Delete("/:id", userHandler.Delete)
+	users.Put("/:id", middleware.RequirePermission(domain.PermUsersWrite), userHandler.Update)
+	users.Delete("/:id", middleware.RequirePermission(domain.PermUsersWrite), userHandler.Delete)
+	users.Post("/:id/revoke-sessions", middleware.RequirePermission(domain.PermUsersWrite), userHandler.RevokeSessions)
+	users.Post("/:id/unlock", middleware.RequirePermission(domain.PermUsersWrite), userHandler.Unlock)
 	users.Get("/role/:role", userHandler.GetByRole)
 
-	// Customer management routes (admin and technician)
-	customers := protected.Group("/customers", middleware.RequireAdminOrTechnician())
-	customers.Post("/", customerHandler.CreateCustomer)
+	// Customer management routes
+	customers := protected.Group("/customers", middleware.RequirePermission(domain.PermCustomersRead))
+	customers.Post("/", middleware.RequirePermission(domain.PermCustomersWrite), customerHandler.CreateCustomer)
 	customers.Get("/", customerHandler.ListCustomers)
+	customers.Get("/export", customerHandler.ExportCustomers)
 	customers.Get("/:id", customerHandler.GetCustomer)
-	customers.Put("/:id", customerHandler.UpdateCustomer)
-	customers.Delete("/:id", customerHandler.DeleteCustomer)
+	customers.Put("/:id", middleware.RequirePermission(domain.PermCustomersWrite), customerHandler.UpdateCustomer)
+	customers.Delete("/:id", middleware.RequirePermission(domain.PermCustomersWrite), customerHandler.DeleteCustomer)
 	customers.Get("/search", customerHandler.SearchCustomers)
 
-	// Technician management routes (admin only)
-	technicians := protected.Group("/technicians", middleware.RequireAdmin())
-	technicians.Post("/", technicianHandler.CreateTechnician)
+	// Technician management routes
+	technicians := protected.Group("/technicians", middleware.RequirePermission(domain.PermTechniciansRead))
+	technicians.Post("/", middleware.RequirePermission(domain.PermTechniciansWrite), technicianHandler.CreateTechnician)
 	technicians.Get("/", technicianHandler.ListTechnicians)
+	technicians.Get("/availability", availabilityHandler.GetAvailability)
 	technicians.Get("/:id", technicianHandler.GetTechnician)
-	technicians.Put("/:id", technicianHandler.UpdateTechnician)
-	technicians.Delete("/:id", technicianHandler.DeleteTechnician)
+	technicians.Get("/:id/availability", availabilityHandler.GetTechnicianAvailability)
+	technicians.Put("/:id", middleware.RequirePermission(domain.PermTechniciansWrite), technicianHandler.UpdateTechnician)
+	technicians.Delete("/:id", middleware.RequirePermission(domain.PermTechniciansWrite), technicianHandler.DeleteTechnician)
 	technicians.Get("/search", technicianHandler.SearchTechnicians)
 
-	// Service management routes (admin only)
-	services := protected.Group("/services", middleware.RequireAdmin())
-	services.Post("/", serviceHandler.CreateService)
+	// Service management routes
+	services := protected.Group("/services", middleware.RequirePermission(domain.PermServicesRead))
+	services.Post("/", middleware.RequirePermission(domain.PermServicesWrite), serviceHandler.CreateService)
 	services.Get("/", serviceHandler.ListServices)
 	services.Get("/:id", serviceHandler.GetService)
-	services.Put("/:id", serviceHandler.UpdateService)
-	services.Delete("/:id", serviceHandler.DeleteService)
+	services.Put("/:id", middleware.RequirePermission(domain.PermServicesWrite), serviceHandler.UpdateService)
+	services.Delete("/:id", middleware.RequirePermission(domain.PermServicesWrite), serviceHandler.DeleteService)
 	services.Get("/search", serviceHandler.SearchServices)
 
-	// Schedule management routes (admin and technician)
-	schedules := protected.Group("/schedules", middleware.RequireAdminOrTechnician())
-	schedules.Post("/", scheduleHandler.CreateSchedule)
+	// Schedule management routes; technicians only see their own jobs
+	schedules := protected.Group("/schedules", middleware.RequirePermission(domain.PermSchedulesRead))
+	schedules.Post("/", middleware.RequirePermission(domain.PermSchedulesWrite), scheduleHandler.CreateSchedule)
 	schedules.Get("/", scheduleHandler.ListSchedules)
+	schedules.Get("/export", scheduleHandler.ExportSchedules)
 	schedules.Get("/:id", scheduleHandler.GetSchedule)
-	schedules.Put("/:id", scheduleHandler.UpdateSchedule)
-	schedules.Delete("/:id", scheduleHandler.DeleteSchedule)
+	schedules.Put("/:id", middleware.RequirePermission(domain.PermSchedulesWrite), scheduleHandler.UpdateSchedule)
+	schedules.Delete("/:id", middleware.RequirePermission(domain.PermSchedulesWrite), scheduleHandler.DeleteSchedule)
+	schedules.Post("/:id/start", middleware.RequirePermission(domain.PermSchedulesWrite), scheduleHandler.StartSchedule)
+	schedules.Post("/:id/complete", middleware.RequirePermission(domain.PermSchedulesWrite), scheduleHandler.CompleteSchedule)
+	schedules.Post("/:id/cancel", middleware.RequirePermission(domain.PermSchedulesWrite), scheduleHandler.CancelSchedule)
+	schedules.Get("/:id/history", scheduleHandler.GetScheduleHistory)
+	schedules.Post("/:id/invoice", middleware.RequirePermission(domain.PermInvoicesWrite), invoiceHandler.CreateInvoiceFromSchedule)
 	schedules.Get("/search", scheduleHandler.SearchSchedules)
 	schedules.Get("/customer/:customer_id", scheduleHandler.GetSchedulesByCustomer)
 	schedules.Get("/technician/:technician_id", scheduleHandler.GetSchedulesByTechnician)
 	schedules.Get("/status/:status", scheduleHandler.GetSchedulesByStatus)
 
-	// Invoice management routes (admin and technician)
-	invoices := protected.Group("/invoices", middleware.RequireAdminOrTechnician())
-	invoices.Post("/", invoiceHandler.CreateInvoice)
+	// Invoice management routes
+	invoices := protected.Group("/invoices", middleware.RequirePermission(domain.PermInvoicesRead))
+	invoices.Post("/", middleware.RequirePermission(domain.PermInvoicesWrite), invoiceHandler.CreateInvoice)
 	invoices.Get("/", invoiceHandler.ListInvoices)
+	invoices.Get("/export", invoiceHandler.ExportInvoices)
 	invoices.Get("/:id", invoiceHandler.GetInvoice)
-	invoices.Put("/:id", invoiceHandler.UpdateInvoice)
-	invoices.Delete("/:id", invoiceHandler.DeleteInvoice)
+	invoices.Put("/:id", middleware.RequirePermission(domain.PermInvoicesWrite), invoiceHandler.UpdateInvoice)
+	invoices.Delete("/:id", middleware.RequirePermission(domain.PermInvoicesWrite), invoiceHandler.DeleteInvoice)
+	invoices.Post("/:id/payments", middleware.RequirePermission(domain.PermPaymentsWrite), paymentHandler.RecordPayment)
+	invoices.Get("/:id/payments", middleware.RequirePermission(domain.PermPaymentsRead), paymentHandler.GetInvoicePayments)
 	invoices.Get("/search", invoiceHandler.SearchInvoices)
 	invoices.Get("/customer/:customer_id", invoiceHandler.GetInvoicesByCustomer)
 	invoices.Get("/schedule/:schedule_id", invoiceHandler.GetInvoicesBySchedule)
 	invoices.Get("/status/:status", invoiceHandler.GetInvoicesByStatus)
 
-	// Invoice detail management routes (admin and technician)
-	invoiceDetails := protected.Group("/invoice-details", middleware.RequireAdminOrTechnician())
-	invoiceDetails.Post("/", invoiceDetailHandler.CreateInvoiceDetail)
+	// Invoice detail management routes, covered by the invoice permissions
+	invoiceDetails := protected.Group("/invoice-details", middleware.RequirePermission(domain.PermInvoicesRead))
+	invoiceDetails.Post("/", middleware.RequirePermission(domain.PermInvoicesWrite), invoiceDetailHandler.CreateInvoiceDetail)
 	invoiceDetails.Get("/:id", invoiceDetailHandler.GetInvoiceDetail)
-	invoiceDetails.Put("/:id", invoiceDetailHandler.UpdateInvoiceDetail)
-	invoiceDetails.Delete("/:id", invoiceDetailHandler.DeleteInvoiceDetail)
+	invoiceDetails.Put("/:id", middleware.RequirePermission(domain.PermInvoicesWrite), invoiceDetailHandler.UpdateInvoiceDetail)
+	invoiceDetails.Delete("/:id", middleware.RequirePermission(domain.PermInvoicesWrite), invoiceDetailHandler.DeleteInvoiceDetail)
 	invoiceDetails.Get("/invoice/:invoice_id", invoiceDetailHandler.GetInvoiceDetailsByInvoice)
-	invoiceDetails.Delete("/invoice/:invoice_id", invoiceDetailHandler.DeleteInvoiceDetailsByInvoice)
+	invoiceDetails.Delete("/invoice/:invoice_id", middleware.RequirePermission(domain.PermInvoicesWrite), invoiceDetailHandler.DeleteInvoiceDetailsByInvoice)
+
+	// Customer self-service portal (customer accounts linked to a customer record)
+	portal := protected.Group("/portal", middleware.RequireCustomerAccount())
+	portal.Get("/schedules", portalHandler.ListMySchedules)
+	portal.Post("/schedules", portalHandler.RequestBooking)
+	portal.Post("/schedules/:id/cancel", portalHandler.CancelBooking)
+	portal.Get("/invoices", portalHandler.ListMyInvoices)
+	portal.Get("/profile", portalHandler.GetMyProfile)
+	portal.Put("/profile", portalHandler.UpdateMyProfile)
 }
\ No newline at end of file
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+	"dashboard-ac-backend/pkg/logger"
+)
+
+// AuditEntry describes one mutation to record. Before and After are the entity as it
+// was and as it is now; leave Before nil for creates and After nil for deletes. The
+// actor, IP address and request ID default to the audit actor of the context.
+type AuditEntry struct {
+	ActorID    *uint
+	ActorEmail string
+	Action     domain.AuditAction
+	EntityType string
+	EntityID   string
+	Before     interface{}
+	After      interface{}
+	IPAddress  string
+	RequestID  string
+}
+
+type AuditService interface {
+	Record(ctx context.Context, entry AuditEntry)
+	List(ctx context.Context, req *request.AuditLogListRequest) ([]*domain.AuditLog, int64, error)
+}
+
+type auditService struct {
+	auditLogRepo repository.AuditLogRepository
+}
+
+func NewAuditService(auditLogRepo repository.AuditLogRepository) AuditService {
+	return &auditService{auditLogRepo: auditLogRepo}
+}
+
+// Record stores the fields that changed between Before and After. Updates that changed
+// nothing are not recorded. Failures are logged rather than returned, so a broken audit
+// log never undoes a change that has already been made.
+func (s *auditService) Record(ctx context.Context, entry AuditEntry) {
+	actor := domain.AuditActorFromContext(ctx)
+	if entry.ActorID == nil && entry.ActorEmail == "" {
+		entry.ActorID, entry.ActorEmail = actor.UserID, actor.Email
+	}
+	if entry.IPAddress == "" {
+		entry.IPAddress = actor.IPAddress
+	}
+	if entry.RequestID == "" {
+		entry.RequestID = actor.RequestID
+	}
+
+	before, err := auditSnapshot(entry.Before)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("entity_type", entry.EntityType).Msg("Failed to snapshot entity for audit log")
+		return
+	}
+	after, err := auditSnapshot(entry.After)
+	if err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("entity_type", entry.EntityType).Msg("Failed to snapshot entity for audit log")
+		return
+	}
+
+	if before != nil && after != nil {
+		before, after = auditDiff(before, after)
+		if len(before) == 0 && len(after) == 0 {
+			return
+		}
+	}
+
+	auditLog := &domain.AuditLog{
+		ActorID:    entry.ActorID,
+		ActorEmail: entry.ActorEmail,
+		Action:     entry.Action,
+		EntityType: entry.EntityType,
+		EntityID:   entry.EntityID,
+		Before:     before,
+		After:      after,
+		IPAddress:  entry.IPAddress,
+		RequestID:  entry.RequestID,
+	}
+	if err := s.auditLogRepo.Create(ctx, auditLog); err != nil {
+		logger.FromContext(ctx).Error().Err(err).
+			Str("entity_type", entry.EntityType).
+			Str("entity_id", entry.EntityID).
+			Str("action", string(entry.Action)).
+			Msg("Failed to write audit log")
+	}
+}
+
+// Helper functions for services to describe the changes they make
+
+func auditCreate(entityType, entityID string, after interface{}) AuditEntry {
+	return AuditEntry{Action: domain.AuditActionCreate, EntityType: entityType, EntityID: entityID, After: after}
+}
+
+func auditUpdate(entityType, entityID string, before, after interface{}) AuditEntry {
+	return AuditEntry{Action: domain.AuditActionUpdate, EntityType: entityType, EntityID: entityID, Before: before, After: after}
+}
+
+func auditDelete(entityType, entityID string, before interface{}) AuditEntry {
+	return AuditEntry{Action: domain.AuditActionDelete, EntityType: entityType, EntityID: entityID, Before: before}
+}
+
+func (s *auditService) List(ctx context.Context, req *request.AuditLogListRequest) ([]*domain.AuditLog, int64, error) {
+	filter := repository.AuditLogFilter{
+		EntityType: req.EntityType,
+		EntityID:   req.EntityID,
+	}
+	if req.ActorID != 0 {
+		filter.ActorID = &req.ActorID
+	}
+	if req.DateFrom != "" {
+		if parsed, err := time.Parse("2006-01-02", req.DateFrom); err == nil {
+			filter.From = &parsed
+		}
+	}
+	if req.DateTo != "" {
+		if parsed, err := time.Parse("2006-01-02", req.DateTo); err == nil {
+			// Include the whole last day
+			end := parsed.AddDate(0, 0, 1)
+			filter.To = &end
+		}
+	}
+
+	return s.auditLogRepo.List(ctx, filter, req.GetOffset(), req.GetLimit())
+}
+
+// auditSnapshot turns an entity into its JSON fields. Nested objects, such as preloaded
+// relations, are left out: they are audited as entities of their own.
+func auditSnapshot(entity interface{}) (domain.AuditChanges, error) {
+	if entity == nil || reflect.ValueOf(entity).Kind() == reflect.Ptr && reflect.ValueOf(entity).IsNil() {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields domain.AuditChanges
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	for key, value := range fields {
+		if isNestedObject(value) {
+			delete(fields, key)
+		}
+	}
+	return fields, nil
+}
+
+func isNestedObject(value interface{}) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return true
+	case []interface{}:
+		return len(v) > 0 && isNestedObject(v[0])
+	}
+	return false
+}
+
+// auditDiff keeps only the fields whose value changed. updated_at is ignored because it
+// changes on every save.
+func auditDiff(before, after domain.AuditChanges) (domain.AuditChanges, domain.AuditChanges) {
+	changedBefore := domain.AuditChanges{}
+	changedAfter := domain.AuditChanges{}
+
+	for key, value := range after {
+		if key == "updated_at" {
+			continue
+		}
+		if previous, ok := before[key]; !ok || !reflect.DeepEqual(previous, value) {
+			if ok {
+				changedBefore[key] = previous
+			}
+			changedAfter[key] = value
+		}
+	}
+	for key, previous := range before {
+		if _, ok := after[key]; !ok && key != "updated_at" {
+			changedBefore[key] = previous
+		}
+	}
+
+	return changedBefore, changedAfter
+}
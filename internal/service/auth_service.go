@@ -1,48 +1,150 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/apperror"
 	"dashboard-ac-backend/internal/domain"
 	"dashboard-ac-backend/internal/repository"
 	"dashboard-ac-backend/pkg/hash"
 	"dashboard-ac-backend/pkg/jwt"
+	"dashboard-ac-backend/pkg/logger"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type AuthService interface {
-	Register(req *request.RegisterRequest) (*domain.User, error)
-	Login(req *request.LoginRequest) (*jwt.TokenPair, *domain.User, error)
-	RefreshToken(refreshToken string) (*jwt.TokenPair, error)
+	Register(ctx context.Context, req *request.RegisterRequest) (*domain.User, error)
+	Login(ctx context.Context, req *request.LoginRequest, client ClientInfo) (*jwt.TokenPair, *domain.User, error)
+	RefreshToken(ctx context.Context, refreshToken string, client ClientInfo) (*jwt.TokenPair, error)
+	Logout(ctx context.Context, refreshToken string) error
+	LogoutAll(ctx context.Context, userID uint) (int64, error)
 	ValidateToken(tokenString string) (*jwt.Claims, error)
+	TokenState(ctx context.Context, userID uint) (tokenVersion int, active bool, err error)
 }
 
+// ClientInfo describes the device a refresh token is issued to
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// LoginPolicy configures brute-force protection for Login. Zero values disable the
+// corresponding check.
+type LoginPolicy struct {
+	MaxFailures     int           // failed attempts before the account is locked
+	LockoutDuration time.Duration // how long a locked account stays locked
+	BaseDelay       time.Duration // wait after the first failure, doubled after each further one
+	IPMaxFailures   int           // failed attempts from one address within IPWindow before it is throttled
+	IPWindow        time.Duration
+}
+
+// retryAfter returns how long the user still has to wait before the next attempt
+func (p LoginPolicy) retryAfter(user *domain.User, now time.Time) time.Duration {
+	if p.BaseDelay <= 0 || user.FailedLoginCount == 0 || user.LastFailedLoginAt == nil {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := 1; i < user.FailedLoginCount && (p.LockoutDuration <= 0 || delay < p.LockoutDuration); i++ {
+		delay *= 2
+	}
+	if p.LockoutDuration > 0 && delay > p.LockoutDuration {
+		delay = p.LockoutDuration
+	}
+
+	return user.LastFailedLoginAt.Add(delay).Sub(now)
+}
+
+// LoginThrottledError is returned when login attempts come in faster than allowed
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return "too many login attempts, please try again later"
+}
+
+func (e *LoginThrottledError) AppError() *apperror.Error {
+	return apperror.TooManyRequests("login_throttled", e.Error(), e.RetryAfter)
+}
+
+// AccountLockedError is returned for accounts locked after too many failed logins
+type AccountLockedError struct {
+	Until time.Time
+}
+
+func (e *AccountLockedError) Error() string {
+	return "account is temporarily locked after too many failed login attempts"
+}
+
+func (e *AccountLockedError) AppError() *apperror.Error {
+	return apperror.Locked("account_locked", e.Error()).WithDetails(map[string]interface{}{
+		"locked_until": e.Until,
+	})
+}
+
+// ErrRefreshTokenReused is returned when an already rotated refresh token is presented
+// again. The whole token family is revoked, so the holder has to log in again.
+var ErrRefreshTokenReused = apperror.Unauthorized("refresh_token_reused", "refresh token reuse detected, please log in again")
+
+var (
+	ErrInvalidCredentials  = apperror.Unauthorized("invalid_credentials", "invalid email or password")
+	ErrAccountDeactivated  = apperror.Unauthorized("account_deactivated", "user account is deactivated")
+	ErrInvalidRefreshToken = apperror.Unauthorized("invalid_refresh_token", "invalid refresh token")
+	ErrInvalidTokenType    = apperror.Unauthorized("invalid_token_type", "invalid token type")
+
+	ErrPhoneRequired = apperror.Validation("phone_required", "phone is required for customer registration",
+		apperror.FieldError{Field: "phone", Message: "phone is required for customer registration"})
+	ErrAddressRequired = apperror.Validation("address_required", "address is required for customer registration",
+		apperror.FieldError{Field: "address", Message: "address is required for customer registration"})
+)
+
 type authService struct {
-	userRepo     repository.UserRepository
-	customerRepo repository.CustomerRepository
-	db           *gorm.DB
-	jwtSecret    string
+	userRepo         repository.UserRepository
+	customerRepo     repository.CustomerRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	loginAttemptRepo repository.LoginAttemptRepository
+	db               *gorm.DB
+	jwtSecret        string
+	loginPolicy      LoginPolicy
+	auditService     AuditService
 }
 
-func NewAuthService(userRepo repository.UserRepository, customerRepo repository.CustomerRepository, db *gorm.DB, jwtSecret string) AuthService {
+func NewAuthService(
+	userRepo repository.UserRepository,
+	customerRepo repository.CustomerRepository,
+	refreshTokenRepo repository.RefreshTokenRepository,
+	loginAttemptRepo repository.LoginAttemptRepository,
+	db *gorm.DB,
+	jwtSecret string,
+	loginPolicy LoginPolicy,
+	auditService AuditService,
+) AuthService {
 	return &authService{
-		userRepo:     userRepo,
-		customerRepo: customerRepo,
-		db:           db,
-		jwtSecret:    jwtSecret,
+		userRepo:         userRepo,
+		customerRepo:     customerRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		loginAttemptRepo: loginAttemptRepo,
+		db:               db,
+		jwtSecret:        jwtSecret,
+		loginPolicy:      loginPolicy,
+		auditService:     auditService,
 	}
 }
 
-func (s *authService) Register(req *request.RegisterRequest) (*domain.User, error) {
+func (s *authService) Register(ctx context.Context, req *request.RegisterRequest) (*domain.User, error) {
 	// Check if user already exists
-	existingUser, err := s.userRepo.GetByEmail(req.Email)
+	existingUser, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, err
 	}
 	if existingUser != nil {
-		return nil, errors.New("user with this email already exists")
+		return nil, ErrEmailTaken
 	}
 
 	// Hash password
@@ -60,10 +162,10 @@ func (s *authService) Register(req *request.RegisterRequest) (*domain.User, erro
 	// Validate customer fields if role is customer
 	if role == domain.RoleCustomer {
 		if req.Phone == "" {
-			return nil, errors.New("phone is required for customer registration")
+			return nil, ErrPhoneRequired
 		}
 		if req.Address == "" {
-			return nil, errors.New("address is required for customer registration")
+			return nil, ErrAddressRequired
 		}
 	}
 
@@ -89,14 +191,10 @@ func (s *authService) Register(req *request.RegisterRequest) (*domain.User, erro
 		IsActive: true,
 	}
 
-	if err := tx.Create(user).Error; err != nil {
-		tx.Rollback()
-		return nil, err
-	}
-
-	// If role is customer, also create customer record
+	// If role is customer, also create customer record and link the user to it
+	var customer *domain.Customer
 	if role == domain.RoleCustomer {
-		customer := &domain.Customer{
+		customer = &domain.Customer{
 			Name:    req.Name,
 			Phone:   req.Phone,
 			Address: req.Address,
@@ -107,6 +205,13 @@ func (s *authService) Register(req *request.RegisterRequest) (*domain.User, erro
 			tx.Rollback()
 			return nil, err
 		}
+
+		user.CustomerID = &customer.ID
+	}
+
+	if err := tx.Create(user).Error; err != nil {
+		tx.Rollback()
+		return nil, err
 	}
 
 	// Commit transaction
@@ -114,59 +219,124 @@ func (s *authService) Register(req *request.RegisterRequest) (*domain.User, erro
 		return nil, err
 	}
 
+	if customer != nil {
+		s.auditService.Record(ctx, auditCreate(domain.AuditEntityCustomer, customer.ID.String(), customer))
+	}
+	s.auditService.Record(ctx, auditCreate(domain.AuditEntityUser, userEntityID(user.ID), user))
+
 	return user, nil
 }
 
-func (s *authService) Login(req *request.LoginRequest) (*jwt.TokenPair, *domain.User, error) {
+// Login authenticates a user by email and password. Addresses that keep failing are
+// throttled, each failure delays the next attempt on the account a little longer, and
+// the account is locked for a while once the policy's failure limit is reached.
+func (s *authService) Login(ctx context.Context, req *request.LoginRequest, client ClientInfo) (*jwt.TokenPair, *domain.User, error) {
+	now := time.Now()
+
+	// Throttle addresses that keep failing, whichever accounts they try
+	if s.loginPolicy.IPMaxFailures > 0 {
+		failures, oldest, err := s.loginAttemptRepo.CountFailuresByIP(ctx, client.IPAddress, now.Add(-s.loginPolicy.IPWindow))
+		if err != nil {
+			return nil, nil, err
+		}
+		if failures >= int64(s.loginPolicy.IPMaxFailures) {
+			s.recordLoginAttempt(ctx, req.Email, nil, client, domain.LoginFailureThrottled)
+			// The address may try again once its oldest failure leaves the window
+			return nil, nil, &LoginThrottledError{RetryAfter: oldest.Add(s.loginPolicy.IPWindow).Sub(now)}
+		}
+	}
+
 	// Get user by email
-	user, err := s.userRepo.GetByEmail(req.Email)
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, nil, errors.New("invalid email or password")
+			s.recordLoginAttempt(ctx, req.Email, nil, client, domain.LoginFailureInvalidCredentials)
+			return nil, nil, ErrInvalidCredentials
 		}
 		return nil, nil, err
 	}
 
+	if user.IsLocked(now) {
+		s.recordLoginAttempt(ctx, req.Email, &user.ID, client, domain.LoginFailureAccountLocked)
+		return nil, nil, &AccountLockedError{Until: *user.LockedUntil}
+	}
+	if wait := s.loginPolicy.retryAfter(user, now); wait > 0 {
+		s.recordLoginAttempt(ctx, req.Email, &user.ID, client, domain.LoginFailureThrottled)
+		return nil, nil, &LoginThrottledError{RetryAfter: wait}
+	}
+
 	// Check if user is active
 	if !user.IsActive {
-		return nil, nil, errors.New("user account is deactivated")
+		s.recordLoginAttempt(ctx, req.Email, &user.ID, client, domain.LoginFailureAccountInactive)
+		return nil, nil, ErrAccountDeactivated
 	}
 
 	// Verify password
 	if err := hash.CheckPassword(user.Password, req.Password); err != nil {
-		return nil, nil, errors.New("invalid email or password")
+		s.recordLoginAttempt(ctx, req.Email, &user.ID, client, domain.LoginFailureInvalidCredentials)
+
+		updated, err := s.userRepo.RegisterLoginFailure(ctx, user.ID, s.loginPolicy.MaxFailures, s.loginPolicy.LockoutDuration)
+		if err != nil {
+			return nil, nil, err
+		}
+		if updated.IsLocked(now) {
+			return nil, nil, &AccountLockedError{Until: *updated.LockedUntil}
+		}
+		return nil, nil, ErrInvalidCredentials
+	}
+
+	if user.FailedLoginCount > 0 || user.LockedUntil != nil {
+		if err := s.userRepo.ResetLoginFailures(ctx, user.ID); err != nil {
+			return nil, nil, err
+		}
 	}
+	s.recordLoginAttempt(ctx, req.Email, &user.ID, client, "")
 
-	// Generate token pair
+	// Generate token pair; a login starts a new refresh token family
 	tokenPair, err := jwt.GenerateTokenPair(user, s.jwtSecret)
 	if err != nil {
 		return nil, nil, err
 	}
 
+	refreshToken, err := newRefreshToken(user, tokenPair, uuid.New(), client)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.refreshTokenRepo.Create(ctx, refreshToken); err != nil {
+		return nil, nil, err
+	}
+
 	return tokenPair, user, nil
 }
 
-func (s *authService) RefreshToken(refreshToken string) (*jwt.TokenPair, error) {
-	// Validate refresh token
-	claims, err := jwt.ValidateToken(refreshToken, s.jwtSecret)
+// RefreshToken rotates a refresh token: the presented token is revoked and a new pair
+// is issued in the same family. Presenting a token that was already rotated means it
+// leaked, so the whole family is revoked.
+func (s *authService) RefreshToken(ctx context.Context, refreshToken string, client ClientInfo) (*jwt.TokenPair, error) {
+	stored, err := s.lookupRefreshToken(ctx, refreshToken)
 	if err != nil {
-		return nil, errors.New("invalid refresh token")
+		return nil, err
 	}
 
-	// Check if it's a refresh token
-	if claims.Subject != "refresh" {
-		return nil, errors.New("invalid token type")
+	if stored.IsRevoked() {
+		return nil, s.revokeReusedFamily(ctx, stored)
+	}
+	if stored.IsExpired(time.Now()) {
+		return nil, ErrInvalidRefreshToken
 	}
 
 	// Get user from database
-	user, err := s.userRepo.GetByID(claims.UserID)
+	user, err := s.userRepo.GetByID(ctx, stored.UserID)
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
 		return nil, err
 	}
 
 	// Check if user is still active
 	if !user.IsActive {
-		return nil, errors.New("user account is deactivated")
+		return nil, ErrAccountDeactivated
 	}
 
 	// Generate new token pair
@@ -175,9 +345,135 @@ func (s *authService) RefreshToken(refreshToken string) (*jwt.TokenPair, error)
 		return nil, err
 	}
 
+	next, err := newRefreshToken(user, tokenPair, stored.FamilyID, client)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.refreshTokenRepo.Rotate(ctx, stored.ID, next); err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenReused) {
+			return nil, s.revokeReusedFamily(ctx, stored)
+		}
+		return nil, err
+	}
+
 	return tokenPair, nil
 }
 
+// Logout revokes the refresh token family the given token belongs to
+func (s *authService) Logout(ctx context.Context, refreshToken string) error {
+	stored, err := s.lookupRefreshToken(ctx, refreshToken)
+	if err != nil {
+		return err
+	}
+
+	return s.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID)
+}
+
+// LogoutAll revokes every refresh token and access token of the user and returns how
+// many refresh tokens were active
+func (s *authService) LogoutAll(ctx context.Context, userID uint) (int64, error) {
+	if err := s.userRepo.IncrementTokenVersion(ctx, userID); err != nil {
+		return 0, err
+	}
+	return s.refreshTokenRepo.RevokeAllForUser(ctx, userID)
+}
+
 func (s *authService) ValidateToken(tokenString string) (*jwt.Claims, error) {
 	return jwt.ValidateToken(tokenString, s.jwtSecret)
-}
\ No newline at end of file
+}
+
+// TokenState returns the current token version of a user and whether they may still
+// authenticate. Deleted users are reported as inactive.
+func (s *authService) TokenState(ctx context.Context, userID uint) (int, bool, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, false, nil
+		}
+		return 0, false, err
+	}
+
+	return user.TokenVersion, user.IsActive, nil
+}
+
+// Helper function to resolve a signed refresh token to its server-side record
+func (s *authService) lookupRefreshToken(ctx context.Context, refreshToken string) (*domain.RefreshToken, error) {
+	claims, err := jwt.ValidateToken(refreshToken, s.jwtSecret)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// Check if it's a refresh token
+	if claims.Subject != "refresh" {
+		return nil, ErrInvalidTokenType
+	}
+
+	stored, err := s.refreshTokenRepo.GetByID(ctx, claims.ID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRefreshToken
+		}
+		return nil, err
+	}
+
+	if stored.TokenHash != hash.HashToken(refreshToken) || stored.UserID != claims.UserID {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return stored, nil
+}
+
+// Helper function to record a login attempt; an empty reason marks a successful login.
+// Failing to record does not fail the login.
+func (s *authService) recordLoginAttempt(ctx context.Context, email string, userID *uint, client ClientInfo, reason domain.LoginFailureReason) {
+	attempt := &domain.LoginAttempt{
+		UserID:        userID,
+		Email:         truncate(email, 255),
+		IPAddress:     truncate(client.IPAddress, 45),
+		UserAgent:     truncate(client.UserAgent, 255),
+		Success:       reason == "",
+		FailureReason: reason,
+	}
+
+	if err := s.loginAttemptRepo.Create(ctx, attempt); err != nil {
+		logger.FromContext(ctx).Error().Err(err).Str("email", email).Msg("Failed to record login attempt")
+	}
+}
+
+// Helper function to revoke the family of a refresh token that was presented twice
+func (s *authService) revokeReusedFamily(ctx context.Context, stored *domain.RefreshToken) error {
+	logger.FromContext(ctx).Warn().
+		Uint("user_id", stored.UserID).
+		Str("family_id", stored.FamilyID.String()).
+		Msg("Refresh token reuse detected, revoking token family")
+
+	if err := s.refreshTokenRepo.RevokeFamily(ctx, stored.FamilyID); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// Helper function to build the server-side record of a newly issued refresh token
+func newRefreshToken(user *domain.User, tokenPair *jwt.TokenPair, familyID uuid.UUID, client ClientInfo) (*domain.RefreshToken, error) {
+	id, err := uuid.Parse(tokenPair.RefreshTokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.RefreshToken{
+		ID:        id,
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hash.HashToken(tokenPair.RefreshToken),
+		UserAgent: truncate(client.UserAgent, 255),
+		IPAddress: truncate(client.IPAddress, 45),
+		ExpiresAt: tokenPair.RefreshExpiresAt,
+	}, nil
+}
+
+func truncate(value string, limit int) string {
+	if len(value) > limit {
+		return value[:limit]
+	}
+	return value
+}
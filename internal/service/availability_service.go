@@ -0,0 +1,277 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/apperror"
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultSlotInterval is the step in minutes between candidate start times
+const DefaultSlotInterval = 30
+
+var ErrInvalidDate = apperror.Validation("invalid_date", "invalid date format",
+	apperror.FieldError{Field: "date", Message: "date must be in YYYY-MM-DD format"})
+
+// TechnicianAvailability lists the open start times of a technician for one service on one day
+type TechnicianAvailability struct {
+	TechnicianID   uuid.UUID `json:"technician_id"`
+	TechnicianName string    `json:"technician_name"`
+	Date           string    `json:"date"`
+	ServiceID      uuid.UUID `json:"service_id"`
+	Duration       int       `json:"duration"` // in minutes
+	WorkStart      string    `json:"work_start"`
+	WorkEnd        string    `json:"work_end"`
+	Slots          []string  `json:"slots"` // HH:MM start times
+}
+
+type AvailabilityService interface {
+	GetTechnicianAvailability(ctx context.Context, technicianID string, req *request.AvailabilityRequest) (*TechnicianAvailability, error)
+	GetAvailability(ctx context.Context, req *request.AvailabilityRequest) ([]*TechnicianAvailability, error)
+	// IsAvailable reports whether the technician has a free slot for the service at start
+	IsAvailable(ctx context.Context, technicianID, serviceID string, start time.Time) (bool, error)
+	// AvailableTechnicians lists the technicians with a free slot for the service at start
+	AvailableTechnicians(ctx context.Context, serviceID string, start time.Time) ([]*domain.Technician, error)
+}
+
+type availabilityService struct {
+	technicianRepo repository.TechnicianRepository
+	scheduleRepo   repository.ScheduleRepository
+	serviceRepo    repository.ServiceRepository
+}
+
+func NewAvailabilityService(
+	technicianRepo repository.TechnicianRepository,
+	scheduleRepo repository.ScheduleRepository,
+	serviceRepo repository.ServiceRepository,
+) AvailabilityService {
+	return &availabilityService{
+		technicianRepo: technicianRepo,
+		scheduleRepo:   scheduleRepo,
+		serviceRepo:    serviceRepo,
+	}
+}
+
+func (s *availabilityService) GetTechnicianAvailability(ctx context.Context, technicianID string, req *request.AvailabilityRequest) (*TechnicianAvailability, error) {
+	date, service, err := s.parseRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	technician, err := s.technicianRepo.GetByID(ctx, technicianID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTechnicianNotFound
+		}
+		return nil, err
+	}
+
+	return s.computeAvailability(ctx, technician, service, date, req.Interval)
+}
+
+func (s *availabilityService) GetAvailability(ctx context.Context, req *request.AvailabilityRequest) ([]*TechnicianAvailability, error) {
+	date, service, err := s.parseRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	technicians, err := s.technicianRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	availability := make([]*TechnicianAvailability, 0, len(technicians))
+	for _, technician := range technicians {
+		result, err := s.computeAvailability(ctx, technician, service, date, req.Interval)
+		if err != nil {
+			return nil, err
+		}
+		availability = append(availability, result)
+	}
+
+	return availability, nil
+}
+
+func (s *availabilityService) IsAvailable(ctx context.Context, technicianID, serviceID string, start time.Time) (bool, error) {
+	service, err := s.getService(ctx, serviceID)
+	if err != nil {
+		return false, err
+	}
+
+	technician, err := s.technicianRepo.GetByID(ctx, technicianID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, ErrTechnicianNotFound
+		}
+		return false, err
+	}
+
+	return s.isFreeAt(ctx, technician, service, start)
+}
+
+func (s *availabilityService) AvailableTechnicians(ctx context.Context, serviceID string, start time.Time) ([]*domain.Technician, error) {
+	service, err := s.getService(ctx, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	technicians, err := s.technicianRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	available := make([]*domain.Technician, 0, len(technicians))
+	for _, technician := range technicians {
+		free, err := s.isFreeAt(ctx, technician, service, start)
+		if err != nil {
+			return nil, err
+		}
+		if free {
+			available = append(available, technician)
+		}
+	}
+
+	return available, nil
+}
+
+// Helper function to parse the requested day and load the service being booked
+func (s *availabilityService) parseRequest(ctx context.Context, req *request.AvailabilityRequest) (time.Time, *domain.Service, error) {
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return time.Time{}, nil, ErrInvalidDate
+	}
+
+	service, err := s.getService(ctx, req.ServiceID)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	return date, service, nil
+}
+
+// Helper function to load the service being booked
+func (s *availabilityService) getService(ctx context.Context, serviceID string) (*domain.Service, error) {
+	if _, err := uuid.Parse(serviceID); err != nil {
+		return nil, ErrInvalidServiceID
+	}
+
+	service, err := s.serviceRepo.GetByID(ctx, serviceID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrServiceNotFound
+		}
+		return nil, err
+	}
+
+	return service, nil
+}
+
+// Helper function to check that a job of the service starting at start fits inside
+// the technician's working hours without touching the break or another booking
+func (s *availabilityService) isFreeAt(ctx context.Context, technician *domain.Technician, service *domain.Service, start time.Time) (bool, error) {
+	end := start.Add(time.Duration(service.Duration) * time.Minute)
+
+	workStart, workEnd, err := technician.WorkingHours(start)
+	if err != nil {
+		return false, err
+	}
+	if start.Before(workStart) || end.After(workEnd) {
+		return false, nil
+	}
+
+	breakStart, breakEnd, hasBreak, err := technician.BreakWindow(start)
+	if err != nil {
+		return false, err
+	}
+	if hasBreak && (domain.ScheduleWindow{Start: breakStart, End: breakEnd}).Overlaps(start, end) {
+		return false, nil
+	}
+
+	busy, err := s.scheduleRepo.GetTechnicianWindows(ctx, technician.ID.String(), start, end, "")
+	if err != nil {
+		return false, err
+	}
+
+	return len(busy) == 0, nil
+}
+
+// Helper function to compute the free slots of one technician
+func (s *availabilityService) computeAvailability(ctx context.Context, technician *domain.Technician, service *domain.Service, date time.Time, interval int) (*TechnicianAvailability, error) {
+	if interval <= 0 {
+		interval = DefaultSlotInterval
+	}
+
+	workStart, workEnd, err := technician.WorkingHours(date)
+	if err != nil {
+		return nil, err
+	}
+
+	busy, err := s.scheduleRepo.GetTechnicianWindows(ctx, technician.ID.String(), workStart, workEnd, "")
+	if err != nil {
+		return nil, err
+	}
+
+	breakStart, breakEnd, hasBreak, err := technician.BreakWindow(date)
+	if err != nil {
+		return nil, err
+	}
+	if hasBreak {
+		busy = append(busy, domain.ScheduleWindow{Start: breakStart, End: breakEnd})
+	}
+
+	starts := ComputeFreeSlots(workStart, workEnd, busy,
+		time.Duration(service.Duration)*time.Minute,
+		time.Duration(interval)*time.Minute,
+	)
+
+	slots := make([]string, 0, len(starts))
+	for _, start := range starts {
+		slots = append(slots, start.Format(domain.ClockLayout))
+	}
+
+	return &TechnicianAvailability{
+		TechnicianID:   technician.ID,
+		TechnicianName: technician.Name,
+		Date:           date.Format("2006-01-02"),
+		ServiceID:      service.ID,
+		Duration:       service.Duration,
+		WorkStart:      workStart.Format(domain.ClockLayout),
+		WorkEnd:        workEnd.Format(domain.ClockLayout),
+		Slots:          slots,
+	}, nil
+}
+
+// ComputeFreeSlots returns every start time between open and close, stepping by step,
+// at which a job of the given duration fits entirely before close without touching
+// any busy window
+func ComputeFreeSlots(open, close time.Time, busy []domain.ScheduleWindow, duration, step time.Duration) []time.Time {
+	slots := []time.Time{}
+	if duration <= 0 || step <= 0 {
+		return slots
+	}
+
+	for start := open; !start.Add(duration).After(close); start = start.Add(step) {
+		end := start.Add(duration)
+
+		free := true
+		for _, window := range busy {
+			if window.Overlaps(start, end) {
+				free = false
+				break
+			}
+		}
+
+		if free {
+			slots = append(slots, start)
+		}
+	}
+
+	return slots
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"errors"
 
 	"dashboard-ac-backend/internal/api/request"
@@ -11,25 +12,28 @@ import (
 )
 
 type CustomerService interface {
-	Create(req *request.CustomerCreateRequest) (*domain.Customer, error)
-	GetByID(id string) (*domain.Customer, error)
-	Update(id string, req *request.CustomerUpdateRequest) (*domain.Customer, error)
-	Delete(id string) error
-	List(pagination *request.PaginationRequest) ([]*domain.Customer, int64, error)
-	Search(req *request.CustomerSearchRequest) ([]*domain.Customer, int64, error)
+	Create(ctx context.Context, req *request.CustomerCreateRequest) (*domain.Customer, error)
+	GetByID(ctx context.Context, id string) (*domain.Customer, error)
+	Update(ctx context.Context, id string, req *request.CustomerUpdateRequest) (*domain.Customer, error)
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, pagination *request.PaginationRequest) ([]*domain.Customer, repository.ListPage, error)
+	Search(ctx context.Context, req *request.CustomerSearchRequest) ([]*domain.Customer, repository.ListPage, error)
+	Export(ctx context.Context, req *request.CustomerSearchRequest) func() ([]*domain.Customer, error)
 }
 
 type customerService struct {
 	customerRepo repository.CustomerRepository
+	auditService AuditService
 }
 
-func NewCustomerService(customerRepo repository.CustomerRepository) CustomerService {
+func NewCustomerService(customerRepo repository.CustomerRepository, auditService AuditService) CustomerService {
 	return &customerService{
 		customerRepo: customerRepo,
+		auditService: auditService,
 	}
 }
 
-func (s *customerService) Create(req *request.CustomerCreateRequest) (*domain.Customer, error) {
+func (s *customerService) Create(ctx context.Context, req *request.CustomerCreateRequest) (*domain.Customer, error) {
 	customer := &domain.Customer{
 		Name:    req.Name,
 		Phone:   req.Phone,
@@ -37,18 +41,19 @@ func (s *customerService) Create(req *request.CustomerCreateRequest) (*domain.Cu
 		Email:   req.Email,
 	}
 
-	if err := s.customerRepo.Create(customer); err != nil {
+	if err := s.customerRepo.Create(ctx, customer); err != nil {
 		return nil, err
 	}
+	s.auditService.Record(ctx, auditCreate(domain.AuditEntityCustomer, customer.ID.String(), customer))
 
 	return customer, nil
 }
 
-func (s *customerService) GetByID(id string) (*domain.Customer, error) {
-	customer, err := s.customerRepo.GetByID(id)
+func (s *customerService) GetByID(ctx context.Context, id string) (*domain.Customer, error) {
+	customer, err := s.customerRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("customer not found")
+			return nil, ErrCustomerNotFound
 		}
 		return nil, err
 	}
@@ -56,15 +61,16 @@ func (s *customerService) GetByID(id string) (*domain.Customer, error) {
 	return customer, nil
 }
 
-func (s *customerService) Update(id string, req *request.CustomerUpdateRequest) (*domain.Customer, error) {
+func (s *customerService) Update(ctx context.Context, id string, req *request.CustomerUpdateRequest) (*domain.Customer, error) {
 	// Check if customer exists
-	customer, err := s.customerRepo.GetByID(id)
+	customer, err := s.customerRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("customer not found")
+			return nil, ErrCustomerNotFound
 		}
 		return nil, err
 	}
+	before := *customer
 
 	// Update fields if provided
 	if req.Name != nil && *req.Name != "" {
@@ -80,36 +86,45 @@ func (s *customerService) Update(id string, req *request.CustomerUpdateRequest)
 		customer.Email = *req.Email
 	}
 
-	if err := s.customerRepo.Update(customer); err != nil {
+	if err := s.customerRepo.Update(ctx, customer); err != nil {
 		return nil, err
 	}
+	s.auditService.Record(ctx, auditUpdate(domain.AuditEntityCustomer, id, &before, customer))
 
 	return customer, nil
 }
 
-func (s *customerService) Delete(id string) error {
+func (s *customerService) Delete(ctx context.Context, id string) error {
 	// Check if customer exists
-	_, err := s.customerRepo.GetByID(id)
+	customer, err := s.customerRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("customer not found")
+			return ErrCustomerNotFound
 		}
 		return err
 	}
 
-	return s.customerRepo.Delete(id)
-}
+	if err := s.customerRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.auditService.Record(ctx, auditDelete(domain.AuditEntityCustomer, id, customer))
 
-func (s *customerService) List(pagination *request.PaginationRequest) ([]*domain.Customer, int64, error) {
-	offset := pagination.GetOffset()
-	limit := pagination.GetLimit()
+	return nil
+}
 
-	return s.customerRepo.List(offset, limit)
+func (s *customerService) List(ctx context.Context, pagination *request.PaginationRequest) ([]*domain.Customer, repository.ListPage, error) {
+	return s.customerRepo.List(ctx, listOptions(pagination))
 }
 
-func (s *customerService) Search(req *request.CustomerSearchRequest) ([]*domain.Customer, int64, error) {
-	offset := req.GetOffset()
-	limit := req.GetLimit()
+func (s *customerService) Search(ctx context.Context, req *request.CustomerSearchRequest) ([]*domain.Customer, repository.ListPage, error) {
+	return s.customerRepo.Search(ctx, req.Name, req.Phone, req.Email, listOptions(req.PaginationRequest))
+}
 
-	return s.customerRepo.Search(req.Name, req.Phone, req.Email, offset, limit)
+// Export returns a function loading the customers matching req batch by batch
+func (s *customerService) Export(ctx context.Context, req *request.CustomerSearchRequest) func() ([]*domain.Customer, error) {
+	search := *req
+	return exportBatches(req.PaginationRequest, "", func(pagination *request.PaginationRequest) ([]*domain.Customer, repository.ListPage, error) {
+		search.PaginationRequest = pagination
+		return s.Search(ctx, &search)
+	})
 }
\ No newline at end of file
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/apperror"
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+)
+
+const (
+	// DefaultTopServices is how many services the summary ranks when not asked otherwise
+	DefaultTopServices = 5
+
+	// maxTimeseriesPoints bounds the length of a revenue time series
+	maxTimeseriesPoints = 366
+)
+
+var (
+	ErrInvalidDateRange = apperror.Validation("invalid_date_range", "date_from must not be after date_to",
+		apperror.FieldError{Field: "date_from", Message: "date_from must not be after date_to"})
+	ErrTimeseriesTooLong = apperror.Validation("timeseries_too_long", "the date range has too many periods for the interval",
+		apperror.FieldError{Field: "interval", Message: "use a longer interval or a shorter date range"})
+)
+
+// DashboardSummary holds the key figures of a date range
+type DashboardSummary struct {
+	DateFrom     string                          `json:"date_from"`
+	DateTo       string                          `json:"date_to"` // inclusive
+	Revenue      domain.RevenueSummary           `json:"revenue"`
+	JobsByStatus map[domain.ScheduleStatus]int64 `json:"jobs_by_status"`
+	TopServices  []domain.ServiceRevenue         `json:"top_services"`
+	Technicians  []domain.TechnicianUtilization  `json:"technician_utilization"`
+}
+
+// RevenueTimeseries is the revenue of a date range period by period
+type RevenueTimeseries struct {
+	DateFrom string                `json:"date_from"`
+	DateTo   string                `json:"date_to"` // inclusive
+	Interval domain.ReportInterval `json:"interval"`
+	Points   []domain.RevenuePoint `json:"points"`
+}
+
+type DashboardService interface {
+	Summary(ctx context.Context, req *request.DashboardSummaryRequest) (*DashboardSummary, error)
+	RevenueTimeseries(ctx context.Context, req *request.RevenueTimeseriesRequest) (*RevenueTimeseries, error)
+}
+
+type dashboardService struct {
+	reportRepo repository.ReportRepository
+}
+
+func NewDashboardService(reportRepo repository.ReportRepository) DashboardService {
+	return &dashboardService{reportRepo: reportRepo}
+}
+
+// Summary reports revenue, jobs by status, the top services and technician utilization.
+// The range defaults to the current month up to today.
+func (s *dashboardService) Summary(ctx context.Context, req *request.DashboardSummaryRequest) (*DashboardSummary, error) {
+	today := startOfDay(time.Now())
+	from, to, err := dateRange(req.DateFrom, req.DateTo, today, func(to time.Time) time.Time {
+		return domain.ReportIntervalMonth.PeriodStart(to)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	top := req.Top
+	if top <= 0 {
+		top = DefaultTopServices
+	}
+
+	// to is exclusive in the repository
+	end := to.AddDate(0, 0, 1)
+	summary := &DashboardSummary{
+		DateFrom: from.Format("2006-01-02"),
+		DateTo:   to.Format("2006-01-02"),
+	}
+	if summary.Revenue, err = s.reportRepo.Revenue(ctx, from, end, today); err != nil {
+		return nil, err
+	}
+	if summary.JobsByStatus, err = s.reportRepo.JobCountsByStatus(ctx, from, end); err != nil {
+		return nil, err
+	}
+	if summary.TopServices, err = s.reportRepo.TopServices(ctx, from, end, top); err != nil {
+		return nil, err
+	}
+	if summary.Technicians, err = s.reportRepo.TechnicianUtilization(ctx, from, end); err != nil {
+		return nil, err
+	}
+
+	return summary, nil
+}
+
+// RevenueTimeseries reports invoiced and paid amounts per day, week or month. The range
+// defaults to the last 30 days, 12 weeks or 12 months up to today.
+func (s *dashboardService) RevenueTimeseries(ctx context.Context, req *request.RevenueTimeseriesRequest) (*RevenueTimeseries, error) {
+	interval := domain.ReportInterval(req.Interval)
+	if interval == "" {
+		interval = domain.ReportIntervalDay
+	}
+
+	today := startOfDay(time.Now())
+	from, to, err := dateRange(req.DateFrom, req.DateTo, today, func(to time.Time) time.Time {
+		switch interval {
+		case domain.ReportIntervalWeek:
+			return interval.PeriodStart(to).AddDate(0, 0, -7*11)
+		case domain.ReportIntervalMonth:
+			return interval.PeriodStart(to).AddDate(0, -11, 0)
+		}
+		return to.AddDate(0, 0, -29)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	periods := 0
+	for start := interval.PeriodStart(from); !start.After(to); start = interval.Next(start) {
+		if periods++; periods > maxTimeseriesPoints {
+			return nil, ErrTimeseriesTooLong
+		}
+	}
+
+	points, err := s.reportRepo.RevenueTimeseries(ctx, from, to.AddDate(0, 0, 1), interval)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RevenueTimeseries{
+		DateFrom: from.Format("2006-01-02"),
+		DateTo:   to.Format("2006-01-02"),
+		Interval: interval,
+		Points:   points,
+	}, nil
+}
+
+// Helper function to read an inclusive date range, defaulting to today and to the
+// start defaultFrom returns for the end of the range
+func dateRange(dateFrom, dateTo string, today time.Time, defaultFrom func(to time.Time) time.Time) (time.Time, time.Time, error) {
+	to := today
+	if dateTo != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", dateTo, today.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidDate
+		}
+		to = parsed
+	}
+
+	from := defaultFrom(to)
+	if dateFrom != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", dateFrom, today.Location())
+		if err != nil {
+			return time.Time{}, time.Time{}, ErrInvalidDate
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, ErrInvalidDateRange
+	}
+	return from, to, nil
+}
+
+// Helper function to truncate t to midnight in its location
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
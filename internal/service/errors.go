@@ -0,0 +1,31 @@
+package service
+
+import (
+	"dashboard-ac-backend/internal/apperror"
+)
+
+// Errors shared by several services. Errors specific to one service are declared next
+// to it.
+var (
+	ErrUserNotFound          = apperror.NotFound("user_not_found", "user not found")
+	ErrRoleNotFound          = apperror.NotFound("role_not_found", "role not found")
+	ErrCustomerNotFound      = apperror.NotFound("customer_not_found", "customer not found")
+	ErrTechnicianNotFound    = apperror.NotFound("technician_not_found", "technician not found")
+	ErrServiceNotFound       = apperror.NotFound("service_not_found", "service not found")
+	ErrScheduleNotFound      = apperror.NotFound("schedule_not_found", "schedule not found")
+	ErrInvoiceNotFound       = apperror.NotFound("invoice_not_found", "invoice not found")
+	ErrInvoiceDetailNotFound = apperror.NotFound("invoice_detail_not_found", "invoice detail not found")
+
+	ErrInvalidCustomerID   = invalidID("customer_id", "invalid customer ID format")
+	ErrInvalidTechnicianID = invalidID("technician_id", "invalid technician ID format")
+	ErrInvalidServiceID    = invalidID("service_id", "invalid service ID format")
+	ErrInvalidScheduleID   = invalidID("schedule_id", "invalid schedule ID format")
+	ErrInvalidInvoiceID    = invalidID("invoice_id", "invalid invoice ID format")
+
+	ErrEmailTaken = apperror.Conflict("email_taken", "user with this email already exists")
+)
+
+// invalidID reports a request field that must hold a UUID
+func invalidID(field, message string) *apperror.Error {
+	return apperror.Validation("invalid_"+field, message, apperror.FieldError{Field: field, Message: "must be a valid UUID"})
+}
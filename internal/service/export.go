@@ -0,0 +1,35 @@
+package service
+
+import (
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/repository"
+)
+
+// ExportBatchSize is how many records an export reads per query
+const ExportBatchSize = 100
+
+// exportBatches pages through a search by cursor so that an export never holds more
+// than one batch of it. Every call of the returned function loads the next batch,
+// with the related records in include; an empty batch means the search is exhausted.
+// The sort, filter and search of pagination apply, its page and limit do not.
+func exportBatches[T any](pagination *request.PaginationRequest, include string, search func(*request.PaginationRequest) ([]T, repository.ListPage, error)) func() ([]T, error) {
+	next := *pagination
+	next.UseCursor = true
+	next.Cursor = ""
+	next.Limit = ExportBatchSize
+	next.Include = include
+
+	done := false
+	return func() ([]T, error) {
+		if done {
+			return nil, nil
+		}
+		batch, page, err := search(&next)
+		if err != nil {
+			return nil, err
+		}
+		next.Cursor = page.NextCursor
+		done = page.NextCursor == ""
+		return batch, nil
+	}
+}
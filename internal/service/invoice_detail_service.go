@@ -1,9 +1,11 @@
 package service
 
 import (
+	"context"
 	"errors"
 
 	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/apperror"
 	"dashboard-ac-backend/internal/domain"
 	"dashboard-ac-backend/internal/repository"
 
@@ -11,65 +13,73 @@ import (
 	"gorm.io/gorm"
 )
 
+// ErrTotalBelowAmountPaid is returned when a line item change would bring the invoice
+// total below what has already been paid
+var ErrTotalBelowAmountPaid = apperror.Conflict("total_below_amount_paid", "invoice total cannot fall below the amount already paid")
+
+// InvoiceDetailService manages invoice line items. Line items share the scope of their
+// invoice; those of invoices outside it are reported as not found.
 type InvoiceDetailService interface {
-	Create(req *request.InvoiceDetailCreateRequest) (*domain.InvoiceDetail, error)
-	GetByID(id string) (*domain.InvoiceDetail, error)
-	Update(id string, req *request.InvoiceDetailUpdateRequest) (*domain.InvoiceDetail, error)
-	Delete(id string) error
-	GetByInvoiceID(invoiceID string) ([]*domain.InvoiceDetail, error)
-	DeleteByInvoiceID(invoiceID string) error
+	Create(ctx context.Context, scope domain.AccessScope, req *request.InvoiceDetailCreateRequest) (*domain.InvoiceDetail, error)
+	GetByID(ctx context.Context, scope domain.AccessScope, id string) (*domain.InvoiceDetail, error)
+	Update(ctx context.Context, scope domain.AccessScope, id string, req *request.InvoiceDetailUpdateRequest) (*domain.InvoiceDetail, error)
+	Delete(ctx context.Context, scope domain.AccessScope, id string) error
+	GetByInvoiceID(ctx context.Context, scope domain.AccessScope, invoiceID string) ([]*domain.InvoiceDetail, error)
+	DeleteByInvoiceID(ctx context.Context, scope domain.AccessScope, invoiceID string) error
 }
 
 type invoiceDetailService struct {
 	invoiceDetailRepo repository.InvoiceDetailRepository
 	invoiceRepo       repository.InvoiceRepository
+	scheduleRepo      repository.ScheduleRepository
 	serviceRepo       repository.ServiceRepository
+	auditService      AuditService
 }
 
 func NewInvoiceDetailService(
 	invoiceDetailRepo repository.InvoiceDetailRepository,
 	invoiceRepo repository.InvoiceRepository,
+	scheduleRepo repository.ScheduleRepository,
 	serviceRepo repository.ServiceRepository,
+	auditService AuditService,
 ) InvoiceDetailService {
 	return &invoiceDetailService{
 		invoiceDetailRepo: invoiceDetailRepo,
 		invoiceRepo:       invoiceRepo,
+		scheduleRepo:      scheduleRepo,
 		serviceRepo:       serviceRepo,
+		auditService:      auditService,
 	}
 }
 
-func (s *invoiceDetailService) Create(req *request.InvoiceDetailCreateRequest) (*domain.InvoiceDetail, error) {
+func (s *invoiceDetailService) Create(ctx context.Context, scope domain.AccessScope, req *request.InvoiceDetailCreateRequest) (*domain.InvoiceDetail, error) {
 	// Parse UUIDs
 	invoiceID, err := uuid.Parse(req.InvoiceID)
 	if err != nil {
-		return nil, errors.New("invalid invoice ID format")
+		return nil, ErrInvalidInvoiceID
 	}
 
 	serviceID, err := uuid.Parse(req.ServiceID)
 	if err != nil {
-		return nil, errors.New("invalid service ID format")
+		return nil, ErrInvalidServiceID
 	}
 
 	// Validate invoice exists
-	_, err = s.invoiceRepo.GetByID(req.InvoiceID)
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("invoice not found")
-		}
+	if _, err := getInvoiceInScope(ctx, s.invoiceRepo, s.scheduleRepo, scope, req.InvoiceID); err != nil {
 		return nil, err
 	}
 
 	// Validate service exists
-	service, err := s.serviceRepo.GetByID(req.ServiceID)
+	service, err := s.serviceRepo.GetByID(ctx, req.ServiceID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("service not found")
+			return nil, ErrServiceNotFound
 		}
 		return nil, err
 	}
 
 	// Calculate subtotal
-	subtotal := service.Price * float64(req.Quantity)
+	subtotal := service.Price.Mul(int64(req.Quantity))
 
 	invoiceDetail := &domain.InvoiceDetail{
 		InvoiceID: invoiceID,
@@ -79,23 +89,29 @@ func (s *invoiceDetailService) Create(req *request.InvoiceDetailCreateRequest) (
 		Subtotal:  subtotal,
 	}
 
-	if err := s.invoiceDetailRepo.Create(invoiceDetail); err != nil {
-		return nil, err
-	}
-
-	// Update invoice total amount
-	if err := s.updateInvoiceTotal(req.InvoiceID); err != nil {
-		return nil, err
+	// Create the detail and update the invoice total in one locked transaction
+	change, err := s.invoiceDetailRepo.CreateAndRecalculate(ctx, invoiceDetail)
+	if err != nil {
+		return nil, recalculateError(err)
 	}
+	s.auditService.Record(ctx, auditCreate(domain.AuditEntityInvoiceDetail, invoiceDetail.ID.String(), invoiceDetail))
+	recordInvoiceChange(ctx, s.auditService, change)
 
 	return invoiceDetail, nil
 }
 
-func (s *invoiceDetailService) GetByID(id string) (*domain.InvoiceDetail, error) {
-	invoiceDetail, err := s.invoiceDetailRepo.GetByID(id)
+func (s *invoiceDetailService) GetByID(ctx context.Context, scope domain.AccessScope, id string) (*domain.InvoiceDetail, error) {
+	invoiceDetail, err := s.invoiceDetailRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("invoice detail not found")
+			return nil, ErrInvoiceDetailNotFound
+		}
+		return nil, err
+	}
+
+	if _, err := getInvoiceInScope(ctx, s.invoiceRepo, s.scheduleRepo, scope, invoiceDetail.InvoiceID.String()); err != nil {
+		if errors.Is(err, ErrInvoiceNotFound) {
+			return nil, ErrInvoiceDetailNotFound
 		}
 		return nil, err
 	}
@@ -103,93 +119,94 @@ func (s *invoiceDetailService) GetByID(id string) (*domain.InvoiceDetail, error)
 	return invoiceDetail, nil
 }
 
-func (s *invoiceDetailService) Update(id string, req *request.InvoiceDetailUpdateRequest) (*domain.InvoiceDetail, error) {
+func (s *invoiceDetailService) Update(ctx context.Context, scope domain.AccessScope, id string, req *request.InvoiceDetailUpdateRequest) (*domain.InvoiceDetail, error) {
 	// Check if invoice detail exists
-	invoiceDetail, err := s.invoiceDetailRepo.GetByID(id)
+	invoiceDetail, err := s.GetByID(ctx, scope, id)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("invoice detail not found")
-		}
 		return nil, err
 	}
+	before := *invoiceDetail
 
 	// Update fields if provided
 	if req.Quantity != nil && *req.Quantity > 0 {
 		invoiceDetail.Quantity = *req.Quantity
 		// Recalculate subtotal
-		invoiceDetail.Subtotal = invoiceDetail.UnitPrice * float64(invoiceDetail.Quantity)
+		invoiceDetail.Subtotal = invoiceDetail.UnitPrice.Mul(int64(invoiceDetail.Quantity))
 	}
 
-	if req.UnitPrice != nil && *req.UnitPrice >= 0 {
+	if req.UnitPrice != nil && !req.UnitPrice.IsNegative() {
 		invoiceDetail.UnitPrice = *req.UnitPrice
 		// Recalculate subtotal
-		invoiceDetail.Subtotal = invoiceDetail.UnitPrice * float64(invoiceDetail.Quantity)
-	}
-
-	if err := s.invoiceDetailRepo.Update(invoiceDetail); err != nil {
-		return nil, err
+		invoiceDetail.Subtotal = invoiceDetail.UnitPrice.Mul(int64(invoiceDetail.Quantity))
 	}
 
-	// Update invoice total amount
-	if err := s.updateInvoiceTotal(invoiceDetail.InvoiceID.String()); err != nil {
-		return nil, err
+	// Save the detail and update the invoice total in one locked transaction
+	change, err := s.invoiceDetailRepo.UpdateAndRecalculate(ctx, invoiceDetail)
+	if err != nil {
+		return nil, recalculateError(err)
 	}
+	s.auditService.Record(ctx, auditUpdate(domain.AuditEntityInvoiceDetail, id, &before, invoiceDetail))
+	recordInvoiceChange(ctx, s.auditService, change)
 
 	return invoiceDetail, nil
 }
 
-func (s *invoiceDetailService) Delete(id string) error {
+func (s *invoiceDetailService) Delete(ctx context.Context, scope domain.AccessScope, id string) error {
 	// Check if invoice detail exists
-	invoiceDetail, err := s.invoiceDetailRepo.GetByID(id)
+	invoiceDetail, err := s.GetByID(ctx, scope, id)
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("invoice detail not found")
-		}
-		return err
-	}
-
-	invoiceID := invoiceDetail.InvoiceID.String()
-
-	if err := s.invoiceDetailRepo.Delete(id); err != nil {
 		return err
 	}
 
-	// Update invoice total amount
-	if err := s.updateInvoiceTotal(invoiceID); err != nil {
-		return err
+	// Delete the detail and update the invoice total in one locked transaction
+	change, err := s.invoiceDetailRepo.DeleteAndRecalculate(ctx, invoiceDetail)
+	if err != nil {
+		return recalculateError(err)
 	}
+	s.auditService.Record(ctx, auditDelete(domain.AuditEntityInvoiceDetail, id, invoiceDetail))
+	recordInvoiceChange(ctx, s.auditService, change)
 
 	return nil
 }
 
-func (s *invoiceDetailService) GetByInvoiceID(invoiceID string) ([]*domain.InvoiceDetail, error) {
-	return s.invoiceDetailRepo.GetByInvoiceID(invoiceID)
-}
+func (s *invoiceDetailService) GetByInvoiceID(ctx context.Context, scope domain.AccessScope, invoiceID string) ([]*domain.InvoiceDetail, error) {
+	if _, err := getInvoiceInScope(ctx, s.invoiceRepo, s.scheduleRepo, scope, invoiceID); err != nil {
+		return nil, err
+	}
 
-func (s *invoiceDetailService) DeleteByInvoiceID(invoiceID string) error {
-	return s.invoiceDetailRepo.DeleteByInvoiceID(invoiceID)
+	return s.invoiceDetailRepo.GetByInvoiceID(ctx, invoiceID)
 }
 
-// Helper function to update invoice total amount
-func (s *invoiceDetailService) updateInvoiceTotal(invoiceID string) error {
-	// Get all invoice details for this invoice
-	details, err := s.invoiceDetailRepo.GetByInvoiceID(invoiceID)
-	if err != nil {
+func (s *invoiceDetailService) DeleteByInvoiceID(ctx context.Context, scope domain.AccessScope, invoiceID string) error {
+	if _, err := getInvoiceInScope(ctx, s.invoiceRepo, s.scheduleRepo, scope, invoiceID); err != nil {
 		return err
 	}
 
-	// Calculate total amount
-	var totalAmount float64
-	for _, detail := range details {
-		totalAmount += detail.Subtotal
+	// Load the line items first so their removal can be recorded
+	invoiceDetails, err := s.invoiceDetailRepo.GetByInvoiceID(ctx, invoiceID)
+	if err != nil {
+		return err
 	}
 
-	// Get invoice and update total amount
-	invoice, err := s.invoiceRepo.GetByID(invoiceID)
+	change, err := s.invoiceDetailRepo.DeleteByInvoiceIDAndRecalculate(ctx, invoiceID)
 	if err != nil {
-		return err
+		return recalculateError(err)
+	}
+	for _, invoiceDetail := range invoiceDetails {
+		s.auditService.Record(ctx, auditDelete(domain.AuditEntityInvoiceDetail, invoiceDetail.ID.String(), invoiceDetail))
 	}
+	recordInvoiceChange(ctx, s.auditService, change)
 
-	invoice.TotalAmount = totalAmount
-	return s.invoiceRepo.Update(invoice)
-}
\ No newline at end of file
+	return nil
+}
+
+// recalculateError maps the errors of a line item change that refreshes the invoice total
+func recalculateError(err error) error {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return ErrInvoiceNotFound
+	case errors.Is(err, repository.ErrTotalBelowAmountPaid):
+		return ErrTotalBelowAmountPaid
+	}
+	return err
+}
@@ -1,166 +1,355 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"time"
 
 	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/apperror"
 	"dashboard-ac-backend/internal/domain"
 	"dashboard-ac-backend/internal/repository"
+	"dashboard-ac-backend/pkg/money"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type InvoiceService interface {
-	Create(req *request.InvoiceCreateRequest) (*domain.Invoice, error)
-	GetByID(id string) (*domain.Invoice, error)
-	Update(id string, req *request.InvoiceUpdateRequest) (*domain.Invoice, error)
-	Delete(id string) error
-	List(pagination *request.PaginationRequest) ([]*domain.Invoice, int64, error)
-	Search(req *request.InvoiceSearchRequest) ([]*domain.Invoice, int64, error)
-	GetByCustomerID(customerID string, pagination *request.PaginationRequest) ([]*domain.Invoice, int64, error)
-	GetByScheduleID(scheduleID string) (*domain.Invoice, error)
-	GetByStatus(status domain.InvoiceStatus, pagination *request.PaginationRequest) ([]*domain.Invoice, int64, error)
+	Create(ctx context.Context, scope domain.AccessScope, req *request.InvoiceCreateRequest) (*domain.Invoice, error)
+	GetByID(ctx context.Context, scope domain.AccessScope, id string) (*domain.Invoice, error)
+	LoadRelations(ctx context.Context, invoice *domain.Invoice, include string) error
+	Update(ctx context.Context, id string, req *request.InvoiceUpdateRequest) (*domain.Invoice, error)
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, scope domain.AccessScope, pagination *request.PaginationRequest) ([]*domain.Invoice, repository.ListPage, error)
+	Search(ctx context.Context, scope domain.AccessScope, req *request.InvoiceSearchRequest) ([]*domain.Invoice, repository.ListPage, error)
+	Export(ctx context.Context, scope domain.AccessScope, req *request.InvoiceSearchRequest) func() ([]*domain.Invoice, error)
+	GetByCustomerID(ctx context.Context, scope domain.AccessScope, customerID string, pagination *request.PaginationRequest) ([]*domain.Invoice, repository.ListPage, error)
+	GetByScheduleID(ctx context.Context, scope domain.AccessScope, scheduleID string) (*domain.Invoice, error)
+	GetByStatus(ctx context.Context, scope domain.AccessScope, status domain.InvoiceStatus, pagination *request.PaginationRequest) ([]*domain.Invoice, repository.ListPage, error)
+	CreateFromSchedule(ctx context.Context, scope domain.AccessScope, scheduleID string) (*domain.Invoice, error)
+	MarkOverdue(ctx context.Context, now time.Time) (int64, error)
 }
 
+var (
+	ErrScheduleNotCompleted    = apperror.Conflict("schedule_not_completed", "schedule is not completed")
+	ErrScheduleAlreadyInvoiced = apperror.Conflict("schedule_already_invoiced", "schedule already has an invoice")
+
+	// ErrInvoiceStatusFromPayments is returned when the status of an invoice with
+	// payments is changed by hand
+	ErrInvoiceStatusFromPayments = apperror.Conflict("invoice_status_from_payments", "status of an invoice with payments is derived from its payments")
+
+	ErrInvalidInvoiceStatus = apperror.Validation("invalid_status", "invalid status",
+		apperror.FieldError{Field: "status", Message: "status must be one of: Unpaid Overdue"})
+
+	// ErrInvoiceCustomerMismatch is returned when an invoice names a customer other
+	// than the one of the schedule it bills
+	ErrInvoiceCustomerMismatch = apperror.Validation("customer_mismatch", "customer does not match the schedule",
+		apperror.FieldError{Field: "customer_id", Message: "must be the customer of the schedule"})
+)
+
 type invoiceService struct {
-	invoiceRepo  repository.InvoiceRepository
-	customerRepo repository.CustomerRepository
-	scheduleRepo repository.ScheduleRepository
+	invoiceRepo      repository.InvoiceRepository
+	customerRepo     repository.CustomerRepository
+	scheduleRepo     repository.ScheduleRepository
+	serviceRepo      repository.ServiceRepository
+	auditService     AuditService
+	paymentTermsDays int
 }
 
 func NewInvoiceService(
 	invoiceRepo repository.InvoiceRepository,
 	customerRepo repository.CustomerRepository,
 	scheduleRepo repository.ScheduleRepository,
+	serviceRepo repository.ServiceRepository,
+	auditService AuditService,
+	paymentTermsDays int,
 ) InvoiceService {
 	return &invoiceService{
-		invoiceRepo:  invoiceRepo,
-		customerRepo: customerRepo,
-		scheduleRepo: scheduleRepo,
+		invoiceRepo:      invoiceRepo,
+		customerRepo:     customerRepo,
+		scheduleRepo:     scheduleRepo,
+		serviceRepo:      serviceRepo,
+		auditService:     auditService,
+		paymentTermsDays: paymentTermsDays,
 	}
 }
 
-func (s *invoiceService) Create(req *request.InvoiceCreateRequest) (*domain.Invoice, error) {
+func (s *invoiceService) Create(ctx context.Context, scope domain.AccessScope, req *request.InvoiceCreateRequest) (*domain.Invoice, error) {
 	// Parse UUIDs
 	customerID, err := uuid.Parse(req.CustomerID)
 	if err != nil {
-		return nil, errors.New("invalid customer ID format")
+		return nil, ErrInvalidCustomerID
 	}
 
 	scheduleID, err := uuid.Parse(req.ScheduleID)
 	if err != nil {
-		return nil, errors.New("invalid schedule ID format")
+		return nil, ErrInvalidScheduleID
 	}
 
 	// Validate customer exists
-	_, err = s.customerRepo.GetByID(req.CustomerID)
+	_, err = s.customerRepo.GetByID(ctx, req.CustomerID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("customer not found")
+			return nil, ErrCustomerNotFound
 		}
 		return nil, err
 	}
 
-	// Validate schedule exists
-	_, err = s.scheduleRepo.GetByID(req.ScheduleID)
+	// Validate schedule exists and is visible to the caller
+	schedule, err := s.scheduleRepo.GetByID(ctx, req.ScheduleID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("schedule not found")
+			return nil, ErrScheduleNotFound
 		}
 		return nil, err
 	}
 
+	if !scope.AllowsSchedule(schedule) {
+		return nil, ErrScheduleNotFound
+	}
+
+	if schedule.CustomerID != customerID {
+		return nil, ErrInvoiceCustomerMismatch
+	}
+
+	if err := s.checkNotInvoiced(ctx, req.ScheduleID); err != nil {
+		return nil, err
+	}
+
+	// Build line items, defaulting unit prices to the current service price
+	details := make([]*domain.InvoiceDetail, 0, len(req.Items))
+	var totalAmount money.Money
+	for _, item := range req.Items {
+		serviceID, err := uuid.Parse(item.ServiceID)
+		if err != nil {
+			return nil, ErrInvalidServiceID
+		}
+
+		service, err := s.serviceRepo.GetByID(ctx, item.ServiceID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrServiceNotFound
+			}
+			return nil, err
+		}
+
+		unitPrice := service.Price
+		if item.UnitPrice != nil {
+			unitPrice = *item.UnitPrice
+		}
+
+		detail := &domain.InvoiceDetail{
+			ServiceID: serviceID,
+			Quantity:  item.Quantity,
+			UnitPrice: unitPrice,
+			Subtotal:  unitPrice.Mul(int64(item.Quantity)),
+		}
+		details = append(details, detail)
+		totalAmount = totalAmount.Add(detail.Subtotal)
+	}
+
 	invoice := &domain.Invoice{
 		CustomerID:  customerID,
 		ScheduleID:  scheduleID,
 		InvoiceDate: req.InvoiceDate,
 		DueDate:     req.DueDate,
 		Status:      domain.InvoiceStatusUnpaid,
-		TotalAmount: 0, // Will be calculated when invoice details are added
+		TotalAmount: totalAmount,
 	}
 
-	if err := s.invoiceRepo.Create(invoice); err != nil {
+	// Invoice, details and total are written atomically
+	if err := s.invoiceRepo.CreateWithDetails(ctx, invoice, details); err != nil {
+		if errors.Is(err, repository.ErrScheduleInvoiced) {
+			return nil, ErrScheduleAlreadyInvoiced
+		}
 		return nil, err
 	}
+	s.recordCreated(ctx, invoice, details)
 
 	return invoice, nil
 }
 
-func (s *invoiceService) GetByID(id string) (*domain.Invoice, error) {
-	invoice, err := s.invoiceRepo.GetByID(id)
+// CreateFromSchedule bills a completed schedule: it creates the invoice and a single
+// line for the schedule's service at the current service price
+func (s *invoiceService) CreateFromSchedule(ctx context.Context, scope domain.AccessScope, scheduleID string) (*domain.Invoice, error) {
+	schedule, err := s.scheduleRepo.GetByID(ctx, scheduleID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("invoice not found")
+			return nil, ErrScheduleNotFound
 		}
 		return nil, err
 	}
 
-	return invoice, nil
-}
+	if !scope.AllowsSchedule(schedule) {
+		return nil, ErrScheduleNotFound
+	}
 
-func (s *invoiceService) Update(id string, req *request.InvoiceUpdateRequest) (*domain.Invoice, error) {
-	// Check if invoice exists
-	invoice, err := s.invoiceRepo.GetByID(id)
+	if schedule.Status != domain.ScheduleStatusCompleted {
+		return nil, ErrScheduleNotCompleted
+	}
+
+	if err := s.checkNotInvoiced(ctx, scheduleID); err != nil {
+		return nil, err
+	}
+
+	service, err := s.serviceRepo.GetByID(ctx, schedule.ServiceID.String())
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("invoice not found")
+			return nil, ErrServiceNotFound
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	invoiceDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	invoice := &domain.Invoice{
+		CustomerID:  schedule.CustomerID,
+		ScheduleID:  schedule.ID,
+		InvoiceDate: invoiceDate,
+		DueDate:     invoiceDate.AddDate(0, 0, s.paymentTermsDays),
+		Status:      domain.InvoiceStatusUnpaid,
+		TotalAmount: service.Price,
+	}
+
+	details := []*domain.InvoiceDetail{
+		{
+			ServiceID: service.ID,
+			Quantity:  1,
+			UnitPrice: service.Price,
+			Subtotal:  service.Price,
+		},
+	}
+
+	if err := s.invoiceRepo.CreateWithDetails(ctx, invoice, details); err != nil {
+		if errors.Is(err, repository.ErrScheduleInvoiced) {
+			return nil, ErrScheduleAlreadyInvoiced
 		}
 		return nil, err
 	}
+	s.recordCreated(ctx, invoice, details)
+
+	return invoice, nil
+}
+
+// Helper function to record a new invoice and its line items in the audit log
+func (s *invoiceService) recordCreated(ctx context.Context, invoice *domain.Invoice, details []*domain.InvoiceDetail) {
+	s.auditService.Record(ctx, auditCreate(domain.AuditEntityInvoice, invoice.ID.String(), invoice))
+	for _, detail := range details {
+		s.auditService.Record(ctx, auditCreate(domain.AuditEntityInvoiceDetail, detail.ID.String(), detail))
+	}
+}
+
+// Helper function to refuse billing the same schedule twice. The unique index on
+// invoices.schedule_id catches requests that race past this check.
+func (s *invoiceService) checkNotInvoiced(ctx context.Context, scheduleID string) error {
+	_, err := s.invoiceRepo.GetByScheduleID(ctx, scheduleID)
+	if err == nil {
+		return ErrScheduleAlreadyInvoiced
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
 
-	// Update fields if provided
-	if req.InvoiceDate != nil && !req.InvoiceDate.IsZero() {
-		invoice.InvoiceDate = *req.InvoiceDate
+// MarkOverdue flags unpaid and partially paid invoices whose due date lies before the
+// day of now, and records each change in the audit log
+func (s *invoiceService) MarkOverdue(ctx context.Context, now time.Time) (int64, error) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	flagged, err := s.invoiceRepo.MarkOverdue(ctx, today)
+	if err != nil {
+		return 0, err
 	}
 
-	if req.DueDate != nil && !req.DueDate.IsZero() {
-		invoice.DueDate = *req.DueDate
+	for _, before := range flagged {
+		after := *before
+		after.Status = domain.InvoiceStatusOverdue
+		s.auditService.Record(ctx, auditUpdate(domain.AuditEntityInvoice, before.ID.String(), before, &after))
 	}
 
+	return int64(len(flagged)), nil
+}
+
+// GetByID returns an invoice visible in scope; invoices outside it are reported as not found
+func (s *invoiceService) GetByID(ctx context.Context, scope domain.AccessScope, id string) (*domain.Invoice, error) {
+	return getInvoiceInScope(ctx, s.invoiceRepo, s.scheduleRepo, scope, id)
+}
+
+// LoadRelations embeds the related records named in include, e.g. "customer,details"
+func (s *invoiceService) LoadRelations(ctx context.Context, invoice *domain.Invoice, include string) error {
+	return s.invoiceRepo.LoadRelations(ctx, []*domain.Invoice{invoice}, include)
+}
+
+func (s *invoiceService) Update(ctx context.Context, id string, req *request.InvoiceUpdateRequest) (*domain.Invoice, error) {
+	var status domain.InvoiceStatus
 	if req.Status != nil && *req.Status != "" {
-		status := domain.InvoiceStatus(*req.Status)
-		// Validate status
-		if status != domain.InvoiceStatusUnpaid && 
-		   status != domain.InvoiceStatusPaid && 
-		   status != domain.InvoiceStatusOverdue {
-			return nil, errors.New("invalid status")
+		status = domain.InvoiceStatus(*req.Status)
+		// Validate status; Paid and PartiallyPaid are only reached by recording payments
+		if status != domain.InvoiceStatusUnpaid &&
+			status != domain.InvoiceStatusOverdue {
+			return nil, ErrInvalidInvoiceStatus
 		}
-		invoice.Status = status
 	}
 
-	if err := s.invoiceRepo.Update(invoice); err != nil {
+	// The invoice is changed under its row lock, so the payment check below sees
+	// the amount paid as it is when the update is written
+	var before domain.Invoice
+	invoice, err := s.invoiceRepo.Update(ctx, id, func(invoice *domain.Invoice) error {
+		before = *invoice
+
+		// Update fields if provided
+		if req.InvoiceDate != nil && !req.InvoiceDate.IsZero() {
+			invoice.InvoiceDate = *req.InvoiceDate
+		}
+
+		if req.DueDate != nil && !req.DueDate.IsZero() {
+			invoice.DueDate = *req.DueDate
+		}
+
+		if status != "" {
+			if !invoice.AmountPaid.IsZero() && status != invoice.Status {
+				return ErrInvoiceStatusFromPayments
+			}
+			invoice.Status = status
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvoiceNotFound
+		}
 		return nil, err
 	}
+	s.auditService.Record(ctx, auditUpdate(domain.AuditEntityInvoice, id, &before, invoice))
 
 	return invoice, nil
 }
 
-func (s *invoiceService) Delete(id string) error {
+func (s *invoiceService) Delete(ctx context.Context, id string) error {
 	// Check if invoice exists
-	_, err := s.invoiceRepo.GetByID(id)
+	invoice, err := s.invoiceRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("invoice not found")
+			return ErrInvoiceNotFound
 		}
 		return err
 	}
 
-	return s.invoiceRepo.Delete(id)
-}
-
-func (s *invoiceService) List(pagination *request.PaginationRequest) ([]*domain.Invoice, int64, error) {
-	offset := pagination.GetOffset()
-	limit := pagination.GetLimit()
+	if err := s.invoiceRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.auditService.Record(ctx, auditDelete(domain.AuditEntityInvoice, id, invoice))
 
-	return s.invoiceRepo.List(offset, limit)
+	return nil
 }
 
-func (s *invoiceService) Search(req *request.InvoiceSearchRequest) ([]*domain.Invoice, int64, error) {
-	offset := req.GetOffset()
-	limit := req.GetLimit()
+func (s *invoiceService) List(ctx context.Context, scope domain.AccessScope, pagination *request.PaginationRequest) ([]*domain.Invoice, repository.ListPage, error) {
+	return s.invoiceRepo.List(ctx, scope, listOptions(pagination))
+}
 
+func (s *invoiceService) Search(ctx context.Context, scope domain.AccessScope, req *request.InvoiceSearchRequest) ([]*domain.Invoice, repository.ListPage, error) {
 	var dateFrom, dateTo *time.Time
 	if req.DateFrom != "" {
 		if parsed, err := time.Parse("2006-01-02", req.DateFrom); err == nil {
@@ -178,23 +367,84 @@ func (s *invoiceService) Search(req *request.InvoiceSearchRequest) ([]*domain.In
 		status = domain.InvoiceStatus(req.Status)
 	}
 
-	return s.invoiceRepo.Search(req.CustomerID, req.ScheduleID, status, dateFrom, dateTo, offset, limit)
+	return s.invoiceRepo.Search(ctx, scope, req.CustomerID, req.ScheduleID, status, dateFrom, dateTo, listOptions(req.PaginationRequest))
+}
+
+// Export returns a function loading the invoices matching req batch by batch, with
+// their customer and the technician and service of their schedule
+func (s *invoiceService) Export(ctx context.Context, scope domain.AccessScope, req *request.InvoiceSearchRequest) func() ([]*domain.Invoice, error) {
+	search := *req
+	return exportBatches(req.PaginationRequest, "customer,technician,service", func(pagination *request.PaginationRequest) ([]*domain.Invoice, repository.ListPage, error) {
+		search.PaginationRequest = pagination
+		return s.Search(ctx, scope, &search)
+	})
+}
+
+func (s *invoiceService) GetByCustomerID(ctx context.Context, scope domain.AccessScope, customerID string, pagination *request.PaginationRequest) ([]*domain.Invoice, repository.ListPage, error) {
+	return s.invoiceRepo.GetByCustomerID(ctx, scope, customerID, listOptions(pagination))
+}
+
+func (s *invoiceService) GetByScheduleID(ctx context.Context, scope domain.AccessScope, scheduleID string) (*domain.Invoice, error) {
+	invoice, err := s.invoiceRepo.GetByScheduleID(ctx, scheduleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvoiceNotFound
+		}
+		return nil, err
+	}
+
+	if err := checkInvoiceScope(ctx, s.scheduleRepo, scope, invoice); err != nil {
+		return nil, err
+	}
+
+	return invoice, nil
+}
+
+func (s *invoiceService) GetByStatus(ctx context.Context, scope domain.AccessScope, status domain.InvoiceStatus, pagination *request.PaginationRequest) ([]*domain.Invoice, repository.ListPage, error) {
+	return s.invoiceRepo.GetByStatus(ctx, scope, status, listOptions(pagination))
 }
 
-func (s *invoiceService) GetByCustomerID(customerID string, pagination *request.PaginationRequest) ([]*domain.Invoice, int64, error) {
-	offset := pagination.GetOffset()
-	limit := pagination.GetLimit()
-	
-	return s.invoiceRepo.GetByCustomerID(customerID, offset, limit)
+// getInvoiceInScope loads an invoice visible in scope, reporting invoices outside it
+// as not found. Payments and line items share the scope of their invoice.
+func getInvoiceInScope(ctx context.Context, invoiceRepo repository.InvoiceRepository, scheduleRepo repository.ScheduleRepository, scope domain.AccessScope, id string) (*domain.Invoice, error) {
+	invoice, err := invoiceRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvoiceNotFound
+		}
+		return nil, err
+	}
+
+	if err := checkInvoiceScope(ctx, scheduleRepo, scope, invoice); err != nil {
+		return nil, err
+	}
+
+	return invoice, nil
 }
 
-func (s *invoiceService) GetByScheduleID(scheduleID string) (*domain.Invoice, error) {
-	return s.invoiceRepo.GetByScheduleID(scheduleID)
+// recordInvoiceChange records the total, amount paid and status an invoice was given
+// by a payment or a line item change
+func recordInvoiceChange(ctx context.Context, auditService AuditService, change *repository.InvoiceChange) {
+	auditService.Record(ctx, auditUpdate(domain.AuditEntityInvoice, change.After.ID.String(), change.Before, change.After))
 }
 
-func (s *invoiceService) GetByStatus(status domain.InvoiceStatus, pagination *request.PaginationRequest) ([]*domain.Invoice, int64, error) {
-	offset := pagination.GetOffset()
-	limit := pagination.GetLimit()
-	
-	return s.invoiceRepo.GetByStatus(status, offset, limit)
+// Helper function to hide invoices whose schedule lies outside the scope
+func checkInvoiceScope(ctx context.Context, scheduleRepo repository.ScheduleRepository, scope domain.AccessScope, invoice *domain.Invoice) error {
+	if !scope.IsRestricted() {
+		return nil
+	}
+
+	schedule, err := scheduleRepo.GetByID(ctx, invoice.ScheduleID.String())
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvoiceNotFound
+		}
+		return err
+	}
+
+	if !scope.AllowsSchedule(schedule) {
+		return ErrInvoiceNotFound
+	}
+
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,21 @@
+package service
+
+import (
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/repository"
+)
+
+// listOptions passes the page or cursor, sort, filter and search of a list request to
+// the repositories, which check sort and filter against the fields each list allows
+func listOptions(pagination *request.PaginationRequest) repository.ListOptions {
+	return repository.ListOptions{
+		Offset:    pagination.GetOffset(),
+		Limit:     pagination.GetLimit(),
+		Sort:      pagination.Sort,
+		Filter:    pagination.Filter,
+		Search:    pagination.Search,
+		UseCursor: pagination.UseCursor,
+		Cursor:    pagination.Cursor,
+		Include:   pagination.Include,
+	}
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/apperror"
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+	"dashboard-ac-backend/pkg/hash"
+	"dashboard-ac-backend/pkg/logger"
+	"dashboard-ac-backend/pkg/notifier"
+
+	"gorm.io/gorm"
+)
+
+type PasswordService interface {
+	ChangePassword(ctx context.Context, userID uint, req *request.ChangePasswordRequest) error
+	ForgotPassword(ctx context.Context, req *request.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, req *request.ResetPasswordRequest) error
+}
+
+var (
+	ErrCurrentPasswordIncorrect = apperror.Validation("current_password_incorrect", "current password is incorrect")
+	ErrPasswordUnchanged        = apperror.Validation("password_unchanged", "new password must differ from the current password")
+	ErrResetTokenInvalid        = apperror.Validation("reset_token_invalid", "reset token is invalid or expired")
+)
+
+type passwordService struct {
+	userRepo          repository.UserRepository
+	passwordResetRepo repository.PasswordResetRepository
+	notifier          notifier.Notifier
+	resetTokenTTL     time.Duration
+	resetURL          string
+	auditService      AuditService
+}
+
+// NewPasswordService creates the password service. resetURL is the page of the frontend
+// that accepts a reset token; the token is appended to it in the mailed link.
+func NewPasswordService(
+	userRepo repository.UserRepository,
+	passwordResetRepo repository.PasswordResetRepository,
+	notifier notifier.Notifier,
+	resetTokenTTL time.Duration,
+	resetURL string,
+	auditService AuditService,
+) PasswordService {
+	return &passwordService{
+		userRepo:          userRepo,
+		passwordResetRepo: passwordResetRepo,
+		notifier:          notifier,
+		resetTokenTTL:     resetTokenTTL,
+		resetURL:          resetURL,
+		auditService:      auditService,
+	}
+}
+
+// ChangePassword replaces the password of a logged-in user. All sessions, including
+// the current one, are ended so every device has to log in with the new password.
+func (s *passwordService) ChangePassword(ctx context.Context, userID uint, req *request.ChangePasswordRequest) error {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	if err := hash.CheckPassword(user.Password, req.CurrentPassword); err != nil {
+		return ErrCurrentPasswordIncorrect
+	}
+	if req.NewPassword == req.CurrentPassword {
+		return ErrPasswordUnchanged
+	}
+
+	hashedPassword, err := hash.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := s.userRepo.UpdatePassword(ctx, user.ID, hashedPassword); err != nil {
+		return err
+	}
+	s.auditService.Record(ctx, auditPasswordChange(user.ID, "changed"))
+
+	return nil
+}
+
+// ForgotPassword mails a reset link to the account with the given email. It succeeds
+// whether or not the account exists so the endpoint cannot be used to probe for emails.
+func (s *passwordService) ForgotPassword(ctx context.Context, req *request.ForgotPasswordRequest) error {
+	user, err := s.userRepo.GetByEmail(ctx, req.Email)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if !user.IsActive {
+		return nil
+	}
+
+	token, err := hash.GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	resetToken := &domain.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: hash.HashToken(token),
+		ExpiresAt: time.Now().Add(s.resetTokenTTL),
+	}
+	if err := s.passwordResetRepo.Create(ctx, resetToken); err != nil {
+		return err
+	}
+
+	msg := notifier.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to choose a new password. It expires in %s and can be used once.\n\n%s%s\n\nIf you did not ask for this, you can ignore this message.",
+			user.Name, s.resetTokenTTL, s.resetURL, token,
+		),
+	}
+	if err := s.notifier.Send(msg); err != nil {
+		// Do not tell the caller; the user can simply ask again
+		logger.FromContext(ctx).Error().Err(err).Uint("user_id", user.ID).Msg("Failed to send password reset message")
+	}
+
+	return nil
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword. The token can be
+// used once, and all sessions of the user are ended.
+func (s *passwordService) ResetPassword(ctx context.Context, req *request.ResetPasswordRequest) error {
+	hashedPassword, err := hash.HashPassword(req.NewPassword)
+	if err != nil {
+		return err
+	}
+
+	userID, err := s.passwordResetRepo.Redeem(ctx, hash.HashToken(req.Token), hashedPassword)
+	if err != nil {
+		if errors.Is(err, repository.ErrResetTokenInvalid) || errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrResetTokenInvalid
+		}
+		return err
+	}
+	s.auditService.Record(ctx, auditPasswordChange(userID, "reset"))
+
+	return nil
+}
+
+// auditPasswordChange describes a password change for the audit log. The hash is never
+// serialized, so the entry only says how the password was replaced.
+func auditPasswordChange(userID uint, how string) AuditEntry {
+	return auditUpdate(domain.AuditEntityUser, userEntityID(userID), domain.AuditChanges{}, domain.AuditChanges{"password": how})
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/apperror"
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvoiceAlreadyPaid    = apperror.Conflict("invoice_already_paid", "invoice is already paid")
+	ErrPaymentExceedsBalance = apperror.Conflict("payment_exceeds_balance", "payment exceeds the invoice balance due")
+	ErrInvalidPaymentMethod  = apperror.Validation("invalid_payment_method", "invalid payment method",
+		apperror.FieldError{Field: "method", Message: "method is invalid"})
+)
+
+// PaymentReceipt is a recorded payment together with the invoice it was applied to
+type PaymentReceipt struct {
+	Payment *domain.Payment `json:"payment"`
+	Invoice *domain.Invoice `json:"invoice"`
+}
+
+type PaymentService interface {
+	Record(ctx context.Context, scope domain.AccessScope, invoiceID string, req *request.PaymentCreateRequest, recordedBy uint) (*PaymentReceipt, error)
+	GetByInvoiceID(ctx context.Context, scope domain.AccessScope, invoiceID string) ([]*domain.Payment, error)
+}
+
+type paymentService struct {
+	paymentRepo  repository.PaymentRepository
+	invoiceRepo  repository.InvoiceRepository
+	scheduleRepo repository.ScheduleRepository
+	auditService AuditService
+}
+
+func NewPaymentService(paymentRepo repository.PaymentRepository, invoiceRepo repository.InvoiceRepository, scheduleRepo repository.ScheduleRepository, auditService AuditService) PaymentService {
+	return &paymentService{
+		paymentRepo:  paymentRepo,
+		invoiceRepo:  invoiceRepo,
+		scheduleRepo: scheduleRepo,
+		auditService: auditService,
+	}
+}
+
+// Record applies a payment to an invoice. The invoice moves to PartiallyPaid or Paid
+// depending on the remaining balance; payments larger than the balance are rejected.
+// Invoices outside scope are reported as not found.
+func (s *paymentService) Record(ctx context.Context, scope domain.AccessScope, invoiceID string, req *request.PaymentCreateRequest, recordedBy uint) (*PaymentReceipt, error) {
+	id, err := uuid.Parse(invoiceID)
+	if err != nil {
+		return nil, ErrInvalidInvoiceID
+	}
+
+	invoice, err := getInvoiceInScope(ctx, s.invoiceRepo, s.scheduleRepo, scope, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+
+	if invoice.Status == domain.InvoiceStatusPaid {
+		return nil, ErrInvoiceAlreadyPaid
+	}
+
+	payment := &domain.Payment{
+		InvoiceID:       id,
+		Amount:          req.Amount,
+		Method:          domain.PaymentMethod(req.Method),
+		ReferenceNumber: req.ReferenceNumber,
+		RecordedBy:      recordedBy,
+	}
+	if req.PaidAt != nil && !req.PaidAt.IsZero() {
+		payment.PaidAt = *req.PaidAt
+	}
+
+	if !payment.IsValidMethod() {
+		return nil, ErrInvalidPaymentMethod
+	}
+
+	change, err := s.paymentRepo.CreateAndApply(ctx, payment)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvoiceNotFound
+		}
+		if errors.Is(err, repository.ErrPaymentExceedsBalance) {
+			return nil, ErrPaymentExceedsBalance
+		}
+		return nil, err
+	}
+	s.auditService.Record(ctx, auditCreate(domain.AuditEntityPayment, payment.ID.String(), payment))
+	recordInvoiceChange(ctx, s.auditService, change)
+
+	return &PaymentReceipt{
+		Payment: payment,
+		Invoice: change.After,
+	}, nil
+}
+
+func (s *paymentService) GetByInvoiceID(ctx context.Context, scope domain.AccessScope, invoiceID string) ([]*domain.Payment, error) {
+	if _, err := getInvoiceInScope(ctx, s.invoiceRepo, s.scheduleRepo, scope, invoiceID); err != nil {
+		return nil, err
+	}
+
+	return s.paymentRepo.GetByInvoiceID(ctx, invoiceID)
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/apperror"
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultPortalCancelReason is recorded when a customer cancels without giving a reason
+const DefaultPortalCancelReason = "Canceled by customer"
+
+var (
+	ErrBookingNotPending     = apperror.Conflict("booking_not_pending", "only pending bookings can be canceled")
+	ErrBookingInPast         = apperror.Validation("booking_in_past", "booking must be in the future")
+	ErrNoTechnicianAvailable = apperror.Conflict("no_technician_available", "no technician is available at the requested time")
+
+	// ErrTechnicianUnavailable is returned when the preferred technician is booked. It
+	// does not list the clashing schedules, which belong to other customers.
+	ErrTechnicianUnavailable = apperror.Conflict("technician_unavailable", "Technician is not available at the requested time")
+)
+
+// PortalService serves customers acting on their own records. Every method takes
+// the caller's customer ID, resolved from the JWT, and never trusts IDs from the
+// request to decide ownership.
+type PortalService interface {
+	ListSchedules(ctx context.Context, customerID uuid.UUID, pagination *request.PaginationRequest) ([]*domain.Schedule, repository.ListPage, error)
+	ListInvoices(ctx context.Context, customerID uuid.UUID, pagination *request.PaginationRequest) ([]*domain.Invoice, repository.ListPage, error)
+	RequestBooking(ctx context.Context, customerID uuid.UUID, req *request.PortalBookingRequest) (*domain.Schedule, error)
+	CancelBooking(ctx context.Context, customerID uuid.UUID, scheduleID string, actorID uint, reason string) (*domain.Schedule, error)
+	GetProfile(ctx context.Context, customerID uuid.UUID) (*domain.Customer, error)
+	UpdateProfile(ctx context.Context, customerID uuid.UUID, req *request.PortalProfileUpdateRequest) (*domain.Customer, error)
+}
+
+type portalService struct {
+	scheduleService     ScheduleService
+	invoiceService      InvoiceService
+	customerService     CustomerService
+	scheduleRepo        repository.ScheduleRepository
+	availabilityService AvailabilityService
+}
+
+func NewPortalService(
+	scheduleService ScheduleService,
+	invoiceService InvoiceService,
+	customerService CustomerService,
+	scheduleRepo repository.ScheduleRepository,
+	availabilityService AvailabilityService,
+) PortalService {
+	return &portalService{
+		scheduleService:     scheduleService,
+		invoiceService:      invoiceService,
+		customerService:     customerService,
+		scheduleRepo:        scheduleRepo,
+		availabilityService: availabilityService,
+	}
+}
+
+func (s *portalService) ListSchedules(ctx context.Context, customerID uuid.UUID, pagination *request.PaginationRequest) ([]*domain.Schedule, repository.ListPage, error) {
+	return s.scheduleService.GetByCustomerID(ctx, domain.AccessScope{}, customerID.String(), pagination)
+}
+
+func (s *portalService) ListInvoices(ctx context.Context, customerID uuid.UUID, pagination *request.PaginationRequest) ([]*domain.Invoice, repository.ListPage, error) {
+	return s.invoiceService.GetByCustomerID(ctx, domain.AccessScope{}, customerID.String(), pagination)
+}
+
+// RequestBooking creates a Pending schedule for the customer at a time the
+// technician has free within their working hours. Without a preferred technician,
+// the first available technician is assigned.
+func (s *portalService) RequestBooking(ctx context.Context, customerID uuid.UUID, req *request.PortalBookingRequest) (*domain.Schedule, error) {
+	start := domain.CombineDateTime(req.Date, req.Time)
+	if start.Before(time.Now()) {
+		return nil, ErrBookingInPast
+	}
+
+	createReq := &request.ScheduleCreateRequest{
+		CustomerID:   customerID.String(),
+		TechnicianID: req.TechnicianID,
+		ServiceID:    req.ServiceID,
+		Date:         req.Date,
+		Time:         req.Time,
+	}
+
+	if createReq.TechnicianID != "" {
+		available, err := s.availabilityService.IsAvailable(ctx, createReq.TechnicianID, req.ServiceID, start)
+		if err != nil {
+			return nil, err
+		}
+		if !available {
+			return nil, ErrTechnicianUnavailable
+		}
+
+		schedule, err := s.scheduleService.Create(ctx, createReq)
+		var conflict *ScheduleConflictError
+		if errors.As(err, &conflict) {
+			return nil, ErrTechnicianUnavailable
+		}
+		return schedule, err
+	}
+
+	technicians, err := s.availabilityService.AvailableTechnicians(ctx, req.ServiceID, start)
+	if err != nil {
+		return nil, err
+	}
+
+	// A technician booked since the check is skipped for the next one
+	for _, technician := range technicians {
+		createReq.TechnicianID = technician.ID.String()
+
+		schedule, err := s.scheduleService.Create(ctx, createReq)
+		if err == nil {
+			return schedule, nil
+		}
+
+		var conflict *ScheduleConflictError
+		if !errors.As(err, &conflict) {
+			return nil, err
+		}
+	}
+
+	return nil, ErrNoTechnicianAvailable
+}
+
+// CancelBooking cancels one of the customer's own Pending schedules. Schedules of
+// other customers are reported as not found.
+func (s *portalService) CancelBooking(ctx context.Context, customerID uuid.UUID, scheduleID string, actorID uint, reason string) (*domain.Schedule, error) {
+	schedule, err := s.scheduleRepo.GetByID(ctx, scheduleID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, err
+	}
+
+	if schedule.CustomerID != customerID {
+		return nil, ErrScheduleNotFound
+	}
+
+	if reason == "" {
+		reason = DefaultPortalCancelReason
+	}
+
+	// The Pending-only rule is checked with the transition, so a job started in
+	// the meantime is not canceled
+	return s.scheduleService.CancelPending(ctx, scheduleID, actorID, reason)
+}
+
+func (s *portalService) GetProfile(ctx context.Context, customerID uuid.UUID) (*domain.Customer, error) {
+	return s.customerService.GetByID(ctx, customerID.String())
+}
+
+func (s *portalService) UpdateProfile(ctx context.Context, customerID uuid.UUID, req *request.PortalProfileUpdateRequest) (*domain.Customer, error) {
+	return s.customerService.Update(ctx, customerID.String(), &request.CustomerUpdateRequest{
+		Name:    req.Name,
+		Phone:   req.Phone,
+		Address: req.Address,
+	})
+}
@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/apperror"
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+
+	"gorm.io/gorm"
+)
+
+type RoleService interface {
+	ListPermissions() []domain.Permission
+	List(ctx context.Context) ([]*domain.RoleDefinition, error)
+	GetByName(ctx context.Context, name string) (*domain.RoleDefinition, error)
+	Create(ctx context.Context, req *request.RoleCreateRequest) (*domain.RoleDefinition, error)
+	Update(ctx context.Context, name string, req *request.RoleUpdateRequest) (*domain.RoleDefinition, error)
+	Delete(ctx context.Context, name string) error
+	PermissionsFor(ctx context.Context, role domain.Role) (domain.PermissionSet, error)
+}
+
+var (
+	ErrRoleExists    = apperror.Conflict("role_exists", "role already exists")
+	ErrRoleReadOnly  = apperror.Conflict("role_read_only", "the admin role always has every permission and cannot be changed")
+	ErrRoleBuiltIn   = apperror.Conflict("role_built_in", "built-in roles cannot be deleted")
+	ErrRoleInUse     = apperror.Conflict("role_in_use", "role is still assigned to users")
+	ErrRoleNameValid = apperror.Validation("invalid_role_name", "role name must be 2-20 lowercase letters, digits, dashes or underscores, starting with a letter",
+		apperror.FieldError{Field: "name", Message: "name must be 2-20 lowercase letters, digits, dashes or underscores, starting with a letter"})
+
+	ErrUnknownPermission = apperror.Validation("unknown_permission", "unknown permission")
+)
+
+type roleService struct {
+	roleRepo     repository.RoleRepository
+	auditService AuditService
+	cacheTTL     time.Duration
+
+	mu    sync.Mutex
+	cache map[domain.Role]permissionCacheEntry
+}
+
+type permissionCacheEntry struct {
+	permissions domain.PermissionSet
+	expiresAt   time.Time
+}
+
+// NewRoleService creates the role service. Effective permissions are cached for
+// cacheTTL; changes made through this service apply immediately on this instance and
+// within cacheTTL on other instances.
+func NewRoleService(roleRepo repository.RoleRepository, auditService AuditService, cacheTTL time.Duration) RoleService {
+	return &roleService{
+		roleRepo:     roleRepo,
+		auditService: auditService,
+		cacheTTL:     cacheTTL,
+		cache:        make(map[domain.Role]permissionCacheEntry),
+	}
+}
+
+func (s *roleService) ListPermissions() []domain.Permission {
+	return domain.AllPermissions()
+}
+
+func (s *roleService) List(ctx context.Context) ([]*domain.RoleDefinition, error) {
+	roles, err := s.roleRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		fillAdminPermissions(role)
+	}
+	return roles, nil
+}
+
+func (s *roleService) GetByName(ctx context.Context, name string) (*domain.RoleDefinition, error) {
+	role, err := s.roleRepo.GetByName(ctx, domain.Role(name))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+	fillAdminPermissions(role)
+	return role, nil
+}
+
+func (s *roleService) Create(ctx context.Context, req *request.RoleCreateRequest) (*domain.RoleDefinition, error) {
+	if !domain.IsValidRoleName(req.Name) {
+		return nil, ErrRoleNameValid
+	}
+
+	// Check if role already exists
+	_, err := s.roleRepo.GetByName(ctx, domain.Role(req.Name))
+	if err == nil {
+		return nil, ErrRoleExists
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	permissions, err := parsePermissions(req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &domain.RoleDefinition{
+		Name:        domain.Role(req.Name),
+		Description: req.Description,
+		Permissions: permissions,
+	}
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		return nil, err
+	}
+	s.auditService.Record(ctx, auditCreate(domain.AuditEntityRole, string(role.Name), role))
+
+	return role, nil
+}
+
+func (s *roleService) Update(ctx context.Context, name string, req *request.RoleUpdateRequest) (*domain.RoleDefinition, error) {
+	role, err := s.GetByName(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if role.Name == domain.RoleAdmin {
+		return nil, ErrRoleReadOnly
+	}
+	before := *role
+
+	if req.Description != nil {
+		role.Description = *req.Description
+	}
+	if req.Permissions != nil {
+		if role.Permissions, err = parsePermissions(*req.Permissions); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.roleRepo.Update(ctx, role); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	s.auditService.Record(ctx, auditUpdate(domain.AuditEntityRole, string(role.Name), &before, role))
+
+	return role, nil
+}
+
+// Delete removes a custom role that no user is assigned to
+func (s *roleService) Delete(ctx context.Context, name string) error {
+	role, err := s.GetByName(ctx, name)
+	if err != nil {
+		return err
+	}
+	if role.BuiltIn {
+		return ErrRoleBuiltIn
+	}
+
+	users, err := s.roleRepo.CountUsers(ctx, role.Name)
+	if err != nil {
+		return err
+	}
+	if users > 0 {
+		return ErrRoleInUse
+	}
+
+	if err := s.roleRepo.Delete(ctx, role.Name); err != nil {
+		return err
+	}
+	s.invalidate()
+	s.auditService.Record(ctx, auditDelete(domain.AuditEntityRole, string(role.Name), role))
+
+	return nil
+}
+
+// PermissionsFor returns the effective permissions of a role. The admin role holds every
+// permission; a role that does not exist holds none.
+func (s *roleService) PermissionsFor(ctx context.Context, role domain.Role) (domain.PermissionSet, error) {
+	if role == domain.RoleAdmin {
+		return domain.NewPermissionSet(domain.AllPermissions()...), nil
+	}
+
+	now := time.Now()
+	s.mu.Lock()
+	entry, ok := s.cache[role]
+	s.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.permissions, nil
+	}
+
+	definition, err := s.roleRepo.GetByName(ctx, role)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	permissions := domain.NewPermissionSet()
+	if definition != nil {
+		permissions = domain.NewPermissionSet(definition.Permissions...)
+	}
+
+	s.mu.Lock()
+	s.cache[role] = permissionCacheEntry{permissions: permissions, expiresAt: now.Add(s.cacheTTL)}
+	s.mu.Unlock()
+
+	return permissions, nil
+}
+
+func (s *roleService) invalidate() {
+	s.mu.Lock()
+	s.cache = make(map[domain.Role]permissionCacheEntry)
+	s.mu.Unlock()
+}
+
+// parsePermissions checks the requested permissions against the known ones and drops
+// duplicates
+func parsePermissions(values []string) ([]domain.Permission, error) {
+	set := domain.NewPermissionSet()
+	for _, value := range values {
+		permission := domain.Permission(value)
+		if !permission.IsValid() {
+			return nil, apperror.Validation(ErrUnknownPermission.Code, fmt.Sprintf("%s: %s", ErrUnknownPermission.Message, value),
+				apperror.FieldError{Field: "permissions", Message: fmt.Sprintf("%s is not a known permission", value)})
+		}
+		set[permission] = struct{}{}
+	}
+	return set.List(), nil
+}
+
+// fillAdminPermissions reports the admin role with every permission, including ones
+// added after it was seeded
+func fillAdminPermissions(role *domain.RoleDefinition) {
+	if role.Name == domain.RoleAdmin {
+		role.Permissions = domain.AllPermissions()
+	}
+}
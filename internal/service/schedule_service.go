@@ -1,34 +1,92 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"time"
 
 	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/apperror"
 	"dashboard-ac-backend/internal/domain"
 	"dashboard-ac-backend/internal/repository"
+	"dashboard-ac-backend/pkg/logger"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 type ScheduleService interface {
-	Create(req *request.ScheduleCreateRequest) (*domain.Schedule, error)
-	GetByID(id string) (*domain.Schedule, error)
-	Update(id string, req *request.ScheduleUpdateRequest) (*domain.Schedule, error)
-	Delete(id string) error
-	List(pagination *request.PaginationRequest) ([]*domain.Schedule, int64, error)
-	Search(req *request.ScheduleSearchRequest) ([]*domain.Schedule, int64, error)
-	GetByCustomerID(customerID string, pagination *request.PaginationRequest) ([]*domain.Schedule, int64, error)
-	GetByTechnicianID(technicianID string, pagination *request.PaginationRequest) ([]*domain.Schedule, int64, error)
-	GetByStatus(status domain.ScheduleStatus, pagination *request.PaginationRequest) ([]*domain.Schedule, int64, error)
+	Create(ctx context.Context, req *request.ScheduleCreateRequest) (*domain.Schedule, error)
+	GetByID(ctx context.Context, scope domain.AccessScope, id string) (*domain.Schedule, error)
+	LoadRelations(ctx context.Context, schedule *domain.Schedule, include string) error
+	Update(ctx context.Context, id string, req *request.ScheduleUpdateRequest, actorID uint) (*domain.Schedule, error)
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, scope domain.AccessScope, pagination *request.PaginationRequest) ([]*domain.Schedule, repository.ListPage, error)
+	Search(ctx context.Context, scope domain.AccessScope, req *request.ScheduleSearchRequest) ([]*domain.Schedule, repository.ListPage, error)
+	Export(ctx context.Context, scope domain.AccessScope, req *request.ScheduleSearchRequest) func() ([]*domain.Schedule, error)
+	GetByCustomerID(ctx context.Context, scope domain.AccessScope, customerID string, pagination *request.PaginationRequest) ([]*domain.Schedule, repository.ListPage, error)
+	GetByTechnicianID(ctx context.Context, scope domain.AccessScope, technicianID string, pagination *request.PaginationRequest) ([]*domain.Schedule, repository.ListPage, error)
+	GetByStatus(ctx context.Context, scope domain.AccessScope, status domain.ScheduleStatus, pagination *request.PaginationRequest) ([]*domain.Schedule, repository.ListPage, error)
+	GetTechnicianAgenda(ctx context.Context, technicianID uuid.UUID, date time.Time) ([]*domain.Schedule, error)
+	Start(ctx context.Context, id string, actorID uint) (*domain.Schedule, error)
+	Complete(ctx context.Context, id string, actorID uint) (*domain.Schedule, error)
+	Cancel(ctx context.Context, id string, actorID uint, reason string) (*domain.Schedule, error)
+	// CancelPending cancels the schedule only while it is still Pending, checked under
+	// the same row lock as the transition, and returns ErrBookingNotPending otherwise
+	CancelPending(ctx context.Context, id string, actorID uint, reason string) (*domain.Schedule, error)
+	GetStatusHistory(ctx context.Context, id string) ([]*domain.ScheduleStatusHistory, error)
+	OnCompleted(hook ScheduleCompletedHook)
 }
 
+// ScheduleCompletedHook runs after a schedule has been moved to Completed
+type ScheduleCompletedHook func(ctx context.Context, schedule *domain.Schedule) error
+
+// ScheduleConflictError is returned when a schedule would overlap another
+// non-canceled schedule of the same technician
+type ScheduleConflictError struct {
+	ConflictingIDs []uuid.UUID
+}
+
+func (e *ScheduleConflictError) Error() string {
+	return "technician is already booked for this time slot"
+}
+
+func (e *ScheduleConflictError) AppError() *apperror.Error {
+	return apperror.Conflict("schedule_conflict", e.Error()).WithDetails(map[string]interface{}{
+		"conflicting_schedule_ids": e.ConflictingIDs,
+	})
+}
+
+// InvalidStatusTransitionError is returned when a schedule is asked to move to a
+// status that is not reachable from its current one
+type InvalidStatusTransitionError struct {
+	From domain.ScheduleStatus
+	To   domain.ScheduleStatus
+}
+
+func (e *InvalidStatusTransitionError) Error() string {
+	return fmt.Sprintf("cannot change schedule status from %s to %s", e.From, e.To)
+}
+
+func (e *InvalidStatusTransitionError) AppError() *apperror.Error {
+	return apperror.Conflict("invalid_status_transition", e.Error()).WithDetails(map[string]interface{}{
+		"from": e.From,
+		"to":   e.To,
+	})
+}
+
+// ErrScheduleStatusChanged is returned when another request changed the status of the
+// schedule while it was being updated
+var ErrScheduleStatusChanged = apperror.Conflict("schedule_status_changed", "schedule status was changed concurrently")
+
 type scheduleService struct {
 	scheduleRepo   repository.ScheduleRepository
 	customerRepo   repository.CustomerRepository
 	technicianRepo repository.TechnicianRepository
 	serviceRepo    repository.ServiceRepository
+	auditService   AuditService
+	completedHooks []ScheduleCompletedHook
 }
 
 func NewScheduleService(
@@ -36,55 +94,57 @@ func NewScheduleService(
 	customerRepo repository.CustomerRepository,
 	technicianRepo repository.TechnicianRepository,
 	serviceRepo repository.ServiceRepository,
+	auditService AuditService,
 ) ScheduleService {
 	return &scheduleService{
 		scheduleRepo:   scheduleRepo,
 		customerRepo:   customerRepo,
 		technicianRepo: technicianRepo,
 		serviceRepo:    serviceRepo,
+		auditService:   auditService,
 	}
 }
 
-func (s *scheduleService) Create(req *request.ScheduleCreateRequest) (*domain.Schedule, error) {
+func (s *scheduleService) Create(ctx context.Context, req *request.ScheduleCreateRequest) (*domain.Schedule, error) {
 	// Parse UUIDs
 	customerID, err := uuid.Parse(req.CustomerID)
 	if err != nil {
-		return nil, errors.New("invalid customer ID format")
+		return nil, ErrInvalidCustomerID
 	}
 
 	technicianID, err := uuid.Parse(req.TechnicianID)
 	if err != nil {
-		return nil, errors.New("invalid technician ID format")
+		return nil, ErrInvalidTechnicianID
 	}
 
 	serviceID, err := uuid.Parse(req.ServiceID)
 	if err != nil {
-		return nil, errors.New("invalid service ID format")
+		return nil, ErrInvalidServiceID
 	}
 
 	// Validate customer exists
-	_, err = s.customerRepo.GetByID(req.CustomerID)
+	_, err = s.customerRepo.GetByID(ctx, req.CustomerID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("customer not found")
+			return nil, ErrCustomerNotFound
 		}
 		return nil, err
 	}
 
 	// Validate technician exists
-	_, err = s.technicianRepo.GetByID(req.TechnicianID)
+	_, err = s.technicianRepo.GetByID(ctx, req.TechnicianID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("technician not found")
+			return nil, ErrTechnicianNotFound
 		}
 		return nil, err
 	}
 
 	// Validate service exists
-	_, err = s.serviceRepo.GetByID(req.ServiceID)
+	service, err := s.serviceRepo.GetByID(ctx, req.ServiceID)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("service not found")
+			return nil, ErrServiceNotFound
 		}
 		return nil, err
 	}
@@ -98,121 +158,186 @@ func (s *scheduleService) Create(req *request.ScheduleCreateRequest) (*domain.Sc
 		Status:       domain.ScheduleStatusPending,
 	}
 
-	if err := s.scheduleRepo.Create(schedule); err != nil {
+	// Make sure the technician is not double-booked; the check and the insert run
+	// under a lock on the technician so concurrent bookings cannot both pass
+	err = s.scheduleRepo.WithTechnicianLock(ctx, technicianID, func(repo repository.ScheduleRepository) error {
+		if err := checkTechnicianAvailability(ctx, repo, schedule, service.Duration, ""); err != nil {
+			return err
+		}
+		return repo.Create(ctx, schedule)
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTechnicianNotFound
+		}
 		return nil, err
 	}
+	s.auditService.Record(ctx, auditCreate(domain.AuditEntitySchedule, schedule.ID.String(), schedule))
 
 	return schedule, nil
 }
 
-func (s *scheduleService) GetByID(id string) (*domain.Schedule, error) {
-	schedule, err := s.scheduleRepo.GetByID(id)
+// GetByID returns a schedule visible in scope; schedules outside it are reported as not found
+func (s *scheduleService) GetByID(ctx context.Context, scope domain.AccessScope, id string) (*domain.Schedule, error) {
+	schedule, err := s.scheduleRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("schedule not found")
+			return nil, ErrScheduleNotFound
 		}
 		return nil, err
 	}
 
+	if !scope.AllowsSchedule(schedule) {
+		return nil, ErrScheduleNotFound
+	}
+
 	return schedule, nil
 }
 
-func (s *scheduleService) Update(id string, req *request.ScheduleUpdateRequest) (*domain.Schedule, error) {
+// LoadRelations embeds the related records named in include, e.g. "customer,service"
+func (s *scheduleService) LoadRelations(ctx context.Context, schedule *domain.Schedule, include string) error {
+	return s.scheduleRepo.LoadRelations(ctx, []*domain.Schedule{schedule}, include)
+}
+
+func (s *scheduleService) Update(ctx context.Context, id string, req *request.ScheduleUpdateRequest, actorID uint) (*domain.Schedule, error) {
 	// Check if schedule exists
-	schedule, err := s.scheduleRepo.GetByID(id)
+	schedule, err := s.scheduleRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("schedule not found")
+			return nil, ErrScheduleNotFound
 		}
 		return nil, err
 	}
+	before := *schedule
+
+	// Track whether the booked window changes so we only re-check conflicts when needed
+	rescheduled := false
 
 	// Update fields if provided
 	if req.TechnicianID != nil && *req.TechnicianID != "" {
 		technicianID, err := uuid.Parse(*req.TechnicianID)
 		if err != nil {
-			return nil, errors.New("invalid technician ID format")
+			return nil, ErrInvalidTechnicianID
 		}
 		
 		// Validate technician exists
-		_, err = s.technicianRepo.GetByID(*req.TechnicianID)
+		_, err = s.technicianRepo.GetByID(ctx, *req.TechnicianID)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return nil, errors.New("technician not found")
+				return nil, ErrTechnicianNotFound
 			}
 			return nil, err
 		}
+		rescheduled = rescheduled || schedule.TechnicianID != technicianID
 		schedule.TechnicianID = technicianID
 	}
 
 	if req.ServiceID != nil && *req.ServiceID != "" {
 		serviceID, err := uuid.Parse(*req.ServiceID)
 		if err != nil {
-			return nil, errors.New("invalid service ID format")
+			return nil, ErrInvalidServiceID
 		}
 		
 		// Validate service exists
-		_, err = s.serviceRepo.GetByID(*req.ServiceID)
+		_, err = s.serviceRepo.GetByID(ctx, *req.ServiceID)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return nil, errors.New("service not found")
+				return nil, ErrServiceNotFound
 			}
 			return nil, err
 		}
+		rescheduled = rescheduled || schedule.ServiceID != serviceID
 		schedule.ServiceID = serviceID
 	}
 
 	if req.Date != nil && !req.Date.IsZero() {
 		schedule.Date = *req.Date
+		rescheduled = true
 	}
 
 	if req.Time != nil && !req.Time.IsZero() {
 		schedule.Time = *req.Time
+		rescheduled = true
 	}
 
-	if req.Status != nil && *req.Status != "" {
+	// Status changes must follow the allowed transitions and are recorded in the history
+	var history *domain.ScheduleStatusHistory
+	if req.Status != nil && *req.Status != "" && domain.ScheduleStatus(*req.Status) != schedule.Status {
 		status := domain.ScheduleStatus(*req.Status)
-		// Validate status
-		if status != domain.ScheduleStatusPending && 
-		   status != domain.ScheduleStatusOnProgress && 
-		   status != domain.ScheduleStatusCompleted && 
-		   status != domain.ScheduleStatusCanceled {
-			return nil, errors.New("invalid status")
+		history, err = s.transition(schedule, status, actorID, "")
+		if err != nil {
+			return nil, err
 		}
-		schedule.Status = status
 	}
 
-	if err := s.scheduleRepo.Update(schedule); err != nil {
+	save := func(repo repository.ScheduleRepository) error {
+		if history != nil {
+			return repo.UpdateWithHistory(ctx, schedule, history)
+		}
+		return repo.Update(ctx, schedule)
+	}
+
+	// Re-check the technician's agenda when the booked window moved, under the same
+	// lock as Create
+	if rescheduled && schedule.Status != domain.ScheduleStatusCanceled {
+		service, err := s.serviceRepo.GetByID(ctx, schedule.ServiceID.String())
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, ErrServiceNotFound
+			}
+			return nil, err
+		}
+
+		err = s.scheduleRepo.WithTechnicianLock(ctx, schedule.TechnicianID, func(repo repository.ScheduleRepository) error {
+			if err := checkTechnicianAvailability(ctx, repo, schedule, service.Duration, schedule.ID.String()); err != nil {
+				return err
+			}
+			return save(repo)
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTechnicianNotFound
+		}
+	} else {
+		err = save(s.scheduleRepo)
+	}
+	if err != nil {
+		if errors.Is(err, repository.ErrScheduleStatusChanged) {
+			return nil, ErrScheduleStatusChanged
+		}
 		return nil, err
 	}
+	s.auditService.Record(ctx, auditUpdate(domain.AuditEntitySchedule, id, &before, schedule))
+
+	if history != nil {
+		s.afterTransition(ctx, schedule)
+	}
 
 	return schedule, nil
 }
 
-func (s *scheduleService) Delete(id string) error {
+func (s *scheduleService) Delete(ctx context.Context, id string) error {
 	// Check if schedule exists
-	_, err := s.scheduleRepo.GetByID(id)
+	schedule, err := s.scheduleRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("schedule not found")
+			return ErrScheduleNotFound
 		}
 		return err
 	}
 
-	return s.scheduleRepo.Delete(id)
-}
-
-func (s *scheduleService) List(pagination *request.PaginationRequest) ([]*domain.Schedule, int64, error) {
-	offset := pagination.GetOffset()
-	limit := pagination.GetLimit()
+	if err := s.scheduleRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.auditService.Record(ctx, auditDelete(domain.AuditEntitySchedule, id, schedule))
 
-	return s.scheduleRepo.List(offset, limit)
+	return nil
 }
 
-func (s *scheduleService) Search(req *request.ScheduleSearchRequest) ([]*domain.Schedule, int64, error) {
-	offset := req.GetOffset()
-	limit := req.GetLimit()
+func (s *scheduleService) List(ctx context.Context, scope domain.AccessScope, pagination *request.PaginationRequest) ([]*domain.Schedule, repository.ListPage, error) {
+	return s.scheduleRepo.List(ctx, scope, listOptions(pagination))
+}
 
+func (s *scheduleService) Search(ctx context.Context, scope domain.AccessScope, req *request.ScheduleSearchRequest) ([]*domain.Schedule, repository.ListPage, error) {
 	var dateFrom, dateTo *time.Time
 	if req.DateFrom != "" {
 		if parsed, err := time.Parse("2006-01-02", req.DateFrom); err == nil {
@@ -230,26 +355,166 @@ func (s *scheduleService) Search(req *request.ScheduleSearchRequest) ([]*domain.
 		status = domain.ScheduleStatus(req.Status)
 	}
 
-	return s.scheduleRepo.Search(req.CustomerID, req.TechnicianID, req.ServiceID, status, dateFrom, dateTo, offset, limit)
+	return s.scheduleRepo.Search(ctx, scope, req.CustomerID, req.TechnicianID, req.ServiceID, status, dateFrom, dateTo, listOptions(req.PaginationRequest))
+}
+
+// Export returns a function loading the schedules matching req batch by batch, with
+// their customer, technician and service
+func (s *scheduleService) Export(ctx context.Context, scope domain.AccessScope, req *request.ScheduleSearchRequest) func() ([]*domain.Schedule, error) {
+	search := *req
+	return exportBatches(req.PaginationRequest, "customer,technician,service", func(pagination *request.PaginationRequest) ([]*domain.Schedule, repository.ListPage, error) {
+		search.PaginationRequest = pagination
+		return s.Search(ctx, scope, &search)
+	})
+}
+
+func (s *scheduleService) GetByCustomerID(ctx context.Context, scope domain.AccessScope, customerID string, pagination *request.PaginationRequest) ([]*domain.Schedule, repository.ListPage, error) {
+	return s.scheduleRepo.GetByCustomerID(ctx, scope, customerID, listOptions(pagination))
 }
 
-func (s *scheduleService) GetByCustomerID(customerID string, pagination *request.PaginationRequest) ([]*domain.Schedule, int64, error) {
-	offset := pagination.GetOffset()
-	limit := pagination.GetLimit()
-	
-	return s.scheduleRepo.GetByCustomerID(customerID, offset, limit)
+func (s *scheduleService) GetByTechnicianID(ctx context.Context, scope domain.AccessScope, technicianID string, pagination *request.PaginationRequest) ([]*domain.Schedule, repository.ListPage, error) {
+	return s.scheduleRepo.GetByTechnicianID(ctx, scope, technicianID, listOptions(pagination))
 }
 
-func (s *scheduleService) GetByTechnicianID(technicianID string, pagination *request.PaginationRequest) ([]*domain.Schedule, int64, error) {
-	offset := pagination.GetOffset()
-	limit := pagination.GetLimit()
-	
-	return s.scheduleRepo.GetByTechnicianID(technicianID, offset, limit)
+func (s *scheduleService) GetByStatus(ctx context.Context, scope domain.AccessScope, status domain.ScheduleStatus, pagination *request.PaginationRequest) ([]*domain.Schedule, repository.ListPage, error) {
+	return s.scheduleRepo.GetByStatus(ctx, scope, status, listOptions(pagination))
 }
 
-func (s *scheduleService) GetByStatus(status domain.ScheduleStatus, pagination *request.PaginationRequest) ([]*domain.Schedule, int64, error) {
-	offset := pagination.GetOffset()
-	limit := pagination.GetLimit()
-	
-	return s.scheduleRepo.GetByStatus(status, offset, limit)
-}
\ No newline at end of file
+// GetTechnicianAgenda returns the technician's non-canceled jobs on the given day in start order
+func (s *scheduleService) GetTechnicianAgenda(ctx context.Context, technicianID uuid.UUID, date time.Time) ([]*domain.Schedule, error) {
+	return s.scheduleRepo.GetTechnicianAgenda(ctx, technicianID.String(), date)
+}
+
+func (s *scheduleService) Start(ctx context.Context, id string, actorID uint) (*domain.Schedule, error) {
+	return s.changeStatus(ctx, id, domain.ScheduleStatusOnProgress, actorID, "", "")
+}
+
+func (s *scheduleService) Complete(ctx context.Context, id string, actorID uint) (*domain.Schedule, error) {
+	return s.changeStatus(ctx, id, domain.ScheduleStatusCompleted, actorID, "", "")
+}
+
+func (s *scheduleService) Cancel(ctx context.Context, id string, actorID uint, reason string) (*domain.Schedule, error) {
+	return s.changeStatus(ctx, id, domain.ScheduleStatusCanceled, actorID, reason, "")
+}
+
+func (s *scheduleService) CancelPending(ctx context.Context, id string, actorID uint, reason string) (*domain.Schedule, error) {
+	schedule, err := s.changeStatus(ctx, id, domain.ScheduleStatusCanceled, actorID, reason, domain.ScheduleStatusPending)
+	var invalid *InvalidStatusTransitionError
+	if errors.As(err, &invalid) || errors.Is(err, ErrScheduleStatusChanged) {
+		return nil, ErrBookingNotPending
+	}
+	return schedule, err
+}
+
+func (s *scheduleService) GetStatusHistory(ctx context.Context, id string) ([]*domain.ScheduleStatusHistory, error) {
+	// Check if schedule exists
+	_, err := s.scheduleRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, err
+	}
+
+	return s.scheduleRepo.GetStatusHistory(ctx, id)
+}
+
+// OnCompleted registers a hook that runs after a schedule is completed. Hooks must
+// be registered during startup, before requests are served.
+func (s *scheduleService) OnCompleted(hook ScheduleCompletedHook) {
+	s.completedHooks = append(s.completedHooks, hook)
+}
+
+// Helper function to run hooks once a transition has been committed. Hook failures
+// are logged but do not undo the transition.
+func (s *scheduleService) afterTransition(ctx context.Context, schedule *domain.Schedule) {
+	if schedule.Status != domain.ScheduleStatusCompleted {
+		return
+	}
+
+	for _, hook := range s.completedHooks {
+		if err := hook(ctx, schedule); err != nil {
+			logger.FromContext(ctx).Error().
+				Err(err).
+				Str("schedule_id", schedule.ID.String()).
+				Msg("Schedule completed hook failed")
+		}
+	}
+}
+
+// Helper function to load a schedule and move it to the given status. A non-empty
+// from limits the move to schedules in that status; UpdateWithHistory rechecks it
+// under the row lock, so a concurrent transition is reported as ErrScheduleStatusChanged.
+func (s *scheduleService) changeStatus(ctx context.Context, id string, status domain.ScheduleStatus, actorID uint, reason string, from domain.ScheduleStatus) (*domain.Schedule, error) {
+	schedule, err := s.scheduleRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrScheduleNotFound
+		}
+		return nil, err
+	}
+	before := *schedule
+
+	if from != "" && schedule.Status != from {
+		return nil, &InvalidStatusTransitionError{From: schedule.Status, To: status}
+	}
+
+	history, err := s.transition(schedule, status, actorID, reason)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.scheduleRepo.UpdateWithHistory(ctx, schedule, history); err != nil {
+		if errors.Is(err, repository.ErrScheduleStatusChanged) {
+			return nil, ErrScheduleStatusChanged
+		}
+		return nil, err
+	}
+	s.auditService.Record(ctx, auditUpdate(domain.AuditEntitySchedule, id, &before, schedule))
+
+	s.afterTransition(ctx, schedule)
+
+	return schedule, nil
+}
+
+// Helper function to validate a status transition, apply it to the schedule and
+// build the matching history entry
+func (s *scheduleService) transition(schedule *domain.Schedule, status domain.ScheduleStatus, actorID uint, reason string) (*domain.ScheduleStatusHistory, error) {
+	if !schedule.Status.CanTransitionTo(status) {
+		return nil, &InvalidStatusTransitionError{From: schedule.Status, To: status}
+	}
+
+	history := &domain.ScheduleStatusHistory{
+		ScheduleID: schedule.ID,
+		FromStatus: schedule.Status,
+		ToStatus:   status,
+		Reason:     reason,
+		ChangedBy:  actorID,
+		ChangedAt:  time.Now(),
+	}
+	schedule.Status = status
+
+	return history, nil
+}
+
+// Helper function to reject schedules that overlap the technician's existing bookings.
+// repo must be the one WithTechnicianLock hands out, so nothing is booked in between.
+func checkTechnicianAvailability(ctx context.Context, repo repository.ScheduleRepository, schedule *domain.Schedule, duration int, excludeID string) error {
+	window := schedule.Window(duration)
+
+	clashes, err := repo.GetTechnicianWindows(ctx, schedule.TechnicianID.String(), window.Start, window.End, excludeID)
+	if err != nil {
+		return err
+	}
+
+	if len(clashes) == 0 {
+		return nil
+	}
+
+	conflictingIDs := make([]uuid.UUID, 0, len(clashes))
+	for _, clash := range clashes {
+		conflictingIDs = append(conflictingIDs, clash.ScheduleID)
+	}
+
+	return &ScheduleConflictError{ConflictingIDs: conflictingIDs}
+}
@@ -1,53 +1,58 @@
 package service
 
 import (
+	"context"
 	"errors"
 
 	"dashboard-ac-backend/internal/api/request"
 	"dashboard-ac-backend/internal/domain"
 	"dashboard-ac-backend/internal/repository"
+	"dashboard-ac-backend/pkg/money"
 
 	"gorm.io/gorm"
 )
 
 type ServiceService interface {
-	Create(req *request.ServiceCreateRequest) (*domain.Service, error)
-	GetByID(id string) (*domain.Service, error)
-	Update(id string, req *request.ServiceUpdateRequest) (*domain.Service, error)
-	Delete(id string) error
-	List(pagination *request.PaginationRequest) ([]*domain.Service, int64, error)
-	Search(req *request.ServiceSearchRequest) ([]*domain.Service, int64, error)
+	Create(ctx context.Context, req *request.ServiceCreateRequest) (*domain.Service, error)
+	GetByID(ctx context.Context, id string) (*domain.Service, error)
+	Update(ctx context.Context, id string, req *request.ServiceUpdateRequest) (*domain.Service, error)
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, pagination *request.PaginationRequest) ([]*domain.Service, repository.ListPage, error)
+	Search(ctx context.Context, req *request.ServiceSearchRequest) ([]*domain.Service, repository.ListPage, error)
 }
 
 type serviceService struct {
-	serviceRepo repository.ServiceRepository
+	serviceRepo  repository.ServiceRepository
+	auditService AuditService
 }
 
-func NewServiceService(serviceRepo repository.ServiceRepository) ServiceService {
+func NewServiceService(serviceRepo repository.ServiceRepository, auditService AuditService) ServiceService {
 	return &serviceService{
-		serviceRepo: serviceRepo,
+		serviceRepo:  serviceRepo,
+		auditService: auditService,
 	}
 }
 
-func (s *serviceService) Create(req *request.ServiceCreateRequest) (*domain.Service, error) {
+func (s *serviceService) Create(ctx context.Context, req *request.ServiceCreateRequest) (*domain.Service, error) {
 	service := &domain.Service{
 		Name:     req.Name,
 		Price:    req.Price,
 		Duration: req.Duration,
 	}
 
-	if err := s.serviceRepo.Create(service); err != nil {
+	if err := s.serviceRepo.Create(ctx, service); err != nil {
 		return nil, err
 	}
+	s.auditService.Record(ctx, auditCreate(domain.AuditEntityService, service.ID.String(), service))
 
 	return service, nil
 }
 
-func (s *serviceService) GetByID(id string) (*domain.Service, error) {
-	service, err := s.serviceRepo.GetByID(id)
+func (s *serviceService) GetByID(ctx context.Context, id string) (*domain.Service, error) {
+	service, err := s.serviceRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("service not found")
+			return nil, ErrServiceNotFound
 		}
 		return nil, err
 	}
@@ -55,57 +60,59 @@ func (s *serviceService) GetByID(id string) (*domain.Service, error) {
 	return service, nil
 }
 
-func (s *serviceService) Update(id string, req *request.ServiceUpdateRequest) (*domain.Service, error) {
+func (s *serviceService) Update(ctx context.Context, id string, req *request.ServiceUpdateRequest) (*domain.Service, error) {
 	// Check if service exists
-	service, err := s.serviceRepo.GetByID(id)
+	service, err := s.serviceRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("service not found")
+			return nil, ErrServiceNotFound
 		}
 		return nil, err
 	}
 
+	before := *service
+
 	// Update fields if provided
 	if req.Name != nil && *req.Name != "" {
 		service.Name = *req.Name
 	}
-	if req.Price != nil && *req.Price > 0 {
+	if req.Price != nil && *req.Price > money.Zero {
 		service.Price = *req.Price
 	}
 	if req.Duration != nil && *req.Duration > 0 {
 		service.Duration = *req.Duration
 	}
 
-	if err := s.serviceRepo.Update(service); err != nil {
+	if err := s.serviceRepo.Update(ctx, service); err != nil {
 		return nil, err
 	}
+	s.auditService.Record(ctx, auditUpdate(domain.AuditEntityService, id, &before, service))
 
 	return service, nil
 }
 
-func (s *serviceService) Delete(id string) error {
+func (s *serviceService) Delete(ctx context.Context, id string) error {
 	// Check if service exists
-	_, err := s.serviceRepo.GetByID(id)
+	service, err := s.serviceRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("service not found")
+			return ErrServiceNotFound
 		}
 		return err
 	}
 
-	return s.serviceRepo.Delete(id)
-}
-
-func (s *serviceService) List(pagination *request.PaginationRequest) ([]*domain.Service, int64, error) {
-	offset := pagination.GetOffset()
-	limit := pagination.GetLimit()
+	if err := s.serviceRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.auditService.Record(ctx, auditDelete(domain.AuditEntityService, id, service))
 
-	return s.serviceRepo.List(offset, limit)
+	return nil
 }
 
-func (s *serviceService) Search(req *request.ServiceSearchRequest) ([]*domain.Service, int64, error) {
-	offset := req.GetOffset()
-	limit := req.GetLimit()
+func (s *serviceService) List(ctx context.Context, pagination *request.PaginationRequest) ([]*domain.Service, repository.ListPage, error) {
+	return s.serviceRepo.List(ctx, listOptions(pagination))
+}
 
-	return s.serviceRepo.Search(req.Name, req.MinPrice, req.MaxPrice, offset, limit)
+func (s *serviceService) Search(ctx context.Context, req *request.ServiceSearchRequest) ([]*domain.Service, repository.ListPage, error) {
+	return s.serviceRepo.Search(ctx, req.Name, req.MinPrice, req.MaxPrice, listOptions(req.PaginationRequest))
 }
\ No newline at end of file
@@ -1,9 +1,11 @@
 package service
 
 import (
+	"context"
 	"errors"
 
 	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/apperror"
 	"dashboard-ac-backend/internal/domain"
 	"dashboard-ac-backend/internal/repository"
 
@@ -11,43 +13,58 @@ import (
 )
 
 type TechnicianService interface {
-	Create(req *request.TechnicianCreateRequest) (*domain.Technician, error)
-	GetByID(id string) (*domain.Technician, error)
-	Update(id string, req *request.TechnicianUpdateRequest) (*domain.Technician, error)
-	Delete(id string) error
-	List(pagination *request.PaginationRequest) ([]*domain.Technician, int64, error)
-	Search(req *request.TechnicianSearchRequest) ([]*domain.Technician, int64, error)
+	Create(ctx context.Context, req *request.TechnicianCreateRequest) (*domain.Technician, error)
+	GetByID(ctx context.Context, id string) (*domain.Technician, error)
+	Update(ctx context.Context, id string, req *request.TechnicianUpdateRequest) (*domain.Technician, error)
+	Delete(ctx context.Context, id string) error
+	List(ctx context.Context, pagination *request.PaginationRequest) ([]*domain.Technician, repository.ListPage, error)
+	Search(ctx context.Context, req *request.TechnicianSearchRequest) ([]*domain.Technician, repository.ListPage, error)
 }
 
+// ErrInvalidWorkingHours is returned when the working day is malformed or the break
+// falls outside it
+var ErrInvalidWorkingHours = apperror.Validation("invalid_working_hours", "Invalid working hours")
+
 type technicianService struct {
 	technicianRepo repository.TechnicianRepository
+	auditService   AuditService
 }
 
-func NewTechnicianService(technicianRepo repository.TechnicianRepository) TechnicianService {
+func NewTechnicianService(technicianRepo repository.TechnicianRepository, auditService AuditService) TechnicianService {
 	return &technicianService{
 		technicianRepo: technicianRepo,
+		auditService:   auditService,
 	}
 }
 
-func (s *technicianService) Create(req *request.TechnicianCreateRequest) (*domain.Technician, error) {
+func (s *technicianService) Create(ctx context.Context, req *request.TechnicianCreateRequest) (*domain.Technician, error) {
 	technician := &domain.Technician{
 		Name:           req.Name,
 		Phone:          req.Phone,
 		Specialization: req.Specialization,
+		WorkStart:      req.WorkStart,
+		WorkEnd:        req.WorkEnd,
+		BreakStart:     req.BreakStart,
+		BreakEnd:       req.BreakEnd,
+	}
+
+	if err := technician.ValidateWorkingHours(); err != nil {
+		return nil, ErrInvalidWorkingHours
 	}
 
-	if err := s.technicianRepo.Create(technician); err != nil {
+	if err := s.technicianRepo.Create(ctx, technician); err != nil {
 		return nil, err
 	}
+	s.auditService.Record(ctx, auditCreate(domain.AuditEntityTechnician, technician.ID.String(), technician))
 
 	return technician, nil
 }
 
-func (s *technicianService) GetByID(id string) (*domain.Technician, error) {
-	technician, err := s.technicianRepo.GetByID(id)
+func (s *technicianService) GetByID(ctx context.Context, id string) (*domain.Technician, error) {
+	technician, err := s.technicianRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("technician not found")
+			return nil, ErrTechnicianNotFound
 		}
 		return nil, err
 	}
@@ -55,16 +72,18 @@ func (s *technicianService) GetByID(id string) (*domain.Technician, error) {
 	return technician, nil
 }
 
-func (s *technicianService) Update(id string, req *request.TechnicianUpdateRequest) (*domain.Technician, error) {
+func (s *technicianService) Update(ctx context.Context, id string, req *request.TechnicianUpdateRequest) (*domain.Technician, error) {
 	// Check if technician exists
-	technician, err := s.technicianRepo.GetByID(id)
+	technician, err := s.technicianRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("technician not found")
+			return nil, ErrTechnicianNotFound
 		}
 		return nil, err
 	}
 
+	before := *technician
+
 	// Update fields if provided
 	if req.Name != nil && *req.Name != "" {
 		technician.Name = *req.Name
@@ -75,37 +94,53 @@ func (s *technicianService) Update(id string, req *request.TechnicianUpdateReque
 	if req.Specialization != nil && *req.Specialization != "" {
 		technician.Specialization = *req.Specialization
 	}
+	if req.WorkStart != nil && *req.WorkStart != "" {
+		technician.WorkStart = *req.WorkStart
+	}
+	if req.WorkEnd != nil && *req.WorkEnd != "" {
+		technician.WorkEnd = *req.WorkEnd
+	}
+	if req.BreakStart != nil {
+		technician.BreakStart = *req.BreakStart
+	}
+	if req.BreakEnd != nil {
+		technician.BreakEnd = *req.BreakEnd
+	}
 
-	if err := s.technicianRepo.Update(technician); err != nil {
+	if err := technician.ValidateWorkingHours(); err != nil {
+		return nil, ErrInvalidWorkingHours
+	}
+
+	if err := s.technicianRepo.Update(ctx, technician); err != nil {
 		return nil, err
 	}
+	s.auditService.Record(ctx, auditUpdate(domain.AuditEntityTechnician, id, &before, technician))
 
 	return technician, nil
 }
 
-func (s *technicianService) Delete(id string) error {
+func (s *technicianService) Delete(ctx context.Context, id string) error {
 	// Check if technician exists
-	_, err := s.technicianRepo.GetByID(id)
+	technician, err := s.technicianRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("technician not found")
+			return ErrTechnicianNotFound
 		}
 		return err
 	}
 
-	return s.technicianRepo.Delete(id)
-}
-
-func (s *technicianService) List(pagination *request.PaginationRequest) ([]*domain.Technician, int64, error) {
-	offset := pagination.GetOffset()
-	limit := pagination.GetLimit()
+	if err := s.technicianRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.auditService.Record(ctx, auditDelete(domain.AuditEntityTechnician, id, technician))
 
-	return s.technicianRepo.List(offset, limit)
+	return nil
 }
 
-func (s *technicianService) Search(req *request.TechnicianSearchRequest) ([]*domain.Technician, int64, error) {
-	offset := req.GetOffset()
-	limit := req.GetLimit()
+func (s *technicianService) List(ctx context.Context, pagination *request.PaginationRequest) ([]*domain.Technician, repository.ListPage, error) {
+	return s.technicianRepo.List(ctx, listOptions(pagination))
+}
 
-	return s.technicianRepo.Search(req.Name, req.Specialization, offset, limit)
+func (s *technicianService) Search(ctx context.Context, req *request.TechnicianSearchRequest) ([]*domain.Technician, repository.ListPage, error) {
+	return s.technicianRepo.Search(ctx, req.Name, req.Specialization, listOptions(req.PaginationRequest))
 }
\ No newline at end of file
@@ -1,43 +1,82 @@
 package service
 
 import (
+	"context"
 	"errors"
+	"strconv"
 
 	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/apperror"
 	"dashboard-ac-backend/internal/domain"
 	"dashboard-ac-backend/internal/repository"
 	"dashboard-ac-backend/pkg/hash"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// ErrUnknownRole is returned when a user is assigned a role that is not defined
+var ErrUnknownRole = apperror.Validation("unknown_role", "role does not exist",
+	apperror.FieldError{Field: "role", Message: "must be an existing role"})
+
+// Errors returned when an account is linked to a record its role does not act as
+var (
+	ErrCustomerLinkRole = apperror.Validation("customer_link_role", "only customer accounts can be linked to a customer",
+		apperror.FieldError{Field: "customer_id", Message: "must be empty unless the role is customer"})
+	ErrTechnicianLinkRole = apperror.Validation("technician_link_role", "only technician accounts can be linked to a technician",
+		apperror.FieldError{Field: "technician_id", Message: "must be empty unless the role is technician"})
+)
+
 type UserService interface {
-	Create(req *request.UserCreateRequest) (*domain.User, error)
-	GetByID(id uint) (*domain.User, error)
-	Update(id uint, req *request.UserUpdateRequest) (*domain.User, error)
-	Delete(id uint) error
-	List(pagination *request.PaginationRequest) ([]*domain.User, int64, error)
-	GetByRole(role domain.Role, pagination *request.PaginationRequest) ([]*domain.User, int64, error)
+	Create(ctx context.Context, req *request.UserCreateRequest) (*domain.User, error)
+	GetByID(ctx context.Context, id uint) (*domain.User, error)
+	Update(ctx context.Context, id uint, req *request.UserUpdateRequest) (*domain.User, error)
+	Delete(ctx context.Context, id uint) error
+	List(ctx context.Context, pagination *request.PaginationRequest) ([]*domain.User, repository.ListPage, error)
+	GetByRole(ctx context.Context, role domain.Role, pagination *request.PaginationRequest) ([]*domain.User, repository.ListPage, error)
+	RevokeSessions(ctx context.Context, id uint) (int64, error)
+	Unlock(ctx context.Context, id uint) error
 }
 
 type userService struct {
-	userRepo repository.UserRepository
+	userRepo         repository.UserRepository
+	customerRepo     repository.CustomerRepository
+	technicianRepo   repository.TechnicianRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	roleRepo         repository.RoleRepository
+	auditService     AuditService
 }
 
-func NewUserService(userRepo repository.UserRepository) UserService {
+func NewUserService(
+	userRepo repository.UserRepository,
+	customerRepo repository.CustomerRepository,
+	technicianRepo repository.TechnicianRepository,
+	refreshTokenRepo repository.RefreshTokenRepository,
+	roleRepo repository.RoleRepository,
+	auditService AuditService,
+) UserService {
 	return &userService{
-		userRepo: userRepo,
+		userRepo:         userRepo,
+		customerRepo:     customerRepo,
+		technicianRepo:   technicianRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		roleRepo:         roleRepo,
+		auditService:     auditService,
 	}
 }
 
-func (s *userService) Create(req *request.UserCreateRequest) (*domain.User, error) {
+func (s *userService) Create(ctx context.Context, req *request.UserCreateRequest) (*domain.User, error) {
 	// Check if user already exists
-	existingUser, err := s.userRepo.GetByEmail(req.Email)
+	existingUser, err := s.userRepo.GetByEmail(ctx, req.Email)
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, err
 	}
 	if existingUser != nil {
-		return nil, errors.New("user with this email already exists")
+		return nil, ErrEmailTaken
+	}
+
+	if err := s.checkRoleExists(ctx, req.Role); err != nil {
+		return nil, err
 	}
 
 	// Hash password
@@ -55,33 +94,46 @@ func (s *userService) Create(req *request.UserCreateRequest) (*domain.User, erro
 		IsActive: true,
 	}
 
-	if err := s.userRepo.Create(user); err != nil {
+	// Link to customer/technician records if provided
+	if user.CustomerID, err = s.resolveCustomerLink(ctx, req.CustomerID); err != nil {
+		return nil, err
+	}
+	if user.TechnicianID, err = s.resolveTechnicianLink(ctx, req.TechnicianID); err != nil {
+		return nil, err
+	}
+	if err := checkLinksMatchRole(user); err != nil {
 		return nil, err
 	}
 
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, err
+	}
+	s.auditService.Record(ctx, auditCreate(domain.AuditEntityUser, userEntityID(user.ID), user))
+
 	return user, nil
 }
 
-func (s *userService) GetByID(id uint) (*domain.User, error) {
-	user, err := s.userRepo.GetByID(id)
+func (s *userService) GetByID(ctx context.Context, id uint) (*domain.User, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
 	return user, nil
 }
 
-func (s *userService) Update(id uint, req *request.UserUpdateRequest) (*domain.User, error) {
+func (s *userService) Update(ctx context.Context, id uint, req *request.UserUpdateRequest) (*domain.User, error) {
 	// Get existing user
-	user, err := s.userRepo.GetByID(id)
+	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, err
 	}
+	before := *user
 
 	// Update fields if provided
 	if req.Name != nil {
@@ -89,53 +141,189 @@ func (s *userService) Update(id uint, req *request.UserUpdateRequest) (*domain.U
 	}
 	if req.Email != nil {
 		// Check if email is already taken by another user
-		existingUser, err := s.userRepo.GetByEmail(*req.Email)
+		existingUser, err := s.userRepo.GetByEmail(ctx, *req.Email)
 		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, err
 		}
 		if existingUser != nil && existingUser.ID != user.ID {
-			return nil, errors.New("email is already taken")
+			return nil, ErrEmailTaken
 		}
 		user.Email = *req.Email
 	}
 	if req.Role != nil {
+		if err := s.checkRoleExists(ctx, *req.Role); err != nil {
+			return nil, err
+		}
 		user.Role = domain.Role(*req.Role)
 	}
 	if req.IsActive != nil {
 		user.IsActive = *req.IsActive
 	}
+	if req.CustomerID != nil {
+		if user.CustomerID, err = s.resolveCustomerLink(ctx, *req.CustomerID); err != nil {
+			return nil, err
+		}
+	}
+	if req.TechnicianID != nil {
+		if user.TechnicianID, err = s.resolveTechnicianLink(ctx, *req.TechnicianID); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkLinksMatchRole(user); err != nil {
+		return nil, err
+	}
+
+	// Deactivation, a new role or new links take effect on the next request instead of
+	// when the current access tokens expire
+	if !user.HasSameClaims(&before) {
+		user.RevokeAccessTokens()
+	}
 
 	// Save updated user
-	if err := s.userRepo.Update(user); err != nil {
+	if err := s.userRepo.Update(ctx, user); err != nil {
 		return nil, err
 	}
+	s.auditService.Record(ctx, auditUpdate(domain.AuditEntityUser, userEntityID(id), &before, user))
 
 	return user, nil
 }
 
-func (s *userService) Delete(id uint) error {
+func (s *userService) Delete(ctx context.Context, id uint) error {
 	// Check if user exists
-	_, err := s.userRepo.GetByID(id)
+	user, err := s.userRepo.GetByID(ctx, id)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return errors.New("user not found")
+			return ErrUserNotFound
 		}
 		return err
 	}
 
-	return s.userRepo.Delete(id)
+	if err := s.userRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.auditService.Record(ctx, auditDelete(domain.AuditEntityUser, userEntityID(id), user))
+
+	return nil
 }
 
-func (s *userService) List(pagination *request.PaginationRequest) ([]*domain.User, int64, error) {
-	offset := pagination.GetOffset()
-	limit := pagination.GetLimit()
-	
-	return s.userRepo.List(offset, limit)
+// RevokeSessions revokes every refresh token and access token of the user, forcing all
+// their devices to log in again
+func (s *userService) RevokeSessions(ctx context.Context, id uint) (int64, error) {
+	// Check if user exists
+	_, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrUserNotFound
+		}
+		return 0, err
+	}
+
+	if err := s.userRepo.IncrementTokenVersion(ctx, id); err != nil {
+		return 0, err
+	}
+
+	return s.refreshTokenRepo.RevokeAllForUser(ctx, id)
 }
 
-func (s *userService) GetByRole(role domain.Role, pagination *request.PaginationRequest) ([]*domain.User, int64, error) {
-	offset := pagination.GetOffset()
-	limit := pagination.GetLimit()
-	
-	return s.userRepo.GetByRole(role, offset, limit)
-}
\ No newline at end of file
+// Unlock lifts a lockout after failed logins and resets the failure counter
+func (s *userService) Unlock(ctx context.Context, id uint) error {
+	// Check if user exists
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUserNotFound
+		}
+		return err
+	}
+
+	before := *user
+
+	if err := s.userRepo.ResetLoginFailures(ctx, id); err != nil {
+		return err
+	}
+
+	user.FailedLoginCount = 0
+	user.LastFailedLoginAt = nil
+	user.LockedUntil = nil
+	s.auditService.Record(ctx, auditUpdate(domain.AuditEntityUser, userEntityID(id), &before, user))
+
+	return nil
+}
+
+func (s *userService) List(ctx context.Context, pagination *request.PaginationRequest) ([]*domain.User, repository.ListPage, error) {
+	return s.userRepo.List(ctx, listOptions(pagination))
+}
+
+func (s *userService) GetByRole(ctx context.Context, role domain.Role, pagination *request.PaginationRequest) ([]*domain.User, repository.ListPage, error) {
+	return s.userRepo.GetByRole(ctx, role, listOptions(pagination))
+}
+
+// Helper function to validate that a role is defined
+func (s *userService) checkRoleExists(ctx context.Context, role string) error {
+	if _, err := s.roleRepo.GetByName(ctx, domain.Role(role)); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrUnknownRole
+		}
+		return err
+	}
+	return nil
+}
+
+// Helper function to validate a customer link; an empty ID means no link
+func (s *userService) resolveCustomerLink(ctx context.Context, customerID string) (*uuid.UUID, error) {
+	if customerID == "" {
+		return nil, nil
+	}
+
+	id, err := uuid.Parse(customerID)
+	if err != nil {
+		return nil, ErrInvalidCustomerID
+	}
+
+	if _, err := s.customerRepo.GetByID(ctx, customerID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCustomerNotFound
+		}
+		return nil, err
+	}
+
+	return &id, nil
+}
+
+// userEntityID is the ID users are recorded under in the audit log
+func userEntityID(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
+}
+
+// checkLinksMatchRole rejects a customer link on an account that is not a customer and
+// a technician link on one that is not a technician, since access is scoped by role
+func checkLinksMatchRole(user *domain.User) error {
+	if user.CustomerID != nil && user.Role != domain.RoleCustomer {
+		return ErrCustomerLinkRole
+	}
+	if user.TechnicianID != nil && user.Role != domain.RoleTechnician {
+		return ErrTechnicianLinkRole
+	}
+	return nil
+}
+
+// Helper function to validate a technician link; an empty ID means no link
+func (s *userService) resolveTechnicianLink(ctx context.Context, technicianID string) (*uuid.UUID, error) {
+	if technicianID == "" {
+		return nil, nil
+	}
+
+	id, err := uuid.Parse(technicianID)
+	if err != nil {
+		return nil, ErrInvalidTechnicianID
+	}
+
+	if _, err := s.technicianRepo.GetByID(ctx, technicianID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTechnicianNotFound
+		}
+		return nil, err
+	}
+
+	return &id, nil
+}
@@ -6,6 +6,7 @@ import (
 	"dashboard-ac-backend/config"
 	"dashboard-ac-backend/internal/domain"
 	"dashboard-ac-backend/pkg/hash"
+	"dashboard-ac-backend/pkg/money"
 
 	"gorm.io/gorm"
 )
@@ -28,6 +29,16 @@ func main() {
 		log.Fatal("Failed to run migrations:", err)
 	}
 
+	// Create the built-in roles and their permissions
+	if err := config.SeedRoles(db); err != nil {
+		log.Fatal("Failed to seed roles:", err)
+	}
+
+	// Link existing accounts to their customer records
+	if err := config.BackfillUserLinks(db); err != nil {
+		log.Fatal("Failed to backfill user links:", err)
+	}
+
 	// Seed database
 	if err := seedDatabase(db); err != nil {
 		log.Fatal("Failed to seed database:", err)
@@ -38,17 +49,8 @@ func main() {
 
 func runMigrations(db *gorm.DB) error {
 	log.Println("Running database migrations...")
-	
-	// Auto migrate all models
-	return db.AutoMigrate(
-		&domain.User{},
-		&domain.Customer{},
-		&domain.Technician{},
-		&domain.Service{},
-		&domain.Schedule{},
-		&domain.Invoice{},
-		&domain.InvoiceDetail{},
-	)
+
+	return config.MigrateSchema(db)
 }
 
 func seedDatabase(db *gorm.DB) error {
@@ -62,8 +64,14 @@ func seedDatabase(db *gorm.DB) error {
 		return nil
 	}
 
-	// Hash default password
-	hashedPassword, err := hash.HashPassword("admin123")
+	// Only the admin is seeded. Technician and customer accounts are created through
+	// the API, where they are linked to their records.
+	password, generated, err := config.InitialAdminPassword()
+	if err != nil {
+		return err
+	}
+
+	hashedPassword, err := hash.HashPassword(password)
 	if err != nil {
 		return err
 	}
@@ -81,68 +89,32 @@ func seedDatabase(db *gorm.DB) error {
 		return err
 	}
 
-	log.Printf("Default admin user created with email: %s and password: admin123", admin.Email)
-
-	// Create default technician user
-	techPassword, err := hash.HashPassword("tech123")
-	if err != nil {
-		return err
-	}
-
-	technician := domain.User{
-		Name:     "Default Technician",
-		Email:    "technician@example.com",
-		Password: techPassword,
-		Role:     domain.RoleTechnician,
-		IsActive: true,
-	}
-
-	if err := db.Create(&technician).Error; err != nil {
-		return err
-	}
-
-	log.Printf("Default technician user created with email: %s and password: tech123", technician.Email)
-
-	// Create default customer user
-	customerPassword, err := hash.HashPassword("customer123")
-	if err != nil {
-		return err
+	if generated {
+		log.Printf("Default admin user created with email: %s and a generated password: %s (change it after the first login)", admin.Email, password)
+	} else {
+		log.Printf("Default admin user created with email: %s and the password from ADMIN_INITIAL_PASSWORD", admin.Email)
 	}
 
-	customer := domain.User{
-		Name:     "Default Customer",
-		Email:    "customer@example.com",
-		Password: customerPassword,
-		Role:     domain.RoleCustomer,
-		IsActive: true,
-	}
-
-	if err := db.Create(&customer).Error; err != nil {
-		return err
-	}
-
-	log.Printf("Default customer user created with email: %s and password: customer123", customer.Email)
-
 	// Seed default services
 	services := []domain.Service{
 		{
 			Name:     "Cuci AC",
-			Price:    150000,
+			Price:    money.FromMajor(150000),
 			Duration: 60, // 1 hour
 		},
 		{
 			Name:     "Isi Freon",
-			Price:    200000,
+			Price:    money.FromMajor(200000),
 			Duration: 45, // 45 minutes
 		},
 		{
 			Name:     "Bongkar Pasang AC",
-			Price:    500000,
+			Price:    money.FromMajor(500000),
 			Duration: 180, // 3 hours
 		},
 		{
 			Name:     "Service Rutin AC",
-			Price:    100000,
+			Price:    money.FromMajor(100000),
 			Duration: 30, // 30 minutes
 		},
 	}
@@ -154,7 +126,7 @@ func seedDatabase(db *gorm.DB) error {
 			if err := db.Create(&service).Error; err != nil {
 				return err
 			}
-			log.Printf("Service created: %s - Rp %.0f", service.Name, service.Price)
+			log.Printf("Service created: %s - %s", service.Name, service.Price.Format())
 		}
 	}
 
@@ -0,0 +1,153 @@
+package export
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// Format is a spreadsheet file format
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatXLSX Format = "xlsx"
+)
+
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// ParseFormat reads a format name, defaulting to CSV when it is empty
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(name)) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatXLSX:
+		return FormatXLSX, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// ContentType returns the MIME type of files in the format
+func (f Format) ContentType() string {
+	if f == FormatXLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Writer writes a table row by row. Cells may be strings, numbers or money.Money
+// amounts; nil is an empty cell. Flush passes the rows written so far on to the
+// underlying writer where the format allows it, which XLSX does not. Close must be
+// called to complete the file.
+type Writer interface {
+	WriteRow(cells ...interface{}) error
+	Flush() error
+	Close() error
+}
+
+// NewWriter returns a Writer producing a file in format on w. XLSX files hold the
+// table in a single worksheet named sheet.
+func NewWriter(format Format, w io.Writer, sheet string) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case FormatXLSX:
+		return newXLSXWriter(w, sheet)
+	}
+	return nil, ErrUnknownFormat
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func (c *csvWriter) WriteRow(cells ...interface{}) error {
+	record := make([]string, len(cells))
+	for i, cell := range cells {
+		switch value := cell.(type) {
+		case nil:
+		case string:
+			record[i] = escapeFormula(value)
+		default:
+			record[i] = fmt.Sprint(value)
+		}
+	}
+	return c.w.Write(record)
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) Close() error {
+	return c.Flush()
+}
+
+// escapeFormula quotes text that spreadsheet applications would otherwise evaluate
+// as a formula when the CSV file is opened
+func escapeFormula(value string) string {
+	if value != "" && strings.ContainsRune("=+-@\t\r", rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// xlsxWriter streams rows into the worksheet, which excelize keeps on disk once it
+// grows large, and writes the workbook out on Close
+type xlsxWriter struct {
+	out    io.Writer
+	file   *excelize.File
+	stream *excelize.StreamWriter
+	row    int
+}
+
+func newXLSXWriter(w io.Writer, sheet string) (*xlsxWriter, error) {
+	file := excelize.NewFile()
+	if err := file.SetSheetName(file.GetSheetName(0), sheet); err != nil {
+		file.Close()
+		return nil, err
+	}
+	stream, err := file.NewStreamWriter(sheet)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &xlsxWriter{out: w, file: file, stream: stream}, nil
+}
+
+func (x *xlsxWriter) WriteRow(cells ...interface{}) error {
+	values := make([]interface{}, len(cells))
+	for i, cell := range cells {
+		// Amounts are written as numbers so the sheet can sum them
+		if amount, ok := cell.(interface{ Float64() float64 }); ok {
+			values[i] = amount.Float64()
+		} else {
+			values[i] = cell
+		}
+	}
+
+	x.row++
+	axis, err := excelize.CoordinatesToCellName(1, x.row)
+	if err != nil {
+		return err
+	}
+	return x.stream.SetRow(axis, values)
+}
+
+func (x *xlsxWriter) Flush() error {
+	return nil
+}
+
+func (x *xlsxWriter) Close() error {
+	defer x.file.Close()
+	if err := x.stream.Flush(); err != nil {
+		return err
+	}
+	_, err := x.file.WriteTo(x.out)
+	return err
+}
@@ -0,0 +1,25 @@
+package hash
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// HashToken returns the hex SHA-256 digest of a high-entropy token such as a refresh
+// token. Unlike passwords these are random, so a fast hash is enough and the digest
+// can be looked up directly.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// GenerateToken returns a random URL-safe token with 256 bits of entropy
+func GenerateToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -7,26 +7,37 @@ import (
 	"dashboard-ac-backend/internal/domain"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type Claims struct {
-	UserID uint        `json:"user_id"`
-	Email  string      `json:"email"`
-	Role   domain.Role `json:"role"`
+	UserID       uint        `json:"user_id"`
+	Email        string      `json:"email"`
+	Role         domain.Role `json:"role"`
+	CustomerID   *uuid.UUID  `json:"customer_id,omitempty"`
+	TechnicianID *uuid.UUID  `json:"technician_id,omitempty"`
+	TokenVersion int         `json:"ver"`
 	jwt.RegisteredClaims
 }
 
 type TokenPair struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
+
+	// RefreshTokenID is the jti of the refresh token, used to track it server-side
+	RefreshTokenID   string    `json:"-"`
+	RefreshExpiresAt time.Time `json:"-"`
 }
 
 func GenerateTokenPair(user *domain.User, secret string) (*TokenPair, error) {
 	// Access token (15 minutes)
 	accessClaims := &Claims{
-		UserID: user.ID,
-		Email:  user.Email,
-		Role:   user.Role,
+		UserID:       user.ID,
+		Email:        user.Email,
+		Role:         user.Role,
+		CustomerID:   user.CustomerID,
+		TechnicianID: user.TechnicianID,
+		TokenVersion: user.TokenVersion,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -40,13 +51,16 @@ func GenerateTokenPair(user *domain.User, secret string) (*TokenPair, error) {
 		return nil, err
 	}
 
-	// Refresh token (7 days)
+	// Refresh token (7 days), identified by a unique jti
+	refreshID := uuid.NewString()
+	refreshExpiresAt := time.Now().Add(7 * 24 * time.Hour)
 	refreshClaims := &Claims{
 		UserID: user.ID,
 		Email:  user.Email,
 		Role:   user.Role,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)),
+			ID:        refreshID,
+			ExpiresAt: jwt.NewNumericDate(refreshExpiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   "refresh",
 		},
@@ -59,8 +73,10 @@ func GenerateTokenPair(user *domain.User, secret string) (*TokenPair, error) {
 	}
 
 	return &TokenPair{
-		AccessToken:  accessTokenString,
-		RefreshToken: refreshTokenString,
+		AccessToken:      accessTokenString,
+		RefreshToken:     refreshTokenString,
+		RefreshTokenID:   refreshID,
+		RefreshExpiresAt: refreshExpiresAt,
 	}, nil
 }
 
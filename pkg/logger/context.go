@@ -0,0 +1,19 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// FromContext returns the logger attached to ctx, such as the request-scoped logger
+// set up by the HTTP middleware, or the global logger when there is none
+func FromContext(ctx context.Context) *zerolog.Logger {
+	if ctx != nil {
+		if l := zerolog.Ctx(ctx); l.GetLevel() != zerolog.Disabled {
+			return l
+		}
+	}
+	return &log.Logger
+}
@@ -0,0 +1,68 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold is the duration above which a query is logged as a warning
+const slowQueryThreshold = 200 * time.Millisecond
+
+// GormLogger writes GORM logs through the logger of the query context, so queries run
+// with db.WithContext carry the request ID and user of the request that issued them
+type GormLogger struct {
+	level gormlogger.LogLevel
+}
+
+func NewGormLogger(level gormlogger.LogLevel) *GormLogger {
+	return &GormLogger{level: level}
+}
+
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	return &GormLogger{level: level}
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Info {
+		FromContext(ctx).Info().Msg(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		FromContext(ctx).Warn().Msg(fmt.Sprintf(msg, args...))
+	}
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Error {
+		FromContext(ctx).Error().Msg(fmt.Sprintf(msg, args...))
+	}
+}
+
+// Trace logs failed queries as errors, slow queries as warnings and every other query
+// at debug level. Missing records are an expected outcome and are not logged as errors.
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	logger := FromContext(ctx)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		sql, rows := fc()
+		logger.Error().Err(err).Str("sql", sql).Int64("rows", rows).Dur("elapsed", elapsed).Msg("Database query failed")
+	case elapsed > slowQueryThreshold && l.level >= gormlogger.Warn:
+		sql, rows := fc()
+		logger.Warn().Str("sql", sql).Int64("rows", rows).Dur("elapsed", elapsed).Msg("Slow database query")
+	case l.level >= gormlogger.Info:
+		sql, rows := fc()
+		logger.Debug().Str("sql", sql).Int64("rows", rows).Dur("elapsed", elapsed).Msg("Database query")
+	}
+}
@@ -0,0 +1,255 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Currency describes an ISO 4217 currency and how many minor-unit digits it uses
+type Currency struct {
+	Code     string
+	Exponent int
+}
+
+// IDR is the Indonesian Rupiah. Amounts are stored with two decimals to match the
+// decimal(10,2) columns in the database.
+var IDR = Currency{Code: "IDR", Exponent: 2}
+
+// DefaultCurrency is the currency every Money value is expressed in
+var DefaultCurrency = IDR
+
+var ErrInvalidAmount = errors.New("invalid money amount")
+
+// Money is an exact monetary amount stored as an integer number of minor units
+// (1/100 Rupiah for IDR). Arithmetic never goes through float64, so sums do not drift.
+//
+// Rounding rule: whenever a value with more precision than the currency allows is
+// converted to Money (parsing strings, scanning floats), it is rounded half away
+// from zero to the nearest minor unit.
+type Money int64
+
+// Zero is the zero amount
+const Zero Money = 0
+
+// FromMajor returns the amount for a whole number of major units, e.g. FromMajor(150000) is Rp 150.000
+func FromMajor(units int64) Money {
+	return Money(units * scale())
+}
+
+// FromMinor returns the amount for a number of minor units
+func FromMinor(units int64) Money {
+	return Money(units)
+}
+
+// FromFloat converts a float amount in major units, rounding half away from zero
+func FromFloat(value float64) Money {
+	return Money(math.Round(value * float64(scale())))
+}
+
+// Parse reads a decimal amount in major units such as "150000", "150000.5" or "-12.345",
+// rounding half away from zero when more digits than the currency exponent are given
+func Parse(value string) (Money, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Zero, ErrInvalidAmount
+	}
+
+	negative := false
+	switch value[0] {
+	case '-':
+		negative = true
+		value = value[1:]
+	case '+':
+		value = value[1:]
+	}
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" && fraction == "" {
+		// A sign or a dot alone is not an amount
+		return Zero, ErrInvalidAmount
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	if !isDigits(whole) || !isDigits(fraction) {
+		return Zero, ErrInvalidAmount
+	}
+
+	exponent := DefaultCurrency.Exponent
+	roundUp := false
+	if len(fraction) > exponent {
+		roundUp = fraction[exponent] >= '5'
+		fraction = fraction[:exponent]
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	units, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Zero, ErrInvalidAmount
+	}
+	if roundUp {
+		units++
+	}
+	if negative {
+		units = -units
+	}
+
+	return Money(units), nil
+}
+
+// MustParse is like Parse but panics on invalid input; intended for constants and tests
+func MustParse(value string) Money {
+	m, err := Parse(value)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Minor returns the amount in minor units
+func (m Money) Minor() int64 {
+	return int64(m)
+}
+
+// Float64 returns the amount in major units; only use it for display or reporting
+func (m Money) Float64() float64 {
+	return float64(m) / float64(scale())
+}
+
+// Currency returns the currency the amount is expressed in
+func (m Money) Currency() Currency {
+	return DefaultCurrency
+}
+
+func (m Money) Add(other Money) Money {
+	return m + other
+}
+
+func (m Money) Sub(other Money) Money {
+	return m - other
+}
+
+// Mul multiplies the amount by a whole quantity
+func (m Money) Mul(quantity int64) Money {
+	return m * Money(quantity)
+}
+
+func (m Money) IsZero() bool {
+	return m == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m < 0
+}
+
+// Sum adds up a list of amounts
+func Sum(amounts ...Money) Money {
+	var total Money
+	for _, amount := range amounts {
+		total += amount
+	}
+	return total
+}
+
+// String formats the amount in major units with exactly Exponent decimals, e.g. "150000.00"
+func (m Money) String() string {
+	exponent := DefaultCurrency.Exponent
+	units := int64(m)
+
+	sign := ""
+	if units < 0 {
+		sign = "-"
+		units = -units
+	}
+
+	if exponent == 0 {
+		return sign + strconv.FormatInt(units, 10)
+	}
+
+	s := scale()
+	return fmt.Sprintf("%s%d.%0*d", sign, units/s, exponent, units%s)
+}
+
+// Format returns the amount prefixed with its currency code, e.g. "IDR 150000.00"
+func (m Money) Format() string {
+	return DefaultCurrency.Code + " " + m.String()
+}
+
+// MarshalJSON encodes the amount as a decimal string so clients never see float rounding
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(m.String())), nil
+}
+
+// UnmarshalJSON accepts either a decimal string ("150000.00") or a JSON number (150000)
+func (m *Money) UnmarshalJSON(data []byte) error {
+	raw := string(data)
+	if raw == "null" {
+		return nil
+	}
+
+	if unquoted, err := strconv.Unquote(raw); err == nil {
+		raw = unquoted
+	}
+
+	parsed, err := Parse(raw)
+	if err != nil {
+		return err
+	}
+
+	*m = parsed
+	return nil
+}
+
+// Value stores the amount as a decimal string in major units
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan reads a decimal column. Postgres returns numerics as text; SQLite may return
+// integers or floats, which are interpreted as major units.
+func (m *Money) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*m = Zero
+	case int64:
+		*m = FromMajor(v)
+	case float64:
+		*m = FromFloat(v)
+	case []byte:
+		parsed, err := Parse(string(v))
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	case string:
+		parsed, err := Parse(v)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	default:
+		return fmt.Errorf("cannot scan %T into money.Money", src)
+	}
+	return nil
+}
+
+func scale() int64 {
+	s := int64(1)
+	for i := 0; i < DefaultCurrency.Exponent; i++ {
+		s *= 10
+	}
+	return s
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,84 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Message is a notification addressed to a single recipient
+type Message struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+// Notifier delivers messages to users. Implementations for real channels (SMTP, WhatsApp)
+// can be plugged in; LogNotifier and FileNotifier are meant for local development.
+type Notifier interface {
+	Send(msg Message) error
+}
+
+// New returns the notifier for a driver name: "log" (default) or "file"
+func New(driver, filePath string) (Notifier, error) {
+	switch driver {
+	case "", "log":
+		return NewLogNotifier(), nil
+	case "file":
+		return NewFileNotifier(filePath), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier driver %q", driver)
+	}
+}
+
+// LogNotifier writes messages to the application log
+type LogNotifier struct{}
+
+func NewLogNotifier() *LogNotifier {
+	return &LogNotifier{}
+}
+
+func (n *LogNotifier) Send(msg Message) error {
+	log.Info().
+		Str("to", msg.To).
+		Str("subject", msg.Subject).
+		Str("body", msg.Body).
+		Msg("Notification")
+	return nil
+}
+
+// FileNotifier appends messages to a file, one JSON object per line
+type FileNotifier struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileNotifier(path string) *FileNotifier {
+	return &FileNotifier{path: path}
+}
+
+func (n *FileNotifier) Send(msg Message) error {
+	line, err := json.Marshal(struct {
+		Message
+		SentAt time.Time `json:"sent_at"`
+	}{msg, time.Now()})
+	if err != nil {
+		return err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	file, err := os.OpenFile(n.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(line, '\n'))
+	return err
+}
@@ -45,13 +45,16 @@ func (suite *AuthTestSuite) SetupSuite() {
 	suite.db = db
 
 	// Auto migrate
-	err = db.AutoMigrate(&domain.User{}, &domain.Customer{})
+	err = db.AutoMigrate(&domain.User{}, &domain.Customer{}, &domain.RefreshToken{}, &domain.LoginAttempt{}, &domain.AuditLog{})
 	suite.Require().NoError(err)
 
 	// Setup repositories and services
 	userRepo := repository.NewUserRepository(db)
 	customerRepo := repository.NewCustomerRepository(db)
-	authService := service.NewAuthService(userRepo, customerRepo, db, "test-secret-key-for-integration-testing")
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(db)
+	auditService := service.NewAuditService(repository.NewAuditLogRepository(db))
+	authService := service.NewAuthService(userRepo, customerRepo, refreshTokenRepo, loginAttemptRepo, db, "test-secret-key-for-integration-testing", service.LoginPolicy{}, auditService)
 
 	// Setup handlers
 	suite.authHandler = handler.NewAuthHandler(authService)
@@ -67,7 +70,7 @@ func (suite *AuthTestSuite) SetupSuite() {
 	auth.Post("/register", suite.authHandler.Register)
 	auth.Post("/login", suite.authHandler.Login)
 	auth.Post("/refresh", suite.authHandler.RefreshToken)
-	auth.Get("/profile", middleware.JWTAuth("test-secret-key-for-integration-testing"), suite.authHandler.Me)
+	auth.Get("/profile", middleware.JWTAuth("test-secret-key-for-integration-testing", nil), suite.authHandler.Me)
 }
 
 func (suite *AuthTestSuite) TearDownSuite() {
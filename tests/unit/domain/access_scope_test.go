@@ -0,0 +1,34 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"dashboard-ac-backend/internal/domain"
+)
+
+func TestAccessScope_Unrestricted(t *testing.T) {
+	scope := domain.AccessScope{}
+	schedule := &domain.Schedule{TechnicianID: uuid.New()}
+
+	assert.False(t, scope.IsRestricted())
+	assert.True(t, scope.AllowsSchedule(schedule))
+}
+
+func TestAccessScope_Technician(t *testing.T) {
+	technicianID := uuid.New()
+	scope := domain.TechnicianScope(technicianID)
+
+	assert.True(t, scope.IsRestricted())
+	assert.True(t, scope.AllowsSchedule(&domain.Schedule{TechnicianID: technicianID}))
+	assert.False(t, scope.AllowsSchedule(&domain.Schedule{TechnicianID: uuid.New()}))
+}
+
+func TestAccessScope_UnlinkedTechnicianSeesNothing(t *testing.T) {
+	scope := domain.TechnicianScope(uuid.Nil)
+
+	assert.True(t, scope.IsRestricted())
+	assert.False(t, scope.AllowsSchedule(&domain.Schedule{TechnicianID: uuid.New()}))
+}
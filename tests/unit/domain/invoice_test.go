@@ -0,0 +1,57 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/pkg/money"
+)
+
+func TestInvoice_ApplyPayments(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          domain.InvoiceStatus
+		amountPaid      money.Money
+		expectedStatus  domain.InvoiceStatus
+		expectedBalance money.Money
+	}{
+		{"No payment keeps Unpaid", domain.InvoiceStatusUnpaid, money.Zero, domain.InvoiceStatusUnpaid, money.MustParse("300000")},
+		{"No payment keeps Overdue", domain.InvoiceStatusOverdue, money.Zero, domain.InvoiceStatusOverdue, money.MustParse("300000")},
+		{"Partial payment", domain.InvoiceStatusUnpaid, money.MustParse("100000"), domain.InvoiceStatusPartiallyPaid, money.MustParse("200000")},
+		{"Partial payment on overdue invoice", domain.InvoiceStatusOverdue, money.MustParse("0.01"), domain.InvoiceStatusPartiallyPaid, money.MustParse("299999.99")},
+		{"Full payment", domain.InvoiceStatusPartiallyPaid, money.MustParse("300000"), domain.InvoiceStatusPaid, money.Zero},
+		{"Paid invoice with payments removed", domain.InvoiceStatusPaid, money.Zero, domain.InvoiceStatusUnpaid, money.MustParse("300000")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			invoice := &domain.Invoice{
+				TotalAmount: money.MustParse("300000"),
+				Status:      tt.status,
+			}
+
+			invoice.ApplyPayments(tt.amountPaid)
+
+			assert.Equal(t, tt.expectedStatus, invoice.Status)
+			assert.Equal(t, tt.amountPaid, invoice.AmountPaid)
+			assert.Equal(t, tt.expectedBalance, invoice.BalanceDue)
+		})
+	}
+}
+
+func TestInvoice_ApplyPaymentsAfterTotalIncrease(t *testing.T) {
+	invoice := &domain.Invoice{
+		TotalAmount: money.MustParse("150000"),
+		Status:      domain.InvoiceStatusUnpaid,
+	}
+	invoice.ApplyPayments(money.MustParse("150000"))
+	assert.Equal(t, domain.InvoiceStatusPaid, invoice.Status)
+
+	// A line item added after settlement reopens the balance
+	invoice.TotalAmount = money.MustParse("250000")
+	invoice.ApplyPayments(invoice.AmountPaid)
+	assert.Equal(t, domain.InvoiceStatusPartiallyPaid, invoice.Status)
+	assert.Equal(t, money.MustParse("100000"), invoice.BalanceDue)
+}
@@ -0,0 +1,116 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"dashboard-ac-backend/internal/domain"
+)
+
+func TestSchedule_Window(t *testing.T) {
+	schedule := &domain.Schedule{
+		ID:   uuid.New(),
+		Date: time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC),
+		Time: time.Date(0, 1, 1, 9, 30, 0, 0, time.UTC),
+	}
+
+	window := schedule.Window(90)
+
+	assert.Equal(t, schedule.ID, window.ScheduleID)
+	assert.Equal(t, time.Date(2024, 5, 10, 9, 30, 0, 0, time.UTC), window.Start)
+	assert.Equal(t, time.Date(2024, 5, 10, 11, 0, 0, 0, time.UTC), window.End)
+}
+
+func TestScheduleWindow_Overlaps(t *testing.T) {
+	day := time.Date(2024, 5, 10, 0, 0, 0, 0, time.UTC)
+	at := func(hour, minute int) time.Time {
+		return day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+
+	window := domain.ScheduleWindow{Start: at(9, 0), End: at(10, 0)}
+
+	tests := []struct {
+		name     string
+		start    time.Time
+		end      time.Time
+		expected bool
+	}{
+		{
+			name:     "Same window",
+			start:    at(9, 0),
+			end:      at(10, 0),
+			expected: true,
+		},
+		{
+			name:     "Starts inside",
+			start:    at(9, 30),
+			end:      at(11, 0),
+			expected: true,
+		},
+		{
+			name:     "Ends inside",
+			start:    at(8, 0),
+			end:      at(9, 15),
+			expected: true,
+		},
+		{
+			name:     "Contains window",
+			start:    at(8, 0),
+			end:      at(11, 0),
+			expected: true,
+		},
+		{
+			name:     "Back to back before",
+			start:    at(8, 0),
+			end:      at(9, 0),
+			expected: false,
+		},
+		{
+			name:     "Back to back after",
+			start:    at(10, 0),
+			end:      at(11, 0),
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, window.Overlaps(tt.start, tt.end))
+		})
+	}
+}
+
+func TestScheduleStatus_CanTransitionTo(t *testing.T) {
+	tests := []struct {
+		name     string
+		from     domain.ScheduleStatus
+		to       domain.ScheduleStatus
+		expected bool
+	}{
+		{"Pending to On-Progress", domain.ScheduleStatusPending, domain.ScheduleStatusOnProgress, true},
+		{"Pending to Canceled", domain.ScheduleStatusPending, domain.ScheduleStatusCanceled, true},
+		{"Pending to Completed", domain.ScheduleStatusPending, domain.ScheduleStatusCompleted, false},
+		{"On-Progress to Completed", domain.ScheduleStatusOnProgress, domain.ScheduleStatusCompleted, true},
+		{"On-Progress to Canceled", domain.ScheduleStatusOnProgress, domain.ScheduleStatusCanceled, true},
+		{"On-Progress to Pending", domain.ScheduleStatusOnProgress, domain.ScheduleStatusPending, false},
+		{"Completed to Pending", domain.ScheduleStatusCompleted, domain.ScheduleStatusPending, false},
+		{"Canceled to On-Progress", domain.ScheduleStatusCanceled, domain.ScheduleStatusOnProgress, false},
+		{"Pending to unknown", domain.ScheduleStatusPending, domain.ScheduleStatus("Unknown"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.from.CanTransitionTo(tt.to))
+		})
+	}
+}
+
+func TestScheduleStatus_IsTerminal(t *testing.T) {
+	assert.False(t, domain.ScheduleStatusPending.IsTerminal())
+	assert.False(t, domain.ScheduleStatusOnProgress.IsTerminal())
+	assert.True(t, domain.ScheduleStatusCompleted.IsTerminal())
+	assert.True(t, domain.ScheduleStatusCanceled.IsTerminal())
+}
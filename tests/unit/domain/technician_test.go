@@ -0,0 +1,50 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"dashboard-ac-backend/internal/domain"
+)
+
+func TestTechnician_ValidateWorkingHours(t *testing.T) {
+	tests := []struct {
+		name       string
+		technician domain.Technician
+		valid      bool
+	}{
+		{
+			name:       "Defaults",
+			technician: domain.Technician{},
+			valid:      true,
+		},
+		{
+			name:       "Break inside working day",
+			technician: domain.Technician{WorkStart: "08:00", WorkEnd: "17:00", BreakStart: "12:00", BreakEnd: "13:00"},
+			valid:      true,
+		},
+		{
+			name:       "End before start",
+			technician: domain.Technician{WorkStart: "17:00", WorkEnd: "08:00"},
+			valid:      false,
+		},
+		{
+			name:       "Break outside working day",
+			technician: domain.Technician{WorkStart: "08:00", WorkEnd: "17:00", BreakStart: "18:00", BreakEnd: "19:00"},
+			valid:      false,
+		},
+		{
+			name:       "Break without end",
+			technician: domain.Technician{BreakStart: "12:00"},
+			valid:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.technician.ValidateWorkingHours()
+			assert.Equal(t, tt.valid, err == nil)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
 
@@ -165,4 +166,41 @@ func TestUser_SoftDelete(t *testing.T) {
 
 	assert.True(t, user.DeletedAt.Valid)
 	assert.Equal(t, now, user.DeletedAt.Time)
-}
\ No newline at end of file
+}
+
+func TestUser_HasSameClaims(t *testing.T) {
+	customerID := uuid.New()
+	user := &domain.User{
+		ID:         1,
+		Email:      "test@example.com",
+		Role:       domain.RoleCustomer,
+		IsActive:   true,
+		CustomerID: &customerID,
+	}
+
+	same := *user
+	otherCustomerID := customerID
+	same.CustomerID = &otherCustomerID
+	same.Name = "Renamed"
+	assert.True(t, user.HasSameClaims(&same))
+
+	downgraded := *user
+	downgraded.Role = domain.RoleTechnician
+	assert.False(t, user.HasSameClaims(&downgraded))
+
+	deactivated := *user
+	deactivated.IsActive = false
+	assert.False(t, user.HasSameClaims(&deactivated))
+
+	unlinked := *user
+	unlinked.CustomerID = nil
+	assert.False(t, user.HasSameClaims(&unlinked))
+}
+
+func TestUser_RevokeAccessTokens(t *testing.T) {
+	user := &domain.User{TokenVersion: 3}
+
+	user.RevokeAccessTokens()
+
+	assert.Equal(t, 4, user.TokenVersion)
+}
@@ -0,0 +1,63 @@
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+
+	"dashboard-ac-backend/pkg/export"
+	"dashboard-ac-backend/pkg/money"
+)
+
+func TestParseFormat(t *testing.T) {
+	format, err := export.ParseFormat("")
+	require.NoError(t, err)
+	assert.Equal(t, export.FormatCSV, format)
+
+	format, err = export.ParseFormat("XLSX")
+	require.NoError(t, err)
+	assert.Equal(t, export.FormatXLSX, format)
+
+	_, err = export.ParseFormat("pdf")
+	assert.ErrorIs(t, err, export.ErrUnknownFormat)
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := export.NewWriter(export.FormatCSV, &buf, "invoices")
+	require.NoError(t, err)
+
+	require.NoError(t, w.WriteRow("Customer", "Total", "Note"))
+	require.NoError(t, w.WriteRow("Budi, Jr.", money.FromMajor(150000), nil))
+	require.NoError(t, w.WriteRow("=HYPERLINK(\"x\")", money.FromMajor(-5), "-"))
+	require.NoError(t, w.Close())
+
+	assert.Equal(t, "Customer,Total,Note\n\"Budi, Jr.\",150000.00,\n\"'=HYPERLINK(\"\"x\"\")\",-5.00,'-\n", buf.String(),
+		"text that would run as a formula is quoted, amounts are not")
+}
+
+func TestXLSXWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := export.NewWriter(export.FormatXLSX, &buf, "invoices")
+	require.NoError(t, err)
+
+	require.NoError(t, w.WriteRow("Customer", "Total"))
+	require.NoError(t, w.WriteRow("Budi", money.MustParse("150000.50")))
+	require.NoError(t, w.Close())
+
+	file, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+	defer file.Close()
+
+	assert.Equal(t, []string{"invoices"}, file.GetSheetList())
+	rows, err := file.GetRows("invoices")
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"Customer", "Total"}, {"Budi", "150000.5"}}, rows)
+
+	cellType, err := file.GetCellType("invoices", "B2")
+	require.NoError(t, err)
+	assert.NotEqual(t, excelize.CellTypeSharedString, cellType, "amounts are numbers")
+}
@@ -137,4 +137,12 @@ func TestCheckPassword_CaseSensitive(t *testing.T) {
 	assert.NoError(t, hash.CheckPassword(hashedPassword, "TestPassword"))
 	assert.Error(t, hash.CheckPassword(hashedPassword, "testpassword"))
 	assert.Error(t, hash.CheckPassword(hashedPassword, "TESTPASSWORD"))
-}
\ No newline at end of file
+}
+
+func TestHashToken(t *testing.T) {
+	digest := hash.HashToken("refresh-token")
+
+	assert.Len(t, digest, 64)
+	assert.Equal(t, digest, hash.HashToken("refresh-token"))
+	assert.NotEqual(t, digest, hash.HashToken("other-token"))
+}
@@ -0,0 +1,72 @@
+package job
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"dashboard-ac-backend/internal/job"
+	"dashboard-ac-backend/internal/repository"
+	"dashboard-ac-backend/internal/service"
+)
+
+// fakeInvoiceService implements only MarkOverdue; other methods panic if called
+type fakeInvoiceService struct {
+	service.InvoiceService
+	marked int64
+	err    error
+	calls  int
+}
+
+func (f *fakeInvoiceService) MarkOverdue(ctx context.Context, now time.Time) (int64, error) {
+	f.calls++
+	return f.marked, f.err
+}
+
+func TestOverdueSweeper_RunOnce(t *testing.T) {
+	tests := []struct {
+		name          string
+		marked        int64
+		err           error
+		expected      int64
+		expectedError bool
+	}{
+		{"Marks invoices", 3, nil, 3, false},
+		{"Lock held elsewhere is skipped", 0, repository.ErrSweepInProgress, 0, false},
+		{"Database error", 0, errors.New("connection refused"), 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeInvoiceService{marked: tt.marked, err: tt.err}
+			sweeper := job.NewOverdueSweeper(fake, time.Minute)
+
+			marked, err := sweeper.RunOnce(context.Background())
+
+			assert.Equal(t, 1, fake.calls)
+			assert.Equal(t, tt.expected, marked)
+			if tt.expectedError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestOverdueSweeper_LogsThroughContextLogger(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := zerolog.New(&buf).With().Str("job", "overdue_sweep").Logger().WithContext(context.Background())
+
+	_, err := job.NewOverdueSweeper(&fakeInvoiceService{marked: 2}, time.Minute).RunOnce(ctx)
+	require.NoError(t, err)
+
+	assert.Contains(t, buf.String(), `"job":"overdue_sweep"`)
+	assert.Contains(t, buf.String(), `"marked_overdue":2`)
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 
 	"dashboard-ac-backend/internal/domain"
@@ -222,4 +223,25 @@ func TestClaims_Structure(t *testing.T) {
 	assert.Equal(t, "refresh", refreshClaims.Subject)
 	assert.NotNil(t, refreshClaims.ExpiresAt)
 	assert.NotNil(t, refreshClaims.IssuedAt)
-}
\ No newline at end of file
+}
+
+func TestTokenClaims_RecordLinks(t *testing.T) {
+	customerID := uuid.New()
+	user := &domain.User{
+		ID:         2,
+		Email:      "customer@example.com",
+		Role:       domain.RoleCustomer,
+		CustomerID: &customerID,
+	}
+	secret := "test-secret-key-for-testing"
+
+	tokenPair, err := jwt.GenerateTokenPair(user, secret)
+	assert.NoError(t, err)
+
+	claims, err := jwt.ValidateToken(tokenPair.AccessToken, secret)
+	assert.NoError(t, err)
+	if assert.NotNil(t, claims.CustomerID) {
+		assert.Equal(t, customerID, *claims.CustomerID)
+	}
+	assert.Nil(t, claims.TechnicianID)
+}
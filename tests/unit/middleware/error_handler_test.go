@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"dashboard-ac-backend/internal/api/middleware"
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/apperror"
+	"dashboard-ac-backend/internal/service"
+	"dashboard-ac-backend/pkg/utils"
+)
+
+func newErrorApp(err error) *fiber.App {
+	app := fiber.New(fiber.Config{ErrorHandler: middleware.ErrorHandler})
+	app.Get("/fail", func(c *fiber.Ctx) error {
+		return err
+	})
+	return app
+}
+
+func doErrorRequest(t *testing.T, app *fiber.App, path string) (int, map[string]interface{}, string) {
+	t.Helper()
+	resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var payload map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &payload))
+	return resp.StatusCode, payload, resp.Header.Get(fiber.HeaderRetryAfter)
+}
+
+func TestErrorHandler_MapsServiceErrors(t *testing.T) {
+	// Wrapped errors keep their meaning
+	status, payload, _ := doErrorRequest(t, newErrorApp(fmt.Errorf("load: %w", service.ErrCustomerNotFound)), "/fail")
+	assert.Equal(t, fiber.StatusNotFound, status)
+	assert.Equal(t, "error", payload["status"])
+	assert.Equal(t, "customer_not_found", payload["code"])
+	assert.Equal(t, "customer not found", payload["message"])
+
+	conflictID := uuid.New()
+	status, payload, _ = doErrorRequest(t, newErrorApp(&service.ScheduleConflictError{ConflictingIDs: []uuid.UUID{conflictID}}), "/fail")
+	assert.Equal(t, fiber.StatusConflict, status)
+	assert.Equal(t, "schedule_conflict", payload["code"])
+	assert.Equal(t, map[string]interface{}{"conflicting_schedule_ids": []interface{}{conflictID.String()}}, payload["error"])
+
+	status, payload, retryAfter := doErrorRequest(t, newErrorApp(&service.LoginThrottledError{RetryAfter: 90 * time.Second}), "/fail")
+	assert.Equal(t, fiber.StatusTooManyRequests, status)
+	assert.Equal(t, "login_throttled", payload["code"])
+	assert.Equal(t, "90", retryAfter)
+}
+
+func TestErrorHandler_ValidationDetails(t *testing.T) {
+	errs := utils.ValidateStruct(&request.LoginRequest{Email: "not-an-email"})
+	require.NotEmpty(t, errs)
+
+	status, payload, _ := doErrorRequest(t, newErrorApp(apperror.ValidationFailed(errs)), "/fail")
+	assert.Equal(t, fiber.StatusBadRequest, status)
+	assert.Equal(t, "validation_failed", payload["code"])
+
+	fields, ok := payload["error"].([]interface{})
+	require.True(t, ok)
+	require.Len(t, fields, len(errs))
+	assert.Equal(t, errs[0].Field, fields[0].(map[string]interface{})["field"])
+}
+
+func TestErrorHandler_HidesUnknownErrors(t *testing.T) {
+	status, payload, _ := doErrorRequest(t, newErrorApp(errors.New("pq: connection refused")), "/fail")
+	assert.Equal(t, fiber.StatusInternalServerError, status)
+	assert.Equal(t, "internal_error", payload["code"])
+	assert.Equal(t, "Internal server error", payload["message"])
+	assert.NotContains(t, payload, "error")
+}
+
+func TestErrorHandler_FiberClientErrors(t *testing.T) {
+	status, payload, _ := doErrorRequest(t, newErrorApp(nil), "/missing")
+	assert.Equal(t, fiber.StatusNotFound, status)
+	assert.Equal(t, "not_found", payload["code"])
+	assert.Equal(t, "Cannot GET /missing", payload["message"])
+}
@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"dashboard-ac-backend/internal/api/middleware"
+	"dashboard-ac-backend/internal/domain"
+)
+
+func newPermissionApp(role domain.Role, resolve middleware.PermissionResolver) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user_role", role)
+		return c.Next()
+	})
+	app.Use(middleware.LoadPermissions(resolve))
+	app.Get("/invoices", middleware.RequirePermission("invoices:read"), func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	app.Post("/invoices", middleware.RequirePermission(domain.PermInvoicesRead, domain.PermInvoicesWrite), func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	return app
+}
+
+func TestRequirePermission(t *testing.T) {
+	app := newPermissionApp("viewer", func(ctx context.Context, role domain.Role) (domain.PermissionSet, error) {
+		return domain.NewPermissionSet(domain.PermInvoicesRead), nil
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/invoices", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("POST", "/invoices", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+}
+
+func TestLoadPermissions_LookupFailure(t *testing.T) {
+	app := newPermissionApp("viewer", func(ctx context.Context, role domain.Role) (domain.PermissionSet, error) {
+		return nil, errors.New("database unavailable")
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/invoices", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+}
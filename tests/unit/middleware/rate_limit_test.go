@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"dashboard-ac-backend/internal/api/middleware"
+)
+
+func TestMemoryRateLimitStore_TokenBucket(t *testing.T) {
+	store := middleware.NewMemoryRateLimitStore()
+	rule := middleware.RateLimitRule{Limit: 3, Period: 3 * time.Second}
+	now := time.Date(2024, 5, 10, 9, 0, 0, 0, time.UTC)
+
+	for i := 2; i >= 0; i-- {
+		result, err := store.Take("ip:1", rule, now)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+	}
+
+	result, err := store.Take("ip:1", rule, now)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+	assert.Equal(t, 3*time.Second, result.Reset)
+
+	// Other keys have their own bucket
+	result, _ = store.Take("ip:2", rule, now)
+	assert.True(t, result.Allowed)
+
+	// One token is refilled per second
+	result, _ = store.Take("ip:1", rule, now.Add(time.Second))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+}
+
+func newRateLimitedApp(limit int) *fiber.App {
+	app := fiber.New()
+	app.Use(middleware.RateLimit(middleware.RateLimitConfig{
+		Name:    "test",
+		Rule:    middleware.RateLimitRule{Limit: limit, Period: time.Minute},
+		KeyFunc: middleware.KeyByIP,
+		Store:   middleware.NewMemoryRateLimitStore(),
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	return app
+}
+
+func TestRateLimit_Headers(t *testing.T) {
+	app := newRateLimitedApp(2)
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "2", resp.Header.Get("RateLimit-Limit"))
+	assert.Equal(t, "1", resp.Header.Get("RateLimit-Remaining"))
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest("GET", "/", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "0", resp.Header.Get("RateLimit-Remaining"))
+	assert.Equal(t, "30", resp.Header.Get("Retry-After"))
+}
+
+func TestRateLimit_DisabledWithoutLimit(t *testing.T) {
+	app := newRateLimitedApp(0)
+
+	for i := 0; i < 5; i++ {
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("RateLimit-Limit"))
+	}
+}
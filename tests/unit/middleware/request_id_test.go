@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"dashboard-ac-backend/internal/api/middleware"
+	"dashboard-ac-backend/internal/api/response"
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/pkg/logger"
+)
+
+func newRequestIDApp() *fiber.App {
+	app := fiber.New()
+	app.Use(middleware.RequestID())
+	app.Get("/ok", func(c *fiber.Ctx) error {
+		logger.FromContext(c.UserContext()).Info().Msg("handled")
+		return response.Success(c, "ok", nil)
+	})
+	app.Get("/missing", func(c *fiber.Ctx) error {
+		return response.NotFound(c, "Customer not found")
+	})
+	return app
+}
+
+func TestRequestID_GeneratesAndEchoesID(t *testing.T) {
+	app := newRequestIDApp()
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/ok", nil))
+	require.NoError(t, err)
+	generated := resp.Header.Get(fiber.HeaderXRequestID)
+	assert.Len(t, generated, 36)
+
+	req := httptest.NewRequest("GET", "/ok", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "lb-7f3a.42_x")
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, "lb-7f3a.42_x", resp.Header.Get(fiber.HeaderXRequestID))
+
+	// Malformed IDs are replaced rather than logged or echoed as sent
+	req = httptest.NewRequest("GET", "/ok", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "bad id\twith spaces")
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	assert.Len(t, resp.Header.Get(fiber.HeaderXRequestID), 36)
+}
+
+func TestRequestID_AddedToErrorResponses(t *testing.T) {
+	app := newRequestIDApp()
+
+	req := httptest.NewRequest("GET", "/missing", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "support-123")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	var payload response.BaseResponse
+	require.NoError(t, json.Unmarshal(body, &payload))
+	assert.Equal(t, "support-123", payload.RequestID)
+
+	// Successful responses are left unchanged
+	resp, err = app.Test(httptest.NewRequest("GET", "/ok", nil))
+	require.NoError(t, err)
+	body, _ = io.ReadAll(resp.Body)
+	assert.NotContains(t, string(body), "request_id")
+}
+
+func TestRequestID_RequestLoggerCarriesID(t *testing.T) {
+	var buf bytes.Buffer
+	original := log.Logger
+	log.Logger = zerolog.New(&buf)
+	defer func() { log.Logger = original }()
+
+	req := httptest.NewRequest("GET", "/ok", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "trace-1")
+	_, err := newRequestIDApp().Test(req)
+	require.NoError(t, err)
+
+	var line map[string]interface{}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+	assert.Equal(t, "trace-1", line["request_id"])
+	assert.Equal(t, "handled", line["message"])
+}
+
+func TestRequestID_AuditActorCarriesID(t *testing.T) {
+	app := fiber.New()
+	app.Use(middleware.RequestID())
+	var actor domain.AuditActor
+	app.Get("/ok", func(c *fiber.Ctx) error {
+		actor = domain.AuditActorFromContext(c.UserContext())
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	req := httptest.NewRequest("GET", "/ok", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "trace-2")
+	_, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, "trace-2", actor.RequestID)
+	assert.NotEmpty(t, actor.IPAddress)
+	assert.Nil(t, actor.UserID, "the user is added by JWTAuth")
+}
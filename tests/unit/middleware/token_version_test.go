@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"dashboard-ac-backend/internal/api/middleware"
+)
+
+type userState struct {
+	version int
+	active  bool
+}
+
+// countingLookup serves user states from a map and counts how often it is called
+func countingLookup(states map[uint]userState, calls *int) middleware.TokenStateLookup {
+	return func(ctx context.Context, userID uint) (int, bool, error) {
+		*calls++
+		state, ok := states[userID]
+		if !ok {
+			return 0, false, nil
+		}
+		return state.version, state.active, nil
+	}
+}
+
+func TestTokenVersionCache_CurrentVersion(t *testing.T) {
+	calls := 0
+	states := map[uint]userState{1: {version: 2, active: true}}
+	cache := middleware.NewTokenVersionCache(countingLookup(states, &calls), time.Minute)
+
+	current, err := cache.IsCurrent(context.Background(), 1, 2)
+	assert.NoError(t, err)
+	assert.True(t, current)
+
+	current, err = cache.IsCurrent(context.Background(), 1, 1)
+	assert.NoError(t, err)
+	assert.False(t, current)
+
+	assert.Equal(t, 1, calls, "second check should be served from the cache")
+}
+
+func TestTokenVersionCache_InactiveOrDeletedUser(t *testing.T) {
+	calls := 0
+	states := map[uint]userState{1: {version: 0, active: false}}
+	cache := middleware.NewTokenVersionCache(countingLookup(states, &calls), time.Minute)
+
+	current, err := cache.IsCurrent(context.Background(), 1, 0)
+	assert.NoError(t, err)
+	assert.False(t, current)
+
+	current, err = cache.IsCurrent(context.Background(), 2, 0)
+	assert.NoError(t, err)
+	assert.False(t, current)
+}
+
+func TestTokenVersionCache_ExpiredEntryIsReloaded(t *testing.T) {
+	calls := 0
+	states := map[uint]userState{1: {version: 0, active: true}}
+	cache := middleware.NewTokenVersionCache(countingLookup(states, &calls), 0)
+
+	current, _ := cache.IsCurrent(context.Background(), 1, 0)
+	assert.True(t, current)
+
+	// The user is re-roled; with no TTL the change is seen immediately
+	states[1] = userState{version: 1, active: true}
+	current, _ = cache.IsCurrent(context.Background(), 1, 0)
+	assert.False(t, current)
+	assert.Equal(t, 2, calls)
+}
+
+func TestTokenVersionCache_LookupError(t *testing.T) {
+	cache := middleware.NewTokenVersionCache(func(context.Context, uint) (int, bool, error) {
+		return 0, false, errors.New("database unavailable")
+	}, time.Minute)
+
+	current, err := cache.IsCurrent(context.Background(), 1, 0)
+	assert.Error(t, err)
+	assert.False(t, current)
+}
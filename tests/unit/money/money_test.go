@@ -0,0 +1,114 @@
+package money
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"dashboard-ac-backend/pkg/money"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected money.Money
+		wantErr  bool
+	}{
+		{"Whole amount", "150000", money.FromMinor(15000000), false},
+		{"Two decimals", "150000.25", money.FromMinor(15000025), false},
+		{"One decimal", "0.5", money.FromMinor(50), false},
+		{"Round half up", "10.005", money.FromMinor(1001), false},
+		{"Round down", "10.004", money.FromMinor(1000), false},
+		{"Negative rounds away from zero", "-10.005", money.FromMinor(-1001), false},
+		{"Leading dot", ".75", money.FromMinor(75), false},
+		{"Empty", "", money.Zero, true},
+		{"Not a number", "abc", money.Zero, true},
+		{"Two dots", "1.2.3", money.Zero, true},
+		{"Sign only", "-", money.Zero, true},
+		{"Plus only", "+", money.Zero, true},
+		{"Dot only", ".", money.Zero, true},
+		{"Signed dot", "-.", money.Zero, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := money.Parse(tt.input)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, money.ErrInvalidAmount)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestMoney_String(t *testing.T) {
+	assert.Equal(t, "150000.00", money.FromMajor(150000).String())
+	assert.Equal(t, "0.05", money.FromMinor(5).String())
+	assert.Equal(t, "-1.50", money.FromMinor(-150).String())
+	assert.Equal(t, "IDR 200000.00", money.FromMajor(200000).Format())
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	// 0.1 + 0.2 drifts as float64 but must be exact here
+	assert.Equal(t, money.MustParse("0.30"), money.MustParse("0.10").Add(money.MustParse("0.20")))
+	assert.Equal(t, money.MustParse("450000.03"), money.MustParse("150000.01").Mul(3))
+	assert.Equal(t, money.MustParse("50.00"), money.MustParse("100").Sub(money.MustParse("50")))
+	assert.Equal(t, money.MustParse("6.60"), money.Sum(money.MustParse("1.10"), money.MustParse("2.20"), money.MustParse("3.30")))
+	assert.True(t, money.Zero.IsZero())
+	assert.True(t, money.MustParse("-0.01").IsNegative())
+}
+
+func TestMoney_JSON(t *testing.T) {
+	type payload struct {
+		Amount money.Money `json:"amount"`
+	}
+
+	data, err := json.Marshal(payload{Amount: money.MustParse("150000.5")})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount":"150000.50"}`, string(data))
+
+	var fromString payload
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":"99.99"}`), &fromString))
+	assert.Equal(t, money.MustParse("99.99"), fromString.Amount)
+
+	var fromNumber payload
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":150000}`), &fromNumber))
+	assert.Equal(t, money.FromMajor(150000), fromNumber.Amount)
+
+	var invalid payload
+	assert.Error(t, json.Unmarshal([]byte(`{"amount":"abc"}`), &invalid))
+}
+
+func TestMoney_Scan(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      interface{}
+		expected money.Money
+	}{
+		{"Nil", nil, money.Zero},
+		{"Integer", int64(150000), money.FromMajor(150000)},
+		{"Float", 1234.565, money.FromMinor(123457)},
+		{"Bytes", []byte("150000.25"), money.FromMinor(15000025)},
+		{"String", "0.10", money.FromMinor(10)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m money.Money
+			require.NoError(t, m.Scan(tt.src))
+			assert.Equal(t, tt.expected, m)
+		})
+	}
+
+	var m money.Money
+	assert.Error(t, m.Scan(true))
+
+	value, err := money.FromMinor(15000025).Value()
+	require.NoError(t, err)
+	assert.Equal(t, "150000.25", value)
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"dashboard-ac-backend/internal/apperror"
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+)
+
+type relationFixture struct {
+	db                                  *gorm.DB
+	customerID, technicianID, serviceID uuid.UUID
+	firstScheduleID, secondScheduleID   uuid.UUID
+	deletedCustomerID                   uuid.UUID
+}
+
+// newRelationFixture creates the tables schedules and invoices refer to, holding two
+// schedules for different customers, one of them deleted, with the same technician
+// and service. The tables are created by hand because SQLite has no gen_random_uuid().
+func newRelationFixture(t *testing.T) relationFixture {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+
+	for _, table := range []string{"customers", "technicians", "services"} {
+		require.NoError(t, db.Exec(`CREATE TABLE `+table+` (id TEXT PRIMARY KEY, name TEXT NOT NULL, deleted_at DATETIME)`).Error)
+	}
+	require.NoError(t, db.Exec(`CREATE TABLE schedules (
+		id TEXT PRIMARY KEY, customer_id TEXT, technician_id TEXT, service_id TEXT, date DATETIME, time DATETIME, status TEXT,
+		created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`).Error)
+	require.NoError(t, db.Exec(`CREATE TABLE invoice_details (
+		id TEXT PRIMARY KEY, invoice_id TEXT, service_id TEXT, quantity INTEGER, unit_price DECIMAL(10,2), subtotal DECIMAL(10,2),
+		created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`).Error)
+
+	f := relationFixture{
+		db:                db,
+		customerID:        uuid.New(),
+		technicianID:      uuid.New(),
+		serviceID:         uuid.New(),
+		firstScheduleID:   uuid.New(),
+		secondScheduleID:  uuid.New(),
+		deletedCustomerID: uuid.New(),
+	}
+	require.NoError(t, db.Exec(`INSERT INTO customers (id, name) VALUES (?, 'Budi')`, f.customerID.String()).Error)
+	require.NoError(t, db.Exec(`INSERT INTO customers (id, name, deleted_at) VALUES (?, 'Sari', ?)`, f.deletedCustomerID.String(), time.Now()).Error)
+	require.NoError(t, db.Exec(`INSERT INTO technicians (id, name) VALUES (?, 'Andi')`, f.technicianID.String()).Error)
+	require.NoError(t, db.Exec(`INSERT INTO services (id, name) VALUES (?, 'Cleaning')`, f.serviceID.String()).Error)
+
+	for i, s := range []struct {
+		id, customerID uuid.UUID
+	}{{f.firstScheduleID, f.customerID}, {f.secondScheduleID, f.deletedCustomerID}} {
+		require.NoError(t, db.Exec(`INSERT INTO schedules (id, customer_id, technician_id, service_id, date, time, status) VALUES (?, ?, ?, ?, ?, ?, 'Pending')`,
+			s.id.String(), s.customerID.String(), f.technicianID.String(), f.serviceID.String(),
+			time.Date(2025, 1, 10-i, 0, 0, 0, 0, time.UTC), time.Date(0, 1, 1, 9, 0, 0, 0, time.UTC)).Error)
+	}
+	return f
+}
+
+func TestInclude_EmbedsScheduleRelations(t *testing.T) {
+	f := newRelationFixture(t)
+	repo := repository.NewScheduleRepository(f.db)
+
+	schedules, _, err := repo.List(context.Background(), domain.AccessScope{}, repository.ListOptions{Limit: 10, Include: "customer, service"})
+	require.NoError(t, err)
+	require.Len(t, schedules, 2)
+
+	require.NotNil(t, schedules[0].Customer)
+	assert.Equal(t, "Budi", schedules[0].Customer.Name)
+	require.NotNil(t, schedules[1].Customer, "deleted customers are still embedded")
+	assert.Equal(t, "Sari", schedules[1].Customer.Name)
+	for _, schedule := range schedules {
+		require.NotNil(t, schedule.Service)
+		assert.Equal(t, "Cleaning", schedule.Service.Name)
+		assert.Nil(t, schedule.Technician, "only the requested relations are loaded")
+	}
+
+	schedules, _, err = repo.List(context.Background(), domain.AccessScope{}, repository.ListOptions{Limit: 10})
+	require.NoError(t, err)
+	assert.Nil(t, schedules[0].Customer)
+}
+
+func TestInclude_EmbedsInvoiceRelations(t *testing.T) {
+	f := newRelationFixture(t)
+	repo := repository.NewInvoiceRepository(f.db)
+
+	invoice := &domain.Invoice{ID: uuid.New(), ScheduleID: f.firstScheduleID, CustomerID: f.customerID}
+	other := &domain.Invoice{ID: uuid.New(), ScheduleID: f.secondScheduleID, CustomerID: f.deletedCustomerID}
+	for i := 0; i < 2; i++ {
+		require.NoError(t, f.db.Exec(`INSERT INTO invoice_details (id, invoice_id, service_id, quantity, unit_price, subtotal, created_at) VALUES (?, ?, ?, 1, 150000, 150000, ?)`,
+			uuid.New().String(), invoice.ID.String(), f.serviceID.String(), time.Now()).Error)
+	}
+
+	require.NoError(t, repo.LoadRelations(context.Background(), []*domain.Invoice{invoice, other}, "technician,details"))
+
+	require.NotNil(t, invoice.Schedule, "the schedule is loaded to carry the technician")
+	assert.Equal(t, f.firstScheduleID, invoice.Schedule.ID)
+	require.NotNil(t, invoice.Schedule.Technician)
+	assert.Equal(t, "Andi", invoice.Schedule.Technician.Name)
+	assert.Nil(t, invoice.Schedule.Service)
+	assert.Len(t, invoice.Details, 2)
+	assert.Empty(t, other.Details)
+	assert.Nil(t, invoice.Customer)
+}
+
+func TestInclude_RejectsUnknownRelations(t *testing.T) {
+	f := newRelationFixture(t)
+	ctx := context.Background()
+
+	_, _, err := repository.NewScheduleRepository(f.db).List(ctx, domain.AccessScope{}, repository.ListOptions{Limit: 10, Include: "details"})
+	require.Error(t, err)
+	appErr := apperror.From(err)
+	require.NotNil(t, appErr)
+	assert.Equal(t, "invalid_include", appErr.Code)
+
+	err = repository.NewInvoiceRepository(f.db).LoadRelations(ctx, nil, "payments")
+	require.Error(t, err)
+	assert.Equal(t, "invalid_include", apperror.From(err).Code)
+}
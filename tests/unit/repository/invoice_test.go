@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+	"dashboard-ac-backend/pkg/money"
+)
+
+// newInvoiceDB creates the invoice tables by hand, because SQLite has no
+// gen_random_uuid(), and the indexes declared on the models
+func newInvoiceDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+
+	for _, statement := range []string{
+		`CREATE TABLE invoices (id TEXT PRIMARY KEY, schedule_id TEXT NOT NULL, customer_id TEXT NOT NULL, invoice_date DATETIME, due_date DATETIME,
+			total_amount DECIMAL(10,2) NOT NULL, amount_paid DECIMAL(10,2) NOT NULL DEFAULT 0, status TEXT,
+			created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE invoice_details (id TEXT PRIMARY KEY, invoice_id TEXT, service_id TEXT, quantity INTEGER, unit_price DECIMAL(10,2), subtotal DECIMAL(10,2),
+			created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE payments (id TEXT PRIMARY KEY, invoice_id TEXT, amount DECIMAL(10,2), method TEXT, reference_number TEXT, paid_at DATETIME,
+			recorded_by INTEGER, created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`,
+	} {
+		require.NoError(t, db.Exec(statement).Error)
+	}
+	require.NoError(t, db.Migrator().CreateIndex(&domain.Invoice{}, "idx_invoices_schedule_id"))
+	return db
+}
+
+func TestInvoice_OneLiveInvoicePerSchedule(t *testing.T) {
+	db := newInvoiceDB(t)
+	repo := repository.NewInvoiceRepository(db)
+	ctx := context.Background()
+	scheduleID, customerID := uuid.New(), uuid.New()
+
+	first := &domain.Invoice{ScheduleID: scheduleID, CustomerID: customerID, TotalAmount: money.FromMajor(150000), Status: domain.InvoiceStatusUnpaid}
+	require.NoError(t, repo.CreateWithDetails(ctx, first, nil))
+
+	second := &domain.Invoice{ScheduleID: scheduleID, CustomerID: customerID, TotalAmount: money.FromMajor(150000), Status: domain.InvoiceStatusUnpaid}
+	assert.ErrorIs(t, repo.CreateWithDetails(ctx, second, nil), repository.ErrScheduleInvoiced)
+	assert.ErrorIs(t, repo.Create(ctx, second), repository.ErrScheduleInvoiced)
+
+	// A deleted invoice does not block billing the schedule again
+	require.NoError(t, repo.Delete(ctx, first.ID.String()))
+	second.ID = uuid.Nil
+	assert.NoError(t, repo.Create(ctx, second))
+}
+
+// newPartlyPaidInvoice stores an invoice with one 150000 line and a 100000 payment
+func newPartlyPaidInvoice(t *testing.T, db *gorm.DB) *domain.Invoice {
+	t.Helper()
+	ctx := context.Background()
+
+	invoice := &domain.Invoice{ScheduleID: uuid.New(), CustomerID: uuid.New(), TotalAmount: money.FromMajor(150000), Status: domain.InvoiceStatusUnpaid}
+	detail := &domain.InvoiceDetail{ServiceID: uuid.New(), Quantity: 1, UnitPrice: money.FromMajor(150000), Subtotal: money.FromMajor(150000)}
+	require.NoError(t, repository.NewInvoiceRepository(db).CreateWithDetails(ctx, invoice, []*domain.InvoiceDetail{detail}))
+
+	payment := &domain.Payment{InvoiceID: invoice.ID, Amount: money.FromMajor(100000), Method: domain.PaymentMethodCash}
+	change, err := repository.NewPaymentRepository(db).CreateAndApply(ctx, payment)
+	require.NoError(t, err)
+	require.Equal(t, domain.InvoiceStatusPartiallyPaid, change.After.Status)
+	return invoice
+}
+
+func TestInvoice_UpdateKeepsPayments(t *testing.T) {
+	db := newInvoiceDB(t)
+	repo := repository.NewInvoiceRepository(db)
+	ctx := context.Background()
+
+	// invoice is the copy loaded before the payment was recorded
+	invoice := newPartlyPaidInvoice(t, db)
+	dueDate := time.Date(2025, 4, 30, 0, 0, 0, 0, time.UTC)
+
+	updated, err := repo.Update(ctx, invoice.ID.String(), func(current *domain.Invoice) error {
+		assert.Equal(t, money.FromMajor(100000), current.AmountPaid, "apply sees the locked row, not a stale copy")
+		current.DueDate = dueDate
+		current.AmountPaid = money.Zero
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, dueDate, updated.DueDate)
+
+	stored, err := repo.GetByID(ctx, invoice.ID.String())
+	require.NoError(t, err)
+	assert.True(t, dueDate.Equal(stored.DueDate))
+	assert.Equal(t, money.FromMajor(100000), stored.AmountPaid, "only the dates and status are written")
+	assert.Equal(t, domain.InvoiceStatusPartiallyPaid, stored.Status)
+}
+
+func TestInvoiceDetail_TotalCannotFallBelowAmountPaid(t *testing.T) {
+	db := newInvoiceDB(t)
+	repo := repository.NewInvoiceDetailRepository(db)
+	ctx := context.Background()
+	invoice := newPartlyPaidInvoice(t, db)
+
+	details, err := repo.GetByInvoiceID(ctx, invoice.ID.String())
+	require.NoError(t, err)
+	require.Len(t, details, 1)
+
+	cheaper := *details[0]
+	cheaper.UnitPrice = money.FromMajor(50000)
+	cheaper.Subtotal = money.FromMajor(50000)
+	_, err = repo.UpdateAndRecalculate(ctx, &cheaper)
+	assert.ErrorIs(t, err, repository.ErrTotalBelowAmountPaid)
+	_, err = repo.DeleteAndRecalculate(ctx, details[0])
+	assert.ErrorIs(t, err, repository.ErrTotalBelowAmountPaid)
+	_, err = repo.DeleteByInvoiceIDAndRecalculate(ctx, invoice.ID.String())
+	assert.ErrorIs(t, err, repository.ErrTotalBelowAmountPaid)
+
+	// The rejected changes are rolled back
+	remaining, err := repo.GetByInvoiceID(ctx, invoice.ID.String())
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, money.FromMajor(150000), remaining[0].Subtotal)
+
+	stored, err := repository.NewInvoiceRepository(db).GetByID(ctx, invoice.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, money.FromMajor(150000), stored.TotalAmount)
+	assert.Equal(t, money.FromMajor(50000), stored.BalanceDue)
+
+	// Lowering the total down to the amount paid settles the invoice
+	exact := *details[0]
+	exact.UnitPrice = money.FromMajor(100000)
+	exact.Subtotal = money.FromMajor(100000)
+	change, err := repo.UpdateAndRecalculate(ctx, &exact)
+	require.NoError(t, err)
+	assert.Equal(t, domain.InvoiceStatusPartiallyPaid, change.Before.Status)
+	assert.Equal(t, domain.InvoiceStatusPaid, change.After.Status)
+	stored, err = repository.NewInvoiceRepository(db).GetByID(ctx, invoice.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, domain.InvoiceStatusPaid, stored.Status)
+}
+
+func TestInvoice_MarkOverdue(t *testing.T) {
+	db := newInvoiceDB(t)
+	repo := repository.NewInvoiceRepository(db)
+	ctx := context.Background()
+	today := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	create := func(dueDate time.Time, total, paid int64, status domain.InvoiceStatus) *domain.Invoice {
+		invoice := &domain.Invoice{ScheduleID: uuid.New(), CustomerID: uuid.New(), DueDate: dueDate,
+			TotalAmount: money.FromMajor(total), AmountPaid: money.FromMajor(paid), Status: status}
+		require.NoError(t, repo.Create(ctx, invoice))
+		return invoice
+	}
+
+	unpaid := create(today.AddDate(0, 0, -1), 150000, 0, domain.InvoiceStatusUnpaid)
+	partlyPaid := create(today.AddDate(0, 0, -1), 150000, 100000, domain.InvoiceStatusPartiallyPaid)
+	paid := create(today.AddDate(0, 0, -1), 150000, 150000, domain.InvoiceStatusPaid)
+	notDue := create(today, 150000, 0, domain.InvoiceStatusUnpaid)
+
+	flagged, err := repo.MarkOverdue(ctx, today)
+	require.NoError(t, err)
+	require.Len(t, flagged, 2)
+	for _, before := range flagged {
+		assert.NotEqual(t, domain.InvoiceStatusOverdue, before.Status, "the invoices are returned as they were before")
+	}
+
+	for invoice, want := range map[*domain.Invoice]domain.InvoiceStatus{
+		unpaid:     domain.InvoiceStatusOverdue,
+		partlyPaid: domain.InvoiceStatusOverdue,
+		paid:       domain.InvoiceStatusPaid,
+		notDue:     domain.InvoiceStatusUnpaid,
+	} {
+		stored, err := repo.GetByID(ctx, invoice.ID.String())
+		require.NoError(t, err)
+		assert.Equal(t, want, stored.Status, "invoice due %s with %s paid", invoice.DueDate.Format(time.DateOnly), invoice.AmountPaid)
+	}
+}
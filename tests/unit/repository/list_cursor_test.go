@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"dashboard-ac-backend/internal/apperror"
+	"dashboard-ac-backend/internal/repository"
+)
+
+func TestListCursor_PagesForwardAndBack(t *testing.T) {
+	repo, _ := newServiceRepository(t)
+	ctx := context.Background()
+	opts := repository.ListOptions{Limit: 2, Sort: "price", UseCursor: true}
+
+	services, page, err := repo.List(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Cleaning", "Freon refill"}, names(services))
+	assert.Zero(t, page.Total, "cursor pages are not counted")
+	assert.Empty(t, page.PrevCursor)
+	require.NotEmpty(t, page.NextCursor)
+
+	opts.Cursor = page.NextCursor
+	services, page, err = repo.List(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Installation"}, names(services))
+	assert.Empty(t, page.NextCursor)
+	require.NotEmpty(t, page.PrevCursor)
+
+	opts.Cursor = page.PrevCursor
+	services, page, err = repo.List(ctx, opts)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Cleaning", "Freon refill"}, names(services))
+	assert.Empty(t, page.PrevCursor)
+	assert.NotEmpty(t, page.NextCursor)
+
+	// Filters apply on top of the cursor
+	services, _, err = repo.List(ctx, repository.ListOptions{Limit: 2, Sort: "price", Filter: "duration:lt:100", UseCursor: true, Cursor: page.NextCursor})
+	require.NoError(t, err)
+	assert.Empty(t, services)
+}
+
+func TestListCursor_RejectsForeignCursors(t *testing.T) {
+	repo, _ := newServiceRepository(t)
+	ctx := context.Background()
+
+	_, page, err := repo.List(ctx, repository.ListOptions{Limit: 1, Sort: "price", UseCursor: true})
+	require.NoError(t, err)
+	require.NotEmpty(t, page.NextCursor)
+
+	for _, opts := range []repository.ListOptions{
+		{Limit: 1, Sort: "-price", Cursor: page.NextCursor},
+		{Limit: 1, Sort: "name", Cursor: page.NextCursor},
+		{Limit: 1, Sort: "price", Cursor: "not-a-cursor"},
+	} {
+		opts.UseCursor = true
+		_, _, err := repo.List(ctx, opts)
+		require.Error(t, err, "%+v", opts)
+
+		appErr := apperror.From(err)
+		require.NotNil(t, appErr)
+		assert.Equal(t, "invalid_cursor", appErr.Code)
+	}
+}
@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"dashboard-ac-backend/internal/apperror"
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+	"dashboard-ac-backend/pkg/money"
+)
+
+// newServiceRepository returns a repository over an in-memory services table holding
+// three services. The table is created by hand because SQLite has no gen_random_uuid().
+func newServiceRepository(t *testing.T) (repository.ServiceRepository, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`CREATE TABLE services (
+		id TEXT PRIMARY KEY, name TEXT NOT NULL, price DECIMAL(10,2) NOT NULL, duration INTEGER NOT NULL,
+		created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`).Error)
+
+	repo := repository.NewServiceRepository(db)
+	for _, s := range []domain.Service{
+		{Name: "Cleaning", Price: money.FromMajor(150000), Duration: 60},
+		{Name: "Freon refill", Price: money.FromMajor(350000), Duration: 45},
+		{Name: "Installation", Price: money.FromMajor(750000), Duration: 180},
+	} {
+		s.ID = uuid.New()
+		require.NoError(t, repo.Create(context.Background(), &s))
+	}
+	return repo, db
+}
+
+func names(services []*domain.Service) []string {
+	result := make([]string, len(services))
+	for i, s := range services {
+		result[i] = s.Name
+	}
+	return result
+}
+
+func TestListQuery_SortsAndFilters(t *testing.T) {
+	repo, _ := newServiceRepository(t)
+	ctx := context.Background()
+
+	services, page, err := repo.List(ctx, repository.ListOptions{Limit: 10, Sort: "-price"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), page.Total)
+	assert.Equal(t, []string{"Installation", "Freon refill", "Cleaning"}, names(services))
+
+	services, page, err = repo.List(ctx, repository.ListOptions{Limit: 10, Sort: "duration asc", Filter: "price:gte:300000"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), page.Total, "the total counts filtered records only")
+	assert.Equal(t, []string{"Freon refill", "Installation"}, names(services))
+
+	services, _, err = repo.List(ctx, repository.ListOptions{Limit: 10, Sort: "name:desc", Filter: "duration:in:45|60,price:lt:500000"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Freon refill", "Cleaning"}, names(services))
+
+	// Paging applies after sorting
+	services, page, err = repo.List(ctx, repository.ListOptions{Offset: 1, Limit: 1, Sort: "price"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), page.Total)
+	assert.Equal(t, []string{"Freon refill"}, names(services))
+}
+
+func TestListQuery_RejectsUnknownFieldsAndValues(t *testing.T) {
+	repo, db := newServiceRepository(t)
+
+	cases := []struct {
+		opts repository.ListOptions
+		code string
+	}{
+		{repository.ListOptions{Sort: "password"}, "invalid_sort"},
+		{repository.ListOptions{Sort: "price sideways"}, "invalid_sort"},
+		{repository.ListOptions{Sort: "price,price"}, "invalid_sort"},
+		{repository.ListOptions{Sort: "name; DROP TABLE services"}, "invalid_sort"},
+		{repository.ListOptions{Filter: "deleted_at:eq:2024-01-01"}, "invalid_filter"},
+		{repository.ListOptions{Filter: "price:like:1"}, "invalid_filter"},
+		{repository.ListOptions{Filter: "duration:gte:an hour"}, "invalid_filter"},
+		{repository.ListOptions{Filter: "name"}, "invalid_filter"},
+		{repository.ListOptions{Filter: "name = '' OR 1=1 --:eq:x"}, "invalid_filter"},
+	}
+	for _, tc := range cases {
+		tc.opts.Limit = 10
+		_, _, err := repo.List(context.Background(), tc.opts)
+		require.Error(t, err, "%+v", tc.opts)
+
+		var queryErr *repository.ListQueryError
+		assert.ErrorAs(t, err, &queryErr)
+		appErr := apperror.From(err)
+		require.NotNil(t, appErr)
+		assert.Equal(t, apperror.KindValidation, appErr.Kind)
+		assert.Equal(t, tc.code, appErr.Code, "%+v", tc.opts)
+	}
+
+	assert.True(t, db.Migrator().HasTable("services"))
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+)
+
+func TestLoginAttempt_CountFailuresByIP(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&domain.LoginAttempt{}))
+	repo := repository.NewLoginAttemptRepository(db)
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for _, attempt := range []domain.LoginAttempt{
+		{IPAddress: "10.0.0.1", CreatedAt: now.Add(-2 * time.Hour), FailureReason: domain.LoginFailureInvalidCredentials},
+		{IPAddress: "10.0.0.1", CreatedAt: now.Add(-40 * time.Minute), FailureReason: domain.LoginFailureInvalidCredentials},
+		{IPAddress: "10.0.0.1", CreatedAt: now.Add(-30 * time.Minute), FailureReason: domain.LoginFailureInvalidCredentials},
+		{IPAddress: "10.0.0.1", CreatedAt: now.Add(-50 * time.Minute), FailureReason: domain.LoginFailureThrottled},
+		{IPAddress: "10.0.0.1", CreatedAt: now.Add(-20 * time.Minute), FailureReason: domain.LoginFailureAccountLocked},
+		{IPAddress: "10.0.0.1", CreatedAt: now.Add(-10 * time.Minute), Success: true},
+		{IPAddress: "10.0.0.2", CreatedAt: now.Add(-45 * time.Minute), FailureReason: domain.LoginFailureInvalidCredentials},
+	} {
+		attempt.Email = "tech@example.com"
+		require.NoError(t, repo.Create(ctx, &attempt))
+	}
+
+	count, oldest, err := repo.CountFailuresByIP(ctx, "10.0.0.1", now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count, "only wrong credentials inside the window count")
+	assert.True(t, now.Add(-40*time.Minute).Equal(oldest), "oldest counted failure, got %s", oldest)
+
+	count, oldest, err = repo.CountFailuresByIP(ctx, "10.0.0.3", now.Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	assert.True(t, oldest.IsZero())
+}
@@ -0,0 +1,174 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+	"dashboard-ac-backend/pkg/money"
+)
+
+func day(month time.Month, d int) time.Time {
+	return time.Date(2025, month, d, 0, 0, 0, 0, time.UTC)
+}
+
+// newReportRepository returns a repository over March 2025 of a small workshop: two
+// technicians, three jobs (one canceled) and three invoices, plus an invoice, a payment
+// and a job outside March. The tables are created by hand because SQLite has no
+// gen_random_uuid().
+func newReportRepository(t *testing.T) repository.ReportRepository {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+
+	for _, statement := range []string{
+		`CREATE TABLE services (id TEXT PRIMARY KEY, name TEXT, duration INTEGER, deleted_at DATETIME)`,
+		`CREATE TABLE technicians (id TEXT PRIMARY KEY, name TEXT, work_start TEXT, work_end TEXT, break_start TEXT, break_end TEXT, deleted_at DATETIME)`,
+		`CREATE TABLE schedules (id TEXT PRIMARY KEY, technician_id TEXT, service_id TEXT, date DATETIME, status TEXT, deleted_at DATETIME)`,
+		`CREATE TABLE invoices (id TEXT PRIMARY KEY, invoice_date DATETIME, due_date DATETIME, total_amount DECIMAL(10,2), amount_paid DECIMAL(10,2), status TEXT, deleted_at DATETIME)`,
+		`CREATE TABLE invoice_details (id TEXT PRIMARY KEY, invoice_id TEXT, service_id TEXT, quantity INTEGER, subtotal DECIMAL(10,2), deleted_at DATETIME)`,
+		`CREATE TABLE payments (id TEXT PRIMARY KEY, invoice_id TEXT, amount DECIMAL(10,2), paid_at DATETIME, deleted_at DATETIME)`,
+	} {
+		require.NoError(t, db.Exec(statement).Error)
+	}
+
+	exec := func(sql string, args ...interface{}) {
+		require.NoError(t, db.Exec(sql, args...).Error)
+	}
+	cleaning, installation := uuid.NewString(), uuid.NewString()
+	exec(`INSERT INTO services (id, name, duration) VALUES (?, 'Cleaning', 60), (?, 'Installation', 180)`, cleaning, installation)
+
+	andi, budi := uuid.NewString(), uuid.NewString()
+	exec(`INSERT INTO technicians (id, name, work_start, work_end, break_start, break_end) VALUES (?, 'Andi', '08:00', '17:00', '12:00', '13:00')`, andi)
+	exec(`INSERT INTO technicians (id, name, work_start, work_end, break_start, break_end) VALUES (?, 'Budi', '08:00', '17:00', '', '')`, budi)
+
+	for _, s := range []struct {
+		technician, service string
+		date                time.Time
+		status              domain.ScheduleStatus
+	}{
+		{andi, cleaning, day(time.March, 5), domain.ScheduleStatusCompleted},
+		{andi, installation, day(time.March, 6), domain.ScheduleStatusPending},
+		{andi, cleaning, day(time.March, 7), domain.ScheduleStatusCanceled},
+		{budi, cleaning, day(time.April, 2), domain.ScheduleStatusPending},
+	} {
+		exec(`INSERT INTO schedules (id, technician_id, service_id, date, status) VALUES (?, ?, ?, ?, ?)`, uuid.NewString(), s.technician, s.service, s.date, s.status)
+	}
+
+	paid, partial, unpaid, old := uuid.NewString(), uuid.NewString(), uuid.NewString(), uuid.NewString()
+	for _, i := range []struct {
+		id                string
+		date, due         time.Time
+		total, amountPaid int64
+		status            domain.InvoiceStatus
+	}{
+		{paid, day(time.March, 5), day(time.March, 19), 300000, 300000, domain.InvoiceStatusPaid},
+		{partial, day(time.March, 10), day(time.March, 15), 750000, 250000, domain.InvoiceStatusPartiallyPaid},
+		{unpaid, day(time.March, 12), day(time.March, 26), 150000, 0, domain.InvoiceStatusUnpaid},
+		{old, day(time.February, 20), day(time.March, 6), 100000, 50000, domain.InvoiceStatusPartiallyPaid},
+	} {
+		exec(`INSERT INTO invoices (id, invoice_date, due_date, total_amount, amount_paid, status) VALUES (?, ?, ?, ?, ?, ?)`, i.id, i.date, i.due, i.total, i.amountPaid, i.status)
+	}
+
+	exec(`INSERT INTO invoice_details (id, invoice_id, service_id, quantity, subtotal) VALUES (?, ?, ?, 2, 300000), (?, ?, ?, 1, 750000), (?, ?, ?, 1, 150000)`,
+		uuid.NewString(), paid, cleaning, uuid.NewString(), partial, installation, uuid.NewString(), unpaid, cleaning)
+
+	exec(`INSERT INTO payments (id, invoice_id, amount, paid_at) VALUES (?, ?, 300000, ?), (?, ?, 250000, ?), (?, ?, 50000, ?)`,
+		uuid.NewString(), paid, day(time.March, 5).Add(10*time.Hour),
+		uuid.NewString(), partial, day(time.March, 11).Add(15*time.Hour),
+		uuid.NewString(), old, day(time.February, 25).Add(9*time.Hour))
+
+	return repository.NewReportRepository(db)
+}
+
+func TestReport_Revenue(t *testing.T) {
+	repo := newReportRepository(t)
+
+	revenue, err := repo.Revenue(context.Background(), day(time.March, 1), day(time.April, 1), day(time.March, 20))
+	require.NoError(t, err)
+	assert.Equal(t, money.FromMajor(1200000).String(), revenue.Invoiced.String())
+	assert.Equal(t, money.FromMajor(550000).String(), revenue.Paid.String())
+	assert.Equal(t, money.FromMajor(650000).String(), revenue.Outstanding.String())
+	assert.Equal(t, money.FromMajor(500000).String(), revenue.Overdue.String(), "a partially paid invoice past its due date is overdue")
+}
+
+func TestReport_JobsServicesAndTechnicians(t *testing.T) {
+	repo := newReportRepository(t)
+	ctx := context.Background()
+	from, to := day(time.March, 1), day(time.April, 1)
+
+	counts, err := repo.JobCountsByStatus(ctx, from, to)
+	require.NoError(t, err)
+	assert.Equal(t, map[domain.ScheduleStatus]int64{
+		domain.ScheduleStatusPending:    1,
+		domain.ScheduleStatusOnProgress: 0,
+		domain.ScheduleStatusCompleted:  1,
+		domain.ScheduleStatusCanceled:   1,
+	}, counts)
+
+	services, err := repo.TopServices(ctx, from, to, 5)
+	require.NoError(t, err)
+	require.Len(t, services, 2)
+	assert.Equal(t, "Installation", services[0].ServiceName)
+	assert.Equal(t, money.FromMajor(750000).String(), services[0].Revenue.String())
+	assert.Equal(t, "Cleaning", services[1].ServiceName)
+	assert.Equal(t, int64(3), services[1].Quantity)
+	assert.Equal(t, money.FromMajor(450000).String(), services[1].Revenue.String())
+
+	services, err = repo.TopServices(ctx, from, to, 1)
+	require.NoError(t, err)
+	assert.Len(t, services, 1)
+
+	technicians, err := repo.TechnicianUtilization(ctx, from, to)
+	require.NoError(t, err)
+	require.Len(t, technicians, 2)
+	assert.Equal(t, "Andi", technicians[0].TechnicianName)
+	assert.Equal(t, int64(2), technicians[0].Jobs, "canceled jobs are not counted")
+	assert.Equal(t, int64(240), technicians[0].BookedMinutes)
+	assert.Equal(t, int64(31*480), technicians[0].AvailableMinutes, "breaks are not available")
+	assert.Equal(t, 1.6, technicians[0].Utilization)
+	assert.Equal(t, "Budi", technicians[1].TechnicianName)
+	assert.Equal(t, int64(0), technicians[1].BookedMinutes)
+	assert.Equal(t, int64(31*540), technicians[1].AvailableMinutes)
+}
+
+func TestReport_RevenueTimeseries(t *testing.T) {
+	repo := newReportRepository(t)
+	ctx := context.Background()
+
+	points, err := repo.RevenueTimeseries(ctx, day(time.February, 1), day(time.April, 1), domain.ReportIntervalMonth)
+	require.NoError(t, err)
+	require.Len(t, points, 2)
+	assert.Equal(t, "2025-02-01", points[0].Period)
+	assert.Equal(t, money.FromMajor(100000).String(), points[0].Invoiced.String())
+	assert.Equal(t, money.FromMajor(50000).String(), points[0].Paid.String())
+	assert.Equal(t, "2025-03-01", points[1].Period)
+	assert.Equal(t, money.FromMajor(1200000).String(), points[1].Invoiced.String())
+
+	// Weeks start on Monday; empty weeks are listed with zeros
+	points, err = repo.RevenueTimeseries(ctx, day(time.March, 3), day(time.March, 24), domain.ReportIntervalWeek)
+	require.NoError(t, err)
+	require.Len(t, points, 3)
+	assert.Equal(t, []string{"2025-03-03", "2025-03-10", "2025-03-17"}, []string{points[0].Period, points[1].Period, points[2].Period})
+	assert.Equal(t, money.FromMajor(300000).String(), points[0].Paid.String())
+	assert.Equal(t, money.FromMajor(900000).String(), points[1].Invoiced.String())
+	assert.Equal(t, money.FromMajor(250000).String(), points[1].Paid.String())
+	assert.True(t, points[2].Invoiced.IsZero())
+	assert.True(t, points[2].Paid.IsZero())
+
+	points, err = repo.RevenueTimeseries(ctx, day(time.March, 10), day(time.March, 13), domain.ReportIntervalDay)
+	require.NoError(t, err)
+	require.Len(t, points, 3)
+	assert.Equal(t, money.FromMajor(750000).String(), points[0].Invoiced.String())
+	assert.Equal(t, money.FromMajor(250000).String(), points[1].Paid.String())
+	assert.Equal(t, money.FromMajor(150000).String(), points[2].Invoiced.String())
+}
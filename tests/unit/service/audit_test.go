@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+	"dashboard-ac-backend/internal/service"
+)
+
+type fakeAuditLogRepository struct {
+	repository.AuditLogRepository
+	entries []*domain.AuditLog
+}
+
+func (f *fakeAuditLogRepository) Create(ctx context.Context, entry *domain.AuditLog) error {
+	f.entries = append(f.entries, entry)
+	return nil
+}
+
+func TestAuditService_RecordsOnlyChangedFields(t *testing.T) {
+	repo := &fakeAuditLogRepository{}
+	auditService := service.NewAuditService(repo)
+	actorID := uint(7)
+
+	before := &domain.Invoice{ID: uuid.New(), CustomerID: uuid.New(), Status: domain.InvoiceStatusUnpaid}
+	after := *before
+	after.Status = domain.InvoiceStatusPaid
+
+	auditService.Record(context.Background(), service.AuditEntry{
+		ActorID:    &actorID,
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityInvoice,
+		EntityID:   before.ID.String(),
+		Before:     before,
+		After:      &after,
+		IPAddress:  "10.0.0.1",
+	})
+
+	require.Len(t, repo.entries, 1)
+	entry := repo.entries[0]
+	assert.Equal(t, &actorID, entry.ActorID)
+	assert.Equal(t, domain.AuditChanges{"status": string(domain.InvoiceStatusUnpaid)}, entry.Before)
+	assert.Equal(t, domain.AuditChanges{"status": string(domain.InvoiceStatusPaid)}, entry.After)
+}
+
+func TestAuditService_SkipsUpdatesWithoutChanges(t *testing.T) {
+	repo := &fakeAuditLogRepository{}
+	auditService := service.NewAuditService(repo)
+
+	customer := &domain.Customer{ID: uuid.New(), Name: "Budi"}
+	auditService.Record(context.Background(), service.AuditEntry{
+		Action:     domain.AuditActionUpdate,
+		EntityType: domain.AuditEntityCustomer,
+		EntityID:   customer.ID.String(),
+		Before:     customer,
+		After:      customer,
+	})
+
+	assert.Empty(t, repo.entries)
+}
+
+func TestAuditService_CreateAndDeleteKeepFullSnapshot(t *testing.T) {
+	repo := &fakeAuditLogRepository{}
+	auditService := service.NewAuditService(repo)
+
+	schedule := &struct {
+		domain.Schedule
+		Customer domain.Customer `json:"customer"`
+	}{
+		Schedule: domain.Schedule{ID: uuid.New(), Status: domain.ScheduleStatusPending},
+		Customer: domain.Customer{Name: "Budi"},
+	}
+	auditService.Record(context.Background(), service.AuditEntry{
+		Action:     domain.AuditActionCreate,
+		EntityType: domain.AuditEntitySchedule,
+		EntityID:   schedule.ID.String(),
+		After:      schedule,
+	})
+	auditService.Record(context.Background(), service.AuditEntry{
+		Action:     domain.AuditActionDelete,
+		EntityType: domain.AuditEntitySchedule,
+		EntityID:   schedule.ID.String(),
+		Before:     schedule,
+	})
+
+	require.Len(t, repo.entries, 2)
+	created, deleted := repo.entries[0], repo.entries[1]
+
+	assert.Nil(t, created.Before)
+	assert.Equal(t, string(domain.ScheduleStatusPending), created.After["status"])
+	assert.NotContains(t, created.After, "customer", "preloaded relations are not part of the snapshot")
+
+	assert.Nil(t, deleted.After)
+	assert.Equal(t, schedule.ID.String(), deleted.Before["id"])
+}
+
+func TestAuditService_TakesActorFromContext(t *testing.T) {
+	repo := &fakeAuditLogRepository{}
+	auditService := service.NewAuditService(repo)
+	actorID := uint(7)
+	ctx := domain.ContextWithAuditActor(context.Background(), domain.AuditActor{
+		UserID:    &actorID,
+		Email:     "admin@example.com",
+		IPAddress: "10.0.0.1",
+		RequestID: "req-1",
+	})
+
+	customer := &domain.Customer{ID: uuid.New(), Name: "Budi"}
+	auditService.Record(ctx, service.AuditEntry{
+		Action:     domain.AuditActionCreate,
+		EntityType: domain.AuditEntityCustomer,
+		EntityID:   customer.ID.String(),
+		After:      customer,
+	})
+	auditService.Record(context.Background(), service.AuditEntry{
+		Action:     domain.AuditActionDelete,
+		EntityType: domain.AuditEntityCustomer,
+		EntityID:   customer.ID.String(),
+		Before:     customer,
+	})
+
+	require.Len(t, repo.entries, 2)
+	byUser, byJob := repo.entries[0], repo.entries[1]
+	assert.Equal(t, &actorID, byUser.ActorID)
+	assert.Equal(t, "admin@example.com", byUser.ActorEmail)
+	assert.Equal(t, "10.0.0.1", byUser.IPAddress)
+	assert.Equal(t, "req-1", byUser.RequestID)
+	assert.Nil(t, byJob.ActorID, "changes outside a request have no actor")
+	assert.Empty(t, byJob.ActorEmail)
+}
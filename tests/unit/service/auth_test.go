@@ -0,0 +1,388 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+	"dashboard-ac-backend/internal/service"
+	"dashboard-ac-backend/pkg/hash"
+)
+
+const testJWTSecret = "test-secret-key-for-testing"
+
+type fakeUserRepository struct {
+	repository.UserRepository
+	users map[uint]*domain.User
+}
+
+func (f *fakeUserRepository) GetByID(ctx context.Context, id uint) (*domain.User, error) {
+	user, ok := f.users[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return user, nil
+}
+
+func (f *fakeUserRepository) GetByEmail(ctx context.Context, email string) (*domain.User, error) {
+	for _, user := range f.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeUserRepository) IncrementTokenVersion(ctx context.Context, id uint) error {
+	if user, ok := f.users[id]; ok {
+		user.TokenVersion++
+	}
+	return nil
+}
+
+func (f *fakeUserRepository) UpdatePassword(ctx context.Context, id uint, passwordHash string) error {
+	user, ok := f.users[id]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	user.Password = passwordHash
+	user.TokenVersion++
+	return nil
+}
+
+func (f *fakeUserRepository) RegisterLoginFailure(ctx context.Context, id uint, maxFailures int, lockout time.Duration) (*domain.User, error) {
+	user := f.users[id]
+	now := time.Now()
+	user.FailedLoginCount++
+	user.LastFailedLoginAt = &now
+	if maxFailures > 0 && user.FailedLoginCount >= maxFailures {
+		lockedUntil := now.Add(lockout)
+		user.LockedUntil = &lockedUntil
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (f *fakeUserRepository) ResetLoginFailures(ctx context.Context, id uint) error {
+	user := f.users[id]
+	user.FailedLoginCount = 0
+	user.LastFailedLoginAt = nil
+	user.LockedUntil = nil
+	return nil
+}
+
+type fakeLoginAttemptRepository struct {
+	repository.LoginAttemptRepository
+	attempts []*domain.LoginAttempt
+}
+
+func (f *fakeLoginAttemptRepository) Create(ctx context.Context, attempt *domain.LoginAttempt) error {
+	attempt.CreatedAt = time.Now()
+	f.attempts = append(f.attempts, attempt)
+	return nil
+}
+
+func (f *fakeLoginAttemptRepository) CountFailuresByIP(ctx context.Context, ipAddress string, since time.Time) (int64, time.Time, error) {
+	var count int64
+	var oldest time.Time
+	for _, attempt := range f.attempts {
+		if attempt.IPAddress == ipAddress && attempt.FailureReason == domain.LoginFailureInvalidCredentials && !attempt.CreatedAt.Before(since) {
+			if count == 0 || attempt.CreatedAt.Before(oldest) {
+				oldest = attempt.CreatedAt
+			}
+			count++
+		}
+	}
+	return count, oldest, nil
+}
+
+// fakeRefreshTokenRepository keeps refresh tokens in memory with the same rotation
+// semantics as the database implementation
+type fakeRefreshTokenRepository struct {
+	repository.RefreshTokenRepository
+	tokens map[uuid.UUID]*domain.RefreshToken
+}
+
+func newFakeRefreshTokenRepository() *fakeRefreshTokenRepository {
+	return &fakeRefreshTokenRepository{tokens: map[uuid.UUID]*domain.RefreshToken{}}
+}
+
+func (f *fakeRefreshTokenRepository) Create(ctx context.Context, token *domain.RefreshToken) error {
+	f.tokens[token.ID] = token
+	return nil
+}
+
+func (f *fakeRefreshTokenRepository) GetByID(ctx context.Context, id string) (*domain.RefreshToken, error) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	token, ok := f.tokens[parsed]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *token
+	return &copied, nil
+}
+
+func (f *fakeRefreshTokenRepository) Rotate(ctx context.Context, currentID uuid.UUID, next *domain.RefreshToken) error {
+	current, ok := f.tokens[currentID]
+	if !ok || current.IsRevoked() {
+		return repository.ErrRefreshTokenReused
+	}
+	now := time.Now()
+	current.RevokedAt = &now
+	current.ReplacedByID = &next.ID
+	f.tokens[next.ID] = next
+	return nil
+}
+
+func (f *fakeRefreshTokenRepository) RevokeFamily(ctx context.Context, familyID uuid.UUID) error {
+	now := time.Now()
+	for _, token := range f.tokens {
+		if token.FamilyID == familyID && !token.IsRevoked() {
+			token.RevokedAt = &now
+		}
+	}
+	return nil
+}
+
+func (f *fakeRefreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uint) (int64, error) {
+	var revoked int64
+	now := time.Now()
+	for _, token := range f.tokens {
+		if token.UserID == userID && !token.IsRevoked() {
+			token.RevokedAt = &now
+			revoked++
+		}
+	}
+	return revoked, nil
+}
+
+func (f *fakeRefreshTokenRepository) activeCount() int {
+	active := 0
+	for _, token := range f.tokens {
+		if !token.IsRevoked() {
+			active++
+		}
+	}
+	return active
+}
+
+func newAuthServiceWithUser(t *testing.T) (service.AuthService, *fakeRefreshTokenRepository) {
+	authService, _, tokens, _ := newAuthServiceWithPolicy(t, service.LoginPolicy{})
+	return authService, tokens
+}
+
+func newAuthServiceWithPolicy(t *testing.T, policy service.LoginPolicy) (service.AuthService, *fakeUserRepository, *fakeRefreshTokenRepository, *fakeLoginAttemptRepository) {
+	password, err := hash.HashPassword("secret123")
+	require.NoError(t, err)
+
+	users := &fakeUserRepository{users: map[uint]*domain.User{
+		1: {ID: 1, Email: "tech@example.com", Password: password, Role: domain.RoleTechnician, IsActive: true},
+	}}
+	tokens := newFakeRefreshTokenRepository()
+	attempts := &fakeLoginAttemptRepository{}
+
+	return service.NewAuthService(users, nil, tokens, attempts, nil, testJWTSecret, policy, service.NewAuditService(&fakeAuditLogRepository{})), users, tokens, attempts
+}
+
+func attemptLogin(authService service.AuthService, password, ipAddress string) error {
+	_, _, err := authService.Login(
+		context.Background(),
+		&request.LoginRequest{Email: "tech@example.com", Password: password},
+		service.ClientInfo{UserAgent: "test-agent", IPAddress: ipAddress},
+	)
+	return err
+}
+
+func login(t *testing.T, authService service.AuthService) string {
+	tokenPair, _, err := authService.Login(
+		context.Background(),
+		&request.LoginRequest{Email: "tech@example.com", Password: "secret123"},
+		service.ClientInfo{UserAgent: "test-agent"},
+	)
+	require.NoError(t, err)
+	return tokenPair.RefreshToken
+}
+
+func TestAuthService_LoginStoresHashedRefreshToken(t *testing.T) {
+	authService, tokens := newAuthServiceWithUser(t)
+
+	refreshToken := login(t, authService)
+
+	require.Len(t, tokens.tokens, 1)
+	for _, stored := range tokens.tokens {
+		assert.Equal(t, hash.HashToken(refreshToken), stored.TokenHash)
+		assert.NotEqual(t, refreshToken, stored.TokenHash)
+		assert.Equal(t, "test-agent", stored.UserAgent)
+	}
+}
+
+func TestAuthService_RefreshTokenRotates(t *testing.T) {
+	authService, tokens := newAuthServiceWithUser(t)
+	first := login(t, authService)
+
+	pair, err := authService.RefreshToken(context.Background(), first, service.ClientInfo{})
+	require.NoError(t, err)
+	assert.NotEqual(t, first, pair.RefreshToken)
+	assert.Equal(t, 1, tokens.activeCount())
+
+	// The rotated token keeps working
+	_, err = authService.RefreshToken(context.Background(), pair.RefreshToken, service.ClientInfo{})
+	assert.NoError(t, err)
+}
+
+func TestAuthService_RefreshTokenReuseRevokesFamily(t *testing.T) {
+	authService, tokens := newAuthServiceWithUser(t)
+	first := login(t, authService)
+	otherDevice := login(t, authService)
+
+	second, err := authService.RefreshToken(context.Background(), first, service.ClientInfo{})
+	require.NoError(t, err)
+
+	_, err = authService.RefreshToken(context.Background(), first, service.ClientInfo{})
+	assert.ErrorIs(t, err, service.ErrRefreshTokenReused)
+
+	// The token issued by the legitimate rotation is revoked as well
+	_, err = authService.RefreshToken(context.Background(), second.RefreshToken, service.ClientInfo{})
+	assert.ErrorIs(t, err, service.ErrRefreshTokenReused)
+
+	// Other logins are untouched
+	assert.Equal(t, 1, tokens.activeCount())
+	_, err = authService.RefreshToken(context.Background(), otherDevice, service.ClientInfo{})
+	assert.NoError(t, err)
+}
+
+func TestAuthService_Logout(t *testing.T) {
+	authService, tokens := newAuthServiceWithUser(t)
+	refreshToken := login(t, authService)
+	login(t, authService)
+
+	require.NoError(t, authService.Logout(context.Background(), refreshToken))
+	assert.Equal(t, 1, tokens.activeCount())
+
+	revoked, err := authService.LogoutAll(context.Background(), 1)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), revoked)
+	assert.Equal(t, 0, tokens.activeCount())
+
+	// Access tokens issued before are revoked as well
+	version, active, err := authService.TokenState(context.Background(), 1)
+	require.NoError(t, err)
+	assert.True(t, active)
+	assert.Equal(t, 1, version)
+}
+
+func TestAuthService_RefreshTokenRejectsUnknownToken(t *testing.T) {
+	authService, _ := newAuthServiceWithUser(t)
+
+	_, err := authService.RefreshToken(context.Background(), "not-a-token", service.ClientInfo{})
+	assert.EqualError(t, err, "invalid refresh token")
+}
+
+func TestAuthService_LoginLocksAccountAfterMaxFailures(t *testing.T) {
+	authService, _, _, attempts := newAuthServiceWithPolicy(t, service.LoginPolicy{
+		MaxFailures:     3,
+		LockoutDuration: time.Minute,
+	})
+
+	assert.EqualError(t, attemptLogin(authService, "wrong", "10.0.0.1"), "invalid email or password")
+	assert.EqualError(t, attemptLogin(authService, "wrong", "10.0.0.1"), "invalid email or password")
+
+	var lockedErr *service.AccountLockedError
+	assert.ErrorAs(t, attemptLogin(authService, "wrong", "10.0.0.1"), &lockedErr)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), lockedErr.Until, time.Second)
+
+	// Even the right password is refused while locked
+	assert.ErrorAs(t, attemptLogin(authService, "secret123", "10.0.0.1"), &lockedErr)
+
+	require.Len(t, attempts.attempts, 4)
+	assert.Equal(t, domain.LoginFailureInvalidCredentials, attempts.attempts[0].FailureReason)
+	assert.Equal(t, domain.LoginFailureAccountLocked, attempts.attempts[3].FailureReason)
+	assert.Equal(t, "10.0.0.1", attempts.attempts[3].IPAddress)
+	assert.Equal(t, "test-agent", attempts.attempts[3].UserAgent)
+}
+
+func TestAuthService_LoginDelaysAfterFailure(t *testing.T) {
+	authService, _, _, _ := newAuthServiceWithPolicy(t, service.LoginPolicy{BaseDelay: time.Minute})
+
+	assert.EqualError(t, attemptLogin(authService, "wrong", "10.0.0.1"), "invalid email or password")
+
+	var throttledErr *service.LoginThrottledError
+	assert.ErrorAs(t, attemptLogin(authService, "secret123", "10.0.0.1"), &throttledErr)
+	assert.InDelta(t, time.Minute.Seconds(), throttledErr.RetryAfter.Seconds(), 1)
+}
+
+func TestAuthService_LoginThrottlesFailingAddress(t *testing.T) {
+	authService, _, _, _ := newAuthServiceWithPolicy(t, service.LoginPolicy{
+		IPMaxFailures: 2,
+		IPWindow:      time.Minute,
+	})
+
+	attemptLogin(authService, "wrong", "10.0.0.1")
+	attemptLogin(authService, "wrong", "10.0.0.1")
+
+	var throttledErr *service.LoginThrottledError
+	assert.ErrorAs(t, attemptLogin(authService, "secret123", "10.0.0.1"), &throttledErr)
+	assert.NoError(t, attemptLogin(authService, "secret123", "10.0.0.2"))
+}
+
+func TestAuthService_LoginThrottleEndsWithOldestFailure(t *testing.T) {
+	authService, _, _, attempts := newAuthServiceWithPolicy(t, service.LoginPolicy{
+		IPMaxFailures: 2,
+		IPWindow:      time.Minute,
+	})
+
+	attemptLogin(authService, "wrong", "10.0.0.1")
+	attemptLogin(authService, "wrong", "10.0.0.1")
+	attempts.attempts[0].CreatedAt = time.Now().Add(-50 * time.Second)
+
+	// Refused attempts are recorded but do not push the throttle further out
+	var throttledErr *service.LoginThrottledError
+	for i := 0; i < 3; i++ {
+		require.ErrorAs(t, attemptLogin(authService, "secret123", "10.0.0.1"), &throttledErr)
+		assert.InDelta(t, (10 * time.Second).Seconds(), throttledErr.RetryAfter.Seconds(), 1)
+	}
+	assert.Equal(t, domain.LoginFailureThrottled, attempts.attempts[len(attempts.attempts)-1].FailureReason)
+
+	// Once the oldest failure leaves the window the address may log in again
+	attempts.attempts[0].CreatedAt = time.Now().Add(-2 * time.Minute)
+	assert.NoError(t, attemptLogin(authService, "secret123", "10.0.0.1"))
+}
+
+func TestAuthService_LockedAccountAttemptsDoNotThrottleAddress(t *testing.T) {
+	authService, _, _, _ := newAuthServiceWithPolicy(t, service.LoginPolicy{
+		MaxFailures:     1,
+		LockoutDuration: time.Minute,
+		IPMaxFailures:   2,
+		IPWindow:        time.Minute,
+	})
+
+	var lockedErr *service.AccountLockedError
+	require.ErrorAs(t, attemptLogin(authService, "wrong", "10.0.0.1"), &lockedErr)
+	for i := 0; i < 3; i++ {
+		assert.ErrorAs(t, attemptLogin(authService, "secret123", "10.0.0.1"), &lockedErr, "the account stays locked rather than the address throttled")
+	}
+}
+
+func TestAuthService_LoginSuccessResetsFailures(t *testing.T) {
+	authService, users, _, attempts := newAuthServiceWithPolicy(t, service.LoginPolicy{
+		MaxFailures:     3,
+		LockoutDuration: time.Minute,
+	})
+
+	attemptLogin(authService, "wrong", "10.0.0.1")
+	require.NoError(t, attemptLogin(authService, "secret123", "10.0.0.1"))
+
+	assert.Equal(t, 0, users.users[1].FailedLoginCount)
+	assert.True(t, attempts.attempts[len(attempts.attempts)-1].Success)
+}
@@ -0,0 +1,46 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/service"
+)
+
+func clock(hour, minute int) time.Time {
+	return time.Date(2024, 5, 10, hour, minute, 0, 0, time.UTC)
+}
+
+func formatSlots(slots []time.Time) []string {
+	formatted := make([]string, 0, len(slots))
+	for _, slot := range slots {
+		formatted = append(formatted, slot.Format(domain.ClockLayout))
+	}
+	return formatted
+}
+
+func TestComputeFreeSlots_EmptyDay(t *testing.T) {
+	slots := service.ComputeFreeSlots(clock(8, 0), clock(10, 0), nil, time.Hour, 30*time.Minute)
+
+	assert.Equal(t, []string{"08:00", "08:30", "09:00"}, formatSlots(slots))
+}
+
+func TestComputeFreeSlots_SkipsBusyWindows(t *testing.T) {
+	busy := []domain.ScheduleWindow{
+		{Start: clock(9, 0), End: clock(10, 0)},
+		{Start: clock(12, 0), End: clock(13, 0)}, // break
+	}
+
+	slots := service.ComputeFreeSlots(clock(8, 0), clock(14, 0), busy, time.Hour, 30*time.Minute)
+
+	assert.Equal(t, []string{"08:00", "10:00", "10:30", "11:00", "13:00"}, formatSlots(slots))
+}
+
+func TestComputeFreeSlots_ServiceLongerThanDay(t *testing.T) {
+	slots := service.ComputeFreeSlots(clock(8, 0), clock(9, 0), nil, 3*time.Hour, 30*time.Minute)
+
+	assert.Empty(t, slots)
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+	"dashboard-ac-backend/internal/service"
+)
+
+// fakeReportRepository records the range and interval of a time series request
+type fakeReportRepository struct {
+	repository.ReportRepository
+	from, to time.Time
+	interval domain.ReportInterval
+}
+
+func (f *fakeReportRepository) RevenueTimeseries(ctx context.Context, from, to time.Time, interval domain.ReportInterval) ([]domain.RevenuePoint, error) {
+	f.from, f.to, f.interval = from, to, interval
+	return []domain.RevenuePoint{}, nil
+}
+
+func TestDashboard_RevenueTimeseriesRange(t *testing.T) {
+	repo := &fakeReportRepository{}
+	svc := service.NewDashboardService(repo)
+
+	result, err := svc.RevenueTimeseries(context.Background(), &request.RevenueTimeseriesRequest{DateFrom: "2025-03-01", DateTo: "2025-03-31", Interval: "week"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.ReportIntervalWeek, result.Interval)
+	assert.Equal(t, "2025-03-01", result.DateFrom)
+	assert.Equal(t, "2025-03-31", result.DateTo)
+	assert.Equal(t, "2025-04-01", repo.to.Format("2006-01-02"), "the last day is included")
+
+	// The interval defaults to days, the range to the 30 days up to today
+	result, err = svc.RevenueTimeseries(context.Background(), &request.RevenueTimeseriesRequest{DateTo: "2025-03-31"})
+	require.NoError(t, err)
+	assert.Equal(t, domain.ReportIntervalDay, repo.interval)
+	assert.Equal(t, "2025-03-02", result.DateFrom)
+}
+
+func TestDashboard_RejectsBadRanges(t *testing.T) {
+	svc := service.NewDashboardService(&fakeReportRepository{})
+
+	_, err := svc.RevenueTimeseries(context.Background(), &request.RevenueTimeseriesRequest{DateFrom: "2025-04-01", DateTo: "2025-03-01"})
+	assert.ErrorIs(t, err, service.ErrInvalidDateRange)
+
+	_, err = svc.Summary(context.Background(), &request.DashboardSummaryRequest{DateFrom: "2025-04-01", DateTo: "2025-03-01"})
+	assert.ErrorIs(t, err, service.ErrInvalidDateRange)
+
+	_, err = svc.RevenueTimeseries(context.Background(), &request.RevenueTimeseriesRequest{DateFrom: "2020-01-01", DateTo: "2025-03-01", Interval: "day"})
+	assert.ErrorIs(t, err, service.ErrTimeseriesTooLong)
+
+	_, err = svc.RevenueTimeseries(context.Background(), &request.RevenueTimeseriesRequest{DateFrom: "2020-01-01", DateTo: "2025-03-01", Interval: "month"})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+	"dashboard-ac-backend/internal/service"
+)
+
+// fakeCustomerRepository serves total customers by cursor and records every query
+type fakeCustomerRepository struct {
+	repository.CustomerRepository
+	total int
+	calls []repository.ListOptions
+	name  string
+}
+
+func (f *fakeCustomerRepository) Search(ctx context.Context, name, phone, email string, opts repository.ListOptions) ([]*domain.Customer, repository.ListPage, error) {
+	f.calls = append(f.calls, opts)
+	f.name = name
+
+	start := 0
+	if opts.Cursor != "" {
+		start, _ = strconv.Atoi(opts.Cursor)
+	}
+	end := min(start+opts.Limit, f.total)
+
+	customers := []*domain.Customer{}
+	for i := start; i < end; i++ {
+		customers = append(customers, &domain.Customer{Name: strconv.Itoa(i)})
+	}
+	var page repository.ListPage
+	if end < f.total {
+		page.NextCursor = strconv.Itoa(end)
+	}
+	return customers, page, nil
+}
+
+func TestExport_LoadsBatchesByCursor(t *testing.T) {
+	repo := &fakeCustomerRepository{total: service.ExportBatchSize*2 + 5}
+	svc := service.NewCustomerService(repo, service.NewAuditService(&fakeAuditLogRepository{}))
+
+	next := svc.Export(context.Background(), &request.CustomerSearchRequest{
+		PaginationRequest: &request.PaginationRequest{Page: 3, Limit: 10, Sort: "name"},
+		Name:              "Budi",
+	})
+
+	var sizes []int
+	for {
+		batch, err := next()
+		require.NoError(t, err)
+		if len(batch) == 0 {
+			break
+		}
+		sizes = append(sizes, len(batch))
+	}
+
+	assert.Equal(t, []int{service.ExportBatchSize, service.ExportBatchSize, 5}, sizes)
+	require.Len(t, repo.calls, 3, "no query is made once the last batch is loaded")
+	for _, opts := range repo.calls {
+		assert.True(t, opts.UseCursor)
+		assert.Equal(t, service.ExportBatchSize, opts.Limit, "the page size of the request does not apply")
+		assert.Equal(t, "name", opts.Sort)
+	}
+	assert.Equal(t, "", repo.calls[0].Cursor)
+	assert.Equal(t, "Budi", repo.name)
+}
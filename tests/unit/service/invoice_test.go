@@ -0,0 +1,417 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+	"dashboard-ac-backend/internal/service"
+	"dashboard-ac-backend/pkg/money"
+)
+
+// fakeInvoiceRepository keeps created invoices by schedule and records their details
+type fakeInvoiceRepository struct {
+	repository.InvoiceRepository
+	bySchedule map[string]*domain.Invoice
+	details    []*domain.InvoiceDetail
+}
+
+// MarkOverdue flags the stored invoices past due that still have a balance
+func (f *fakeInvoiceRepository) MarkOverdue(ctx context.Context, dueBefore time.Time) ([]*domain.Invoice, error) {
+	var flagged []*domain.Invoice
+	for _, invoice := range f.bySchedule {
+		if invoice.Status != domain.InvoiceStatusPaid && invoice.Status != domain.InvoiceStatusOverdue && invoice.DueDate.Before(dueBefore) {
+			before := *invoice
+			flagged = append(flagged, &before)
+			invoice.Status = domain.InvoiceStatusOverdue
+		}
+	}
+	return flagged, nil
+}
+
+func newFakeInvoiceRepository() *fakeInvoiceRepository {
+	return &fakeInvoiceRepository{bySchedule: make(map[string]*domain.Invoice)}
+}
+
+func (f *fakeInvoiceRepository) GetByScheduleID(ctx context.Context, scheduleID string) (*domain.Invoice, error) {
+	invoice, ok := f.bySchedule[scheduleID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return invoice, nil
+}
+
+func (f *fakeInvoiceRepository) CreateWithDetails(ctx context.Context, invoice *domain.Invoice, details []*domain.InvoiceDetail) error {
+	invoice.ID = uuid.New()
+	f.bySchedule[invoice.ScheduleID.String()] = invoice
+	f.details = details
+	return nil
+}
+
+type fakeServiceRepository struct {
+	repository.ServiceRepository
+	services map[string]*domain.Service
+}
+
+func (f *fakeServiceRepository) GetByID(ctx context.Context, id string) (*domain.Service, error) {
+	service, ok := f.services[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return service, nil
+}
+
+type invoiceFixture struct {
+	invoices  *fakeInvoiceRepository
+	schedules *fakeScheduleRepository
+	cleaning  *domain.Service
+	freon     *domain.Service
+	audit     *fakeAuditLogRepository
+	svc       service.InvoiceService
+}
+
+// newInvoiceFixture returns an invoice service with 14 day payment terms over two
+// services and no schedules
+func newInvoiceFixture() *invoiceFixture {
+	f := &invoiceFixture{
+		invoices:  newFakeInvoiceRepository(),
+		schedules: &fakeScheduleRepository{schedules: make(map[string]*domain.Schedule)},
+		cleaning:  &domain.Service{ID: uuid.New(), Name: "Cleaning", Price: money.FromMajor(150000)},
+		freon:     &domain.Service{ID: uuid.New(), Name: "Freon", Price: money.FromMajor(200000)},
+		audit:     &fakeAuditLogRepository{},
+	}
+	services := &fakeServiceRepository{services: map[string]*domain.Service{
+		f.cleaning.ID.String(): f.cleaning,
+		f.freon.ID.String():    f.freon,
+	}}
+	f.svc = service.NewInvoiceService(f.invoices, stubCustomerRepository{}, f.schedules, services, service.NewAuditService(f.audit), 14)
+	return f
+}
+
+func (f *invoiceFixture) addSchedule(status domain.ScheduleStatus) *domain.Schedule {
+	schedule := &domain.Schedule{ID: uuid.New(), CustomerID: uuid.New(), TechnicianID: uuid.New(), ServiceID: f.cleaning.ID, Status: status}
+	f.schedules.schedules[schedule.ID.String()] = schedule
+	return schedule
+}
+
+func TestInvoiceService_CreateFromSchedule(t *testing.T) {
+	f := newInvoiceFixture()
+	schedule := f.addSchedule(domain.ScheduleStatusCompleted)
+
+	invoice, err := f.svc.CreateFromSchedule(context.Background(), domain.AccessScope{}, schedule.ID.String())
+	require.NoError(t, err)
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	assert.Equal(t, today, invoice.InvoiceDate)
+	assert.Equal(t, today.AddDate(0, 0, 14), invoice.DueDate, "due after the payment terms")
+	assert.Equal(t, schedule.CustomerID, invoice.CustomerID)
+	assert.Equal(t, domain.InvoiceStatusUnpaid, invoice.Status)
+	assert.Equal(t, f.cleaning.Price, invoice.TotalAmount)
+
+	require.Len(t, f.invoices.details, 1, "a single line for the schedule's service")
+	line := f.invoices.details[0]
+	assert.Equal(t, f.cleaning.ID, line.ServiceID)
+	assert.Equal(t, 1, line.Quantity)
+	assert.Equal(t, f.cleaning.Price, line.UnitPrice)
+	assert.Equal(t, f.cleaning.Price, line.Subtotal)
+
+	require.Len(t, f.audit.entries, 2, "the invoice and its line are recorded")
+	assert.Equal(t, domain.AuditEntityInvoice, f.audit.entries[0].EntityType)
+	assert.Equal(t, invoice.ID.String(), f.audit.entries[0].EntityID)
+	assert.Equal(t, domain.AuditEntityInvoiceDetail, f.audit.entries[1].EntityType)
+
+	_, err = f.svc.CreateFromSchedule(context.Background(), domain.AccessScope{}, schedule.ID.String())
+	assert.ErrorIs(t, err, service.ErrScheduleAlreadyInvoiced)
+	assert.Len(t, f.audit.entries, 2)
+}
+
+func TestInvoiceService_MarkOverdueIsAudited(t *testing.T) {
+	f := newInvoiceFixture()
+	ctx := context.Background()
+	now := time.Date(2025, 3, 10, 9, 0, 0, 0, time.UTC)
+
+	late := &domain.Invoice{ID: uuid.New(), DueDate: now.AddDate(0, 0, -1), Status: domain.InvoiceStatusUnpaid}
+	notDue := &domain.Invoice{ID: uuid.New(), DueDate: now, Status: domain.InvoiceStatusUnpaid}
+	f.invoices.bySchedule[uuid.NewString()] = late
+	f.invoices.bySchedule[uuid.NewString()] = notDue
+
+	marked, err := f.svc.MarkOverdue(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), marked)
+
+	require.Len(t, f.audit.entries, 1)
+	entry := f.audit.entries[0]
+	assert.Equal(t, domain.AuditActionUpdate, entry.Action)
+	assert.Equal(t, late.ID.String(), entry.EntityID)
+	assert.Nil(t, entry.ActorID, "the sweep runs without a user")
+	assert.Equal(t, domain.AuditChanges{"status": string(domain.InvoiceStatusUnpaid)}, entry.Before)
+	assert.Equal(t, domain.AuditChanges{"status": string(domain.InvoiceStatusOverdue)}, entry.After)
+}
+
+func TestInvoiceService_CreateFromScheduleRejects(t *testing.T) {
+	f := newInvoiceFixture()
+	ctx := context.Background()
+
+	pending := f.addSchedule(domain.ScheduleStatusPending)
+	_, err := f.svc.CreateFromSchedule(ctx, domain.AccessScope{}, pending.ID.String())
+	assert.ErrorIs(t, err, service.ErrScheduleNotCompleted)
+
+	completed := f.addSchedule(domain.ScheduleStatusCompleted)
+	_, err = f.svc.CreateFromSchedule(ctx, domain.TechnicianScope(uuid.New()), completed.ID.String())
+	assert.ErrorIs(t, err, service.ErrScheduleNotFound, "another technician's schedule is hidden")
+
+	_, err = f.svc.CreateFromSchedule(ctx, domain.AccessScope{}, uuid.NewString())
+	assert.ErrorIs(t, err, service.ErrScheduleNotFound)
+
+	assert.Empty(t, f.invoices.bySchedule)
+}
+
+func TestInvoiceService_CreateWithItems(t *testing.T) {
+	f := newInvoiceFixture()
+	schedule := f.addSchedule(domain.ScheduleStatusCompleted)
+	discounted := money.FromMajor(175000)
+
+	invoice, err := f.svc.Create(context.Background(), domain.AccessScope{}, &request.InvoiceCreateRequest{
+		ScheduleID:  schedule.ID.String(),
+		CustomerID:  schedule.CustomerID.String(),
+		InvoiceDate: time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		DueDate:     time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC),
+		Items: []request.InvoiceItemRequest{
+			{ServiceID: f.cleaning.ID.String(), Quantity: 2},
+			{ServiceID: f.freon.ID.String(), Quantity: 1, UnitPrice: &discounted},
+		},
+	})
+	require.NoError(t, err)
+
+	require.Len(t, f.invoices.details, 2)
+	assert.Equal(t, f.cleaning.Price, f.invoices.details[0].UnitPrice, "the unit price defaults to the service price")
+	assert.Equal(t, money.FromMajor(300000), f.invoices.details[0].Subtotal)
+	assert.Equal(t, discounted, f.invoices.details[1].UnitPrice, "an explicit unit price wins")
+	assert.Equal(t, discounted, f.invoices.details[1].Subtotal)
+	assert.Equal(t, money.FromMajor(475000), invoice.TotalAmount)
+	assert.Equal(t, domain.InvoiceStatusUnpaid, invoice.Status)
+
+	_, err = f.svc.Create(context.Background(), domain.AccessScope{}, &request.InvoiceCreateRequest{
+		ScheduleID: schedule.ID.String(),
+		CustomerID: schedule.CustomerID.String(),
+	})
+	assert.ErrorIs(t, err, service.ErrScheduleAlreadyInvoiced)
+}
+
+func TestInvoiceService_CreateWithUnknownService(t *testing.T) {
+	f := newInvoiceFixture()
+	schedule := f.addSchedule(domain.ScheduleStatusCompleted)
+
+	_, err := f.svc.Create(context.Background(), domain.AccessScope{}, &request.InvoiceCreateRequest{
+		ScheduleID: schedule.ID.String(),
+		CustomerID: schedule.CustomerID.String(),
+		Items:      []request.InvoiceItemRequest{{ServiceID: uuid.NewString(), Quantity: 1}},
+	})
+	assert.ErrorIs(t, err, service.ErrServiceNotFound)
+	assert.Empty(t, f.invoices.bySchedule, "nothing is written when an item is invalid")
+}
+
+func TestInvoiceService_CreateRejects(t *testing.T) {
+	f := newInvoiceFixture()
+	ctx := context.Background()
+	schedule := f.addSchedule(domain.ScheduleStatusCompleted)
+
+	_, err := f.svc.Create(ctx, domain.TechnicianScope(uuid.New()), &request.InvoiceCreateRequest{
+		ScheduleID: schedule.ID.String(),
+		CustomerID: schedule.CustomerID.String(),
+	})
+	assert.ErrorIs(t, err, service.ErrScheduleNotFound, "another technician's schedule is hidden")
+
+	_, err = f.svc.Create(ctx, domain.AccessScope{}, &request.InvoiceCreateRequest{
+		ScheduleID: schedule.ID.String(),
+		CustomerID: uuid.NewString(),
+	})
+	assert.ErrorIs(t, err, service.ErrInvoiceCustomerMismatch)
+
+	_, err = f.svc.Create(ctx, domain.TechnicianScope(schedule.TechnicianID), &request.InvoiceCreateRequest{
+		ScheduleID: schedule.ID.String(),
+		CustomerID: schedule.CustomerID.String(),
+	})
+	assert.NoError(t, err, "the assigned technician can bill the schedule")
+}
+
+// newInvoicingDB holds a 150000 cleaning service and an invoice of schedule for one
+// cleaning with 100000 paid. The tables are created by hand because SQLite has no
+// gen_random_uuid().
+func newInvoicingDB(t *testing.T, schedule *domain.Schedule) (db *gorm.DB, invoiceID, serviceID uuid.UUID) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	require.NoError(t, err)
+
+	for _, statement := range []string{
+		`CREATE TABLE services (id TEXT PRIMARY KEY, name TEXT, price DECIMAL(10,2), duration INTEGER,
+			created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE invoices (id TEXT PRIMARY KEY, schedule_id TEXT, customer_id TEXT, invoice_date DATETIME, due_date DATETIME,
+			total_amount DECIMAL(10,2), amount_paid DECIMAL(10,2), status TEXT, created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE invoice_details (id TEXT PRIMARY KEY, invoice_id TEXT, service_id TEXT, quantity INTEGER, unit_price DECIMAL(10,2), subtotal DECIMAL(10,2),
+			created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`,
+		`CREATE TABLE payments (id TEXT PRIMARY KEY, invoice_id TEXT, amount DECIMAL(10,2), method TEXT, reference_number TEXT, paid_at DATETIME,
+			recorded_by INTEGER, created_at DATETIME, updated_at DATETIME, deleted_at DATETIME)`,
+	} {
+		require.NoError(t, db.Exec(statement).Error)
+	}
+
+	invoiceID, serviceID = uuid.New(), uuid.New()
+	require.NoError(t, db.Exec(`INSERT INTO services (id, name, price, duration) VALUES (?, 'Cleaning', 150000, 60)`, serviceID.String()).Error)
+	require.NoError(t, db.Exec(`INSERT INTO invoices (id, schedule_id, customer_id, total_amount, amount_paid, status) VALUES (?, ?, ?, 150000, 100000, ?)`,
+		invoiceID.String(), schedule.ID.String(), schedule.CustomerID.String(), domain.InvoiceStatusPartiallyPaid).Error)
+	require.NoError(t, db.Exec(`INSERT INTO invoice_details (id, invoice_id, service_id, quantity, unit_price, subtotal, created_at) VALUES (?, ?, ?, 1, 150000, 150000, ?)`,
+		uuid.NewString(), invoiceID.String(), serviceID.String(), time.Now()).Error)
+	require.NoError(t, db.Exec(`INSERT INTO payments (id, invoice_id, amount, method, paid_at, recorded_by) VALUES (?, ?, 100000, ?, ?, 1)`,
+		uuid.NewString(), invoiceID.String(), domain.PaymentMethodCash, time.Now()).Error)
+	return db, invoiceID, serviceID
+}
+
+// newScheduleRepository holds a completed schedule of one technician
+func newScheduleRepository() (*fakeScheduleRepository, *domain.Schedule) {
+	schedule := &domain.Schedule{ID: uuid.New(), CustomerID: uuid.New(), TechnicianID: uuid.New(), Status: domain.ScheduleStatusCompleted}
+	return &fakeScheduleRepository{schedules: map[string]*domain.Schedule{schedule.ID.String(): schedule}}, schedule
+}
+
+func TestInvoiceDetailService_RecalculatesInvoiceTotal(t *testing.T) {
+	schedules, schedule := newScheduleRepository()
+	db, invoiceID, serviceID := newInvoicingDB(t, schedule)
+	invoiceRepo := repository.NewInvoiceRepository(db)
+	svc := service.NewInvoiceDetailService(repository.NewInvoiceDetailRepository(db), invoiceRepo, schedules, repository.NewServiceRepository(db), service.NewAuditService(&fakeAuditLogRepository{}))
+	ctx := context.Background()
+	scope := domain.TechnicianScope(schedule.TechnicianID)
+
+	invoice := func() *domain.Invoice {
+		invoice, err := invoiceRepo.GetByID(ctx, invoiceID.String())
+		require.NoError(t, err)
+		return invoice
+	}
+
+	detail, err := svc.Create(ctx, scope, &request.InvoiceDetailCreateRequest{InvoiceID: invoiceID.String(), ServiceID: serviceID.String(), Quantity: 2})
+	require.NoError(t, err)
+	assert.Equal(t, money.FromMajor(300000), detail.Subtotal)
+	assert.Equal(t, money.FromMajor(450000), invoice().TotalAmount)
+
+	quantity := 1
+	_, err = svc.Update(ctx, scope, detail.ID.String(), &request.InvoiceDetailUpdateRequest{Quantity: &quantity})
+	require.NoError(t, err)
+	assert.Equal(t, money.FromMajor(300000), invoice().TotalAmount)
+
+	require.NoError(t, svc.Delete(ctx, scope, detail.ID.String()))
+	updated := invoice()
+	assert.Equal(t, money.FromMajor(150000), updated.TotalAmount)
+	assert.Equal(t, money.FromMajor(50000), updated.BalanceDue)
+	assert.Equal(t, domain.InvoiceStatusPartiallyPaid, updated.Status)
+}
+
+func TestInvoiceDetailService_HidesOtherTechniciansInvoices(t *testing.T) {
+	schedules, schedule := newScheduleRepository()
+	db, invoiceID, serviceID := newInvoicingDB(t, schedule)
+	svc := service.NewInvoiceDetailService(repository.NewInvoiceDetailRepository(db), repository.NewInvoiceRepository(db), schedules, repository.NewServiceRepository(db), service.NewAuditService(&fakeAuditLogRepository{}))
+	ctx := context.Background()
+	other := domain.TechnicianScope(uuid.New())
+
+	details, err := svc.GetByInvoiceID(ctx, domain.AccessScope{}, invoiceID.String())
+	require.NoError(t, err)
+	require.Len(t, details, 1)
+	detailID := details[0].ID.String()
+
+	_, err = svc.GetByInvoiceID(ctx, other, invoiceID.String())
+	assert.ErrorIs(t, err, service.ErrInvoiceNotFound)
+	_, err = svc.Create(ctx, other, &request.InvoiceDetailCreateRequest{InvoiceID: invoiceID.String(), ServiceID: serviceID.String(), Quantity: 1})
+	assert.ErrorIs(t, err, service.ErrInvoiceNotFound)
+	assert.ErrorIs(t, svc.DeleteByInvoiceID(ctx, other, invoiceID.String()), service.ErrInvoiceNotFound)
+
+	_, err = svc.GetByID(ctx, other, detailID)
+	assert.ErrorIs(t, err, service.ErrInvoiceDetailNotFound)
+	quantity := 3
+	_, err = svc.Update(ctx, other, detailID, &request.InvoiceDetailUpdateRequest{Quantity: &quantity})
+	assert.ErrorIs(t, err, service.ErrInvoiceDetailNotFound)
+	assert.ErrorIs(t, svc.Delete(ctx, other, detailID), service.ErrInvoiceDetailNotFound)
+
+	// Nothing was changed
+	details, err = svc.GetByInvoiceID(ctx, domain.TechnicianScope(schedule.TechnicianID), invoiceID.String())
+	require.NoError(t, err)
+	require.Len(t, details, 1)
+	assert.Equal(t, 1, details[0].Quantity)
+}
+
+func TestPaymentService_HidesOtherTechniciansInvoices(t *testing.T) {
+	schedules, schedule := newScheduleRepository()
+	db, invoiceID, _ := newInvoicingDB(t, schedule)
+	svc := service.NewPaymentService(repository.NewPaymentRepository(db), repository.NewInvoiceRepository(db), schedules, service.NewAuditService(&fakeAuditLogRepository{}))
+	ctx := context.Background()
+	payment := &request.PaymentCreateRequest{Amount: money.FromMajor(50000), Method: string(domain.PaymentMethodCash)}
+
+	_, err := svc.Record(ctx, domain.TechnicianScope(uuid.New()), invoiceID.String(), payment, 1)
+	assert.ErrorIs(t, err, service.ErrInvoiceNotFound)
+	_, err = svc.GetByInvoiceID(ctx, domain.TechnicianScope(uuid.New()), invoiceID.String())
+	assert.ErrorIs(t, err, service.ErrInvoiceNotFound)
+
+	receipt, err := svc.Record(ctx, domain.TechnicianScope(schedule.TechnicianID), invoiceID.String(), payment, 1)
+	require.NoError(t, err)
+	assert.Equal(t, domain.InvoiceStatusPaid, receipt.Invoice.Status)
+
+	payments, err := svc.GetByInvoiceID(ctx, domain.TechnicianScope(schedule.TechnicianID), invoiceID.String())
+	require.NoError(t, err)
+	assert.Len(t, payments, 2)
+}
+
+func TestPaymentService_RecordIsAudited(t *testing.T) {
+	schedules, schedule := newScheduleRepository()
+	db, invoiceID, _ := newInvoicingDB(t, schedule)
+	audit := &fakeAuditLogRepository{}
+	svc := service.NewPaymentService(repository.NewPaymentRepository(db), repository.NewInvoiceRepository(db), schedules, service.NewAuditService(audit))
+
+	receipt, err := svc.Record(context.Background(), domain.AccessScope{}, invoiceID.String(),
+		&request.PaymentCreateRequest{Amount: money.FromMajor(50000), Method: string(domain.PaymentMethodCash)}, 1)
+	require.NoError(t, err)
+
+	require.Len(t, audit.entries, 2)
+	payment, invoice := audit.entries[0], audit.entries[1]
+	assert.Equal(t, domain.AuditEntityPayment, payment.EntityType)
+	assert.Equal(t, receipt.Payment.ID.String(), payment.EntityID)
+	assert.Equal(t, domain.AuditActionCreate, payment.Action)
+
+	assert.Equal(t, domain.AuditEntityInvoice, invoice.EntityType)
+	assert.Equal(t, invoiceID.String(), invoice.EntityID)
+	assert.Equal(t, domain.AuditChanges{"amount_paid": "100000.00", "balance_due": "50000.00", "status": string(domain.InvoiceStatusPartiallyPaid)}, invoice.Before)
+	assert.Equal(t, domain.AuditChanges{"amount_paid": "150000.00", "balance_due": "0.00", "status": string(domain.InvoiceStatusPaid)}, invoice.After)
+}
+
+func TestInvoiceDetailService_TotalChangeIsAudited(t *testing.T) {
+	schedules, schedule := newScheduleRepository()
+	db, invoiceID, _ := newInvoicingDB(t, schedule)
+	audit := &fakeAuditLogRepository{}
+	svc := service.NewInvoiceDetailService(repository.NewInvoiceDetailRepository(db), repository.NewInvoiceRepository(db), schedules, repository.NewServiceRepository(db), service.NewAuditService(audit))
+	ctx := context.Background()
+
+	details, err := svc.GetByInvoiceID(ctx, domain.AccessScope{}, invoiceID.String())
+	require.NoError(t, err)
+	require.Len(t, details, 1)
+
+	price := money.FromMajor(100000)
+	_, err = svc.Update(ctx, domain.AccessScope{}, details[0].ID.String(), &request.InvoiceDetailUpdateRequest{UnitPrice: &price})
+	require.NoError(t, err)
+
+	require.Len(t, audit.entries, 2)
+	detail, invoice := audit.entries[0], audit.entries[1]
+	assert.Equal(t, domain.AuditEntityInvoiceDetail, detail.EntityType)
+	assert.Equal(t, "100000.00", detail.After["subtotal"])
+
+	assert.Equal(t, domain.AuditEntityInvoice, invoice.EntityType)
+	assert.Equal(t, "150000.00", invoice.Before["total_amount"])
+	assert.Equal(t, "100000.00", invoice.After["total_amount"])
+	assert.Equal(t, string(domain.InvoiceStatusPaid), invoice.After["status"], "lowering the total to the amount paid settles the invoice")
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+	"dashboard-ac-backend/internal/service"
+	"dashboard-ac-backend/pkg/hash"
+	"dashboard-ac-backend/pkg/notifier"
+)
+
+type fakePasswordResetRepository struct {
+	repository.PasswordResetRepository
+	users  *fakeUserRepository
+	tokens []*domain.PasswordResetToken
+}
+
+func (f *fakePasswordResetRepository) Create(ctx context.Context, token *domain.PasswordResetToken) error {
+	now := time.Now()
+	for _, existing := range f.tokens {
+		if existing.UserID == token.UserID && existing.UsedAt == nil {
+			existing.UsedAt = &now
+		}
+	}
+	f.tokens = append(f.tokens, token)
+	return nil
+}
+
+func (f *fakePasswordResetRepository) Redeem(ctx context.Context, tokenHash, passwordHash string) (uint, error) {
+	now := time.Now()
+	for _, token := range f.tokens {
+		if token.TokenHash == tokenHash && token.UsedAt == nil && now.Before(token.ExpiresAt) {
+			token.UsedAt = &now
+			return token.UserID, f.users.UpdatePassword(ctx, token.UserID, passwordHash)
+		}
+	}
+	return 0, repository.ErrResetTokenInvalid
+}
+
+type recordingNotifier struct {
+	sent []notifier.Message
+}
+
+func (n *recordingNotifier) Send(msg notifier.Message) error {
+	n.sent = append(n.sent, msg)
+	return nil
+}
+
+var resetTokenPattern = regexp.MustCompile(`token=([A-Za-z0-9_-]+)`)
+
+func newPasswordService(t *testing.T) (service.PasswordService, *fakeUserRepository, *recordingNotifier, *fakeAuditLogRepository) {
+	password, err := hash.HashPassword("secret123")
+	require.NoError(t, err)
+
+	users := &fakeUserRepository{users: map[uint]*domain.User{
+		1: {ID: 1, Name: "Budi", Email: "budi@example.com", Password: password, IsActive: true},
+	}}
+	resets := &fakePasswordResetRepository{users: users}
+	sink := &recordingNotifier{}
+	audit := &fakeAuditLogRepository{}
+
+	passwordService := service.NewPasswordService(users, resets, sink, 30*time.Minute, "http://localhost:3000/reset-password?token=", service.NewAuditService(audit))
+	return passwordService, users, sink, audit
+}
+
+func TestPasswordService_ChangePassword(t *testing.T) {
+	passwordService, users, _, audit := newPasswordService(t)
+
+	err := passwordService.ChangePassword(context.Background(), 1, &request.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "newsecret"})
+	assert.ErrorIs(t, err, service.ErrCurrentPasswordIncorrect)
+
+	err = passwordService.ChangePassword(context.Background(), 1, &request.ChangePasswordRequest{CurrentPassword: "secret123", NewPassword: "secret123"})
+	assert.ErrorIs(t, err, service.ErrPasswordUnchanged)
+
+	err = passwordService.ChangePassword(context.Background(), 1, &request.ChangePasswordRequest{CurrentPassword: "secret123", NewPassword: "newsecret"})
+	require.NoError(t, err)
+	assert.NoError(t, hash.CheckPassword(users.users[1].Password, "newsecret"))
+	assert.Equal(t, 1, users.users[1].TokenVersion)
+
+	require.Len(t, audit.entries, 1, "only the successful change is recorded")
+	assert.Equal(t, domain.AuditEntityUser, audit.entries[0].EntityType)
+	assert.Equal(t, "1", audit.entries[0].EntityID)
+	assert.Equal(t, domain.AuditChanges{"password": "changed"}, audit.entries[0].After)
+}
+
+func TestPasswordService_ForgotPasswordUnknownEmail(t *testing.T) {
+	passwordService, _, sink, _ := newPasswordService(t)
+
+	err := passwordService.ForgotPassword(context.Background(), &request.ForgotPasswordRequest{Email: "nobody@example.com"})
+
+	assert.NoError(t, err)
+	assert.Empty(t, sink.sent)
+}
+
+func TestPasswordService_ResetPasswordIsSingleUse(t *testing.T) {
+	passwordService, users, sink, audit := newPasswordService(t)
+
+	require.NoError(t, passwordService.ForgotPassword(context.Background(), &request.ForgotPasswordRequest{Email: "budi@example.com"}))
+	require.Len(t, sink.sent, 1)
+	assert.Equal(t, "budi@example.com", sink.sent[0].To)
+
+	match := resetTokenPattern.FindStringSubmatch(sink.sent[0].Body)
+	require.Len(t, match, 2)
+	token := match[1]
+
+	err := passwordService.ResetPassword(context.Background(), &request.ResetPasswordRequest{Token: token, NewPassword: "newsecret"})
+	require.NoError(t, err)
+	assert.NoError(t, hash.CheckPassword(users.users[1].Password, "newsecret"))
+	require.Len(t, audit.entries, 1)
+	assert.Equal(t, "1", audit.entries[0].EntityID)
+	assert.Equal(t, domain.AuditChanges{"password": "reset"}, audit.entries[0].After)
+
+	err = passwordService.ResetPassword(context.Background(), &request.ResetPasswordRequest{Token: token, NewPassword: "another"})
+	assert.ErrorIs(t, err, service.ErrResetTokenInvalid)
+}
+
+func TestPasswordService_NewResetLinkRetiresOldOne(t *testing.T) {
+	passwordService, _, sink, _ := newPasswordService(t)
+
+	require.NoError(t, passwordService.ForgotPassword(context.Background(), &request.ForgotPasswordRequest{Email: "budi@example.com"}))
+	require.NoError(t, passwordService.ForgotPassword(context.Background(), &request.ForgotPasswordRequest{Email: "budi@example.com"}))
+	require.Len(t, sink.sent, 2)
+
+	first := resetTokenPattern.FindStringSubmatch(sink.sent[0].Body)[1]
+	err := passwordService.ResetPassword(context.Background(), &request.ResetPasswordRequest{Token: first, NewPassword: "newsecret"})
+	assert.ErrorIs(t, err, service.ErrResetTokenInvalid)
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+	"dashboard-ac-backend/internal/service"
+)
+
+// fakeScheduleService records calls; technicians listed in busy reject bookings and
+// only the Pending ones of schedules are canceled
+type fakeScheduleService struct {
+	service.ScheduleService
+	busy      map[string]bool
+	schedules *fakeScheduleRepository
+	created   []*request.ScheduleCreateRequest
+	canceled  []string
+}
+
+func (f *fakeScheduleService) Create(ctx context.Context, req *request.ScheduleCreateRequest) (*domain.Schedule, error) {
+	copied := *req
+	f.created = append(f.created, &copied)
+	if f.busy[req.TechnicianID] {
+		return nil, &service.ScheduleConflictError{ConflictingIDs: []uuid.UUID{uuid.New()}}
+	}
+	return &domain.Schedule{
+		ID:           uuid.New(),
+		CustomerID:   uuid.MustParse(req.CustomerID),
+		TechnicianID: uuid.MustParse(req.TechnicianID),
+		Status:       domain.ScheduleStatusPending,
+	}, nil
+}
+
+func (f *fakeScheduleService) CancelPending(ctx context.Context, id string, actorID uint, reason string) (*domain.Schedule, error) {
+	if f.schedules != nil && f.schedules.schedules[id].Status != domain.ScheduleStatusPending {
+		return nil, service.ErrBookingNotPending
+	}
+	f.canceled = append(f.canceled, id)
+	return &domain.Schedule{ID: uuid.MustParse(id), Status: domain.ScheduleStatusCanceled}, nil
+}
+
+type fakeScheduleRepository struct {
+	repository.ScheduleRepository
+	schedules map[string]*domain.Schedule
+}
+
+func (f *fakeScheduleRepository) GetByID(ctx context.Context, id string) (*domain.Schedule, error) {
+	schedule, ok := f.schedules[id]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return schedule, nil
+}
+
+// GetTechnicianWindows treats every stored schedule as a one hour job
+func (f *fakeScheduleRepository) GetTechnicianWindows(ctx context.Context, technicianID string, from, to time.Time, excludeID string) ([]domain.ScheduleWindow, error) {
+	var windows []domain.ScheduleWindow
+	for _, schedule := range f.schedules {
+		window := schedule.Window(60)
+		if schedule.TechnicianID.String() == technicianID && window.Overlaps(from, to) {
+			windows = append(windows, window)
+		}
+	}
+	return windows, nil
+}
+
+type fakeTechnicianRepository struct {
+	repository.TechnicianRepository
+	technicians []*domain.Technician
+}
+
+func (f *fakeTechnicianRepository) GetAll(ctx context.Context) ([]*domain.Technician, error) {
+	return f.technicians, nil
+}
+
+func (f *fakeTechnicianRepository) GetByID(ctx context.Context, id string) (*domain.Technician, error) {
+	for _, technician := range f.technicians {
+		if technician.ID.String() == id {
+			return technician, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+// newBookingPortal returns a portal booking one hour services among the technicians
+func newBookingPortal(schedules *fakeScheduleService, technicians ...*domain.Technician) service.PortalService {
+	availability := service.NewAvailabilityService(&fakeTechnicianRepository{technicians: technicians},
+		&fakeScheduleRepository{schedules: make(map[string]*domain.Schedule)}, stubServiceRepository{})
+	return service.NewPortalService(schedules, nil, nil, nil, availability)
+}
+
+func TestPortalService_RequestBookingAssignsFreeTechnician(t *testing.T) {
+	busyTech := &domain.Technician{ID: uuid.New()}
+	freeTech := &domain.Technician{ID: uuid.New()}
+	schedules := &fakeScheduleService{busy: map[string]bool{busyTech.ID.String(): true}}
+	portal := newBookingPortal(schedules, busyTech, freeTech)
+
+	customerID := uuid.New()
+	tomorrow := time.Now().AddDate(0, 0, 1)
+	schedule, err := portal.RequestBooking(context.Background(), customerID, &request.PortalBookingRequest{
+		ServiceID: uuid.NewString(),
+		Date:      tomorrow,
+		Time:      time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC),
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, freeTech.ID, schedule.TechnicianID)
+	assert.Equal(t, customerID, schedule.CustomerID)
+	assert.Len(t, schedules.created, 2)
+}
+
+func TestPortalService_RequestBookingNoTechnicianAvailable(t *testing.T) {
+	tech := &domain.Technician{ID: uuid.New()}
+	schedules := &fakeScheduleService{busy: map[string]bool{tech.ID.String(): true}}
+	portal := newBookingPortal(schedules, tech)
+
+	_, err := portal.RequestBooking(context.Background(), uuid.New(), &request.PortalBookingRequest{
+		ServiceID: uuid.NewString(),
+		Date:      time.Now().AddDate(0, 0, 1),
+		Time:      time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC),
+	})
+
+	assert.ErrorIs(t, err, service.ErrNoTechnicianAvailable)
+}
+
+func TestPortalService_RequestBookingWithinWorkingHours(t *testing.T) {
+	lateShift := &domain.Technician{ID: uuid.New(), WorkStart: "13:00", WorkEnd: "21:00"}
+	onBreak := &domain.Technician{ID: uuid.New(), BreakStart: "10:30", BreakEnd: "11:00"}
+	dayShift := &domain.Technician{ID: uuid.New()}
+	tomorrow := time.Now().AddDate(0, 0, 1)
+
+	book := func(portal service.PortalService, technicianID string, hour int) (*domain.Schedule, error) {
+		return portal.RequestBooking(context.Background(), uuid.New(), &request.PortalBookingRequest{
+			ServiceID:    uuid.NewString(),
+			TechnicianID: technicianID,
+			Date:         tomorrow,
+			Time:         time.Date(0, 1, 1, hour, 0, 0, 0, time.UTC),
+		})
+	}
+
+	schedules := &fakeScheduleService{}
+	portal := newBookingPortal(schedules, lateShift, onBreak, dayShift)
+
+	schedule, err := book(portal, "", 10)
+	require.NoError(t, err)
+	assert.Equal(t, dayShift.ID, schedule.TechnicianID, "the job would run into the break of the other technician")
+	assert.Len(t, schedules.created, 1, "technicians off duty are not tried")
+
+	_, err = book(portal, "", 22)
+	assert.ErrorIs(t, err, service.ErrNoTechnicianAvailable)
+
+	_, err = book(portal, onBreak.ID.String(), 10)
+	assert.ErrorIs(t, err, service.ErrTechnicianUnavailable)
+
+	schedule, err = book(portal, lateShift.ID.String(), 20)
+	require.NoError(t, err)
+	assert.Equal(t, lateShift.ID, schedule.TechnicianID)
+	assert.Len(t, schedules.created, 2)
+}
+
+func TestPortalService_RequestBookingInPast(t *testing.T) {
+	schedules := &fakeScheduleService{}
+	portal := service.NewPortalService(schedules, nil, nil, nil, nil)
+
+	_, err := portal.RequestBooking(context.Background(), uuid.New(), &request.PortalBookingRequest{
+		ServiceID:    uuid.NewString(),
+		TechnicianID: uuid.NewString(),
+		Date:         time.Now().AddDate(0, 0, -1),
+		Time:         time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC),
+	})
+
+	assert.ErrorIs(t, err, service.ErrBookingInPast)
+	assert.Empty(t, schedules.created)
+}
+
+func TestPortalService_CancelBooking(t *testing.T) {
+	owner := uuid.New()
+	pending := &domain.Schedule{ID: uuid.New(), CustomerID: owner, Status: domain.ScheduleStatusPending}
+	started := &domain.Schedule{ID: uuid.New(), CustomerID: owner, Status: domain.ScheduleStatusOnProgress}
+	repo := &fakeScheduleRepository{schedules: map[string]*domain.Schedule{
+		pending.ID.String(): pending,
+		started.ID.String(): started,
+	}}
+
+	tests := []struct {
+		name       string
+		customerID uuid.UUID
+		scheduleID string
+		wantErr    error
+		wantMsg    string
+	}{
+		{name: "Owner cancels pending booking", customerID: owner, scheduleID: pending.ID.String()},
+		{name: "Other customer gets not found", customerID: uuid.New(), scheduleID: pending.ID.String(), wantMsg: "schedule not found"},
+		{name: "Started booking cannot be canceled", customerID: owner, scheduleID: started.ID.String(), wantErr: service.ErrBookingNotPending},
+		{name: "Unknown schedule", customerID: owner, scheduleID: uuid.NewString(), wantMsg: "schedule not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedules := &fakeScheduleService{schedules: repo}
+			portal := service.NewPortalService(schedules, nil, nil, repo, nil)
+
+			_, err := portal.CancelBooking(context.Background(), tt.customerID, tt.scheduleID, 1, "")
+
+			switch {
+			case tt.wantErr != nil:
+				assert.True(t, errors.Is(err, tt.wantErr))
+				assert.Empty(t, schedules.canceled)
+			case tt.wantMsg != "":
+				assert.EqualError(t, err, tt.wantMsg)
+				assert.Empty(t, schedules.canceled)
+			default:
+				assert.NoError(t, err)
+				assert.Equal(t, []string{tt.scheduleID}, schedules.canceled)
+			}
+		})
+	}
+}
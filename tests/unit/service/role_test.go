@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+	"dashboard-ac-backend/internal/service"
+)
+
+type fakeRoleRepository struct {
+	repository.RoleRepository
+	roles   map[domain.Role]*domain.RoleDefinition
+	users   map[domain.Role]int64
+	lookups int
+}
+
+func newFakeRoleRepository() *fakeRoleRepository {
+	repo := &fakeRoleRepository{
+		roles: make(map[domain.Role]*domain.RoleDefinition),
+		users: make(map[domain.Role]int64),
+	}
+	for _, role := range domain.DefaultRoles() {
+		role := role
+		repo.roles[role.Name] = &role
+	}
+	return repo
+}
+
+func (f *fakeRoleRepository) GetByName(ctx context.Context, name domain.Role) (*domain.RoleDefinition, error) {
+	f.lookups++
+	role, ok := f.roles[name]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *role
+	copied.Permissions = append([]domain.Permission{}, role.Permissions...)
+	return &copied, nil
+}
+
+func (f *fakeRoleRepository) Create(ctx context.Context, role *domain.RoleDefinition) error {
+	copied := *role
+	f.roles[role.Name] = &copied
+	return nil
+}
+
+func (f *fakeRoleRepository) Update(ctx context.Context, role *domain.RoleDefinition) error {
+	copied := *role
+	f.roles[role.Name] = &copied
+	return nil
+}
+
+func (f *fakeRoleRepository) Delete(ctx context.Context, name domain.Role) error {
+	delete(f.roles, name)
+	return nil
+}
+
+func (f *fakeRoleRepository) CountUsers(ctx context.Context, name domain.Role) (int64, error) {
+	return f.users[name], nil
+}
+
+func TestRoleService_DefaultPermissions(t *testing.T) {
+	roleService := service.NewRoleService(newFakeRoleRepository(), service.NewAuditService(&fakeAuditLogRepository{}), time.Minute)
+
+	admin, err := roleService.PermissionsFor(context.Background(), domain.RoleAdmin)
+	require.NoError(t, err)
+	for _, permission := range domain.AllPermissions() {
+		assert.True(t, admin.Has(permission), permission)
+	}
+
+	technician, err := roleService.PermissionsFor(context.Background(), domain.RoleTechnician)
+	require.NoError(t, err)
+	assert.True(t, technician.Has(domain.PermInvoicesWrite))
+	assert.True(t, technician.Has(domain.PermPaymentsWrite))
+	assert.False(t, technician.Has(domain.PermUsersRead))
+	assert.False(t, technician.Has(domain.PermServicesWrite))
+
+	customer, err := roleService.PermissionsFor(context.Background(), domain.RoleCustomer)
+	require.NoError(t, err)
+	assert.Empty(t, customer)
+
+	unknown, err := roleService.PermissionsFor(context.Background(), "intern")
+	require.NoError(t, err)
+	assert.Empty(t, unknown)
+}
+
+func TestRoleService_PermissionsAreCachedUntilChanged(t *testing.T) {
+	repo := newFakeRoleRepository()
+	roleService := service.NewRoleService(repo, service.NewAuditService(&fakeAuditLogRepository{}), time.Minute)
+
+	_, err := roleService.PermissionsFor(context.Background(), domain.RoleTechnician)
+	require.NoError(t, err)
+	_, err = roleService.PermissionsFor(context.Background(), domain.RoleTechnician)
+	require.NoError(t, err)
+	assert.Equal(t, 1, repo.lookups)
+
+	permissions := []string{"schedules:read"}
+	_, err = roleService.Update(context.Background(), "technician", &request.RoleUpdateRequest{Permissions: &permissions})
+	require.NoError(t, err)
+
+	technician, err := roleService.PermissionsFor(context.Background(), domain.RoleTechnician)
+	require.NoError(t, err)
+	assert.True(t, technician.Has(domain.PermSchedulesRead))
+	assert.False(t, technician.Has(domain.PermInvoicesWrite))
+}
+
+func TestRoleService_Create(t *testing.T) {
+	roleService := service.NewRoleService(newFakeRoleRepository(), service.NewAuditService(&fakeAuditLogRepository{}), time.Minute)
+
+	role, err := roleService.Create(context.Background(), &request.RoleCreateRequest{
+		Name:        "dispatcher",
+		Permissions: []string{"schedules:write", "schedules:read", "schedules:write"},
+	})
+	require.NoError(t, err)
+	assert.False(t, role.BuiltIn)
+	assert.Equal(t, []domain.Permission{domain.PermSchedulesRead, domain.PermSchedulesWrite}, role.Permissions)
+
+	_, err = roleService.Create(context.Background(), &request.RoleCreateRequest{Name: "dispatcher"})
+	assert.ErrorIs(t, err, service.ErrRoleExists)
+
+	_, err = roleService.Create(context.Background(), &request.RoleCreateRequest{Name: "Dispatch Team"})
+	assert.ErrorIs(t, err, service.ErrRoleNameValid)
+
+	_, err = roleService.Create(context.Background(), &request.RoleCreateRequest{Name: "auditor", Permissions: []string{"invoices:approve"}})
+	assert.ErrorIs(t, err, service.ErrUnknownPermission)
+}
+
+func TestRoleService_ProtectsBuiltInRoles(t *testing.T) {
+	repo := newFakeRoleRepository()
+	roleService := service.NewRoleService(repo, service.NewAuditService(&fakeAuditLogRepository{}), time.Minute)
+
+	permissions := []string{}
+	_, err := roleService.Update(context.Background(), "admin", &request.RoleUpdateRequest{Permissions: &permissions})
+	assert.ErrorIs(t, err, service.ErrRoleReadOnly)
+
+	assert.ErrorIs(t, roleService.Delete(context.Background(), "technician"), service.ErrRoleBuiltIn)
+	assert.EqualError(t, roleService.Delete(context.Background(), "intern"), "role not found")
+
+	_, err = roleService.Create(context.Background(), &request.RoleCreateRequest{Name: "dispatcher"})
+	require.NoError(t, err)
+	repo.users["dispatcher"] = 2
+	assert.ErrorIs(t, roleService.Delete(context.Background(), "dispatcher"), service.ErrRoleInUse)
+
+	repo.users["dispatcher"] = 0
+	require.NoError(t, roleService.Delete(context.Background(), "dispatcher"))
+	assert.NotContains(t, repo.roles, domain.Role("dispatcher"))
+}
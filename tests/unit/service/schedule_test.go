@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/repository"
+	"dashboard-ac-backend/internal/service"
+)
+
+// lockingScheduleRepository keeps bookings in memory behind a mutex standing in for
+// the technician row lock. Reads and writes outside the lock fail the test.
+type lockingScheduleRepository struct {
+	repository.ScheduleRepository
+	t       *testing.T
+	mu      sync.Mutex
+	locked  bool
+	windows []domain.ScheduleWindow
+}
+
+func (f *lockingScheduleRepository) WithTechnicianLock(ctx context.Context, technicianID uuid.UUID, fn func(repo repository.ScheduleRepository) error) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.locked = true
+	defer func() { f.locked = false }()
+	return fn(f)
+}
+
+func (f *lockingScheduleRepository) GetTechnicianWindows(ctx context.Context, technicianID string, from, to time.Time, excludeID string) ([]domain.ScheduleWindow, error) {
+	assert.True(f.t, f.locked, "availability is checked under the technician lock")
+	var clashes []domain.ScheduleWindow
+	for _, window := range f.windows {
+		if window.Overlaps(from, to) {
+			clashes = append(clashes, window)
+		}
+	}
+	// Give a concurrent booking the chance to interleave if the lock were missing
+	time.Sleep(time.Millisecond)
+	return clashes, nil
+}
+
+func (f *lockingScheduleRepository) Create(ctx context.Context, schedule *domain.Schedule) error {
+	assert.True(f.t, f.locked, "the schedule is written under the technician lock")
+	schedule.ID = uuid.New()
+	f.windows = append(f.windows, schedule.Window(60))
+	return nil
+}
+
+type stubCustomerRepository struct {
+	repository.CustomerRepository
+}
+
+func (stubCustomerRepository) GetByID(ctx context.Context, id string) (*domain.Customer, error) {
+	return &domain.Customer{ID: uuid.MustParse(id)}, nil
+}
+
+type stubTechnicianRepository struct {
+	repository.TechnicianRepository
+}
+
+func (stubTechnicianRepository) GetByID(ctx context.Context, id string) (*domain.Technician, error) {
+	return &domain.Technician{ID: uuid.MustParse(id)}, nil
+}
+
+type stubServiceRepository struct {
+	repository.ServiceRepository
+}
+
+func (stubServiceRepository) GetByID(ctx context.Context, id string) (*domain.Service, error) {
+	return &domain.Service{ID: uuid.MustParse(id), Duration: 60}, nil
+}
+
+func TestScheduleService_ConcurrentBookingsOfOneTechnician(t *testing.T) {
+	repo := &lockingScheduleRepository{t: t}
+	svc := service.NewScheduleService(repo, stubCustomerRepository{}, stubTechnicianRepository{}, stubServiceRepository{}, service.NewAuditService(&fakeAuditLogRepository{}))
+
+	req := &request.ScheduleCreateRequest{
+		CustomerID:   uuid.NewString(),
+		TechnicianID: uuid.NewString(),
+		ServiceID:    uuid.NewString(),
+		Date:         time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC),
+		Time:         time.Date(0, 1, 1, 10, 0, 0, 0, time.UTC),
+	}
+
+	const attempts = 5
+	errs := make([]error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = svc.Create(context.Background(), req)
+		}(i)
+	}
+	wg.Wait()
+
+	booked := 0
+	for _, err := range errs {
+		if err == nil {
+			booked++
+			continue
+		}
+		var conflict *service.ScheduleConflictError
+		assert.ErrorAs(t, err, &conflict)
+	}
+	assert.Equal(t, 1, booked, "only one of the overlapping bookings succeeds")
+	require.Len(t, repo.windows, 1)
+}
+
+// racingScheduleRepository reports the schedule as Pending but finds it started by
+// the time the row is locked
+type racingScheduleRepository struct {
+	fakeScheduleRepository
+}
+
+func (f *racingScheduleRepository) UpdateWithHistory(ctx context.Context, schedule *domain.Schedule, history *domain.ScheduleStatusHistory) error {
+	return repository.ErrScheduleStatusChanged
+}
+
+func TestScheduleService_CancelPending(t *testing.T) {
+	pending := &domain.Schedule{ID: uuid.New(), Status: domain.ScheduleStatusPending}
+	started := &domain.Schedule{ID: uuid.New(), Status: domain.ScheduleStatusOnProgress}
+	repo := &racingScheduleRepository{fakeScheduleRepository: fakeScheduleRepository{schedules: map[string]*domain.Schedule{
+		pending.ID.String(): pending,
+		started.ID.String(): started,
+	}}}
+	audit := &fakeAuditLogRepository{}
+	svc := service.NewScheduleService(repo, stubCustomerRepository{}, stubTechnicianRepository{}, stubServiceRepository{}, service.NewAuditService(audit))
+	ctx := context.Background()
+
+	_, err := svc.CancelPending(ctx, started.ID.String(), 1, "")
+	assert.ErrorIs(t, err, service.ErrBookingNotPending)
+
+	_, err = svc.CancelPending(ctx, pending.ID.String(), 1, "")
+	assert.ErrorIs(t, err, service.ErrBookingNotPending, "a job started after the read is not canceled")
+
+	_, err = svc.CancelPending(ctx, uuid.NewString(), 1, "")
+	assert.ErrorIs(t, err, service.ErrScheduleNotFound)
+	assert.Empty(t, audit.entries)
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"dashboard-ac-backend/internal/api/request"
+	"dashboard-ac-backend/internal/domain"
+	"dashboard-ac-backend/internal/service"
+)
+
+func (f *fakeUserRepository) Create(ctx context.Context, user *domain.User) error {
+	user.ID = uint(len(f.users) + 1)
+	f.users[user.ID] = user
+	return nil
+}
+
+func (f *fakeUserRepository) Update(ctx context.Context, user *domain.User) error {
+	f.users[user.ID] = user
+	return nil
+}
+
+func newUserService(users *fakeUserRepository, audit *fakeAuditLogRepository) service.UserService {
+	return service.NewUserService(users, stubCustomerRepository{}, stubTechnicianRepository{}, nil, newFakeRoleRepository(), service.NewAuditService(audit))
+}
+
+func TestUserService_CreateRejectsLinksOfAnotherRole(t *testing.T) {
+	svc := newUserService(&fakeUserRepository{users: make(map[uint]*domain.User)}, &fakeAuditLogRepository{})
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		role         domain.Role
+		customerID   string
+		technicianID string
+		want         error
+	}{
+		{"Customer linked to a customer", domain.RoleCustomer, uuid.NewString(), "", nil},
+		{"Technician linked to a technician", domain.RoleTechnician, "", uuid.NewString(), nil},
+		{"Customer linked to a technician", domain.RoleCustomer, "", uuid.NewString(), service.ErrTechnicianLinkRole},
+		{"Technician linked to a customer", domain.RoleTechnician, uuid.NewString(), "", service.ErrCustomerLinkRole},
+		{"Admin linked to a customer", domain.RoleAdmin, uuid.NewString(), "", service.ErrCustomerLinkRole},
+		{"Admin linked to a technician", domain.RoleAdmin, "", uuid.NewString(), service.ErrTechnicianLinkRole},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Create(ctx, &request.UserCreateRequest{
+				Name:         tt.name,
+				Email:        uuid.NewString() + "@example.com",
+				Password:     "secret123",
+				Role:         string(tt.role),
+				CustomerID:   tt.customerID,
+				TechnicianID: tt.technicianID,
+			})
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestUserService_UpdateRejectsLinksOfAnotherRole(t *testing.T) {
+	customerID := uuid.New()
+	users := &fakeUserRepository{users: map[uint]*domain.User{
+		1: {ID: 1, Email: "budi@example.com", Role: domain.RoleCustomer, CustomerID: &customerID},
+	}}
+	svc := newUserService(users, &fakeAuditLogRepository{})
+	ctx := context.Background()
+
+	technicianID := uuid.NewString()
+	_, err := svc.Update(ctx, 1, &request.UserUpdateRequest{TechnicianID: &technicianID})
+	assert.ErrorIs(t, err, service.ErrTechnicianLinkRole)
+
+	technician := string(domain.RoleTechnician)
+	_, err = svc.Update(ctx, 1, &request.UserUpdateRequest{Role: &technician})
+	assert.ErrorIs(t, err, service.ErrCustomerLinkRole, "the customer link has to be removed with the role change")
+
+	unlink := ""
+	updated, err := svc.Update(ctx, 1, &request.UserUpdateRequest{Role: &technician, CustomerID: &unlink, TechnicianID: &technicianID})
+	require.NoError(t, err)
+	assert.Nil(t, updated.CustomerID)
+	assert.Equal(t, technicianID, updated.TechnicianID.String())
+}
+
+func TestUserService_UnlockIsAudited(t *testing.T) {
+	lockedUntil := time.Now().Add(time.Hour)
+	users := &fakeUserRepository{users: map[uint]*domain.User{
+		1: {ID: 1, Email: "budi@example.com", Role: domain.RoleCustomer, FailedLoginCount: 5, LastFailedLoginAt: &lockedUntil, LockedUntil: &lockedUntil},
+	}}
+	audit := &fakeAuditLogRepository{}
+	actorID := uint(9)
+	ctx := domain.ContextWithAuditActor(context.Background(), domain.AuditActor{UserID: &actorID, Email: "admin@example.com"})
+
+	require.NoError(t, newUserService(users, audit).Unlock(ctx, 1))
+
+	require.Len(t, audit.entries, 1)
+	entry := audit.entries[0]
+	assert.Equal(t, domain.AuditActionUpdate, entry.Action)
+	assert.Equal(t, "1", entry.EntityID)
+	assert.Equal(t, &actorID, entry.ActorID)
+	assert.Equal(t, float64(5), entry.Before["failed_login_count"])
+	assert.Equal(t, float64(0), entry.After["failed_login_count"])
+	assert.Contains(t, entry.Before, "locked_until")
+	assert.NotContains(t, entry.After, "locked_until")
+}